@@ -108,6 +108,7 @@ context('Domain User Edit page', () => {
                             ['Reply notifications',          ''],
                             ['Moderator notifications',      ''],
                             ['Comment status notifications', ''],
+                            ['Mention notifications',        '✔'],
                             ['Created',                      REGEXES.datetime],
                         ]);
 
@@ -126,6 +127,7 @@ context('Domain User Edit page', () => {
                         ['Reply notifications',          '✔'],
                         ['Moderator notifications',      ''],
                         ['Comment status notifications', '✔'],
+                        ['Mention notifications',        '✔'],
                         ['Created',                      REGEXES.datetime],
                     ]);
 
@@ -66,6 +66,7 @@ context('Domain User Properties', () => {
                         ['Reply notifications',          '✔'],
                         ['Moderator notifications',      '✔'],
                         ['Comment status notifications', '✔'],
+                        ['Mention notifications',        '✔'],
                         ['Created',                      REGEXES.datetime],
                     ]);
 
@@ -112,6 +113,7 @@ context('Domain User Properties', () => {
                 ['Reply notifications',          '✔'],
                 ['Moderator notifications',      '✔'],
                 ['Comment status notifications', '✔'],
+                ['Mention notifications',        '✔'],
                 ['Created',                      REGEXES.datetime],
             ]);
 
@@ -392,8 +392,9 @@ declare namespace Cypress {
          * @param notifyModerator Whether the user is to receive moderator notifications.
          * @param notifyCommentStatus Whether the user is to be notified about status changes (approved/rejected) of
          *     their comments.
+         * @param notifyMentions Whether the user is to be notified about being mentioned in comments. Defaults to true.
          */
-        commenterUpdateSettingsViaApi(domainId: string, notifyReplies: boolean, notifyModerator: boolean, notifyCommentStatus: boolean): Chainable<Response<void>>;
+        commenterUpdateSettingsViaApi(domainId: string, notifyReplies: boolean, notifyModerator: boolean, notifyCommentStatus: boolean, notifyMentions?: boolean): Chainable<Response<void>>;
 
         /***************************************************************************************************************
           Test site
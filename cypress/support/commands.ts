@@ -647,7 +647,7 @@ Cypress.Commands.add(
 Cypress.Commands.add(
     'commenterUpdateSettingsViaApi',
     {prevSubject: false},
-    (domainId: string, notifyReplies: boolean, notifyModerator: boolean, notifyCommentStatus: boolean) =>
+    (domainId: string, notifyReplies: boolean, notifyModerator: boolean, notifyCommentStatus: boolean, notifyMentions?: boolean) =>
         // Fetch the user session cookie
         cy.getCookie(COOKIES.embedCommenterSession)
             // Then issue an API request
@@ -655,7 +655,7 @@ Cypress.Commands.add(
                 void cy.request({
                     method:  'PUT',
                     url:     '/api/embed/auth/user',
-                    body:    {domainId, notifyReplies, notifyModerator, notifyCommentStatus},
+                    body:    {domainId, notifyReplies, notifyModerator, notifyCommentStatus, notifyMentions: notifyMentions ?? true},
                     headers: {'X-User-Session': token?.value},
                 })
                 .its('status').should('eq', 204)));
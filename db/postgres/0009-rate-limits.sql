@@ -0,0 +1,11 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add rate limit buckets table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_rate_limits (
+    key        varchar(255)     primary key,                       -- Bucket key, composed of the action and the client identity
+    tokens     double precision                          not null, -- Number of tokens available in the bucket
+    ts_updated timestamp        default current_timestamp not null -- When the bucket was last updated
+);
+
+create index idx_rate_limits_ts_updated on cm_rate_limits(ts_updated);
@@ -0,0 +1,31 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add moderation log table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_moderation_log (
+    id           uuid primary key,                                -- Unique record ID
+    action       varchar(32)                            not null, -- Kind of moderation action
+    domain_id    uuid,                                            -- Reference to the domain the action applies to, if any
+    page_id      uuid,                                            -- Reference to the page the action applies to, if any
+    comment_id   uuid,                                            -- Reference to the comment the action applies to, if any
+    user_id      uuid,                                            -- Reference to the user the action applies to, if any
+    user_actor   uuid,                                            -- Reference to the user who performed the action, null if it was automatic or the user has been deleted
+    value_before text         default ''                not null, -- Value of the affected property before the action
+    value_after  text         default ''                not null, -- Value of the affected property after the action
+    reason       varchar(255) default ''                not null, -- Reason for the action
+    ts_created   timestamp    default current_timestamp not null  -- When the action was performed
+);
+
+-- Constraints
+alter table cm_moderation_log add constraint fk_moderation_log_domain_id  foreign key (domain_id)  references cm_domains(id)      on delete cascade;
+alter table cm_moderation_log add constraint fk_moderation_log_page_id    foreign key (page_id)    references cm_domain_pages(id) on delete set null;
+alter table cm_moderation_log add constraint fk_moderation_log_comment_id foreign key (comment_id) references cm_comments(id)     on delete set null;
+alter table cm_moderation_log add constraint fk_moderation_log_user_id    foreign key (user_id)    references cm_users(id)        on delete set null;
+alter table cm_moderation_log add constraint fk_moderation_log_user_actor foreign key (user_actor) references cm_users(id)        on delete set null;
+
+-- Indices
+create index idx_moderation_log_domain_id  on cm_moderation_log(domain_id);
+create index idx_moderation_log_comment_id on cm_moderation_log(comment_id);
+create index idx_moderation_log_user_id    on cm_moderation_log(user_id);
+create index idx_moderation_log_user_actor on cm_moderation_log(user_actor);
+create index idx_moderation_log_ts_created on cm_moderation_log(ts_created);
@@ -0,0 +1,9 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add domain user shadow-ban state and shadowed comments
+------------------------------------------------------------------------------------------------------------------------
+
+-- Domain users
+alter table cm_domains_users add column is_shadow_banned boolean default false not null; -- Whether the user's new comments are only visible to them and moderators
+
+-- Comments
+alter table cm_comments add column is_shadowed boolean default false not null; -- Whether the comment is only visible to its author and moderators
@@ -0,0 +1,33 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add Bayesian spam classifier tables
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_bayes_models (
+    domain_id  uuid primary key,                             -- Reference to the domain
+    count_spam integer   default 0                 not null, -- Number of learnt spam comments
+    count_ham  integer   default 0                 not null, -- Number of learnt legitimate comments
+    ts_updated timestamp default current_timestamp not null  -- When the model was last updated
+);
+
+create table cm_bayes_tokens (
+    domain_id  uuid                  not null, -- Reference to the domain
+    token      varchar(64)           not null, -- Token (lowercase word)
+    count_spam integer     default 0 not null, -- Number of learnt spam comments containing the token
+    count_ham  integer     default 0 not null  -- Number of learnt legitimate comments containing the token
+);
+
+create table cm_bayes_comments (
+    comment_id uuid primary key, -- Reference to the learnt comment
+    domain_id  uuid    not null, -- Reference to the domain
+    is_spam    boolean not null  -- Whether the comment was learnt as spam
+);
+
+-- Constraints
+alter table cm_bayes_models   add constraint fk_bayes_models_domain_id   foreign key (domain_id)  references cm_domains(id)  on delete cascade;
+alter table cm_bayes_tokens   add primary key (domain_id, token);
+alter table cm_bayes_tokens   add constraint fk_bayes_tokens_domain_id   foreign key (domain_id)  references cm_domains(id)  on delete cascade;
+alter table cm_bayes_comments add constraint fk_bayes_comments_comment_id foreign key (comment_id) references cm_comments(id) on delete cascade;
+alter table cm_bayes_comments add constraint fk_bayes_comments_domain_id  foreign key (domain_id)  references cm_domains(id)  on delete cascade;
+
+-- Indices
+create index idx_bayes_comments_domain_id on cm_bayes_comments(domain_id);
@@ -0,0 +1,18 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add comment revisions table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_comment_revisions (
+    id           uuid primary key,                             -- Unique record ID
+    comment_id   uuid                                not null, -- Reference to the comment
+    markdown     text                                not null, -- Comment text in markdown, as it was before the edit
+    html         text                                not null, -- Rendered comment text in HTML, as it was before the edit
+    ts_created   timestamp default current_timestamp not null, -- When the revised text was created (by the comment author or an editor)
+    user_created uuid                                          -- Reference to the user who created the revised text, null if the user has been deleted
+);
+
+-- Constraints
+alter table cm_comment_revisions add constraint fk_comment_revisions_comment_id   foreign key (comment_id)   references cm_comments(id) on delete cascade;
+alter table cm_comment_revisions add constraint fk_comment_revisions_user_created foreign key (user_created) references cm_users(id)    on delete set null;
+
+create index idx_comment_revisions_comment_id on cm_comment_revisions(comment_id);
@@ -0,0 +1,15 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add comment reactions table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_comment_reactions (
+    comment_id uuid                                   not null, -- Reference to the comment
+    user_id    uuid                                   not null, -- Reference to the user who reacted
+    reaction   varchar(32)                            not null, -- Reaction (emoji)
+    ts_created timestamp    default current_timestamp not null  -- When the record was created
+);
+
+-- Constraints
+alter table cm_comment_reactions add primary key (comment_id, user_id, reaction);
+alter table cm_comment_reactions add constraint fk_comment_reactions_comment_id foreign key (comment_id) references cm_comments(id) on delete cascade;
+alter table cm_comment_reactions add constraint fk_comment_reactions_user_id    foreign key (user_id)    references cm_users(id)    on delete cascade;
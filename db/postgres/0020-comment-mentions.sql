@@ -0,0 +1,19 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add comment mentions table and mention notifications
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_comment_mentions (
+    comment_id uuid not null, -- Reference to the comment
+    user_id    uuid not null  -- Reference to the mentioned user
+);
+
+-- Constraints
+alter table cm_comment_mentions add primary key (comment_id, user_id);
+alter table cm_comment_mentions add constraint fk_comment_mentions_comment_id foreign key (comment_id) references cm_comments(id) on delete cascade;
+alter table cm_comment_mentions add constraint fk_comment_mentions_user_id    foreign key (user_id)    references cm_users(id)    on delete cascade;
+
+-- Indices
+create index idx_comment_mentions_user_id on cm_comment_mentions(user_id);
+
+-- Domain users
+alter table cm_domains_users add column notify_mentions boolean default true not null; -- Whether the user is to be notified about being mentioned in comments
@@ -0,0 +1,16 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Make comment flags unique per user (or, for anonymous flags, per IP address)
+------------------------------------------------------------------------------------------------------------------------
+
+-- Remove any duplicate flags that may have slipped in
+delete from cm_comment_flags a
+    using cm_comment_flags b
+    where a.comment_id = b.comment_id and a.user_id = b.user_id and a.id::text > b.id::text and
+        (a.user_id <> '00000000-0000-0000-0000-000000000000'::uuid or a.author_ip = b.author_ip);
+
+-- Recalculate flag counts
+update cm_comments set count_flags = (select count(*) from cm_comment_flags f where f.comment_id = cm_comments.id) where count_flags > 0;
+
+-- Indices
+create unique index idx_comment_flags_comment_user on cm_comment_flags(comment_id, user_id)   where user_id <> '00000000-0000-0000-0000-000000000000'::uuid;
+create unique index idx_comment_flags_comment_ip   on cm_comment_flags(comment_id, author_ip) where user_id =  '00000000-0000-0000-0000-000000000000'::uuid;
@@ -0,0 +1,5 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Allow hiding comment revisions from readers
+------------------------------------------------------------------------------------------------------------------------
+
+alter table cm_comment_revisions add column is_hidden boolean default false not null; -- Whether the revision is only visible to moderators, because its text was replaced by a moderator
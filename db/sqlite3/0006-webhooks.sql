@@ -0,0 +1,42 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add domain webhooks table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_webhooks (
+    id           uuid primary key,                                 -- Unique record ID
+    domain_id    uuid                                    not null, -- Reference to the domain
+    url          varchar(2083)                           not null, -- URL to post payloads to
+    secret       char(64)                                not null, -- Secret used for signing payloads, as a hex string
+    events       varchar(1024) default ''                not null, -- Comma-separated list of events the webhook is subscribed to
+    is_enabled   boolean       default true              not null, -- Whether the webhook is enabled
+    ts_created   timestamp     default current_timestamp not null, -- When the record was created
+    user_created uuid,                                             -- Reference to the user who created the webhook, null if the user has been deleted
+    -- Constraints
+    constraint fk_webhooks_domain_id    foreign key (domain_id)    references cm_domains(id) on delete cascade,
+    constraint fk_webhooks_user_created foreign key (user_created) references cm_users(id)   on delete set null
+);
+
+create index idx_webhooks_domain_id on cm_webhooks(domain_id);
+
+------------------------------------------------------------------------------------------------------------------------
+-- Add webhook deliveries table, which serves both as a delivery queue and a delivery log
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_webhook_deliveries (
+    id              uuid primary key,                  -- Unique record ID
+    webhook_id      uuid                     not null, -- Reference to the webhook
+    event           varchar(32)              not null, -- Event that triggered the delivery
+    payload         text                     not null, -- JSON payload to deliver
+    status          varchar(16)              not null, -- Delivery status: 'pending', 'succeeded', 'failed'
+    attempts        integer       default 0  not null, -- Number of delivery attempts made
+    ts_created      timestamp                not null, -- When the record was created
+    ts_next_attempt timestamp,                         -- When the next delivery attempt is due, null if no further attempt is to be made
+    ts_last_attempt timestamp,                         -- When the last delivery attempt was made
+    response_status integer       default 0  not null, -- HTTP status code of the last response, 0 if no response was received
+    error           varchar(1024) default '' not null, -- Error message of the last failed attempt
+    -- Constraints
+    constraint fk_webhook_deliveries_webhook_id foreign key (webhook_id) references cm_webhooks(id) on delete cascade
+);
+
+create index idx_webhook_deliveries_webhook_id      on cm_webhook_deliveries(webhook_id);
+create index idx_webhook_deliveries_ts_next_attempt on cm_webhook_deliveries(ts_next_attempt);
@@ -0,0 +1,10 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add plugin jobs table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_plugin_jobs (
+    name        varchar(255),          -- Job name, prefixed with the plugin ID, and the primary key
+    ts_last_run timestamp     not null, -- Scheduled time of the last claimed job run
+    -- Constraints
+    primary key (name)
+);
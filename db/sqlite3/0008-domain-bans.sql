@@ -0,0 +1,20 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add domain ban rules table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_domain_bans (
+    id           uuid primary key,                                 -- Unique record ID
+    domain_id    uuid                                    not null, -- Reference to the domain
+    kind         varchar(16)                             not null, -- Rule kind: 'ip', 'cidr', 'country', 'emailDomain'
+    value        varchar(255)                            not null, -- Value to match against, depending on the kind
+    reason       varchar(255)  default ''                not null, -- Optional reason for the ban
+    ts_created   timestamp     default current_timestamp not null, -- When the record was created
+    user_created uuid,                                             -- Reference to the user who created the rule, null if the user has been deleted
+    ts_expires   timestamp,                                        -- When the rule expires, null if it never does
+    -- Constraints
+    constraint fk_domain_bans_domain_id           foreign key (domain_id)    references cm_domains(id) on delete cascade,
+    constraint fk_domain_bans_user_created        foreign key (user_created) references cm_users(id)   on delete set null,
+    constraint uk_domain_bans_domain_id_kind_value unique (domain_id, kind, value)
+);
+
+create index idx_domain_bans_ts_expires on cm_domain_bans(ts_expires);
@@ -0,0 +1,22 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add comment flags table and flag counter
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_comment_flags (
+    id         uuid primary key,                                -- Unique record ID
+    comment_id uuid                                   not null, -- Reference to the comment
+    user_id    uuid                                   not null, -- Reference to the user who flagged the comment
+    reason     varchar(16)                            not null, -- Reason category: 'spam', 'abuse', 'offTopic', 'other'
+    details    varchar(255) default ''                not null, -- Optional details provided by the user
+    author_ip  varchar(39)  default ''                not null, -- IP address of the user who flagged the comment
+    ts_created timestamp    default current_timestamp not null, -- When the record was created
+    -- Constraints
+    constraint fk_comment_flags_comment_id foreign key (comment_id) references cm_comments(id) on delete cascade,
+    constraint fk_comment_flags_user_id    foreign key (user_id)    references cm_users(id)    on delete cascade
+);
+
+-- Indices
+create index idx_comment_flags_comment_id on cm_comment_flags(comment_id);
+
+-- Comments
+alter table cm_comments add column count_flags integer default 0 not null; -- Number of flags raised against the comment
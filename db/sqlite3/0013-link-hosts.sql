@@ -0,0 +1,6 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add domain trusted and blocked link hosts
+------------------------------------------------------------------------------------------------------------------------
+
+alter table cm_domains add column mod_trusted_hosts text default '' not null; -- Hosts links and images to which don't trigger moderation, separated by whitespace or commas
+alter table cm_domains add column mod_blocked_hosts text default '' not null; -- Hosts links and images to which always trigger moderation, separated by whitespace or commas
@@ -0,0 +1,16 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add comment scans table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_comment_scans (
+    comment_id   uuid                                    not null, -- Reference to the comment
+    extension_id varchar(32)                             not null, -- ID of the extension that scanned the comment
+    is_positive  boolean                                 not null, -- Whether the comment is deemed inappropriate: the extension's verdict, updated once a contradicting moderator's decision is reported back
+    user_ip      varchar(39)   default ''                not null, -- IP address of the commenter
+    user_agent   varchar(1024) default ''                not null, -- User agent of the commenter's browser
+    referrer     varchar(2083) default ''                not null, -- Referrer of the commenter's request
+    ts_created   timestamp     default current_timestamp not null, -- When the comment was (last) scanned
+    -- Constraints
+    primary key (comment_id, extension_id),
+    constraint fk_comment_scans_comment_id foreign key (comment_id) references cm_comments(id) on delete cascade
+);
@@ -0,0 +1,34 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add Bayesian spam classifier tables
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_bayes_models (
+    domain_id  uuid primary key,                             -- Reference to the domain
+    count_spam integer   default 0                 not null, -- Number of learnt spam comments
+    count_ham  integer   default 0                 not null, -- Number of learnt legitimate comments
+    ts_updated timestamp default current_timestamp not null, -- When the model was last updated
+    -- Constraints
+    constraint fk_bayes_models_domain_id foreign key (domain_id) references cm_domains(id) on delete cascade
+);
+
+create table cm_bayes_tokens (
+    domain_id  uuid                  not null, -- Reference to the domain
+    token      varchar(64)           not null, -- Token (lowercase word)
+    count_spam integer     default 0 not null, -- Number of learnt spam comments containing the token
+    count_ham  integer     default 0 not null, -- Number of learnt legitimate comments containing the token
+    -- Constraints
+    primary key (domain_id, token),
+    constraint fk_bayes_tokens_domain_id foreign key (domain_id) references cm_domains(id) on delete cascade
+);
+
+create table cm_bayes_comments (
+    comment_id uuid primary key, -- Reference to the learnt comment
+    domain_id  uuid    not null, -- Reference to the domain
+    is_spam    boolean not null, -- Whether the comment was learnt as spam
+    -- Constraints
+    constraint fk_bayes_comments_comment_id foreign key (comment_id) references cm_comments(id) on delete cascade,
+    constraint fk_bayes_comments_domain_id  foreign key (domain_id)  references cm_domains(id)  on delete cascade
+);
+
+-- Indices
+create index idx_bayes_comments_domain_id on cm_bayes_comments(domain_id);
@@ -0,0 +1,8 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add automatic closing of domain pages
+------------------------------------------------------------------------------------------------------------------------
+
+alter table cm_domains add column auto_close_days integer default 0 not null; -- Number of days after the first comment (or creation) a page gets readonly, 0 to disable
+alter table cm_domains add column auto_close_paths text default '' not null;  -- Path prefixes, separated by whitespace, automatic closing is limited to; empty means all pages
+
+alter table cm_domain_pages add column no_auto_close boolean default false not null; -- Whether the page is exempt from automatic closing
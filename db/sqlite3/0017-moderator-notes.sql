@@ -0,0 +1,21 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Add moderator notes table
+------------------------------------------------------------------------------------------------------------------------
+
+create table cm_moderator_notes (
+    id           uuid primary key,                                 -- Unique record ID
+    domain_id    uuid                                    not null, -- Reference to the domain
+    user_id      uuid,                                             -- Reference to the user the note is about, null if it's about a comment
+    comment_id   uuid,                                             -- Reference to the comment the note is about, null if it's about a user
+    text         varchar(4096)                           not null, -- Note text
+    ts_created   timestamp     default current_timestamp not null, -- When the record was created
+    user_created uuid,                                             -- Reference to the user who created the note, null if the user has been deleted
+    -- Constraints
+    constraint fk_moderator_notes_domain_id    foreign key (domain_id)    references cm_domains(id)  on delete cascade,
+    constraint fk_moderator_notes_user_id      foreign key (user_id)      references cm_users(id)    on delete cascade,
+    constraint fk_moderator_notes_comment_id   foreign key (comment_id)   references cm_comments(id) on delete cascade,
+    constraint fk_moderator_notes_user_created foreign key (user_created) references cm_users(id)    on delete set null
+);
+
+create index idx_moderator_notes_domain_id_user_id on cm_moderator_notes(domain_id, user_id);
+create index idx_moderator_notes_comment_id        on cm_moderator_notes(comment_id);
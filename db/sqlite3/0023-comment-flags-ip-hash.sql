@@ -0,0 +1,12 @@
+------------------------------------------------------------------------------------------------------------------------
+-- Tell anonymous comment flags apart by the hash of the full IP address rather than by the (possibly masked) address
+------------------------------------------------------------------------------------------------------------------------
+
+alter table cm_comment_flags add column ip_hash varchar(64) default '' not null; -- Hash of the full IP address of the user who flagged the comment
+
+-- Existing flags have no hash: keep them distinct
+update cm_comment_flags set ip_hash = cast(id as text);
+
+-- Indices
+drop index idx_comment_flags_comment_ip;
+create unique index idx_comment_flags_comment_ip_hash on cm_comment_flags(comment_id, ip_hash) where user_id = '00000000-0000-0000-0000-000000000000';
@@ -1,4 +1,4 @@
-import { Comment, Commenter, PageInfo, Principal, UUID } from './models';
+import { Comment, Commenter, CommentFlagReason, CommentReaction, CommentRevision, MentionCandidate, PageInfo, Principal, UUID } from './models';
 import { HttpClient, HttpHeaders } from './http-client';
 import { Utils } from './utils';
 
@@ -43,6 +43,16 @@ export interface ApiCommentPreviewResponse {
     readonly html: string;
 }
 
+export interface ApiCommentReactResponse {
+    /** Updated reactions to the comment. */
+    readonly reactions: CommentReaction[];
+}
+
+export interface ApiCommentRevisionListResponse {
+    /** Revisions of the comment text, oldest first. */
+    readonly revisions?: CommentRevision[];
+}
+
 export interface ApiCommentUpdateResponse {
     readonly comment: Comment;
 }
@@ -51,6 +61,11 @@ export interface ApiCommentVoteResponse {
     readonly score: number;
 }
 
+export interface ApiPageMentionListResponse {
+    /** Users who can be mentioned, most recently commenting first. */
+    readonly users: MentionCandidate[];
+}
+
 export interface ApiAuthSignupResponse {
     /** Whether the user has been immediately confirmed. */
     readonly isConfirmed: boolean;
@@ -225,9 +240,10 @@ export class ApiService {
      * @param notifyModerator Whether the user is to receive moderator notifications.
      * @param notifyCommentStatus Whether the user is to be notified about status changes (approved/rejected) of their
      *     comments.
+     * @param notifyMentions Whether the user is to be notified about being mentioned in comments.
      */
-    async authUserSettingsUpdate(domainId: UUID, notifyReplies: boolean, notifyModerator: boolean, notifyCommentStatus: boolean): Promise<void> {
-        await this.httpClient.put<void>('embed/auth/user', {domainId, notifyReplies, notifyModerator, notifyCommentStatus}, this.addAuth());
+    async authUserSettingsUpdate(domainId: UUID, notifyReplies: boolean, notifyModerator: boolean, notifyCommentStatus: boolean, notifyMentions: boolean): Promise<void> {
+        await this.httpClient.put<void>('embed/auth/user', {domainId, notifyReplies, notifyModerator, notifyCommentStatus, notifyMentions}, this.addAuth());
 
         // Reload the principal to reflect the updates
         this._principal = await this.fetchPrincipal() ?? null;
@@ -264,6 +280,16 @@ export class ApiService {
         return this.httpClient.delete<void>(`embed/comments/${id}`, undefined, this.addAuth());
     }
 
+    /**
+     * Flag (report) specified comment.
+     * @param id ID of the comment to flag.
+     * @param reason Reason category.
+     * @param details Optional details explaining the reason.
+     */
+    async commentFlag(id: UUID, reason: CommentFlagReason, details?: string): Promise<void> {
+        return this.httpClient.post<void>(`embed/comments/${id}/flag`, {reason, details}, this.addAuth());
+    }
+
     /**
      * Fetch the specified comment and the related commenter.
      * @param id ID of the comment to retrieve.
@@ -316,6 +342,15 @@ export class ApiService {
         return r.html;
     }
 
+    /**
+     * Get a list of earlier versions of the specified comment's text.
+     * @param id ID of the comment to retrieve revisions for.
+     */
+    async commentRevisionList(id: UUID): Promise<CommentRevision[]> {
+        const r = await this.httpClient.get<ApiCommentRevisionListResponse>(`embed/comments/${id}/revisions`, this.addAuth());
+        return r.revisions ?? [];
+    }
+
     /**
      * Set sticky value for specified comment.
      * @param id ID of the comment to update.
@@ -325,6 +360,15 @@ export class ApiService {
         return this.httpClient.post<void>(`embed/comments/${id}/sticky`, {sticky}, this.addAuth());
     }
 
+    /**
+     * Add or remove the current user's reaction to specified comment.
+     * @param id ID of the comment to react to.
+     * @param reaction Reaction to toggle.
+     */
+    async commentReact(id: UUID, reaction: string): Promise<ApiCommentReactResponse> {
+        return this.httpClient.post<ApiCommentReactResponse>(`embed/comments/${id}/react`, {reaction}, this.addAuth());
+    }
+
     /**
      * Update an existing comment.
      * @param id ID of the comment to update.
@@ -343,6 +387,18 @@ export class ApiService {
         return this.httpClient.post<ApiCommentVoteResponse>(`embed/comments/${id}/vote`, {direction}, this.addAuth());
     }
 
+    /**
+     * Get a list of users who can be mentioned in comments on specified page.
+     * @param id ID of the page.
+     * @param filter Substring of user name to filter the list by.
+     */
+    async pageMentionList(id: UUID, filter: string): Promise<MentionCandidate[]> {
+        const r = await this.httpClient.get<ApiPageMentionListResponse>(
+            `embed/page/${id}/mentions?filter=${encodeURIComponent(filter)}`,
+            this.addAuth());
+        return r.users;
+    }
+
     /**
      * Update specified page's properties
      * @param id ID of the page to update.
@@ -13,6 +13,7 @@ import { WebSocketClient, WebSocketMessage } from './ws-client';
 import { I18nService } from './i18n';
 import { PopupBlockedDialog } from './popup-blocked-dialog';
 import { RssDialog } from './rss-dialog';
+import { CommentHistoryDialog } from './comment-history-dialog';
 
 /**
  * Web component implementing the <comentario-comments> element.
@@ -484,7 +485,8 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
             this.pageInfo!,
             async () => this.cancelCommentEdits(),
             editor => this.submitNewComment(parentCard, editor.markdown),
-            s => this.apiService.commentPreview(this.pageInfo!.domainId, s));
+            s => this.apiService.commentPreview(this.pageInfo!.domainId, s),
+            this.principal ? s => this.apiService.pageMentionList(this.pageInfo!.pageId, s) : undefined);
     }
 
     /**
@@ -504,7 +506,8 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
             this.pageInfo!,
             async () => this.cancelCommentEdits(),
             editor => this.submitCommentEdits(card, editor.markdown),
-            s => this.apiService.commentPreview(this.pageInfo!.domainId, s));
+            s => this.apiService.commentPreview(this.pageInfo!.domainId, s),
+            this.principal ? s => this.apiService.pageMentionList(this.pageInfo!.pageId, s) : undefined);
     }
 
     /**
@@ -862,6 +865,37 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
         }
     }
 
+    /**
+     * Add or remove the current user's reaction to the given comment.
+     */
+    private async reactComment(card: CommentCard, reaction: string): Promise<void> {
+        // Only registered users can react
+        let reloaded = false;
+        if (!this.principal) {
+            await this.profileBar!.loginUser();
+
+            // Failed to authenticate
+            if (!this.principal) {
+                return;
+            }
+
+            // The original card is gone at this point, because the comment tree is reloaded after the login
+            reloaded = true;
+        }
+
+        // Toggle the reaction with the backend
+        const c = card.comment;
+        this.lastCommentId = c.id;
+        const r = await this.apiService.commentReact(c.id, reaction);
+
+        // Update the comment and the card, if there's still one; otherwise reload the tree again
+        if (reloaded) {
+            await this.reload();
+        } else {
+            card.comment = this.parentMap.replaceComment(c.id, c.parentId, {reactions: r.reactions});
+        }
+    }
+
     /**
      * Vote (upvote, downvote, or undo vote) for the given comment.
      */
@@ -909,11 +943,15 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
             modCommentDeletion: !!this.pageInfo?.commentDeletionModerator,
             ownCommentEditing:  !!this.pageInfo?.commentEditingAuthor,
             modCommentEditing:  !!this.pageInfo?.commentEditingModerator,
+            commentEditHistory: !!this.pageInfo?.commentEditHistory,
             maxLevel:           this.maxLevel,
             enableVoting:       !!this.pageInfo?.enableCommentVoting,
+            commentReactions:   this.pageInfo?.commentReactions ?? [],
             t:                  this.i18n.t,
             onGetAvatar:        user => this.createAvatarElement(user),
+            onHistory:          (card, ref) => this.showCommentHistory(card, ref),
             onModerate:         (card, approve) => this.moderateComment(card, approve),
+            onReact:            (card, reaction) => this.reactComment(card, reaction),
             onDelete:           card => this.deleteComment(card),
             onEdit:             card => this.editComment(card),
             onReply:            card => this.addComment(card),
@@ -927,7 +965,7 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
      */
     private async saveUserSettings(data: UserSettings): Promise<void> {
         // Run the update with the backend
-        await this.apiService.authUserSettingsUpdate(this.pageInfo!.domainId, data.notifyReplies, data.notifyModerator, data.notifyCommentStatus);
+        await this.apiService.authUserSettingsUpdate(this.pageInfo!.domainId, data.notifyReplies, data.notifyModerator, data.notifyCommentStatus, data.notifyMentions);
 
         // Refresh the principal (it holds the profile settings) and update the profile bar
         await this.updateAuthStatus();
@@ -961,7 +999,18 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
 
     private async handleLiveUpdate(msg: WebSocketMessage) {
         // Make sure the message is intended for us
-        if (msg.domain !== this.pageInfo?.domainId || msg.path !== this.pagePath || !msg.comment) {
+        if (msg.domain !== this.pageInfo?.domainId || msg.path !== this.pagePath) {
+            return;
+        }
+
+        // Multiple comments have changed at once: reload the whole page
+        if (msg.action === 'refresh') {
+            await this.reload();
+            return;
+        }
+
+        // Any other action requires a comment
+        if (!msg.comment) {
             return;
         }
 
@@ -993,7 +1042,7 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
             return;
         }
 
-        // Any other action (new, update, vote, sticky): fetch the comment in question
+        // Any other action (new, update, vote, react, sticky): fetch the comment in question
         let comment: Comment;
         let commenter: Commenter | undefined;
         this.ignoreApiErrors = true;
@@ -1048,8 +1097,8 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
         // Update the thread toolbar on comment list change
         this.updateThreadToolbar();
 
-        // On success blink the card, except for vote updates
-        if (msg.action !== 'vote') {
+        // On success blink the card, except for vote and reaction updates
+        if (msg.action !== 'vote' && msg.action !== 'react') {
             card?.blink();
         }
     }
@@ -1115,6 +1164,16 @@ export class ComentarioComments extends ComentarioBase implements WebComponent {
         }
     }
 
+    /**
+     * Show the edit history popup dialog for the given comment.
+     * @param card Card of the comment to show the history for.
+     * @param ref Reference element for the popup
+     */
+    private async showCommentHistory(card: CommentCard, ref: Wrap<any>): Promise<void> {
+        const revisions = await this.apiService.commentRevisionList(card.comment.id);
+        await CommentHistoryDialog.run(this.i18n.t, this.root, {ref, placement: 'bottom-start'}, revisions);
+    }
+
     /**
      * Show RSS popup dialog.
      * @param ref Reference element for the popup
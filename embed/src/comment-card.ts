@@ -3,6 +3,7 @@ import {
     ANONYMOUS_ID, AsyncProcWithArg,
     Comment,
     CommenterMap,
+    CommentReaction,
     CommentSort,
     CommentSortComparators,
     Principal,
@@ -16,7 +17,9 @@ import { ConfirmDialog } from './confirm-dialog';
 
 export type CommentCardEventHandler = (c: CommentCard) => void;
 export type CommentCardGetAvatarHandler = (user: User | undefined) => Wrap<any>;
+export type CommentCardHistoryEventHandler = (c: CommentCard, ref: Wrap<any>) => Promise<void>;
 export type CommentCardModerateEventHandler = (c: CommentCard, approve: boolean) => Promise<void>;
+export type CommentCardReactEventHandler = (c: CommentCard, reaction: string) => Promise<void>;
 export type CommentCardVoteEventHandler = (c: CommentCard, direction: -1 | 0 | 1) => Promise<void>;
 
 /**
@@ -183,16 +186,22 @@ export interface CommentRenderingContext {
     readonly ownCommentEditing: boolean;
     /** Whether moderators can edit others' comments on this page. */
     readonly modCommentEditing: boolean;
+    /** Whether users can view the edit history of comments on this page. */
+    readonly commentEditHistory: boolean;
     /** Max comment nesting level. */
     readonly maxLevel: number;
     /** Whether voting on comments is enabled. */
     readonly enableVoting: boolean;
+    /** Reactions allowed on comments. Empty if reactions are disabled. */
+    readonly commentReactions: string[];
     /** i18n translation function. */
     readonly t: TranslateFunc;
 
     // Events
     readonly onGetAvatar: CommentCardGetAvatarHandler;
+    readonly onHistory:   CommentCardHistoryEventHandler;
     readonly onModerate:  CommentCardModerateEventHandler;
+    readonly onReact:     CommentCardReactEventHandler;
     readonly onDelete:    AsyncProcWithArg<CommentCard>;
     readonly onEdit:      CommentCardEventHandler;
     readonly onReply:     CommentCardEventHandler;
@@ -220,7 +229,9 @@ export class CommentCard extends Wrap<HTMLDivElement> {
     private eModeratorBadge?: Wrap<HTMLSpanElement>;
     private ePendingBadge?: Wrap<HTMLSpanElement>;
     private eModNotice?: Wrap<HTMLDivElement>;
+    private eSubtitle?: Wrap<HTMLDivElement>;
     private eSubtitleLink?: Wrap<HTMLAnchorElement>;
+    private eHistory?: Wrap<HTMLSpanElement>;
     private btnApprove?: Wrap<HTMLButtonElement>;
     private btnReject?: Wrap<HTMLButtonElement>;
     private btnDelete?: Wrap<HTMLButtonElement>;
@@ -229,8 +240,10 @@ export class CommentCard extends Wrap<HTMLDivElement> {
     private btnReply?: Wrap<HTMLButtonElement>;
     private btnSticky?: Wrap<HTMLButtonElement>;
     private btnUpvote?: Wrap<HTMLButtonElement>;
+    private btnReactions = new Map<string, Wrap<HTMLButtonElement>>();
     private collapsed = false;
     private isModerator = false;
+    private onHistory?: (ref: Wrap<any>) => Promise<void>;
 
     /** Localisation function (mapped to the I18n service). */
     private readonly t: TranslateFunc;
@@ -312,6 +325,7 @@ export class CommentCard extends Wrap<HTMLDivElement> {
         // Update card elements
         } else {
             this.updateVoteScore(c.score, c.direction);
+            this.updateReactions(c.reactions);
             this.updateStatus(c.isPending, c.isApproved);
             this.updateSticky(c.isSticky);
             this.updateModerationNotice(c.isPending, c.isApproved);
@@ -370,7 +384,7 @@ export class CommentCard extends Wrap<HTMLDivElement> {
                                         // Moderator badge
                                         this.eModeratorBadge),
                                 // Subtitle
-                                UIToolkit.div('subtitle')
+                                this.eSubtitle = UIToolkit.div('subtitle')
                                     // Permalink to the comment, with creation/editing metadata
                                     .append(this.eSubtitleLink = Wrap.new('a').attr({href: `#${Wrap.idPrefix}${id}`})))),
                 // Card body
@@ -409,6 +423,11 @@ export class CommentCard extends Wrap<HTMLDivElement> {
         this.isModerator = !!ctx.principal && (ctx.principal.isSuperuser || ctx.principal.isOwner || ctx.principal.isModerator);
         const ownComment = ctx.principal && this._comment.userCreated === ctx.principal.id;
 
+        // Edit history is always available to moderators
+        if (this.isModerator || ctx.commentEditHistory) {
+            this.onHistory = ref => ctx.onHistory(this, ref);
+        }
+
         // Left- and right-hand side of the toolbar
         const left = UIToolkit.div('toolbar-section').appendTo(toolbar);
         const right = UIToolkit.div('toolbar-section').appendTo(toolbar);
@@ -421,6 +440,13 @@ export class CommentCard extends Wrap<HTMLDivElement> {
                 this.btnDownvote = UIToolkit.toolButton('arrowDown', this.t('actionDownvote'), btn => btn.spin(() => ctx.onVote(this, this._comment.direction < 0 ? 0 : -1))).disabled(ownComment));
         }
 
+        // Reaction buttons
+        ctx.commentReactions.forEach(reaction => {
+            const btn = UIToolkit.button('', b => b.spin(() => ctx.onReact(this, reaction)), 'btn-reaction').attr({tabindex: '-1'});
+            this.btnReactions.set(reaction, btn);
+            left.append(btn);
+        });
+
         // Reply button
         if (ctx.canAddComments) {
             this.btnReply = UIToolkit.toolButton('reply', this.t('actionReply'), () => ctx.onReply(this)).appendTo(left);
@@ -496,6 +522,8 @@ export class CommentCard extends Wrap<HTMLDivElement> {
         this.btnReply?.remove();
         this.btnSticky?.remove();
         this.btnUpvote?.remove();
+        this.btnReactions.forEach(btn => btn.remove());
+        this.btnReactions.clear();
 
         // Update the card text
         this.eBody?.inner(`(${this.t('statusDeleted')})`);
@@ -523,6 +551,18 @@ export class CommentCard extends Wrap<HTMLDivElement> {
         this.btnDownvote?.setClasses(direction < 0, 'downvoted');
     }
 
+    /**
+     * Update the card's reaction buttons with the given reaction counts.
+     */
+    private updateReactions(reactions: CommentReaction[] | undefined) {
+        this.btnReactions.forEach((btn, reaction) => {
+            const r = reactions?.find(cr => cr.reaction === reaction);
+            btn.inner(r?.count ? `${reaction} ${r.count}` : reaction)
+                .attr({title: r?.users.map(u => u.name).join(', ') || this.t('actionReact')})
+                .setClasses(!!r?.reacted, 'reacted');
+        });
+    }
+
     /**
      * Update the card according to the comment's status.
      */
@@ -615,6 +655,23 @@ export class CommentCard extends Wrap<HTMLDivElement> {
             // Comment edited time text, if present
             addTime(c.editedTime, c.userEdited, 'statusEditedByAuthor', 'statusEditedByModerator');
         }
+
+        // Add a link to the edit history of an edited comment, if it's available
+        this.eHistory?.remove();
+        this.eHistory = undefined;
+        if (!c.isDeleted && c.editedTime && this.onHistory) {
+            const onHistory = this.onHistory;
+            this.eHistory = UIToolkit.span(' · ')
+                .append(
+                    Wrap.new('a')
+                        .inner(this.t('actionShowHistory'))
+                        .attr({href: '', role: 'button'})
+                        .click((a, e) => {
+                            e.preventDefault();
+                            void onHistory(a);
+                        }))
+                .appendTo(this.eSubtitle!);
+        }
     }
 
     /**
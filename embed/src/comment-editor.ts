@@ -1,10 +1,11 @@
 import { Wrap } from './element-wrap';
 import { UIToolkit } from './ui-toolkit';
-import { AsyncProcWithArg, PageInfo, TranslateFunc } from './models';
+import { AsyncProcWithArg, MentionCandidate, PageInfo, TranslateFunc } from './models';
 import { Utils } from './utils';
 import { BlockEditorCommand, EditorCommand, InlineEditorCommand } from './editor-command';
 
 export type CommentEditorPreviewCallback = (markdown: string) => Promise<string>;
+export type CommentEditorMentionCallback = (filter: string) => Promise<MentionCandidate[]>;
 
 export class CommentEditor extends Wrap<HTMLFormElement>{
 
@@ -14,10 +15,12 @@ export class CommentEditor extends Wrap<HTMLFormElement>{
     private readonly btnPreview: Wrap<HTMLButtonElement>;
     private readonly btnSubmit:  Wrap<HTMLButtonElement>;
     private readonly toolbar:    Wrap<HTMLDivElement>;
+    private readonly mentions:   Wrap<HTMLDivElement>;
     private readonly commands = this.createCommands();
 
     private previewing = false;
     private submitting = false;
+    private mentionTimer?: ReturnType<typeof setTimeout>;
 
     /**
      * Create a new editor for editing comment text.
@@ -29,6 +32,8 @@ export class CommentEditor extends Wrap<HTMLFormElement>{
      * @param onCancel Cancel callback.
      * @param onSubmit Submit callback.
      * @param onPreview Preview callback.
+     * @param onMentionLookup Optional callback for looking up users to mention. If not provided, no mention suggestions
+     *     are offered.
      */
     constructor(
         private readonly t: TranslateFunc,
@@ -39,6 +44,7 @@ export class CommentEditor extends Wrap<HTMLFormElement>{
         private readonly onCancel: AsyncProcWithArg<CommentEditor>,
         private readonly onSubmit: AsyncProcWithArg<CommentEditor>,
         private readonly onPreview: CommentEditorPreviewCallback,
+        private readonly onMentionLookup?: CommentEditorMentionCallback,
     ) {
         super(UIToolkit.form(() => this.submitEdit(), () => this.cancelEdit()).element);
 
@@ -54,7 +60,9 @@ export class CommentEditor extends Wrap<HTMLFormElement>{
                 this.textarea = UIToolkit.textarea(null, true, true)
                     .attr({name: 'comentario-comment-editor', maxlength: String(pageInfo.maxCommentLength)})
                     .value(initialText)
-                    .on('input', () => this.updateControls()),
+                    .on('input', () => this.textChanged()),
+                // Mention suggestions
+                this.mentions = UIToolkit.div('comment-editor-mentions', 'hidden'),
                 // Preview
                 this.preview = UIToolkit.div('comment-editor-preview', 'hidden'),
                 // Editor footer
@@ -232,6 +240,69 @@ export class CommentEditor extends Wrap<HTMLFormElement>{
         return r;
     }
 
+    /**
+     * Handle a change of the text in the editor.
+     * @private
+     */
+    private textChanged() {
+        this.updateControls();
+        this.suggestMentions();
+    }
+
+    /**
+     * Offer users to mention if there's a mention being typed right before the caret, otherwise hide the suggestions.
+     * @private
+     */
+    private suggestMentions() {
+        // Cancel any pending lookup
+        clearTimeout(this.mentionTimer);
+
+        // Check if a mention, either "@Name" or "@[Name with spaces", is being typed
+        const ta = this.textarea.element;
+        const m = this.onMentionLookup && ta.selectionStart === ta.selectionEnd &&
+            ta.value.substring(0, ta.selectionStart).match(/(?:^|\s)@(?:\[([^\]\n]{0,50})|([^\s@[\]]{0,50}))$/);
+        if (!m) {
+            this.hideMentions();
+            return;
+        }
+
+        // Look the users up after a short delay, to avoid querying the backend on every keystroke
+        const start = ta.selectionStart - m[0].replace(/^\s/, '').length;
+        this.mentionTimer = setTimeout(async () => {
+            let users: MentionCandidate[] = [];
+            try {
+                users = await this.onMentionLookup!(m[1] ?? m[2]);
+            } catch {
+                // Suggestions are optional, ignore any errors
+            }
+            this.mentions
+                .html('')
+                .setClasses(!users.length, 'hidden')
+                .append(...users.map(u => UIToolkit.button('', () => this.insertMention(start, u.name), 'btn-link').inner(u.name)));
+        }, 300);
+    }
+
+    /**
+     * Replace the mention being typed, starting at the given position and ending at the caret, with a mention of the
+     * user with the given name.
+     * @private
+     */
+    private insertMention(start: number, name: string) {
+        const ta = this.textarea.element;
+        ta.setRangeText(`@[${name}] `, start, ta.selectionStart, 'end');
+        this.hideMentions();
+        this.updateControls();
+        this.textarea.focus();
+    }
+
+    /**
+     * Hide and clean up the mention suggestions.
+     * @private
+     */
+    private hideMentions() {
+        this.mentions.html('').classes('hidden');
+    }
+
     /**
      * Update the editor controls' state according to the current situation.
      * @private
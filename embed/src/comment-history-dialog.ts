@@ -0,0 +1,47 @@
+import { Wrap } from './element-wrap';
+import { UIToolkit } from './ui-toolkit';
+import { Dialog, DialogPositioning } from './dialog';
+import { CommentRevision, TranslateFunc } from './models';
+import { Utils } from './utils';
+
+export class CommentHistoryDialog extends Dialog {
+
+    private constructor(
+        t: TranslateFunc,
+        parent: Wrap<any>,
+        pos: DialogPositioning,
+        private readonly revisions: CommentRevision[],
+    ) {
+        super(t, parent, t('dlgTitleCommentHistory'), pos);
+    }
+
+    /**
+     * Instantiate and show the dialog. Return a promise that resolves as soon as the dialog is closed.
+     * @param t Function for obtaining translated messages.
+     * @param parent Parent element for the dialog.
+     * @param pos Positioning options.
+     * @param revisions Earlier versions of the comment text, oldest first.
+     */
+    static run(t: TranslateFunc, parent: Wrap<any>, pos: DialogPositioning, revisions: CommentRevision[]): Promise<CommentHistoryDialog> {
+        const dlg = new CommentHistoryDialog(t, parent, pos, revisions);
+        return dlg.run(dlg);
+    }
+
+    override renderContent(): Wrap<any> {
+        // No earlier versions
+        if (!this.revisions.length) {
+            return UIToolkit.div('dialog-centered').inner(this.t('noEarlierVersions'));
+        }
+
+        // Render the revisions, latest first
+        return UIToolkit.div()
+            .append(
+                ...[...this.revisions].reverse().map(r => {
+                    const date = Utils.parseDate(r.createdTime);
+                    return UIToolkit.div('revision')
+                        .append(
+                            UIToolkit.div('revision-time').inner(date?.toLocaleString() ?? ''),
+                            UIToolkit.div('revision-body').html(r.html ?? ''));
+                }));
+    }
+}
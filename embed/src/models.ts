@@ -42,6 +42,7 @@ export interface Principal extends User {
     readonly notifyReplies:       boolean; // Whether the user is to be notified about replies to their comments
     readonly notifyModerator:     boolean; // Whether the user is to receive moderator notifications
     readonly notifyCommentStatus: boolean; // Whether the user is to be notified about status changes (approved/rejected) of their comments
+    readonly notifyMentions:      boolean; // Whether the user is to be notified about being mentioned in comments
 }
 
 /** Comment residing on a page. */
@@ -66,6 +67,37 @@ export interface Comment {
     readonly userEdited?:    UUID;    // ID of the user who last edited the comment (edited comment only). Undefined if the comment was edited by another user and the current user isn't a moderator
     readonly authorName?:    string;  // Name of the author, in case the user isn't registered
     readonly direction:      number;  // Vote direction for the current user
+    readonly reactions?:     CommentReaction[]; // Reactions to the comment, in the order of their first occurrence
+}
+
+/** User who reacted to a comment. */
+export interface CommentReactionUser {
+    readonly id:   UUID;   // ID of the user
+    readonly name: string; // Name of the user
+}
+
+/** User who can be mentioned in a comment. */
+export interface MentionCandidate {
+    readonly id:   UUID;   // ID of the user
+    readonly name: string; // Name of the user
+}
+
+/** Aggregated (emoji) reaction to a comment. */
+export interface CommentReaction {
+    readonly reaction: string;                // Reaction (emoji)
+    readonly count:    number;                // Number of users who reacted this way
+    readonly reacted:  boolean;               // Whether the current user has reacted this way
+    readonly users:    CommentReactionUser[]; // Users who reacted this way, in the order of reacting
+}
+
+/** Earlier version of a comment's text, as it was before an edit. */
+export interface CommentRevision {
+    readonly id:           UUID;   // Unique record ID
+    readonly commentId:    UUID;   // ID of the comment
+    readonly markdown?:    string; // Comment text in markdown (moderators only)
+    readonly html?:        string; // Rendered comment text in HTML
+    readonly createdTime:  string; // When the text was created
+    readonly userCreated?: UUID;   // ID of the user who created the text
 }
 
 /** Stripped-down, read-only version of the user who authored a comment. For now equivalent to User. */
@@ -118,6 +150,14 @@ export interface PageInfo {
     readonly commentEditingAuthor: boolean;
     /** Whether domain moderators are allowed to edit comments */
     readonly commentEditingModerator: boolean;
+    /** Whether readers can view earlier versions of edited comments */
+    readonly commentEditHistory: boolean;
+    /** Whether readers can flag comments */
+    readonly commentFlagging: boolean;
+    /** Whether unauthenticated readers can flag comments */
+    readonly commentFlaggingAnonymous: boolean;
+    /** Reactions readers are allowed to add to comments. Empty if reactions are disabled */
+    readonly commentReactions: string[];
     /** Whether voting on comments is enabled */
     readonly enableCommentVoting: boolean;
     /** Whether comment RSS feeds are enabled */
@@ -177,6 +217,9 @@ export type ComparatorFunc<T> = (a: T, b: T) => number;
 /** Comment sorting. 1st letter defines the property, 2nd letter the direction. */
 export type CommentSort = 'ta' | 'td' | 'sa' | 'sd';
 
+/** Reason category for flagging a comment. */
+export type CommentFlagReason = 'spam' | 'abuse' | 'offTopic' | 'other';
+
 /** Login choices available for the user in the Login dialog. */
 export enum LoginChoice {
     /** Signup (registration) instead of login. */
@@ -215,6 +258,7 @@ export interface UserSettings {
     notifyModerator:     boolean; // Whether to send moderator notifications to the user
     notifyReplies:       boolean; // Whether to send reply notifications to the user
     notifyCommentStatus: boolean; // Whether to send comment status notifications to the user
+    notifyMentions:      boolean; // Whether to send mention notifications to the user
 }
 
 export const ANONYMOUS_ID: UUID = '00000000-0000-0000-0000-000000000000';
@@ -8,6 +8,7 @@ export class SettingsDialog extends Dialog {
     private _cbNotifyModerator?: Wrap<HTMLInputElement>;
     private _cbNotifyReplies?: Wrap<HTMLInputElement>;
     private _cbNotifyCommentStatus?: Wrap<HTMLInputElement>;
+    private _cbNotifyMentions?: Wrap<HTMLInputElement>;
     private _btnSave?: Wrap<HTMLButtonElement>;
 
     private constructor(
@@ -64,7 +65,15 @@ export class SettingsDialog extends Dialog {
                                 .id('cb-notify-comment-status')
                                 .attr({type: 'checkbox'})
                                 .checked(this.principal.notifyCommentStatus),
-                            Wrap.new('label').attr({for: this._cbNotifyCommentStatus.getAttr('id')}).inner(this.t('fieldComStatusNotifications')))),
+                            Wrap.new('label').attr({for: this._cbNotifyCommentStatus.getAttr('id')}).inner(this.t('fieldComStatusNotifications'))),
+                    // Mention notifications checkbox
+                    UIToolkit.div('checkbox-container')
+                        .append(
+                            this._cbNotifyMentions = Wrap.new('input')
+                                .id('cb-notify-mentions')
+                                .attr({type: 'checkbox'})
+                                .checked(this.principal.notifyMentions),
+                            Wrap.new('label').attr({for: this._cbNotifyMentions.getAttr('id')}).inner(this.t('fieldMentionNotifications')))),
                 // Submit button
                 UIToolkit.div('dialog-centered')
                     .append(this._btnSave = UIToolkit.submit(this.t('actionSave'), false)),
@@ -96,6 +105,7 @@ export class SettingsDialog extends Dialog {
                 notifyModerator:     !!this._cbNotifyModerator?.isChecked,
                 notifyReplies:       !!this._cbNotifyReplies?.isChecked,
                 notifyCommentStatus: !!this._cbNotifyCommentStatus?.isChecked,
+                notifyMentions:      !!this._cbNotifyMentions?.isChecked,
             }));
 
         // Close the dialog
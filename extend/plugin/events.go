@@ -3,6 +3,8 @@
 
 package plugin
 
+import "github.com/google/uuid"
+
 // UserPayload is implemented by events carrying a user
 type UserPayload interface {
 	// User payload
@@ -24,6 +26,129 @@ func (e *UserEvent) SetUser(u *User) {
 	e.user = u
 }
 
+// CommentPayload is implemented by events carrying a comment, along with its page and domain
+type CommentPayload interface {
+	// Comment payload
+	Comment() *Comment
+	// SetComment updates the comment payload
+	SetComment(*Comment)
+	// Page the comment belongs to. Changes to the page are ignored
+	Page() *DomainPage
+	// SetPage updates the page the comment belongs to
+	SetPage(*DomainPage)
+	// Domain the comment belongs to. Changes to the domain are ignored
+	Domain() *Domain
+	// SetDomain updates the domain the comment belongs to
+	SetDomain(*Domain)
+}
+
+// CommentEvent is an event related to comment, which implements CommentPayload
+type CommentEvent struct {
+	comment *Comment
+	page    *DomainPage
+	domain  *Domain
+}
+
+func (e *CommentEvent) Comment() *Comment {
+	return e.comment
+}
+
+func (e *CommentEvent) SetComment(c *Comment) {
+	e.comment = c
+}
+
+func (e *CommentEvent) Page() *DomainPage {
+	return e.page
+}
+
+func (e *CommentEvent) SetPage(p *DomainPage) {
+	e.page = p
+}
+
+func (e *CommentEvent) Domain() *Domain {
+	return e.domain
+}
+
+func (e *CommentEvent) SetDomain(d *Domain) {
+	e.domain = d
+}
+
+// DomainPayload is implemented by events carrying a domain
+type DomainPayload interface {
+	// Domain payload
+	Domain() *Domain
+	// SetDomain updates the domain payload
+	SetDomain(*Domain)
+}
+
+// DomainEvent is an event related to domain, which implements DomainPayload
+type DomainEvent struct {
+	domain *Domain
+}
+
+func (e *DomainEvent) Domain() *Domain {
+	return e.domain
+}
+
+func (e *DomainEvent) SetDomain(d *Domain) {
+	e.domain = d
+}
+
+// DomainUserPayload is implemented by events carrying a domain user
+type DomainUserPayload interface {
+	// DomainUser payload
+	DomainUser() *DomainUser
+	// SetDomainUser updates the domain user payload
+	SetDomainUser(*DomainUser)
+}
+
+// DomainUserEvent is an event related to domain user, which implements DomainUserPayload
+type DomainUserEvent struct {
+	domainUser *DomainUser
+}
+
+func (e *DomainUserEvent) DomainUser() *DomainUser {
+	return e.domainUser
+}
+
+func (e *DomainUserEvent) SetDomainUser(du *DomainUser) {
+	e.domainUser = du
+}
+
+// PagePayload is implemented by events carrying a domain page, along with its domain
+type PagePayload interface {
+	// Page payload
+	Page() *DomainPage
+	// SetPage updates the page payload
+	SetPage(*DomainPage)
+	// Domain the page belongs to. Changes to the domain are ignored
+	Domain() *Domain
+	// SetDomain updates the domain the page belongs to
+	SetDomain(*Domain)
+}
+
+// PageEvent is an event related to domain page, which implements PagePayload
+type PageEvent struct {
+	page   *DomainPage
+	domain *Domain
+}
+
+func (e *PageEvent) Page() *DomainPage {
+	return e.page
+}
+
+func (e *PageEvent) SetPage(p *DomainPage) {
+	e.page = p
+}
+
+func (e *PageEvent) Domain() *Domain {
+	return e.domain
+}
+
+func (e *PageEvent) SetDomain(d *Domain) {
+	e.domain = d
+}
+
 // ---------------------------------------------------------------------------------------------------------------------
 
 // UserCreateEvent is fired on user creation
@@ -65,3 +190,105 @@ type UserLoginLockedStatusEvent struct {
 type UserMadeSuperuserEvent struct {
 	UserUpdateEvent
 }
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// CommentCreateEvent is fired before a new comment is persisted. Changes to the comment are saved
+type CommentCreateEvent struct {
+	CommentEvent
+}
+
+// CommentUpdateEvent is fired before an edited comment is persisted. Changes to the comment's text (Markdown, HTML) are
+// saved
+type CommentUpdateEvent struct {
+	CommentEvent
+}
+
+// CommentModerateEvent is fired before a comment's moderation status is persisted. Changes to the comment's moderation
+// status (IsApproved, IsPending, PendingReason) are saved
+type CommentModerateEvent struct {
+	CommentEvent
+}
+
+// CommentDeleteEvent is fired before a comment is marked deleted. Changes to the comment are ignored
+type CommentDeleteEvent struct {
+	CommentEvent
+	UserID uuid.UUID // ID of the user deleting the comment
+}
+
+// CommentStickyEvent is fired before a comment's sticky status is updated. Changes to the comment's IsSticky are saved
+type CommentStickyEvent struct {
+	CommentEvent
+}
+
+// CommentVoteEvent is fired before a vote for a comment is persisted. Changes to the comment are ignored
+type CommentVoteEvent struct {
+	CommentEvent
+	VoterID   uuid.UUID // ID of the voting user
+	Direction int8      // Vote direction: 1 for upvote, -1 for downvote, 0 for vote removal
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// DomainCreateEvent is fired before a new domain is persisted. Changes to the domain are saved
+type DomainCreateEvent struct {
+	DomainEvent
+	OwnerUserID uuid.UUID // ID of the user who will become the domain's owner
+}
+
+// DomainUpdateEvent is fired before domain changes are persisted. Changes to the domain are saved
+type DomainUpdateEvent struct {
+	DomainEvent
+}
+
+// DomainReadonlyEvent is fired before a domain's readonly status is updated. Changes to the domain's IsReadonly are
+// saved
+type DomainReadonlyEvent struct {
+	DomainUpdateEvent
+}
+
+// DomainClearEvent is fired before all domain's pages, comments, and stats are removed. Changes to the domain are
+// ignored
+type DomainClearEvent struct {
+	DomainEvent
+}
+
+// DomainPurgeEvent is fired before the domain's comments are purged. Changes to the domain are ignored
+type DomainPurgeEvent struct {
+	DomainEvent
+	Deleted     bool // Whether comments marked as deleted are to be purged
+	UserDeleted bool // Whether comments by deleted users are to be purged
+}
+
+// DomainDeleteEvent is fired before domain deletion. Changes to the domain are ignored
+type DomainDeleteEvent struct {
+	DomainEvent
+}
+
+// DomainUserAddEvent is fired before a user gets linked to a domain. Changes to the domain user are saved
+type DomainUserAddEvent struct {
+	DomainUserEvent
+}
+
+// DomainUserUpdateEvent is fired before domain user changes are persisted. Changes to the domain user are saved
+type DomainUserUpdateEvent struct {
+	DomainUserEvent
+}
+
+// DomainUserRemoveEvent is fired before a user gets unlinked from a domain. Changes to the domain user are ignored
+type DomainUserRemoveEvent struct {
+	DomainUserEvent
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// PageCreateEvent is fired after a new domain page has been created. Changes to the page's Title and IsReadonly are
+// saved
+type PageCreateEvent struct {
+	PageEvent
+}
+
+// PageUpdateEvent is fired before page changes are persisted. Changes to the page's Title and IsReadonly are saved
+type PageUpdateEvent struct {
+	PageEvent
+}
@@ -1,8 +1,10 @@
 package plugin
 
 import (
+	"context"
 	"github.com/google/uuid"
 	"net/http"
+	"time"
 )
 
 // Logger represents a logger provided to plugins
@@ -29,12 +31,25 @@ type Logger interface {
 type HostApp interface {
 	// AuthenticateBySessionCookie authenticates a principal given a session cookie value
 	AuthenticateBySessionCookie(value string) (*User, error)
+	// CommentStore returns an instance of the comment store
+	CommentStore() CommentStore
 	// Config is the host configuration
 	Config() *HostConfig
 	// CreateLogger creates and returns a logger used for logging plugin messages
 	CreateLogger(module string) Logger
 	// DomainAttrStore returns an instance of the domain attributes store for the plugin
 	DomainAttrStore() AttrStore
+	// DomainStore returns an instance of the domain store
+	DomainStore() DomainStore
+	// DynConfigStore returns an instance of the store for the dynamic configuration items declared by the plugin
+	DynConfigStore() DynConfigStore
+	// PageStore returns an instance of the domain page store
+	PageStore() PageStore
+	// RegisterMailTransport registers a mail transport under the given name, unique within the plugin. The transport can
+	// then be chosen in the secrets file as "<pluginId>/<name>"
+	RegisterMailTransport(name string, t MailTransport) error
+	// Scheduler returns an instance of the background job scheduler for the plugin
+	Scheduler() Scheduler
 	// UserAttrStore returns an instance of the user attributes store for the plugin
 	UserAttrStore() AttrStore
 	// UserStore returns an instance of the user store
@@ -60,6 +75,78 @@ type UserStore interface {
 	FindUserByID(id *uuid.UUID) (*User, error)
 }
 
+// CommentStore allows to retrieve and modify Comentario comments on behalf of a user, who must be a moderator of the
+// comment's domain or a superuser. Creating comments only requires the user to have a role on the domain
+type CommentStore interface {
+	// CreateComment creates and returns a new comment authored by the given user on the page with the given ID.
+	// parentID is an optional ID of the parent comment, which must not be deleted. The comment undergoes the same checks
+	// as one submitted by the user via the API, so it may end up pending moderation, or get rejected. Fails if the
+	// domain or the page is readonly, or the user is readonly or banned
+	CreateComment(userID, pageID, parentID *uuid.UUID, markdown string) (*Comment, error)
+	// FindCommentByID finds and returns a comment by the given comment ID
+	FindCommentByID(userID, id *uuid.UUID) (*Comment, error)
+	// ListComments returns all comments on the domain with the given ID, optionally filtered by page ID
+	ListComments(userID, domainID, pageID *uuid.UUID) ([]*Comment, error)
+	// ModerateComment updates the moderation status of a non-deleted comment with the given ID. The user must be a
+	// moderator of the comment's domain or a superuser
+	ModerateComment(userID, id *uuid.UUID, pending, approved bool, reason string) error
+}
+
+// DomainStore allows to retrieve Comentario domains on behalf of a user
+type DomainStore interface {
+	// FindDomainByID finds and returns a domain by the given ID. The user must have a role on the domain or be a
+	// superuser
+	FindDomainByID(userID, id *uuid.UUID) (*Domain, error)
+	// ListDomains returns all domains the user has a role on, or all domains if the user is a superuser
+	ListDomains(userID *uuid.UUID) ([]*Domain, error)
+}
+
+// PageStore allows to retrieve and modify Comentario domain pages on behalf of a user
+type PageStore interface {
+	// FindPageByID finds and returns a domain page by the given ID. The user must have a role on the page's domain or be
+	// a superuser
+	FindPageByID(userID, id *uuid.UUID) (*DomainPage, error)
+	// ListPages returns all pages of the domain with the given ID. The user must have a role on the domain or be a
+	// superuser
+	ListPages(userID, domainID *uuid.UUID) ([]*DomainPage, error)
+	// UpdatePage persists the Title and IsReadonly properties of the given page. The user must be a moderator of the
+	// page's domain or a superuser
+	UpdatePage(userID *uuid.UUID, page *DomainPage) error
+}
+
+// DynConfigStore allows to retrieve values of the dynamic configuration items declared by the plugin. Keys are the ones
+// specified in the item declarations
+type DynConfigStore interface {
+	// Get returns the value of an instance-scope item with the given key
+	Get(key string) (string, error)
+	// GetForDomain returns the value of a domain-scope item with the given key for the domain with the given ID
+	GetForDomain(domainID *uuid.UUID, key string) (string, error)
+}
+
+// JobFunc is a function implementing a scheduled job. The passed context is cancelled when the host is shutting down,
+// and the job is supposed to return as soon as possible after that
+type JobFunc func(ctx context.Context) error
+
+// Scheduler allows to run jobs periodically in the background. Jobs start running once the host app is fully
+// initialised, and are stopped when the host shuts down. If multiple Comentario instances share the same database,
+// each scheduled run of a job only happens on one of them
+type Scheduler interface {
+	// ScheduleCron schedules a job with the given name, unique within the plugin, to run according to the given
+	// cron-style spec "<minute> <hour> <day-of-month> <month> <day-of-week>", evaluated in UTC. Macros @hourly, @daily,
+	// @weekly, @monthly, and @yearly are also supported
+	ScheduleCron(name, spec string, f JobFunc) error
+	// ScheduleInterval schedules a job with the given name, unique within the plugin, to run every interval. Runs are
+	// aligned to multiples of the interval since zero time
+	ScheduleInterval(name string, interval time.Duration, f JobFunc) error
+}
+
+// MailTransport can send emails on behalf of the host app
+// Warning: Unstable API
+type MailTransport interface {
+	// Send sends out the given message
+	Send(msg *MailMessage) error
+}
+
 // UIResource describes a UI resource required by the plugin
 type UIResource struct {
 	Type string // Resource type
@@ -101,11 +188,136 @@ type MessageEntry struct {
 // Config describes plugin configuration
 // Warning: Unstable API
 type Config struct {
-	Path          string         // Path the plugin's handlers are invoked on
-	UIResources   []UIResource   // UI resources to be loaded for the plugin
-	UIPlugs       []UIPlug       // UI plugs
-	Messages      []MessageEntry // Plugin messages
-	XSRFSafePaths []string       // API endpoint path prefixes to exclude from XSRF protection (for methods other than GET/HEAD/OPTIONS), relative to plugin API root (may contain leading "/")
+	Path            string           // Path the plugin's handlers are invoked on
+	UIResources     []UIResource     // UI resources to be loaded for the plugin
+	UIPlugs         []UIPlug         // UI plugs
+	Messages        []MessageEntry   // Plugin messages
+	XSRFSafePaths   []string         // API endpoint path prefixes to exclude from XSRF protection (for methods other than GET/HEAD/OPTIONS), relative to plugin API root (may contain leading "/")
+	CommentScanners []CommentScanner // Comment scanners provided by the plugin
+	FederatedIdPs   []FederatedIdP   // Federated identity providers provided by the plugin
+	DynConfigItems  []DynConfigItem  // Dynamic configuration items declared by the plugin
+}
+
+// DynConfigItemScope is a scope of a dynamic configuration item
+type DynConfigItemScope string
+
+const (
+	DynConfigItemScopeInstance DynConfigItemScope = "instance" // The item is set for the entire instance by a superuser
+	DynConfigItemScopeDomain   DynConfigItemScope = "domain"   // The item is set per domain, defaulting to an instance-wide value
+)
+
+// DynConfigItemDatatype is a datatype of a dynamic configuration item
+type DynConfigItemDatatype string
+
+const (
+	DynConfigItemDatatypeBool   DynConfigItemDatatype = "bool"
+	DynConfigItemDatatypeInt    DynConfigItemDatatype = "int"
+	DynConfigItemDatatypeString DynConfigItemDatatype = "string"
+)
+
+// DynConfigItem describes a dynamic configuration item declared by a plugin. The item is registered under the key
+// "plugin.<pluginId>.<Key>" and is editable in the UI along with the built-in ones
+// Warning: Unstable API
+type DynConfigItem struct {
+	Key          string                // Item key, unique within the plugin, consisting of letters, digits, dots, and dashes
+	Scope        DynConfigItemScope    // Item scope
+	Datatype     DynConfigItemDatatype // Item datatype
+	Section      string                // Key of the section the item belongs to. Defaults to "plugins"
+	DefaultValue string                // Item's default value
+	Min          int                   // Minimum allowed value of an int item
+	Max          int                   // Maximum allowed value of an int item. If both Min and Max are zero, any non-negative value is allowed
+}
+
+// FederatedIdP describes a federated identity provider supplied by a plugin. The provider is registered under the ID
+// "plugin:<ID>" and is available for login on domains just like a built-in one
+// Warning: Unstable API
+type FederatedIdP struct {
+	ID   string // Unique provider ID, at most 25 characters long, consisting of lowercase letters, digits, and dashes
+	Name string // Provider display name
+	// NewProvider instantiates the provider given the full OAuth callback URL it must redirect to
+	NewProvider func(callbackURL string) (FederatedProvider, error)
+}
+
+// FederatedUser is a user authenticated by a federated identity provider
+// Warning: Unstable API
+type FederatedUser struct {
+	ID        string // Unique user ID within the provider, mandatory
+	Email     string // User's email, mandatory
+	Name      string // User's full name. If empty, NickName is used instead
+	NickName  string // User's nickname
+	AvatarURL string // Optional URL of the user's avatar image
+}
+
+// FederatedParams provides access to the query parameters of an OAuth callback request
+type FederatedParams interface {
+	// Get returns the value of the parameter with the given name, or an empty string if there's none
+	Get(name string) string
+}
+
+// FederatedSession is an authentication session of a federated identity provider. It's persisted between the login
+// request and the callback
+// Warning: Unstable API
+type FederatedSession interface {
+	// AuthURL returns the URL of the provider's authentication endpoint the user gets redirected to. The URL must
+	// include the state passed to FederatedProvider.BeginAuth as the "state" query parameter
+	AuthURL() (string, error)
+	// Authorize validates the parameters of the provider's callback request and completes the authentication
+	Authorize(params FederatedParams) error
+	// Marshal serialises the session into a string, which FederatedProvider.UnmarshalSession can restore it from
+	Marshal() string
+}
+
+// FederatedProvider is the implementation of a federated identity provider supplied by a plugin
+// Warning: Unstable API
+type FederatedProvider interface {
+	// BeginAuth starts a new authentication session with the given state
+	BeginAuth(state string) (FederatedSession, error)
+	// UnmarshalSession restores a session from a string produced by FederatedSession.Marshal
+	UnmarshalSession(data string) (FederatedSession, error)
+	// FetchUser returns the user authenticated in the given (authorised) session
+	FetchUser(sess FederatedSession) (*FederatedUser, error)
+}
+
+// CommentScanContext is a context for scanning a comment
+type CommentScanContext struct {
+	Request    *http.Request // HTTP request sent by the commenter, nil when reporting feedback
+	UserIP     string        // IP address of the commenter
+	UserAgent  string        // User agent of the commenter's browser
+	Referrer   string        // Referrer of the commenter's request
+	Comment    *Comment      // Comment being submitted
+	Domain     *Domain       // Comment's domain
+	Page       *DomainPage   // Comment's domain page
+	User       *User         // User who submitted the comment
+	DomainUser *DomainUser   // Domain user corresponding to User, can be nil
+	IsEdit     bool          // Whether the comment was edited, as opposed to a new comment
+}
+
+// CommentScanner can scan a comment for inappropriate content. Each scanner is exposed as a domain extension, which
+// can be enabled and configured by domain owners per domain
+// Warning: Unstable API
+type CommentScanner interface {
+	// ID returns a unique scanner ID, which also serves as the domain extension ID. Must be at most 32 characters long
+	// and consist of letters, digits, and characters '-', '_', '.'
+	ID() string
+	// Name returns the scanner's display name
+	Name() string
+	// DefaultConfig returns the default domain extension configuration, as a linebreak-separated list of key=value
+	// pairs
+	DefaultConfig() string
+	// Scan scans the provided comment for inappropriate content and returns whether it was found, and a reason for that.
+	// config is the domain extension configuration parsed into a parameter map
+	Scan(config map[string]string, ctx *CommentScanContext) (bool, string, error)
+}
+
+// CommentScannerFeedback can optionally be implemented by a CommentScanner to learn from moderators' decisions. Once
+// a moderator approves a comment the scanner found inappropriate, or rejects one it passed, the decision gets reported
+// back to the scanner
+// Warning: Unstable API
+type CommentScannerFeedback interface {
+	// Feedback reports a moderator's decision contradicting the scanner's verdict on a comment. positive indicates
+	// whether the comment is inappropriate. ctx holds the request details captured at scanning time (ctx.Request is
+	// nil); config is the current domain extension configuration parsed into a parameter map
+	Feedback(config map[string]string, ctx *CommentScanContext, positive bool) error
 }
 
 // YAMLDecoder allows for unmarshalling configuration into a user-defined structure, which provides `yaml` metadata
@@ -6,6 +6,7 @@ package plugin
 import (
 	"github.com/google/uuid"
 	"net/url"
+	"time"
 )
 
 // HostConfig provides access to the host app configuration
@@ -25,3 +26,66 @@ type User struct {
 	Banned      bool      // Whether the user is banned
 	IsLocked    bool      // Whether the user is locked out
 }
+
+// Domain represents a domain Comentario serves comments for
+type Domain struct {
+	ID          uuid.UUID // Unique domain ID
+	Name        string    // Domain display name
+	Host        string    // Domain host
+	CreatedTime time.Time // When the domain was created
+	IsHTTPS     bool      // Whether HTTPS should be used to resolve URLs on this domain
+	IsReadonly  bool      // Whether the domain is readonly (no new comments are allowed)
+}
+
+// DomainUser represents a link between a domain and a user, holding the user's roles and notification settings on it
+type DomainUser struct {
+	DomainID            uuid.UUID // ID of the domain
+	UserID              uuid.UUID // ID of the user
+	IsOwner             bool      // Whether the user is an owner of the domain
+	IsModerator         bool      // Whether the user is a moderator of the domain
+	IsCommenter         bool      // Whether the user is a commenter of the domain (if false, the user is readonly on the domain)
+	NotifyReplies       bool      // Whether the user is to be notified about replies to their comments
+	NotifyModerator     bool      // Whether the user is to receive moderator notifications
+	NotifyCommentStatus bool      // Whether the user is to be notified about status changes of their comments
+	NotifyMentions      bool      // Whether the user is to be notified about being mentioned in comments
+	CreatedTime         time.Time // When the domain user was created
+}
+
+// DomainPage represents a page on a specific domain
+type DomainPage struct {
+	ID          uuid.UUID // Unique page ID
+	DomainID    uuid.UUID // ID of the domain
+	Path        string    // Page path
+	Title       string    // Page title
+	IsReadonly  bool      // Whether the page is readonly (no new comments are allowed)
+	CreatedTime time.Time // When the page was created
+}
+
+// Comment represents a comment on a domain page
+type Comment struct {
+	ID            uuid.UUID     // Unique comment ID
+	ParentID      uuid.NullUUID // Parent comment ID, null if it's a root comment on the page
+	PageID        uuid.UUID     // ID of the page
+	Markdown      string        // Comment text in markdown
+	HTML          string        // Rendered comment text in HTML. Must be kept in sync with Markdown when the latter is updated
+	Score         int           // Comment score
+	IsSticky      bool          // Whether the comment is sticky (attached to the top of page)
+	IsApproved    bool          // Whether the comment is approved and can be seen by everyone
+	IsPending     bool          // Whether the comment is pending approval
+	IsDeleted     bool          // Whether the comment is marked as deleted
+	CreatedTime   time.Time     // When the comment was created
+	UserCreated   uuid.NullUUID // ID of the user who created the comment
+	PendingReason string        // The reason for the pending status
+	AuthorName    string        // Name of the author, in case the user isn't registered
+	AuthorCountry string        // 2-letter country code of the author
+}
+
+// MailMessage represents an email to be sent out
+type MailMessage struct {
+	From       string   // Sender address
+	ReplyTo    string   // Optional Reply-To address
+	To         string   // Recipient address
+	Subject    string   // Email subject
+	HTML       string   // HTML body
+	EmbedFiles []string // Paths to files to embed into the email, referenced in the body as "cid:<file name>"
+}
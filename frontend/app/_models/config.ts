@@ -6,46 +6,69 @@ export const ConfigKeyDomainDefaultsPrefix = 'domain.defaults.';
 
 /** Domain config item keys. */
 export enum DomainConfigItemKey {
-    commentDeletionAuthor    = 'comments.deletion.author',
-    commentDeletionModerator = 'comments.deletion.moderator',
-    commentEditingAuthor     = 'comments.editing.author',
-    commentEditingModerator  = 'comments.editing.moderator',
-    enableCommentVoting      = 'comments.enableVoting',
-    enableRss                = 'comments.rss.enabled',
-    showDeletedComments      = 'comments.showDeleted',
-    maxCommentLength         = 'comments.text.maxLength',
-    markdownImagesEnabled    = 'markdown.images.enabled',
-    markdownLinksEnabled     = 'markdown.links.enabled',
-    markdownTablesEnabled    = 'markdown.tables.enabled',
-    localSignupEnabled       = 'signup.enableLocal',
-    federatedSignupEnabled   = 'signup.enableFederated',
-    ssoSignupEnabled         = 'signup.enableSso',
+    commentDeletionAuthor           = 'comments.deletion.author',
+    commentDeletionModerator        = 'comments.deletion.moderator',
+    commentEditingAuthor            = 'comments.editing.author',
+    commentEditingModerator         = 'comments.editing.moderator',
+    enableCommentVoting             = 'comments.enableVoting',
+    enableRss                       = 'comments.rss.enabled',
+    showDeletedComments             = 'comments.showDeleted',
+    maxCommentLength                = 'comments.text.maxLength',
+    markdownImagesEnabled           = 'markdown.images.enabled',
+    markdownLinksEnabled            = 'markdown.links.enabled',
+    markdownTablesEnabled           = 'markdown.tables.enabled',
+    localSignupEnabled              = 'signup.enableLocal',
+    federatedSignupEnabled          = 'signup.enableFederated',
+    ssoSignupEnabled                = 'signup.enableSso',
+    rateLimitCommentPerMinute       = 'ratelimit.comment.perMinute',
+    rateLimitCommentDomainPerMinute = 'ratelimit.comment.domainPerMinute',
+    rateLimitFlagPerHour            = 'ratelimit.flag.perHour',
+    rateLimitLoginPerMinute         = 'ratelimit.login.perMinute',
+    rateLimitSignupPerHour          = 'ratelimit.signup.perHour',
+    commentFlaggingEnabled          = 'comments.flagging.enabled',
+    commentFlaggingAnonymous        = 'comments.flagging.anonymous',
+    commentFlaggingThreshold        = 'comments.flagging.threshold',
+    commentEditHistory              = 'comments.editing.history',
+    commentReactions                = 'comments.reactions.allowed',
 }
 
 /** Instance dynamic config item keys. */
 export enum InstanceConfigItemKey {
-    authEmailUpdateEnabled                 = 'auth.emailUpdate.enabled',
-    authLoginLocalMaxAttempts              = 'auth.login.local.maxAttempts',
-    authSignupConfirmCommenter             = 'auth.signup.confirm.commenter',
-    authSignupConfirmUser                  = 'auth.signup.confirm.user',
-    authSignupEnabled                      = 'auth.signup.enabled',
-    integrationsUseGravatar                = 'integrations.useGravatar',
-    operationNewOwnerEnabled               = 'operation.newOwner.enabled',
+    authEmailUpdateEnabled                        = 'auth.emailUpdate.enabled',
+    authLoginLocalMaxAttempts                     = 'auth.login.local.maxAttempts',
+    authSignupConfirmCommenter                    = 'auth.signup.confirm.commenter',
+    authSignupConfirmUser                         = 'auth.signup.confirm.user',
+    authSignupEnabled                             = 'auth.signup.enabled',
+    integrationsUseGravatar                       = 'integrations.useGravatar',
+    operationNewOwnerEnabled                      = 'operation.newOwner.enabled',
+    rateLimitLoginPerMinute                       = 'ratelimit.login.perMinute',
+    rateLimitPwdResetPerHour                      = 'ratelimit.pwdReset.perHour',
+    rateLimitSignupPerHour                        = 'ratelimit.signup.perHour',
     // Domain defaults
-    domainDefaultsCommentDeletionAuthor    = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentDeletionAuthor,
-    domainDefaultsCommentDeletionModerator = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentDeletionModerator,
-    domainDefaultsCommentEditingAuthor     = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentEditingAuthor,
-    domainDefaultsCommentEditingModerator  = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentEditingModerator,
-    domainDefaultsEnableCommentVoting      = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.enableCommentVoting,
-    domainDefaultsEnableRss                = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.enableRss,
-    domainDefaultsShowDeletedComments      = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.showDeletedComments,
-    domainDefaultsMaxCommentLength         = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.maxCommentLength,
-    domainDefaultsMarkdownImagesEnabled    = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.markdownImagesEnabled,
-    domainDefaultsMarkdownLinksEnabled     = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.markdownLinksEnabled,
-    domainDefaultsMarkdownTablesEnabled    = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.markdownTablesEnabled,
-    domainDefaultsLocalSignupEnabled       = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.localSignupEnabled,
-    domainDefaultsFederatedSignupEnabled   = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.federatedSignupEnabled,
-    domainDefaultsSsoSignupEnabled         = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.ssoSignupEnabled,
+    domainDefaultsCommentDeletionAuthor           = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentDeletionAuthor,
+    domainDefaultsCommentDeletionModerator        = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentDeletionModerator,
+    domainDefaultsCommentEditingAuthor            = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentEditingAuthor,
+    domainDefaultsCommentEditingModerator         = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentEditingModerator,
+    domainDefaultsEnableCommentVoting             = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.enableCommentVoting,
+    domainDefaultsEnableRss                       = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.enableRss,
+    domainDefaultsShowDeletedComments             = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.showDeletedComments,
+    domainDefaultsMaxCommentLength                = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.maxCommentLength,
+    domainDefaultsMarkdownImagesEnabled           = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.markdownImagesEnabled,
+    domainDefaultsMarkdownLinksEnabled            = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.markdownLinksEnabled,
+    domainDefaultsMarkdownTablesEnabled           = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.markdownTablesEnabled,
+    domainDefaultsLocalSignupEnabled              = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.localSignupEnabled,
+    domainDefaultsFederatedSignupEnabled          = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.federatedSignupEnabled,
+    domainDefaultsSsoSignupEnabled                = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.ssoSignupEnabled,
+    domainDefaultsRateLimitCommentPerMinute       = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.rateLimitCommentPerMinute,
+    domainDefaultsRateLimitCommentDomainPerMinute = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.rateLimitCommentDomainPerMinute,
+    domainDefaultsRateLimitFlagPerHour            = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.rateLimitFlagPerHour,
+    domainDefaultsRateLimitLoginPerMinute         = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.rateLimitLoginPerMinute,
+    domainDefaultsRateLimitSignupPerHour          = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.rateLimitSignupPerHour,
+    domainDefaultsCommentFlaggingEnabled          = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentFlaggingEnabled,
+    domainDefaultsCommentFlaggingAnonymous        = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentFlaggingAnonymous,
+    domainDefaultsCommentFlaggingThreshold        = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentFlaggingThreshold,
+    domainDefaultsCommentEditHistory              = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentEditHistory,
+    domainDefaultsCommentReactions                = ConfigKeyDomainDefaultsPrefix + DomainConfigItemKey.commentReactions,
 }
 
 /**
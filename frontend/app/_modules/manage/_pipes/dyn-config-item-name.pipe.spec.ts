@@ -11,45 +11,64 @@ describe('DynConfigItemNamePipe', () => {
     });
 
     [
-        {in: undefined,                                     want: ''},
-        {in: null,                                          want: ''},
-        {in: '',                                            want: ''},
-        {in: 'foo',                                         want: '[foo]'},
+        {in: undefined,                                           want: ''},
+        {in: null,                                                want: ''},
+        {in: '',                                                  want: ''},
+        {in: 'foo',                                               want: '[foo]'},
         // Instance settings
-        {in: 'auth.emailUpdate.enabled',                    want: 'Allow users to update their emails'},
-        {in: 'auth.login.local.maxAttempts',                want: 'Max. failed login attempts'},
-        {in: 'auth.signup.confirm.commenter',               want: 'New commenters must confirm their email'},
-        {in: 'auth.signup.confirm.user',                    want: 'New users must confirm their email'},
-        {in: 'auth.signup.enabled',                         want: 'Enable registration of new users'},
-        {in: 'integrations.useGravatar',                    want: 'Use Gravatar for user avatars'},
-        {in: 'operation.newOwner.enabled',                  want: 'Non-owner users can add domains'},
+        {in: 'auth.emailUpdate.enabled',                          want: 'Allow users to update their emails'},
+        {in: 'auth.login.local.maxAttempts',                      want: 'Max. failed login attempts'},
+        {in: 'auth.signup.confirm.commenter',                     want: 'New commenters must confirm their email'},
+        {in: 'auth.signup.confirm.user',                          want: 'New users must confirm their email'},
+        {in: 'auth.signup.enabled',                               want: 'Enable registration of new users'},
+        {in: 'integrations.useGravatar',                          want: 'Use Gravatar for user avatars'},
+        {in: 'operation.newOwner.enabled',                        want: 'Non-owner users can add domains'},
+        {in: 'ratelimit.login.perMinute',                         want: 'Max. login attempts per minute from one IP'},
+        {in: 'ratelimit.pwdReset.perHour',                        want: 'Max. password reset requests per hour from one IP'},
+        {in: 'ratelimit.signup.perHour',                          want: 'Max. registrations per hour from one IP'},
         // Domain defaults
-        {in: 'domain.defaults.comments.deletion.author',    want: 'Allow comment authors to delete comments'},
-        {in: 'domain.defaults.comments.deletion.moderator', want: 'Allow moderators to delete comments'},
-        {in: 'domain.defaults.comments.editing.author',     want: 'Allow comment authors to edit comments'},
-        {in: 'domain.defaults.comments.editing.moderator',  want: 'Allow moderators to edit comments'},
-        {in: 'domain.defaults.comments.enableVoting',       want: 'Enable voting on comments'},
-        {in: 'domain.defaults.comments.rss.enabled',        want: 'Enable comment RSS feeds'},
-        {in: 'domain.defaults.comments.showDeleted',        want: 'Show deleted comments'},
-        {in: 'domain.defaults.comments.text.maxLength',     want: 'Maximum comment text length'},
-        {in: 'domain.defaults.markdown.images.enabled',     want: 'Enable images in comments'},
-        {in: 'domain.defaults.markdown.links.enabled',      want: 'Enable links in comments'},
-        {in: 'domain.defaults.markdown.tables.enabled',     want: 'Enable tables in comments'},
-        {in: 'domain.defaults.signup.enableLocal',          want: 'Enable local commenter registration'},
-        {in: 'domain.defaults.signup.enableFederated',      want: 'Enable commenter registration via external provider'},
-        {in: 'domain.defaults.signup.enableSso',            want: 'Enable commenter registration via SSO'},
+        {in: 'domain.defaults.comments.deletion.author',          want: 'Allow comment authors to delete comments'},
+        {in: 'domain.defaults.comments.deletion.moderator',       want: 'Allow moderators to delete comments'},
+        {in: 'domain.defaults.comments.editing.author',           want: 'Allow comment authors to edit comments'},
+        {in: 'domain.defaults.comments.editing.moderator',        want: 'Allow moderators to edit comments'},
+        {in: 'domain.defaults.comments.enableVoting',             want: 'Enable voting on comments'},
+        {in: 'domain.defaults.comments.rss.enabled',              want: 'Enable comment RSS feeds'},
+        {in: 'domain.defaults.comments.showDeleted',              want: 'Show deleted comments'},
+        {in: 'domain.defaults.comments.text.maxLength',           want: 'Maximum comment text length'},
+        {in: 'domain.defaults.markdown.images.enabled',           want: 'Enable images in comments'},
+        {in: 'domain.defaults.markdown.links.enabled',            want: 'Enable links in comments'},
+        {in: 'domain.defaults.markdown.tables.enabled',           want: 'Enable tables in comments'},
+        {in: 'domain.defaults.signup.enableLocal',                want: 'Enable local commenter registration'},
+        {in: 'domain.defaults.signup.enableFederated',            want: 'Enable commenter registration via external provider'},
+        {in: 'domain.defaults.signup.enableSso',                  want: 'Enable commenter registration via SSO'},
+        {in: 'domain.defaults.ratelimit.comment.perMinute',       want: 'Max. comments per minute from one IP or user'},
+        {in: 'domain.defaults.ratelimit.comment.domainPerMinute', want: 'Max. comments per minute on the domain'},
+        {in: 'domain.defaults.ratelimit.flag.perHour',            want: 'Max. comment flags per hour from one IP or user'},
+        {in: 'domain.defaults.comments.flagging.enabled',         want: 'Allow readers to flag comments'},
+        {in: 'domain.defaults.comments.flagging.anonymous',       want: 'Allow anonymous readers to flag comments'},
+        {in: 'domain.defaults.comments.flagging.threshold',       want: 'Number of flags that send a comment back to moderation'},
+        {in: 'domain.defaults.comments.editing.history',          want: 'Show comment edit history to readers'},
+        {in: 'domain.defaults.comments.reactions.allowed',        want: 'Allowed comment reactions (comma-separated)'},
         // Domain settings
-        {in: 'comments.deletion.author',                    want: 'Allow comment authors to delete comments'},
-        {in: 'comments.deletion.moderator',                 want: 'Allow moderators to delete comments'},
-        {in: 'comments.editing.author',                     want: 'Allow comment authors to edit comments'},
-        {in: 'comments.editing.moderator',                  want: 'Allow moderators to edit comments'},
-        {in: 'comments.enableVoting',                       want: 'Enable voting on comments'},
-        {in: 'comments.rss.enabled',                        want: 'Enable comment RSS feeds'},
-        {in: 'comments.showDeleted',                        want: 'Show deleted comments'},
-        {in: 'comments.text.maxLength',                     want: 'Maximum comment text length'},
-        {in: 'signup.enableLocal',                          want: 'Enable local commenter registration'},
-        {in: 'signup.enableFederated',                      want: 'Enable commenter registration via external provider'},
-        {in: 'signup.enableSso',                            want: 'Enable commenter registration via SSO'},
+        {in: 'comments.deletion.author',                          want: 'Allow comment authors to delete comments'},
+        {in: 'comments.deletion.moderator',                       want: 'Allow moderators to delete comments'},
+        {in: 'comments.editing.author',                           want: 'Allow comment authors to edit comments'},
+        {in: 'comments.editing.moderator',                        want: 'Allow moderators to edit comments'},
+        {in: 'comments.enableVoting',                             want: 'Enable voting on comments'},
+        {in: 'comments.rss.enabled',                              want: 'Enable comment RSS feeds'},
+        {in: 'comments.showDeleted',                              want: 'Show deleted comments'},
+        {in: 'comments.text.maxLength',                           want: 'Maximum comment text length'},
+        {in: 'signup.enableLocal',                                want: 'Enable local commenter registration'},
+        {in: 'signup.enableFederated',                            want: 'Enable commenter registration via external provider'},
+        {in: 'signup.enableSso',                                  want: 'Enable commenter registration via SSO'},
+        {in: 'ratelimit.comment.perMinute',                       want: 'Max. comments per minute from one IP or user'},
+        {in: 'ratelimit.comment.domainPerMinute',                 want: 'Max. comments per minute on the domain'},
+        {in: 'ratelimit.flag.perHour',                            want: 'Max. comment flags per hour from one IP or user'},
+        {in: 'comments.flagging.enabled',                         want: 'Allow readers to flag comments'},
+        {in: 'comments.flagging.anonymous',                       want: 'Allow anonymous readers to flag comments'},
+        {in: 'comments.flagging.threshold',                       want: 'Number of flags that send a comment back to moderation'},
+        {in: 'comments.editing.history',                          want: 'Show comment edit history to readers'},
+        {in: 'comments.reactions.allowed',                        want: 'Allowed comment reactions (comma-separated)'},
     ]
         .forEach(test =>
             it(`transforms '${test.in}' into '${test.want}'`, () =>
@@ -7,28 +7,41 @@ import { ConfigKeyDomainDefaultsPrefix, InstanceConfigItemKey } from '../../../_
 export class DynConfigItemNamePipe implements PipeTransform {
 
     private static ITEM_NAMES: Record<InstanceConfigItemKey, string> = {
-        [InstanceConfigItemKey.authEmailUpdateEnabled]:                 $localize`Allow users to update their emails`,
-        [InstanceConfigItemKey.authLoginLocalMaxAttempts]:              $localize`Max. failed login attempts`,
-        [InstanceConfigItemKey.authSignupConfirmCommenter]:             $localize`New commenters must confirm their email`,
-        [InstanceConfigItemKey.authSignupConfirmUser]:                  $localize`New users must confirm their email`,
-        [InstanceConfigItemKey.authSignupEnabled]:                      $localize`Enable registration of new users`,
-        [InstanceConfigItemKey.integrationsUseGravatar]:                $localize`Use Gravatar for user avatars`,
-        [InstanceConfigItemKey.operationNewOwnerEnabled]:               $localize`Non-owner users can add domains`,
+        [InstanceConfigItemKey.authEmailUpdateEnabled]:                        $localize`Allow users to update their emails`,
+        [InstanceConfigItemKey.authLoginLocalMaxAttempts]:                     $localize`Max. failed login attempts`,
+        [InstanceConfigItemKey.authSignupConfirmCommenter]:                    $localize`New commenters must confirm their email`,
+        [InstanceConfigItemKey.authSignupConfirmUser]:                         $localize`New users must confirm their email`,
+        [InstanceConfigItemKey.authSignupEnabled]:                             $localize`Enable registration of new users`,
+        [InstanceConfigItemKey.integrationsUseGravatar]:                       $localize`Use Gravatar for user avatars`,
+        [InstanceConfigItemKey.operationNewOwnerEnabled]:                      $localize`Non-owner users can add domains`,
+        [InstanceConfigItemKey.rateLimitLoginPerMinute]:                       $localize`Max. login attempts per minute from one IP`,
+        [InstanceConfigItemKey.rateLimitPwdResetPerHour]:                      $localize`Max. password reset requests per hour from one IP`,
+        [InstanceConfigItemKey.rateLimitSignupPerHour]:                        $localize`Max. registrations per hour from one IP`,
         // Domain defaults
-        [InstanceConfigItemKey.domainDefaultsCommentDeletionAuthor]:    $localize`Allow comment authors to delete comments`,
-        [InstanceConfigItemKey.domainDefaultsCommentDeletionModerator]: $localize`Allow moderators to delete comments`,
-        [InstanceConfigItemKey.domainDefaultsCommentEditingAuthor]:     $localize`Allow comment authors to edit comments`,
-        [InstanceConfigItemKey.domainDefaultsCommentEditingModerator]:  $localize`Allow moderators to edit comments`,
-        [InstanceConfigItemKey.domainDefaultsEnableCommentVoting]:      $localize`Enable voting on comments`,
-        [InstanceConfigItemKey.domainDefaultsEnableRss]:                $localize`Enable comment RSS feeds`,
-        [InstanceConfigItemKey.domainDefaultsShowDeletedComments]:      $localize`Show deleted comments`,
-        [InstanceConfigItemKey.domainDefaultsMaxCommentLength]:         $localize`Maximum comment text length`,
-        [InstanceConfigItemKey.domainDefaultsMarkdownImagesEnabled]:    $localize`Enable images in comments`,
-        [InstanceConfigItemKey.domainDefaultsMarkdownLinksEnabled]:     $localize`Enable links in comments`,
-        [InstanceConfigItemKey.domainDefaultsMarkdownTablesEnabled]:    $localize`Enable tables in comments`,
-        [InstanceConfigItemKey.domainDefaultsLocalSignupEnabled]:       $localize`Enable local commenter registration`,
-        [InstanceConfigItemKey.domainDefaultsFederatedSignupEnabled]:   $localize`Enable commenter registration via external provider`,
-        [InstanceConfigItemKey.domainDefaultsSsoSignupEnabled]:         $localize`Enable commenter registration via SSO`,
+        [InstanceConfigItemKey.domainDefaultsCommentDeletionAuthor]:           $localize`Allow comment authors to delete comments`,
+        [InstanceConfigItemKey.domainDefaultsCommentDeletionModerator]:        $localize`Allow moderators to delete comments`,
+        [InstanceConfigItemKey.domainDefaultsCommentEditingAuthor]:            $localize`Allow comment authors to edit comments`,
+        [InstanceConfigItemKey.domainDefaultsCommentEditingModerator]:         $localize`Allow moderators to edit comments`,
+        [InstanceConfigItemKey.domainDefaultsEnableCommentVoting]:             $localize`Enable voting on comments`,
+        [InstanceConfigItemKey.domainDefaultsEnableRss]:                       $localize`Enable comment RSS feeds`,
+        [InstanceConfigItemKey.domainDefaultsShowDeletedComments]:             $localize`Show deleted comments`,
+        [InstanceConfigItemKey.domainDefaultsMaxCommentLength]:                $localize`Maximum comment text length`,
+        [InstanceConfigItemKey.domainDefaultsMarkdownImagesEnabled]:           $localize`Enable images in comments`,
+        [InstanceConfigItemKey.domainDefaultsMarkdownLinksEnabled]:            $localize`Enable links in comments`,
+        [InstanceConfigItemKey.domainDefaultsMarkdownTablesEnabled]:           $localize`Enable tables in comments`,
+        [InstanceConfigItemKey.domainDefaultsLocalSignupEnabled]:              $localize`Enable local commenter registration`,
+        [InstanceConfigItemKey.domainDefaultsFederatedSignupEnabled]:          $localize`Enable commenter registration via external provider`,
+        [InstanceConfigItemKey.domainDefaultsSsoSignupEnabled]:                $localize`Enable commenter registration via SSO`,
+        [InstanceConfigItemKey.domainDefaultsRateLimitCommentPerMinute]:       $localize`Max. comments per minute from one IP or user`,
+        [InstanceConfigItemKey.domainDefaultsRateLimitCommentDomainPerMinute]: $localize`Max. comments per minute on the domain`,
+        [InstanceConfigItemKey.domainDefaultsRateLimitFlagPerHour]:            $localize`Max. comment flags per hour from one IP or user`,
+        [InstanceConfigItemKey.domainDefaultsRateLimitLoginPerMinute]:         $localize`Max. login attempts per minute from one IP`,
+        [InstanceConfigItemKey.domainDefaultsRateLimitSignupPerHour]:          $localize`Max. registrations per hour from one IP`,
+        [InstanceConfigItemKey.domainDefaultsCommentFlaggingEnabled]:          $localize`Allow readers to flag comments`,
+        [InstanceConfigItemKey.domainDefaultsCommentFlaggingAnonymous]:        $localize`Allow anonymous readers to flag comments`,
+        [InstanceConfigItemKey.domainDefaultsCommentFlaggingThreshold]:        $localize`Number of flags that send a comment back to moderation`,
+        [InstanceConfigItemKey.domainDefaultsCommentEditHistory]:              $localize`Show comment edit history to readers`,
+        [InstanceConfigItemKey.domainDefaultsCommentReactions]:                $localize`Allowed comment reactions (comma-separated)`,
     };
 
     transform(key: string | null | undefined): string {
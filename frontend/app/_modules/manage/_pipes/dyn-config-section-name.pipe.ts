@@ -11,6 +11,8 @@ export class DynConfigSectionNamePipe implements PipeTransform {
         'integrations': $localize`Integrations`,
         'markdown':     $localize`Markdown`,
         'misc':         $localize`Miscellaneous`,
+        'plugins':      $localize`Plugins`,
+        'rateLimits':   $localize`Rate limits`,
     };
 
     transform(key: string | null | undefined): string {
@@ -5,7 +5,7 @@ import { debounceTime, distinctUntilChanged, merge, mergeWith, Subject, switchMa
 import { filter, map } from 'rxjs/operators';
 import { UntilDestroy, untilDestroyed } from '@ngneat/until-destroy';
 import { FaIconComponent } from '@fortawesome/angular-fontawesome';
-import { faCheck, faQuestion, faTrashAlt, faXmark } from '@fortawesome/free-solid-svg-icons';
+import { faCheck, faFlag, faQuestion, faTrashAlt, faXmark } from '@fortawesome/free-solid-svg-icons';
 import { ApiGeneralService, Comment, Commenter } from '../../../../../../generated-api';
 import { DomainMeta, DomainSelectorService } from '../../../_services/domain-selector.service';
 import { ConfigService } from '../../../../../_services/config.service';
@@ -86,11 +86,13 @@ export class CommentListComponent implements OnInit, OnChanges {
         pending:  true,
         rejected: true,
         deleted:  false,
+        flagged:  false,
         filter:   '',
     });
 
     // Icons
     readonly faCheck    = faCheck;
+    readonly faFlag     = faFlag;
     readonly faQuestion = faQuestion;
     readonly faTrashAlt = faTrashAlt;
     readonly faXmark    = faXmark;
@@ -120,7 +122,8 @@ export class CommentListComponent implements OnInit, OnChanges {
                                 this.filterForm.controls.approved,
                                 this.filterForm.controls.pending,
                                 this.filterForm.controls.rejected,
-                                this.filterForm.controls.deleted);
+                                this.filterForm.controls.deleted,
+                                this.filterForm.controls.flagged);
                         })),
                 // Subscribe to sort changes
                 this.sort.changes.pipe(untilDestroyed(this)),
@@ -156,6 +159,7 @@ export class CommentListComponent implements OnInit, OnChanges {
                             !isMod || f.pending,
                             !isMod || f.rejected,
                             !isMod || f.deleted,
+                            isMod && f.flagged,
                             f.filter,
                             ++this.loadedPageNum,
                             this.sort.property as any,
@@ -234,6 +238,18 @@ export class CommentListComponent implements OnInit, OnChanges {
             pending:  true,
             rejected: true,
             deleted:  true,
+            flagged:  false,
+            filter:   '',
+        });
+    }
+
+    filterFlagged() {
+        this.filterForm.setValue({
+            approved: true,
+            pending:  true,
+            rejected: false,
+            deleted:  false,
+            flagged:  true,
             filter:   '',
         });
     }
@@ -244,6 +260,7 @@ export class CommentListComponent implements OnInit, OnChanges {
             pending:  true,
             rejected: false,
             deleted:  false,
+            flagged:  false,
             filter:   '',
         });
     }
@@ -254,6 +271,7 @@ export class CommentListComponent implements OnInit, OnChanges {
             pending:  true,
             rejected: true,
             deleted:  false,
+            flagged:  false,
             filter:   '',
         });
     }
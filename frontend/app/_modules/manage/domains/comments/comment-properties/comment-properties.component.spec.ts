@@ -8,6 +8,7 @@ import { ApiGeneralService } from '../../../../../../generated-api';
 import { NoDataComponent } from '../../../../tools/no-data/no-data.component';
 import { mockDomainSelector, MockHighlightDirective, mockHighlightLoaderStub } from '../../../../../_utils/_mocks.spec';
 import { UserLinkComponent } from '../../../user-link/user-link.component';
+import { ModeratorNotesComponent } from '../../moderator-notes/moderator-notes.component';
 
 describe('CommentPropertiesComponent', () => {
 
@@ -21,7 +22,7 @@ describe('CommentPropertiesComponent', () => {
                     FontAwesomeTestingModule,
                     NgbModalModule,
                     CommentPropertiesComponent,
-                    MockComponents(NoDataComponent, UserLinkComponent),
+                    MockComponents(NoDataComponent, UserLinkComponent, ModeratorNotesComponent),
                     MockHighlightDirective,
                 ],
                 providers: [
@@ -5,9 +5,9 @@ import { catchError, filter } from 'rxjs/operators';
 import { UntilDestroy, untilDestroyed } from '@ngneat/until-destroy';
 import { NgbModal, NgbNavModule } from '@ng-bootstrap/ng-bootstrap';
 import { FaIconComponent } from '@fortawesome/angular-fontawesome';
-import { faCheck, faTrashAlt, faXmark } from '@fortawesome/free-solid-svg-icons';
+import { faCheck, faClockRotateLeft, faCodeCompare, faFlag, faTrashAlt, faXmark } from '@fortawesome/free-solid-svg-icons';
 import { Highlight } from 'ngx-highlightjs';
-import { ApiGeneralService, Comment, Commenter, DomainPage, Principal, User } from '../../../../../../generated-api';
+import { ApiGeneralService, Comment, Commenter, CommentFlag, CommentRevision, DomainPage, ModeratorNote, Principal, User } from '../../../../../../generated-api';
 import { DomainMeta, DomainSelectorService } from '../../../_services/domain-selector.service';
 import { ProcessingStatus } from '../../../../../_utils/processing-status';
 import { AnonymousUser, Paths } from '../../../../../_utils/consts';
@@ -22,6 +22,7 @@ import { UserLinkComponent } from '../../../user-link/user-link.component';
 import { CountryNamePipe } from '../../../_pipes/country-name.pipe';
 import { CopyTextDirective } from '../../../../tools/_directives/copy-text.directive';
 import { NoDataComponent } from '../../../../tools/no-data/no-data.component';
+import { ModeratorNotesComponent } from '../../moderator-notes/moderator-notes.component';
 
 @UntilDestroy()
 @Component({
@@ -41,6 +42,7 @@ import { NoDataComponent } from '../../../../tools/no-data/no-data.component';
         NgbNavModule,
         Highlight,
         NoDataComponent,
+        ModeratorNotesComponent,
     ],
 })
 export class CommentPropertiesComponent implements OnInit {
@@ -75,6 +77,21 @@ export class CommentPropertiesComponent implements OnInit {
     /** Domain page the comment is on. */
     page?: DomainPage;
 
+    /** Flags raised against the comment by readers. */
+    flags?: CommentFlag[];
+
+    /** Moderator notes on the comment. */
+    notes?: ModeratorNote[];
+
+    /** Moderator notes on the comment author. */
+    authorNotes?: ModeratorNote[];
+
+    /** Earlier versions of the comment text, latest first. */
+    revisions?: CommentRevision[];
+
+    /** Differences between each revision and the current comment text, indexed by revision ID. */
+    revisionDiffs: Record<string, { op: string; text: string }[]> = {};
+
     /** Domain/user metadata. */
     domainMeta?: DomainMeta;
 
@@ -88,10 +105,16 @@ export class CommentPropertiesComponent implements OnInit {
     readonly deleting = new ProcessingStatus();
     readonly updating = new ProcessingStatus();
 
+    readonly flagsLoading     = new ProcessingStatus();
+    readonly revisionsLoading = new ProcessingStatus();
+
     // Icons
-    readonly faCheck    = faCheck;
-    readonly faTrashAlt = faTrashAlt;
-    readonly faXmark    = faXmark;
+    readonly faCheck           = faCheck;
+    readonly faClockRotateLeft = faClockRotateLeft;
+    readonly faCodeCompare     = faCodeCompare;
+    readonly faFlag            = faFlag;
+    readonly faTrashAlt        = faTrashAlt;
+    readonly faXmark           = faXmark;
 
     private readonly reload$ = new BehaviorSubject<void>(undefined);
     private readonly id$     = new ReplaySubject<string>(1);
@@ -133,6 +156,7 @@ export class CommentPropertiesComponent implements OnInit {
                 this.userDeleted   = r.deleter;
                 this.userEdited    = r.editor;
                 this.page          = r.page;
+                this.notes         = r.notes;
 
                 // If the comment is by an unregistered user, imitate the anonymous user
                 if (!this.commenter && this.comment?.userCreated === AnonymousUser.id) {
@@ -176,6 +200,30 @@ export class CommentPropertiesComponent implements OnInit {
                     }
                 }
 
+                // Load the flags, if there are any
+                this.flags = undefined;
+                if (this.comment?.countFlags && this.domainMeta?.canModerateDomain) {
+                    this.api.commentFlagList(this.comment.id!)
+                        .pipe(this.flagsLoading.processing())
+                        .subscribe(fr => this.flags = fr.flags);
+                }
+
+                // Load the notes on the author, if it's a registered user
+                this.authorNotes = undefined;
+                if (this.commenter && this.commenter.id !== AnonymousUser.id && this.page && this.domainMeta?.canModerateDomain) {
+                    this.api.moderatorNoteList(this.page.domainId!, this.commenter.id!)
+                        .subscribe(nr => this.authorNotes = nr.notes);
+                }
+
+                // Load the revisions, if the comment has been edited
+                this.revisions = undefined;
+                this.revisionDiffs = {};
+                if (this.comment?.editedTime && this.domainMeta?.canModerateDomain) {
+                    this.api.commentRevisionList(this.comment.id!)
+                        .pipe(this.revisionsLoading.processing())
+                        .subscribe(rr => this.revisions = rr.revisions?.reverse());
+                }
+
                 // If there's a comment and an action, apply it
                 if (this.comment && this.action) {
                     this.runAction();
@@ -203,6 +251,16 @@ export class CommentPropertiesComponent implements OnInit {
             });
     }
 
+    dismissFlags() {
+        // Delete all flags of the comment
+        this.api.commentFlagsDismiss(this.comment!.id!)
+            .pipe(this.updating.processing())
+            .subscribe(() => {
+                this.reload$.next();
+                this.commentService.refresh();
+            });
+    }
+
     moderate(approve: boolean) {
         if (!this.comment) {
             return;
@@ -227,6 +285,40 @@ export class CommentPropertiesComponent implements OnInit {
             });
     }
 
+    restoreRevision(r: CommentRevision) {
+        // Show a confirmation dialog
+        const mr = this.modal.open(ConfirmDialogComponent);
+        const dlg = (mr.componentInstance as ConfirmDialogComponent);
+        dlg.content     = $localize`Are you sure you want to replace the comment text with this earlier version?`;
+        dlg.actionLabel = $localize`Restore version`;
+        dlg.actionType  = 'warning';
+
+        // Run the dialog
+        from(mr.result)
+            .pipe(
+                // Ignore when canceled
+                catchError(() => EMPTY),
+                // Run restoring when confirmed
+                switchMap(() => this.api.commentRevisionRestore(this.comment!.id!, {revisionId: r.id!}).pipe(this.updating.processing())))
+            .subscribe(() => {
+                this.reload$.next();
+                this.commentService.refresh();
+            });
+    }
+
+    toggleRevisionDiff(r: CommentRevision) {
+        // If the diff is shown, hide it
+        if (r.id! in this.revisionDiffs) {
+            delete this.revisionDiffs[r.id!];
+            return;
+        }
+
+        // Compare the revision to the current text
+        this.api.commentRevisionDiff(this.comment!.id!, r.id!)
+            .pipe(this.revisionsLoading.processing())
+            .subscribe(dr => this.revisionDiffs[r.id!] = dr.chunks ?? []);
+    }
+
     private runAction() {
         switch (this.action) {
             case 'approve':
@@ -171,6 +171,11 @@ export class DomainEditComponent implements OnInit {
                                 userAgeDays:   d.modUserAgeDays || 7,
                                 images:        d.modImages,
                                 links:         d.modLinks,
+                                trustedHosts:  d.modTrustedHosts ?? '',
+                                blockedHosts:  d.modBlockedHosts ?? '',
+                                autoCloseOn:   !!d.autoCloseDays,
+                                autoCloseDays: d.autoCloseDays || 90,
+                                autoClosePaths: d.autoClosePaths ?? '',
                                 notifyPolicy:  d.modNotifyPolicy,
                             }
                         });
@@ -233,7 +238,11 @@ export class DomainEditComponent implements OnInit {
                 modUserAgeDays:    vals.mod.userAgeDaysOn ? (vals.mod.userAgeDays ?? 0) : 0,
                 modImages:         !!vals.mod.images,
                 modLinks:          !!vals.mod.links,
+                modTrustedHosts:   vals.mod.trustedHosts?.trim() ?? '',
+                modBlockedHosts:   vals.mod.blockedHosts?.trim() ?? '',
                 modNotifyPolicy:   vals.mod.notifyPolicy ?? DomainModNotifyPolicy.Pending,
+                autoCloseDays:     vals.mod.autoCloseOn ? (vals.mod.autoCloseDays ?? 0) : 0,
+                autoClosePaths:    vals.mod.autoCloseOn ? (vals.mod.autoClosePaths?.trim() ?? '') : '',
             };
 
             // Prepare config, keeping only key and value from each item (other fields are readonly and shouldn't be
@@ -311,6 +320,11 @@ export class DomainEditComponent implements OnInit {
                             userAgeDays:   [{value: 7, disabled: true}, [Validators.required, Validators.min(1), Validators.max(999)]],
                             images:        true,
                             links:         true,
+                            trustedHosts:  ['', [Validators.maxLength(4096)]],
+                            blockedHosts:  ['', [Validators.maxLength(4096)]],
+                            autoCloseOn:   false,
+                            autoCloseDays: [{value: 90, disabled: true}, [Validators.required, Validators.min(1), Validators.max(36500)]],
+                            autoClosePaths: [{value: '', disabled: true}, [Validators.maxLength(4096)]],
                             notifyPolicy:  DomainModNotifyPolicy.Pending,
                         }),
                         extensions: this.getExtensionsFormGroup(),
@@ -329,6 +343,9 @@ export class DomainEditComponent implements OnInit {
                     f.controls.mod.controls.userAgeDaysOn.valueChanges
                         .pipe(untilDestroyed(this))
                         .subscribe(b => Utils.enableControls(b, f.controls.mod.controls.userAgeDays));
+                    f.controls.mod.controls.autoCloseOn.valueChanges
+                        .pipe(untilDestroyed(this))
+                        .subscribe(b => Utils.enableControls(b, f.controls.mod.controls.autoCloseDays, f.controls.mod.controls.autoClosePaths));
 
                     // Extensions: disable the config control when the extension is disabled
                     this.extensions?.forEach((_, idx) =>
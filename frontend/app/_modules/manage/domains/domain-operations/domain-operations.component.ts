@@ -6,6 +6,7 @@ import { UntilDestroy, untilDestroyed } from '@ngneat/until-destroy';
 import { FaIconComponent } from '@fortawesome/angular-fontawesome';
 import {
     faAngleDown,
+    faBrain,
     faCalendarXmark,
     faCircleQuestion,
     faClone, faEraser,
@@ -47,6 +48,7 @@ export class DomainOperationsComponent implements OnInit {
 
     readonly downloading = new ProcessingStatus();
     readonly freezing    = new ProcessingStatus();
+    readonly retraining  = new ProcessingStatus();
     readonly purging     = new ProcessingStatus();
     readonly clearing    = new ProcessingStatus();
     readonly deleting    = new ProcessingStatus();
@@ -60,6 +62,7 @@ export class DomainOperationsComponent implements OnInit {
 
     // Icons
     readonly faAngleDown       = faAngleDown;
+    readonly faBrain           = faBrain;
     readonly faCalendarXmark   = faCalendarXmark;
     readonly faCircleQuestion  = faCircleQuestion;
     readonly faClone           = faClone;
@@ -146,6 +149,17 @@ export class DomainOperationsComponent implements OnInit {
             });
     }
 
+    retrainSpamClassifier() {
+        // Run retraining with the API
+        this.api.domainSpamClassifierRetrain(this.domain!.id!)
+            .pipe(this.retraining.processing())
+            // Add a toast
+            .subscribe(r => this.toastSvc.success({
+                messageId: 'data-saved',
+                details:   $localize`Learnt ${r.countSpam} spam and ${r.countHam} legitimate comment(s)`,
+            }));
+    }
+
     toggleFrozen() {
         // Run toggle with the API
         this.api.domainReadonly(this.domain!.id!, {readonly: !this.domain!.isReadonly})
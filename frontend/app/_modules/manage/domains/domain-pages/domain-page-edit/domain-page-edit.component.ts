@@ -34,8 +34,9 @@ export class DomainPageEditComponent implements OnInit {
     readonly loading = new ProcessingStatus();
     readonly saving  = new ProcessingStatus();
     readonly form = this.fb.nonNullable.group({
-        readOnly: false,
-        path:     [{value: '', disabled: true}, [Validators.required, Validators.pattern(/^\//), Validators.maxLength(2075)]],
+        readOnly:    false,
+        noAutoClose: false,
+        path:        [{value: '', disabled: true}, [Validators.required, Validators.pattern(/^\//), Validators.maxLength(2075)]],
     });
 
     /** Page ID, set via input binding. */
@@ -55,6 +56,11 @@ export class DomainPageEditComponent implements OnInit {
     }
 
     ngOnInit(): void {
+        // Reopening a read-only page exempts it from automatic closing by default
+        this.form.controls.readOnly.valueChanges
+            .pipe(untilDestroyed(this), filter(ro => !ro && !!this.page?.isReadonly))
+            .subscribe(() => this.form.controls.noAutoClose.setValue(true));
+
         // Subscribe to domain changes
         this.domainSelectorSvc.domainMeta(true)
             .pipe(
@@ -71,8 +77,9 @@ export class DomainPageEditComponent implements OnInit {
             .subscribe(r => {
                 this.page = r.page;
                 this.form.setValue({
-                    readOnly: !!r.page!.isReadonly,
-                    path:     r.page!.path ?? '',
+                    readOnly:    !!r.page!.isReadonly,
+                    noAutoClose: !!r.page!.noAutoClose,
+                    path:        r.page!.path ?? '',
                 });
 
                 // Only domain managers are allowed to edit the path
@@ -90,8 +97,9 @@ export class DomainPageEditComponent implements OnInit {
         if (this.page && this.form.valid) {
             const val = this.form.value;
             this.api.domainPageUpdate(this.page.id!, {
-                    isReadonly: val.readOnly!,
-                    path:       val.path || this.page.path,
+                    isReadonly:  val.readOnly!,
+                    noAutoClose: val.noAutoClose!,
+                    path:        val.path || this.page.path,
                 })
                 .pipe(this.saving.processing())
                 .subscribe(() => {
@@ -45,6 +45,7 @@ export class DomainUserEditComponent implements OnInit {
         notifyReplies:       false,
         notifyModerator:     false,
         notifyCommentStatus: false,
+        notifyMentions:      false,
     });
 
     private readonly id$ = new ReplaySubject<string>(1);
@@ -85,6 +86,7 @@ export class DomainUserEditComponent implements OnInit {
                     notifyReplies:       du.notifyReplies,
                     notifyModerator:     du.notifyModerator,
                     notifyCommentStatus: du.notifyCommentStatus,
+                    notifyMentions:      du.notifyMentions,
                 });
 
                 // Only superuser can change their own role
@@ -109,6 +111,7 @@ export class DomainUserEditComponent implements OnInit {
                         notifyReplies:       val.notifyReplies,
                         notifyModerator:     val.notifyModerator,
                         notifyCommentStatus: val.notifyCommentStatus,
+                        notifyMentions:      val.notifyMentions,
                     })
                 .pipe(this.saving.processing())
                 .subscribe(() => {
@@ -5,7 +5,7 @@ import { filter } from 'rxjs/operators';
 import { UntilDestroy, untilDestroyed } from '@ngneat/until-destroy';
 import { FaIconComponent } from '@fortawesome/angular-fontawesome';
 import { faEdit } from '@fortawesome/free-solid-svg-icons';
-import { ApiGeneralService, DomainUser, Principal, User } from '../../../../../../generated-api';
+import { ApiGeneralService, DomainUser, ModeratorNote, Principal, User } from '../../../../../../generated-api';
 import { DomainSelectorService } from '../../../_services/domain-selector.service';
 import { ProcessingStatus } from '../../../../../_utils/processing-status';
 import { Paths } from '../../../../../_utils/consts';
@@ -16,6 +16,7 @@ import { DatetimePipe } from '../../../_pipes/datetime.pipe';
 import { UserDetailsComponent } from '../../../users/user-details/user-details.component';
 import { CommentListComponent } from '../../comments/comment-list/comment-list.component';
 import { NoDataComponent } from '../../../../tools/no-data/no-data.component';
+import { ModeratorNotesComponent } from '../../moderator-notes/moderator-notes.component';
 
 @UntilDestroy()
 @Component({
@@ -30,6 +31,7 @@ import { NoDataComponent } from '../../../../tools/no-data/no-data.component';
         UserDetailsComponent,
         CommentListComponent,
         NoDataComponent,
+        ModeratorNotesComponent,
         RouterLink,
     ],
 })
@@ -41,6 +43,9 @@ export class DomainUserPropertiesComponent implements OnInit {
     /** The user corresponding to domainUser. */
     user?: User;
 
+    /** Moderator notes on the user. */
+    notes?: ModeratorNote[];
+
     /** Currently authenticated principal. */
     principal?: Principal;
 
@@ -79,6 +84,7 @@ export class DomainUserPropertiesComponent implements OnInit {
             .subscribe(r => {
                 this.domainUser = r.domainUser;
                 this.user       = r.user;
+                this.notes      = r.notes;
             });
     }
 }
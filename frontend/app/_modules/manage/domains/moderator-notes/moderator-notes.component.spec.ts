@@ -0,0 +1,27 @@
+import { ComponentFixture, TestBed } from '@angular/core/testing';
+import { FontAwesomeTestingModule } from '@fortawesome/angular-fontawesome/testing';
+import { MockProvider } from 'ng-mocks';
+import { ModeratorNotesComponent } from './moderator-notes.component';
+import { ApiGeneralService } from '../../../../../generated-api';
+
+describe('ModeratorNotesComponent', () => {
+
+    let component: ModeratorNotesComponent;
+    let fixture: ComponentFixture<ModeratorNotesComponent>;
+
+    beforeEach(async () => {
+        await TestBed.configureTestingModule({
+                imports: [FontAwesomeTestingModule, ModeratorNotesComponent],
+                providers: [MockProvider(ApiGeneralService)],
+            })
+            .compileComponents();
+
+        fixture = TestBed.createComponent(ModeratorNotesComponent);
+        component = fixture.componentInstance;
+        fixture.detectChanges();
+    });
+
+    it('is created', () => {
+        expect(component).toBeTruthy();
+    });
+});
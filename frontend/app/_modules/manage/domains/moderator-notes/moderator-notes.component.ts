@@ -0,0 +1,89 @@
+import { Component, Input } from '@angular/core';
+import { FormBuilder, ReactiveFormsModule, Validators } from '@angular/forms';
+import { FaIconComponent } from '@fortawesome/angular-fontawesome';
+import { faPlus, faTrashAlt } from '@fortawesome/free-solid-svg-icons';
+import { ApiGeneralService, ModeratorNote } from '../../../../../generated-api';
+import { ProcessingStatus } from '../../../../_utils/processing-status';
+import { SpinnerDirective } from '../../../tools/_directives/spinner.directive';
+import { ConfirmDirective } from '../../../tools/_directives/confirm.directive';
+import { ValidatableDirective } from '../../../tools/_directives/validatable.directive';
+import { DatetimePipe } from '../../_pipes/datetime.pipe';
+
+@Component({
+    selector: 'app-moderator-notes',
+    templateUrl: './moderator-notes.component.html',
+    imports: [
+        ReactiveFormsModule,
+        FaIconComponent,
+        SpinnerDirective,
+        ConfirmDirective,
+        ValidatableDirective,
+        DatetimePipe,
+    ],
+})
+export class ModeratorNotesComponent {
+
+    /** ID of the domain the notes belong to. */
+    @Input({required: true})
+    domainId?: string;
+
+    /** ID of the user the notes are about. Either this or commentId must be provided. */
+    @Input()
+    userId?: string;
+
+    /** ID of the comment the notes are about. Either this or userId must be provided. */
+    @Input()
+    commentId?: string;
+
+    /** Notes to display, latest first. */
+    @Input()
+    notes?: ModeratorNote[];
+
+    /** Heading of the section. */
+    @Input()
+    heading = $localize`Moderator notes`;
+
+    /** ID of the section element. */
+    @Input()
+    sectionId = 'moderator-notes';
+
+    readonly adding   = new ProcessingStatus();
+    readonly deleting = new ProcessingStatus();
+
+    readonly form = this.fb.nonNullable.group({
+        text: ['', [Validators.required, Validators.maxLength(4096)]],
+    });
+
+    // Icons
+    readonly faPlus     = faPlus;
+    readonly faTrashAlt = faTrashAlt;
+
+    constructor(
+        private readonly fb: FormBuilder,
+        private readonly api: ApiGeneralService,
+    ) {}
+
+    add() {
+        // Mark all controls touched to display validation results
+        this.form.markAllAsTouched();
+
+        // Submit the form if it's valid
+        if (this.form.valid) {
+            this.api.moderatorNoteNew(this.domainId!, {userId: this.userId, commentId: this.commentId, text: this.form.value.text!})
+                .pipe(this.adding.processing())
+                .subscribe(n => {
+                    // Prepend the new note to the list
+                    this.notes = [n, ...(this.notes ?? [])];
+                    // Clean up the form
+                    this.form.reset();
+                });
+        }
+    }
+
+    delete(note: ModeratorNote) {
+        this.api.moderatorNoteDelete(note.id!)
+            .pipe(this.deleting.processing())
+            // Remove the note from the list
+            .subscribe(() => this.notes = this.notes?.filter(n => n !== note));
+    }
+}
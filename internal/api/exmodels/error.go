@@ -38,6 +38,7 @@ var (
 	ErrorUnknown = &Error{Message: "Internal server error"}
 
 	ErrorBadToken              = &Error{ID: "bad-token", Message: "Token is missing or invalid"}
+	ErrorCommentRejected       = &Error{ID: "comment-rejected", Message: "Comment contains disallowed content"}
 	ErrorCommentTextTooLong    = &Error{ID: "comment-text-too-long", Message: "Comment text is too long"}
 	ErrorDeletingLastSuperuser = &Error{ID: "deleting-last-superuser", Message: "Can't delete the last superuser in the system"}
 	ErrorDeletingLastOwner     = &Error{ID: "deleting-last-owner", Message: "Can't delete the last owner in domain(s)"}
@@ -70,15 +71,18 @@ var (
 	ErrorPageReadonly          = &Error{ID: "page-readonly", Message: "This page is read-only"}
 	ErrorResourceFetchFailed   = &Error{ID: "resource-fetch-failed", Message: "Failed to fetch external resource"}
 	ErrorSelfOperation         = &Error{ID: "self-operation", Message: "You cannot do this to yourself"}
+	ErrorSelfFlag              = &Error{ID: "self-flag", Message: "You cannot flag your own comment"}
 	ErrorSelfVote              = &Error{ID: "self-vote", Message: "You cannot vote for your own comment"}
 	ErrorSignupsForbidden      = &Error{ID: "signups-forbidden", Message: "New signups are forbidden"}
 	ErrorSSOMisconfigured      = &Error{ID: "sso-misconfigured", Message: "Domain's SSO configuration is invalid"}
+	ErrorTooManyRequests       = &Error{ID: "too-many-requests", Message: "Too many requests, please try again later"}
 	ErrorUnauthenticated       = &Error{ID: "unauthenticated", Message: "User isn't authenticated"}
 	ErrorUnauthorized          = &Error{ID: "unauthorized", Message: "You are not allowed to perform this operation"}
 	ErrorUnknownHost           = &Error{ID: "unknown-host", Message: "Unknown host"}
 	ErrorUserBanned            = &Error{ID: "user-banned", Message: "User is banned"}
 	ErrorUserLocked            = &Error{ID: "user-locked", Message: "User is locked"}
 	ErrorUserReadonly          = &Error{ID: "user-readonly", Message: "This user is read-only on this domain"}
+	ErrorVisitorBanned         = &Error{ID: "visitor-banned", Message: "You are banned on this domain"}
 	ErrorWrongCurPassword      = &Error{ID: "wrong-cur-password", Message: "Wrong current password"}
 	ErrorXSRFTokenInvalid      = &Error{ID: "xsrf-token-invalid", Message: "XSRF token is missing or invalid"}
 )
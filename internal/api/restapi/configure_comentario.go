@@ -116,6 +116,7 @@ func configureAPI(api *operations.ComentarioAPI) http.Handler {
 	api.APIGeneralDomainListHandler = api_general.DomainListHandlerFunc(handlers.DomainList)
 	api.APIGeneralDomainNewHandler = api_general.DomainNewHandlerFunc(handlers.DomainNew)
 	api.APIGeneralDomainPurgeHandler = api_general.DomainPurgeHandlerFunc(handlers.DomainPurge)
+	api.APIGeneralDomainSpamClassifierRetrainHandler = api_general.DomainSpamClassifierRetrainHandlerFunc(handlers.DomainSpamClassifierRetrain)
 	api.APIGeneralDomainSsoSecretNewHandler = api_general.DomainSsoSecretNewHandlerFunc(handlers.DomainSsoSecretNew)
 	api.APIGeneralDomainReadonlyHandler = api_general.DomainReadonlyHandlerFunc(handlers.DomainReadonly)
 	api.APIGeneralDomainUpdateHandler = api_general.DomainUpdateHandlerFunc(handlers.DomainUpdate)
@@ -125,15 +126,33 @@ func configureAPI(api *operations.ComentarioAPI) http.Handler {
 	api.APIGeneralDomainPageUpdateHandler = api_general.DomainPageUpdateHandlerFunc(handlers.DomainPageUpdate)
 	api.APIGeneralDomainPageUpdateTitleHandler = api_general.DomainPageUpdateTitleHandlerFunc(handlers.DomainPageUpdateTitle)
 	// Comments
+	api.APIGeneralCommentBulkModerateHandler = api_general.CommentBulkModerateHandlerFunc(handlers.CommentBulkModerate)
 	api.APIGeneralCommentCountHandler = api_general.CommentCountHandlerFunc(handlers.CommentCount)
 	api.APIGeneralCommentDeleteHandler = api_general.CommentDeleteHandlerFunc(handlers.CommentDelete)
+	api.APIGeneralCommentFlagListHandler = api_general.CommentFlagListHandlerFunc(handlers.CommentFlagList)
+	api.APIGeneralCommentFlagsDismissHandler = api_general.CommentFlagsDismissHandlerFunc(handlers.CommentFlagsDismiss)
 	api.APIGeneralCommentGetHandler = api_general.CommentGetHandlerFunc(handlers.CommentGet)
 	api.APIGeneralCommentListHandler = api_general.CommentListHandlerFunc(handlers.CommentList)
 	api.APIGeneralCommentModerateHandler = api_general.CommentModerateHandlerFunc(handlers.CommentModerate)
+	api.APIGeneralCommentRevisionDiffHandler = api_general.CommentRevisionDiffHandlerFunc(handlers.CommentRevisionDiff)
+	api.APIGeneralCommentRevisionListHandler = api_general.CommentRevisionListHandlerFunc(handlers.CommentRevisionList)
+	api.APIGeneralCommentRevisionRestoreHandler = api_general.CommentRevisionRestoreHandlerFunc(handlers.CommentRevisionRestore)
+	// Domain bans
+	api.APIGeneralDomainBanDeleteHandler = api_general.DomainBanDeleteHandlerFunc(handlers.DomainBanDelete)
+	api.APIGeneralDomainBanListHandler = api_general.DomainBanListHandlerFunc(handlers.DomainBanList)
+	api.APIGeneralDomainBanNewHandler = api_general.DomainBanNewHandlerFunc(handlers.DomainBanNew)
+	api.APIGeneralDomainBanUpdateHandler = api_general.DomainBanUpdateHandlerFunc(handlers.DomainBanUpdate)
 	// Domain users
 	api.APIGeneralDomainUserListHandler = api_general.DomainUserListHandlerFunc(handlers.DomainUserList)
 	api.APIGeneralDomainUserGetHandler = api_general.DomainUserGetHandlerFunc(handlers.DomainUserGet)
+	api.APIGeneralDomainUserShadowBanHandler = api_general.DomainUserShadowBanHandlerFunc(handlers.DomainUserShadowBan)
 	api.APIGeneralDomainUserUpdateHandler = api_general.DomainUserUpdateHandlerFunc(handlers.DomainUserUpdate)
+	// Moderation log
+	api.APIGeneralModerationLogListHandler = api_general.ModerationLogListHandlerFunc(handlers.ModerationLogList)
+	// Moderator notes
+	api.APIGeneralModeratorNoteDeleteHandler = api_general.ModeratorNoteDeleteHandlerFunc(handlers.ModeratorNoteDelete)
+	api.APIGeneralModeratorNoteListHandler = api_general.ModeratorNoteListHandlerFunc(handlers.ModeratorNoteList)
+	api.APIGeneralModeratorNoteNewHandler = api_general.ModeratorNoteNewHandlerFunc(handlers.ModeratorNoteNew)
 	// Users
 	api.APIGeneralUserAvatarGetHandler = api_general.UserAvatarGetHandlerFunc(handlers.UserAvatarGet)
 	api.APIGeneralUserBanHandler = api_general.UserBanHandlerFunc(handlers.UserBan)
@@ -144,6 +163,15 @@ func configureAPI(api *operations.ComentarioAPI) http.Handler {
 	api.APIGeneralUserSessionsExpireHandler = api_general.UserSessionsExpireHandlerFunc(handlers.UserSessionsExpire)
 	api.APIGeneralUserUnlockHandler = api_general.UserUnlockHandlerFunc(handlers.UserUnlock)
 	api.APIGeneralUserUpdateHandler = api_general.UserUpdateHandlerFunc(handlers.UserUpdate)
+	// Webhooks
+	api.APIGeneralWebhookDeleteHandler = api_general.WebhookDeleteHandlerFunc(handlers.WebhookDelete)
+	api.APIGeneralWebhookDeliveryListHandler = api_general.WebhookDeliveryListHandlerFunc(handlers.WebhookDeliveryList)
+	api.APIGeneralWebhookGetHandler = api_general.WebhookGetHandlerFunc(handlers.WebhookGet)
+	api.APIGeneralWebhookListHandler = api_general.WebhookListHandlerFunc(handlers.WebhookList)
+	api.APIGeneralWebhookNewHandler = api_general.WebhookNewHandlerFunc(handlers.WebhookNew)
+	api.APIGeneralWebhookSecretNewHandler = api_general.WebhookSecretNewHandlerFunc(handlers.WebhookSecretNew)
+	api.APIGeneralWebhookTestHandler = api_general.WebhookTestHandlerFunc(handlers.WebhookTest)
+	api.APIGeneralWebhookUpdateHandler = api_general.WebhookUpdateHandlerFunc(handlers.WebhookUpdate)
 
 	//------------------------------------------------------------------------------------------------------------------
 	// Embed API
@@ -162,15 +190,19 @@ func configureAPI(api *operations.ComentarioAPI) http.Handler {
 	// Comment
 	api.APIEmbedEmbedCommentCountHandler = api_embed.EmbedCommentCountHandlerFunc(handlers.EmbedCommentCount)
 	api.APIEmbedEmbedCommentDeleteHandler = api_embed.EmbedCommentDeleteHandlerFunc(handlers.EmbedCommentDelete)
+	api.APIEmbedEmbedCommentFlagHandler = api_embed.EmbedCommentFlagHandlerFunc(handlers.EmbedCommentFlag)
 	api.APIEmbedEmbedCommentGetHandler = api_embed.EmbedCommentGetHandlerFunc(handlers.EmbedCommentGet)
 	api.APIEmbedEmbedCommentListHandler = api_embed.EmbedCommentListHandlerFunc(handlers.EmbedCommentList)
 	api.APIEmbedEmbedCommentModerateHandler = api_embed.EmbedCommentModerateHandlerFunc(handlers.EmbedCommentModerate)
 	api.APIEmbedEmbedCommentNewHandler = api_embed.EmbedCommentNewHandlerFunc(handlers.EmbedCommentNew)
 	api.APIEmbedEmbedCommentPreviewHandler = api_embed.EmbedCommentPreviewHandlerFunc(handlers.EmbedCommentPreview)
+	api.APIEmbedEmbedCommentReactHandler = api_embed.EmbedCommentReactHandlerFunc(handlers.EmbedCommentReact)
+	api.APIEmbedEmbedCommentRevisionListHandler = api_embed.EmbedCommentRevisionListHandlerFunc(handlers.EmbedCommentRevisionList)
 	api.APIEmbedEmbedCommentStickyHandler = api_embed.EmbedCommentStickyHandlerFunc(handlers.EmbedCommentSticky)
 	api.APIEmbedEmbedCommentUpdateHandler = api_embed.EmbedCommentUpdateHandlerFunc(handlers.EmbedCommentUpdate)
 	api.APIEmbedEmbedCommentVoteHandler = api_embed.EmbedCommentVoteHandlerFunc(handlers.EmbedCommentVote)
 	// Page
+	api.APIEmbedEmbedPageMentionListHandler = api_embed.EmbedPageMentionListHandlerFunc(handlers.EmbedPageMentionList)
 	api.APIEmbedEmbedPageUpdateHandler = api_embed.EmbedPageUpdateHandlerFunc(handlers.EmbedPageUpdate)
 
 	//------------------------------------------------------------------------------------------------------------------
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/swag"
 	"github.com/google/uuid"
@@ -75,7 +76,7 @@ func AuthDeleteProfile(params api_general.AuthDeleteProfileParams, user *data.Us
 		// Figure out which domains have no other owners
 		for _, d := range ownedDomains {
 			hasOtherOwners := false
-			_, dus, err := svc.TheUserService.ListByDomain(&d.ID, false, "", "", data.SortAsc, -1)
+			_, dus, err := svc.TheUserService.ListByDomain(&d.ID, false, false, "", "", data.SortAsc, -1)
 			if err != nil {
 				return respServiceError(err)
 			}
@@ -110,6 +111,11 @@ func AuthDeleteProfile(params api_general.AuthDeleteProfileParams, user *data.Us
 
 // AuthLogin logs a user in using local authentication (email and password)
 func AuthLogin(params api_general.AuthLoginParams) middleware.Responder {
+	// Verify the rate limit isn't exceeded
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionLogin, nil, params.HTTPRequest, nil); r != nil {
+		return r
+	}
+
 	// Log the user in
 	user, us, r := loginLocalUser(
 		data.EmailPtrToString(params.Body.Email),
@@ -176,6 +182,11 @@ func AuthPwdResetChange(params api_general.AuthPwdResetChangeParams, user *data.
 }
 
 func AuthPwdResetSendEmail(params api_general.AuthPwdResetSendEmailParams) middleware.Responder {
+	// Verify the rate limit isn't exceeded
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionPwdReset, nil, params.HTTPRequest, nil); r != nil {
+		return r
+	}
+
 	// Find the user with that email
 	user, err := svc.TheUserService.FindUserByEmail(data.EmailPtrToString(params.Body.Email))
 	if errors.Is(err, svc.ErrNotFound) || err == nil && !user.IsLocal() {
@@ -213,6 +224,11 @@ func AuthSignup(params api_general.AuthSignupParams) middleware.Responder {
 		return r
 	}
 
+	// Verify the rate limit isn't exceeded
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionSignup, nil, params.HTTPRequest, nil); r != nil {
+		return r
+	}
+
 	// Verify no such email is registered yet
 	email := data.EmailPtrToString(params.Body.Email)
 	if _, r := Verifier.UserCanSignupWithEmail(email); r != nil {
@@ -311,12 +327,18 @@ func loginLocalUser(email, password, host string, req *http.Request) (*data.User
 		user.WithLastLogin(false)
 
 		// Lock the user out if they exhausted the allowed attempts (and maxAttempts > 0)
+		wasLocked := user.IsLocked
 		if i := svc.TheDynConfigService.GetInt(data.ConfigKeyAuthLoginLocalMaxAttempts); i > 0 && user.FailedLoginAttempts > i {
 			user.WithLocked(true)
 		}
 
-		// Persist ignoring possible errors
-		_ = svc.TheUserService.UpdateLoginLocked(user)
+		// Persist ignoring possible errors, recording a lockout in the moderation log, without an actor
+		if err := svc.TheUserService.UpdateLoginLocked(user); err == nil && user.IsLocked && !wasLocked {
+			moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionUserLock, nil).
+				WithUser(&user.ID).
+				WithValues("false", "true").
+				WithReason(fmt.Sprintf("Locked out after %d failed login attempts", user.FailedLoginAttempts)))
+		}
 
 		// Pause for a random while
 		util.RandomSleep(util.WrongAuthDelayMin, util.WrongAuthDelayMax)
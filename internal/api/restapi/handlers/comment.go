@@ -6,15 +6,168 @@ import (
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/exmodels"
 	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
+	"gitlab.com/comentario/comentario/internal/util"
 	"maps"
 	"slices"
+	"strconv"
 	"time"
 )
 
+func CommentBulkModerate(params api_general.CommentBulkModerateParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user is a domain moderator
+	domain, domainUser, r := domainGetWithUser(*params.Body.DomainID, user, false)
+	if r != nil {
+		return r
+	}
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return r
+	}
+
+	// Parse the comment IDs
+	f := &svc.CommentBulkFilter{
+		Status:      params.Body.Status,
+		Text:        params.Body.Filter,
+		CreatedFrom: time.Time(params.Body.CreatedFrom),
+		CreatedTo:   time.Time(params.Body.CreatedTo),
+	}
+	for _, sid := range params.Body.CommentIds {
+		id, r := parseUUID(sid)
+		if r != nil {
+			return r
+		}
+		f.IDs = append(f.IDs, *id)
+	}
+
+	// Parse the optional page and user IDs
+	if params.Body.PageID != "" {
+		if f.PageID, r = parseUUID(params.Body.PageID); r != nil {
+			return r
+		}
+	}
+	if params.Body.UserID != "" {
+		if f.AuthorUserID, r = parseUUID(params.Body.UserID); r != nil {
+			return r
+		}
+	}
+
+	// Refuse to process all domain comments at once
+	if f.IsEmpty() {
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("no comment IDs or filter criteria specified"))
+	}
+
+	// Validate the status filter
+	switch f.Status {
+	case "", "approved", "pending", "rejected":
+		// Valid
+	default:
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("status"))
+	}
+
+	// Bulk deletion is subject to the same moderator deletion setting as deleting individual comments
+	action := *params.Body.Action
+	if action == models.CommentBulkActionDelete &&
+		!svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentDeletionModerator) {
+		return respForbidden(exmodels.ErrorNotAllowed)
+	}
+
+	// Apply the action
+	changes, truncated, err := svc.TheCommentService.ModerateBulk(&domain.ID, &user.ID, f, action)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Fetch the affected pages
+	pages := map[uuid.UUID]*data.DomainPage{}
+	var pageIDs []uuid.UUID
+	for _, ch := range changes {
+		if _, ok := pages[ch.Comment.PageID]; !ok {
+			page, err := svc.ThePageService.FindByID(&ch.Comment.PageID)
+			if err != nil {
+				return respServiceError(err)
+			}
+			pages[page.ID] = page
+			pageIDs = append(pageIDs, page.ID)
+		}
+	}
+
+	// Record the changes in the moderation log and notify webhooks
+	comments := make([]*data.Comment, len(changes))
+	for i, ch := range changes {
+		comment, page := ch.Comment, pages[ch.Comment.PageID]
+		comments[i] = comment
+		switch action {
+		case models.CommentBulkActionApprove, models.CommentBulkActionReject:
+			moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentStatus, &user.ID).
+				WithComment(page, comment).
+				WithValues(ch.ValueBefore, ch.ValueAfter).
+				WithReason(params.Body.Reason))
+			if comment.IsApproved {
+				svc.TheCommentNotifyService.Webhook(domain, page, comment, models.WebhookEventCommentDotApproved)
+			} else {
+				svc.TheCommentNotifyService.Webhook(domain, page, comment, models.WebhookEventCommentDotRejected)
+			}
+			svc.TheCommentNotifyService.MentionsOnApproval(domain, page, comment, ch.ValueBefore)
+
+		case models.CommentBulkActionDelete:
+			moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentDelete, &user.ID).
+				WithComment(page, comment).
+				WithValues(ch.ValueBefore, ch.ValueAfter).
+				WithReason(params.Body.Reason))
+			svc.TheCommentNotifyService.Webhook(domain, page, comment, models.WebhookEventCommentDotDeleted)
+
+		default:
+			moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentSticky, &user.ID).
+				WithComment(page, comment).
+				WithValues(ch.ValueBefore, ch.ValueAfter).
+				WithReason(params.Body.Reason))
+		}
+	}
+
+	switch action {
+	// Notify the comment authors about the status change, in the background
+	case models.CommentBulkActionApprove, models.CommentBulkActionReject:
+		go svc.TheCommentNotifyService.NotifyStatusSummary(domain, pages, comments, action == models.CommentBulkActionApprove)
+
+	// Decrement page/domain comment counts in the background, ignoring any errors. Shadowed comments aren't counted
+	case models.CommentBulkActionDelete:
+		go func() {
+			total := 0
+			perPage := map[uuid.UUID]int{}
+			for _, c := range comments {
+				if !c.IsShadowed {
+					perPage[c.PageID]--
+					total--
+				}
+			}
+			for id, cnt := range perPage {
+				_ = svc.ThePageService.IncrementCounts(&id, cnt, 0)
+			}
+			_ = svc.TheDomainService.IncrementCounts(&domain.ID, total, 0)
+		}()
+	}
+
+	// Send a single refresh notification per affected page to websocket subscribers
+	if svc.TheWebSocketsService.Active() && len(pageIDs) > 0 {
+		go func() {
+			// Postpone the update a bit to let the client finish the API call
+			time.Sleep(500 * time.Millisecond)
+			for _, id := range pageIDs {
+				page := pages[id]
+				svc.TheWebSocketsService.Send(&page.DomainID, nil, nil, page.Path, "refresh")
+			}
+		}()
+	}
+
+	// Succeeded
+	return api_general.NewCommentBulkModerateOK().
+		WithPayload(&api_general.CommentBulkModerateOKBody{CountAffected: int64(len(changes)), Truncated: truncated})
+}
+
 func CommentCount(params api_general.CommentCountParams, user *data.User) middleware.Responder {
 	// Extract domain ID
 	domainID, r := parseUUID(params.Domain)
@@ -69,6 +222,177 @@ func CommentDelete(params api_general.CommentDeleteParams, user *data.User) midd
 	return api_general.NewCommentDeleteNoContent()
 }
 
+func CommentFlagList(params api_general.CommentFlagListParams, user *data.User) middleware.Responder {
+	// Find the comment and related objects
+	comment, _, _, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Verify the user is a domain moderator
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return r
+	}
+
+	// Fetch the comment's flags
+	fs, err := svc.TheCommentFlagService.ListByComment(&comment.ID)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewCommentFlagListOK().
+		WithPayload(&api_general.CommentFlagListOKBody{
+			Flags: data.SliceToDTOs[*data.CommentFlag, *models.CommentFlag](fs),
+		})
+}
+
+func CommentFlagsDismiss(params api_general.CommentFlagsDismissParams, user *data.User) middleware.Responder {
+	// Find the comment and related objects
+	comment, page, _, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Verify the user is a domain moderator
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return r
+	}
+
+	// Delete the comment's flags
+	if err := svc.TheCommentFlagService.DeleteByComment(&comment.ID); err != nil {
+		return respServiceError(err)
+	}
+
+	// Record the action in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentFlagsDismiss, &user.ID).
+		WithComment(page, comment).
+		WithValues(strconv.Itoa(comment.CountFlags), "0"))
+
+	// Succeeded
+	return api_general.NewCommentFlagsDismissNoContent()
+}
+
+func CommentRevisionDiff(params api_general.CommentRevisionDiffParams, user *data.User) middleware.Responder {
+	// Find the comment and related objects
+	comment, _, _, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Verify the user is a domain moderator
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return r
+	}
+
+	// Find the revision to compare from
+	from, r := commentRevisionGet(comment, params.From)
+	if r != nil {
+		return r
+	}
+
+	// Find the revision to compare to, defaulting to the current text
+	to := comment.Markdown
+	if params.To != nil {
+		if rev, r := commentRevisionGet(comment, *params.To); r != nil {
+			return r
+		} else {
+			to = rev.Markdown
+		}
+	}
+
+	// Compare the texts
+	diff := util.TextDiff(from.Markdown, to)
+	chunks := make([]*api_general.CommentRevisionDiffOKBodyChunksItems0, len(diff))
+	for i, c := range diff {
+		chunks[i] = &api_general.CommentRevisionDiffOKBodyChunksItems0{Op: string(c.Op), Text: c.Text}
+	}
+
+	// Succeeded
+	return api_general.NewCommentRevisionDiffOK().
+		WithPayload(&api_general.CommentRevisionDiffOKBody{Chunks: chunks})
+}
+
+func CommentRevisionList(params api_general.CommentRevisionListParams, user *data.User) middleware.Responder {
+	// Find the comment and related objects
+	comment, _, _, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Verify the user is a domain moderator
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return r
+	}
+
+	// Fetch the comment's revisions
+	rs, err := svc.TheCommentRevisionService.ListByComment(&comment.ID)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewCommentRevisionListOK().
+		WithPayload(&api_general.CommentRevisionListOKBody{
+			Revisions: data.SliceToDTOs[*data.CommentRevision, *models.CommentRevision](rs),
+		})
+}
+
+func CommentRevisionRestore(params api_general.CommentRevisionRestoreParams, user *data.User) middleware.Responder {
+	// Find the comment and related objects
+	comment, page, domain, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Verify the user is a domain moderator
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return r
+	}
+
+	// Restoring is editing, so it's subject to the same editing settings
+	if r := Verifier.UserCanUpdateComment(&domain.ID, user, domainUser, comment); r != nil {
+		return r
+	}
+
+	// A deleted comment's text is gone for good, don't let it come back from its revisions
+	if comment.IsDeleted {
+		return respForbidden(exmodels.ErrorNotAllowed.WithDetails("comment is deleted"))
+	}
+
+	// Find the revision to restore
+	rev, r := commentRevisionGet(comment, params.Body.RevisionID)
+	if r != nil {
+		return r
+	}
+
+	// Update the comment text/HTML, re-rendering it with the current domain settings
+	mdBefore := comment.Markdown
+	if err := svc.TheCommentService.SetMarkdown(comment, rev.Markdown, &domain.ID, &user.ID); err != nil {
+		return respServiceError(err)
+	}
+
+	// Persist the changes. This keeps the replaced text as a revision, too
+	if err := svc.TheCommentService.Edited(comment); err != nil {
+		return respServiceError(err)
+	}
+
+	// Record the edit in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentEdit, &user.ID).
+		WithComment(page, comment).
+		WithValues(mdBefore, comment.Markdown).
+		WithReason("Restored revision " + rev.ID.String()))
+
+	// Notify websocket subscribers
+	svc.TheCommentNotifyService.WebSocket(page, comment, "update")
+
+	// Succeeded
+	return api_general.NewCommentRevisionRestoreOK().
+		WithPayload(&api_general.CommentRevisionRestoreOKBody{
+			Comment: comment.CloneWithClearance(user, domainUser).ToDTO(domain.IsHTTPS, domain.Host, page.Path),
+		})
+}
+
 func CommentGet(params api_general.CommentGetParams, user *data.User) middleware.Responder {
 	// Find the comment and related objects
 	comment, page, domain, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
@@ -117,6 +441,16 @@ func CommentGet(params api_general.CommentGetParams, user *data.User) middleware
 		}
 	}
 
+	// Fetch moderator notes on the comment, if the current user is a moderator
+	var notes []*models.ModeratorNote
+	if user.IsSuperuser || domainUser.IsAModerator() {
+		if ns, err := svc.TheModeratorNoteService.List(&domain.ID, nil, &comment.ID); err != nil {
+			return respServiceError(err)
+		} else {
+			notes = data.SliceToDTOs[*data.ModeratorNote, *models.ModeratorNote](ns)
+		}
+	}
+
 	// Succeeded
 	return api_general.NewCommentGetOK().
 		WithPayload(&api_general.CommentGetOKBody{
@@ -125,6 +459,7 @@ func CommentGet(params api_general.CommentGetParams, user *data.User) middleware
 			Deleter:   ud,
 			Editor:    ue,
 			Moderator: um,
+			Notes:     notes,
 			Page:      page.CloneWithClearance(user.IsSuperuser, domainUser.IsAnOwner()).ToDTO(),
 		})
 }
@@ -166,6 +501,8 @@ func CommentList(params api_general.CommentListParams, user *data.User) middlewa
 		swag.BoolValue(params.Pending),
 		swag.BoolValue(params.Rejected),
 		swag.BoolValue(params.Deleted),
+		swag.BoolValue(params.Flagged),
+		swag.BoolValue(params.Shadowed),
 		false,
 		swag.StringValue(params.Filter),
 		swag.StringValue(params.SortBy),
@@ -184,7 +521,7 @@ func CommentList(params api_general.CommentListParams, user *data.User) middlewa
 
 func CommentModerate(params api_general.CommentModerateParams, user *data.User) middleware.Responder {
 	// Update the comment
-	if r := commentModerate(params.UUID, user, swag.BoolValue(params.Body.Pending), swag.BoolValue(params.Body.Approve)); r != nil {
+	if r := commentModerate(params.UUID, user, swag.BoolValue(params.Body.Pending), swag.BoolValue(params.Body.Approve), params.Body.Reason); r != nil {
 		return r
 	}
 
@@ -210,14 +547,25 @@ func commentDelete(commentUUID strfmt.UUID, user *data.User) middleware.Responde
 		return respServiceError(err)
 	}
 
-	// Decrement page/domain comment count in the background, ignoring any errors
-	go func() {
-		_ = svc.ThePageService.IncrementCounts(&page.ID, -1, 0)
-		_ = svc.TheDomainService.IncrementCounts(&domain.ID, -1, 0)
-	}()
+	// Record the action in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentDelete, &user.ID).
+		WithComment(page, comment).
+		WithValues(comment.Status(), "deleted"))
+
+	// Decrement page/domain comment count in the background, ignoring any errors. Shadowed comments aren't counted
+	if !comment.IsShadowed {
+		go func() {
+			_ = svc.ThePageService.IncrementCounts(&page.ID, -1, 0)
+			_ = svc.TheDomainService.IncrementCounts(&domain.ID, -1, 0)
+		}()
+	}
 
 	// Notify websocket subscribers
-	commentWebSocketNotify(page, comment, "delete")
+	svc.TheCommentNotifyService.WebSocket(page, comment, "delete")
+
+	// Notify webhooks
+	comment.IsDeleted = true
+	svc.TheCommentNotifyService.Webhook(domain, page, comment, models.WebhookEventCommentDotDeleted)
 
 	// Succeeded
 	return nil
@@ -248,8 +596,9 @@ func commentGetCommentPageDomainUser(commentUUID strfmt.UUID, curUserID *uuid.UU
 	}
 }
 
-// commentModerate verifies the user is allowed to moderate a comment (specified by its ID) and updates it
-func commentModerate(commentUUID strfmt.UUID, curUser *data.User, pending, approve bool) middleware.Responder {
+// commentModerate verifies the user is allowed to moderate a comment (specified by its ID) and updates it. modReason is
+// an optional reason for the decision, recorded in the moderation log
+func commentModerate(commentUUID strfmt.UUID, curUser *data.User, pending, approve bool, modReason string) middleware.Responder {
 	// Find the comment and related objects
 	comment, page, domain, curDomainUser, r := commentGetCommentPageDomainUser(commentUUID, &curUser.ID)
 	if r != nil {
@@ -268,28 +617,35 @@ func commentModerate(commentUUID strfmt.UUID, curUser *data.User, pending, appro
 	}
 
 	// Update the comment's state in the database
+	statusBefore := comment.Status()
 	comment.WithModerated(&curUser.ID, pending, approve, reason)
 	if err := svc.TheCommentService.Moderated(comment); err != nil {
 		return respServiceError(err)
 	}
 
-	// Notify the comment author about the status change, in the background
-	go func() { _ = sendCommentStatusNotifications(domain, page, comment) }()
-
-	// Notify websocket subscribers
-	commentWebSocketNotify(page, comment, "update")
+	// Log the action and send out notifications
+	svc.TheCommentNotifyService.Moderated(domain, page, comment, curUser, statusBefore, modReason)
 
 	// Succeeded
 	return nil
 }
 
-// commentWebSocketNotify notifies websocket subscribers about a change in the given comment, in background
-func commentWebSocketNotify(page *data.DomainPage, comment *data.Comment, action string) {
-	if svc.TheWebSocketsService.Active() {
-		go func() {
-			// Postpone the update a bit to let the client finish the API call
-			time.Sleep(500 * time.Millisecond)
-			svc.TheWebSocketsService.Send(&page.DomainID, &comment.ID, data.NullUUIDPtr(&comment.ParentID), page.Path, action)
-		}()
+// commentRevisionGet finds and returns a revision of the given comment by a string revision ID
+func commentRevisionGet(comment *data.Comment, revUUID strfmt.UUID) (*data.CommentRevision, middleware.Responder) {
+	// Parse revision ID
+	if revID, r := parseUUID(revUUID); r != nil {
+		return nil, r
+
+		// Find the revision
+	} else if rev, err := svc.TheCommentRevisionService.FindByID(revID); err != nil {
+		return nil, respServiceError(err)
+
+		// Make sure the revision belongs to the comment
+	} else if rev.CommentID != comment.ID {
+		return nil, respNotFound(nil)
+
+	} else {
+		// Succeeded
+		return rev, nil
 	}
 }
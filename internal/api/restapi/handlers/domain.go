@@ -16,6 +16,7 @@ import (
 	"gitlab.com/comentario/comentario/internal/util"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -216,6 +217,11 @@ func DomainNew(params api_general.DomainNewParams, user *data.User) middleware.R
 		return r
 	}
 
+	// Validate link moderation hosts
+	if r := Verifier.DomainLinkHosts(d.ModTrustedHosts, d.ModBlockedHosts); r != nil {
+		return r
+	}
+
 	// Validate domain configuration
 	if r := Verifier.DomainConfigItems(params.Body.Configuration); r != nil {
 		return r
@@ -265,6 +271,22 @@ func DomainPurge(params api_general.DomainPurgeParams, user *data.User) middlewa
 	}
 }
 
+func DomainSpamClassifierRetrain(params api_general.DomainSpamClassifierRetrainParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	if d, _, r := domainGetWithUser(params.UUID, user, true); r != nil {
+		return r
+
+		// Retrain the classifier
+	} else if cntSpam, cntHam, err := svc.TheSpamClassifierService.Retrain(&d.ID); err != nil {
+		return respServiceError(err)
+
+	} else {
+		// Succeeded
+		return api_general.NewDomainSpamClassifierRetrainOK().
+			WithPayload(&api_general.DomainSpamClassifierRetrainOKBody{CountSpam: int64(cntSpam), CountHam: int64(cntHam)})
+	}
+}
+
 func DomainSsoSecretNew(params api_general.DomainSsoSecretNewParams, user *data.User) middleware.Responder {
 	// Find the domain and verify the user's privileges
 	if d, _, r := domainGetWithUser(params.UUID, user, true); r != nil {
@@ -283,12 +305,22 @@ func DomainSsoSecretNew(params api_general.DomainSsoSecretNewParams, user *data.
 // DomainReadonly sets the domain's readonly state
 func DomainReadonly(params api_general.DomainReadonlyParams, user *data.User) middleware.Responder {
 	// Find the domain and verify the user's privileges
-	if d, _, r := domainGetWithUser(params.UUID, user, true); r != nil {
+	d, _, r := domainGetWithUser(params.UUID, user, true)
+	if r != nil {
 		return r
+	}
 
-		// Update the domain status
-	} else if err := svc.TheDomainService.SetReadonly(&d.ID, swag.BoolValue(params.Body.Readonly)); err != nil {
-		return respServiceError(err)
+	// Update the domain status, if necessary
+	ro := swag.BoolValue(params.Body.Readonly)
+	if d.IsReadonly != ro {
+		if err := svc.TheDomainService.SetReadonly(&d.ID, ro); err != nil {
+			return respServiceError(err)
+		}
+
+		// Record the action in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionDomainLock, &user.ID).
+			WithDomain(&d.ID).
+			WithValues(strconv.FormatBool(d.IsReadonly), strconv.FormatBool(ro)))
 	}
 
 	// Succeeded
@@ -307,6 +339,11 @@ func DomainUpdate(params api_general.DomainUpdateParams, user *data.User) middle
 		return respBadRequest(exmodels.ErrorImmutableProperty.WithDetails("host"))
 	}
 
+	// Validate link moderation hosts
+	if r := Verifier.DomainLinkHosts(params.Body.Domain.ModTrustedHosts, params.Body.Domain.ModBlockedHosts); r != nil {
+		return r
+	}
+
 	// Validate domain configuration
 	if r := Verifier.DomainConfigItems(params.Body.Configuration); r != nil {
 		return r
@@ -354,12 +391,20 @@ func domainConvertExtensions(exIn []*models.DomainExtension) ([]*data.DomainExte
 
 		} else {
 			// Convert the model
-			exOut = append(exOut, &data.DomainExtension{
+			de := &data.DomainExtension{
 				ID:      ex.ID,
 				Name:    ex.Name,
 				Config:  e.Config,
 				Enabled: true,
-			})
+			}
+
+			// Validate blocklist rules
+			if de.ID == data.DomainExtensionIDBlocklist {
+				if _, err := util.ParseBlocklist(de.ConfigParams()); err != nil {
+					return nil, respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails(err.Error()))
+				}
+			}
+			exOut = append(exOut, de)
 		}
 	}
 	return exOut, nil
@@ -411,3 +456,40 @@ func domainGetWithUser(domainUUID strfmt.UUID, user *data.User, checkCanManage b
 		return domain, domainUser, nil
 	}
 }
+
+// domainModerateGetWithUser parses a string UUID and fetches the corresponding domain, verifying the user is allowed to
+// moderate it
+func domainModerateGetWithUser(domainUUID strfmt.UUID, user *data.User) (*data.Domain, middleware.Responder) {
+	// Find the domain and the domain user
+	domain, domainUser, r := domainGetWithUser(domainUUID, user, false)
+	if r != nil {
+		return nil, r
+	}
+
+	// Verify the user can moderate the domain
+	if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+		return nil, r
+	}
+
+	// Succeeded
+	return domain, nil
+}
+
+// domainModerateVerifyByID verifies the user is allowed to moderate the domain with the given ID, which an object being
+// accessed belongs to
+func domainModerateVerifyByID(domainID *uuid.UUID, user *data.User) middleware.Responder {
+	// Find the domain user
+	_, domainUser, err := svc.TheDomainService.FindDomainUserByID(domainID, &user.ID, false)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// If no user record is present, the user isn't allowed to view the object at all (unless it's a superuser): respond
+	// with Not Found as if the object doesn't exist
+	if !user.IsSuperuser && domainUser == nil {
+		return respNotFound(nil)
+	}
+
+	// Verify the user can moderate the domain
+	return Verifier.UserCanModerateDomain(user, domainUser)
+}
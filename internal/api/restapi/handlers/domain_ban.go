@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"gitlab.com/comentario/comentario/internal/api/exmodels"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/svc"
+)
+
+func DomainBanDelete(params api_general.DomainBanDeleteParams, user *data.User) middleware.Responder {
+	// Find the ban rule and verify the user's privileges
+	b, r := domainBanGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Delete the rule
+	if err := svc.TheDomainBanService.DeleteByID(&b.ID); err != nil {
+		return respServiceError(err)
+	}
+
+	// Record the action in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionDomainBanDelete, &user.ID).
+		WithDomain(&b.DomainID).
+		WithValues(b.Summary(), "").
+		WithReason(b.Reason))
+
+	// Succeeded
+	return api_general.NewDomainBanDeleteNoContent()
+}
+
+func DomainBanList(params api_general.DomainBanListParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	domain, r := domainModerateGetWithUser(params.Domain, user)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the domain's ban rules
+	bs, err := svc.TheDomainBanService.ListByDomain(&domain.ID)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewDomainBanListOK().
+		WithPayload(&api_general.DomainBanListOKBody{
+			Bans: data.SliceToDTOs[*data.DomainBan, *models.DomainBan](bs),
+		})
+}
+
+func DomainBanNew(params api_general.DomainBanNewParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	domain, r := domainModerateGetWithUser(params.Domain, user)
+	if r != nil {
+		return r
+	}
+
+	// Create a new ban rule, validating its kind and value
+	b := data.NewDomainBan(&domain.ID, &user.ID)
+	if err := b.WithRule(*params.Body.Kind, swag.StringValue(params.Body.Value)); err != nil {
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails(err.Error()))
+	}
+	b.FromDTO(params.Body)
+
+	// Persist the rule
+	if err := svc.TheDomainBanService.Create(b); err != nil {
+		return respServiceError(err)
+	}
+
+	// Record the action in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionDomainBanAdd, &user.ID).
+		WithDomain(&domain.ID).
+		WithValues("", b.Summary()).
+		WithReason(b.Reason))
+
+	// Succeeded
+	return api_general.NewDomainBanNewOK().WithPayload(b.ToDTO())
+}
+
+func DomainBanUpdate(params api_general.DomainBanUpdateParams, user *data.User) middleware.Responder {
+	// Find the ban rule and verify the user's privileges
+	b, r := domainBanGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Update the rule
+	before := b.Summary()
+	b.FromDTO(params.Body)
+	if err := svc.TheDomainBanService.Update(b); err != nil {
+		return respServiceError(err)
+	}
+
+	// Record the action in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionDomainBanUpdate, &user.ID).
+		WithDomain(&b.DomainID).
+		WithValues(before, b.Summary()).
+		WithReason(b.Reason))
+
+	// Succeeded
+	return api_general.NewDomainBanUpdateNoContent()
+}
+
+// domainBanGetWithUser parses a string UUID and fetches the corresponding ban rule, verifying the user is allowed to
+// moderate its domain
+func domainBanGetWithUser(banID strfmt.UUID, user *data.User) (*data.DomainBan, middleware.Responder) {
+	// Extract ban rule ID
+	id, r := parseUUID(banID)
+	if r != nil {
+		return nil, r
+	}
+
+	// Fetch the rule
+	b, err := svc.TheDomainBanService.FindByID(id)
+	if err != nil {
+		return nil, respServiceError(err)
+	}
+
+	// Verify the user can moderate the rule's domain
+	if r := domainModerateVerifyByID(&b.DomainID, user); r != nil {
+		return nil, r
+	}
+
+	// Succeeded
+	return b, nil
+}
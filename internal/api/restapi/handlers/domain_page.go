@@ -8,6 +8,7 @@ import (
 	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
+	"strconv"
 )
 
 func DomainPageGet(params api_general.DomainPageGetParams, user *data.User) middleware.Responder {
@@ -79,10 +80,18 @@ func DomainPageUpdate(params api_general.DomainPageUpdateParams, user *data.User
 
 	// Update the page
 	ro := swag.BoolValue(params.Body.IsReadonly)
-	if err := svc.ThePageService.Update(page.WithIsReadonly(ro).WithPath(path)); err != nil {
+	roBefore := page.IsReadonly
+	if err := svc.ThePageService.Update(page.WithIsReadonly(ro).WithNoAutoClose(params.Body.NoAutoClose).WithPath(path)); err != nil {
 		return respServiceError(err)
 	}
 
+	// Record a change of the readonly status in the moderation log
+	if roBefore != ro {
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionPageLock, &user.ID).
+			WithPage(page).
+			WithValues(strconv.FormatBool(roBefore), strconv.FormatBool(ro)))
+	}
+
 	// Succeeded
 	return api_general.NewDomainPageUpdateNoContent()
 }
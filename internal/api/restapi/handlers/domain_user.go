@@ -9,6 +9,7 @@ import (
 	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
+	"strconv"
 )
 
 func DomainUserGet(params api_general.DomainUserGetParams, user *data.User) middleware.Responder {
@@ -16,10 +17,18 @@ func DomainUserGet(params api_general.DomainUserGetParams, user *data.User) midd
 	if u, du, r := domainUserGet(params.Domain, params.UUID, user); r != nil {
 		return r
 
+		// Fetch moderator notes on the user
+	} else if ns, err := svc.TheModeratorNoteService.List(&du.DomainID, &du.UserID, nil); err != nil {
+		return respServiceError(err)
+
 	} else {
 		// Succeeded
 		return api_general.NewDomainUserGetOK().
-			WithPayload(&api_general.DomainUserGetOKBody{DomainUser: du.ToDTO(), User: u.ToDTO()})
+			WithPayload(&api_general.DomainUserGetOKBody{
+				DomainUser: du.ToDTO(),
+				Notes:      data.SliceToDTOs[*data.ModeratorNote, *models.ModeratorNote](ns),
+				User:       u.ToDTO(),
+			})
 	}
 }
 
@@ -34,6 +43,7 @@ func DomainUserList(params api_general.DomainUserListParams, user *data.User) mi
 	um, dus, err := svc.TheUserService.ListByDomain(
 		&domain.ID,
 		user.IsSuperuser,
+		swag.BoolValue(params.ShadowBanned),
 		swag.StringValue(params.Filter),
 		swag.StringValue(params.SortBy),
 		data.SortDirection(swag.BoolValue(params.SortDesc)),
@@ -56,6 +66,81 @@ func DomainUserList(params api_general.DomainUserListParams, user *data.User) mi
 		})
 }
 
+func DomainUserShadowBan(params api_general.DomainUserShadowBanParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user is a domain moderator
+	domain, curDU, r := domainGetWithUser(*params.Body.DomainID, user, false)
+	if r != nil {
+		return r
+	}
+	if r := Verifier.UserCanModerateDomain(user, curDU); r != nil {
+		return r
+	}
+
+	// Parse user ID
+	userID, r := parseUUID(params.UUID)
+	if r != nil {
+		return r
+	}
+
+	// Users can't shadow-ban themselves
+	if *userID == user.ID {
+		return respBadRequest(exmodels.ErrorSelfOperation)
+	}
+
+	// Find the domain user
+	_, du, err := svc.TheUserService.FindDomainUserByID(userID, &domain.ID)
+	if err != nil {
+		return respServiceError(err)
+	} else if du == nil {
+		return respNotFound(nil)
+	}
+
+	// Owners and moderators can't be shadow-banned
+	if du.CanModerate() {
+		return respForbidden(exmodels.ErrorNotAllowed)
+	}
+
+	// Don't bother if the state doesn't change
+	shadowBanned := swag.BoolValue(params.Body.ShadowBanned)
+	if du.IsShadowBanned == shadowBanned {
+		return api_general.NewDomainUserShadowBanNoContent()
+	}
+
+	// Update the domain user
+	du.WithShadowBanned(shadowBanned)
+	if err := svc.TheDomainService.UserModify(du); err != nil {
+		return respServiceError(err)
+	}
+
+	// If the ban is lifted, make the user's shadowed comments visible
+	if !shadowBanned {
+		cnts, err := svc.TheCommentService.UnshadowByUser(&domain.ID, &du.UserID)
+		if err != nil {
+			return respServiceError(err)
+		}
+
+		// Increment page/domain comment counts in the background, ignoring any errors
+		go func() {
+			total := 0
+			for id, cnt := range cnts {
+				_ = svc.ThePageService.IncrementCounts(&id, cnt, 0)
+				total += cnt
+			}
+			_ = svc.TheDomainService.IncrementCounts(&domain.ID, total, 0)
+		}()
+	}
+
+	// Record the action in the moderation log
+	moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionDomainUserShadowBan, &user.ID).
+		WithDomain(&du.DomainID).
+		WithUser(&du.UserID).
+		WithValues(strconv.FormatBool(!shadowBanned), strconv.FormatBool(shadowBanned)).
+		WithReason(params.Body.Reason))
+
+	// Succeeded
+	return api_general.NewDomainUserShadowBanNoContent()
+}
+
 func DomainUserUpdate(params api_general.DomainUserUpdateParams, user *data.User) middleware.Responder {
 	// Find the domain user
 	_, du, r := domainUserGet(*params.Body.DomainID, params.UUID, user)
@@ -70,14 +155,24 @@ func DomainUserUpdate(params api_general.DomainUserUpdateParams, user *data.User
 	}
 
 	// Update the domain user
+	roleBefore := du.Role()
 	du.WithRole(role).
 		WithNotifyReplies(params.Body.NotifyReplies).
 		WithNotifyModerator(params.Body.NotifyModerator).
-		WithNotifyCommentStatus(params.Body.NotifyCommentStatus)
+		WithNotifyCommentStatus(params.Body.NotifyCommentStatus).
+		WithNotifyMentions(params.Body.NotifyMentions)
 	if err := svc.TheDomainService.UserModify(du); err != nil {
 		return respServiceError(err)
 	}
 
+	// Record a role change in the moderation log
+	if roleBefore != role {
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionDomainUserRole, &user.ID).
+			WithDomain(&du.DomainID).
+			WithUser(&du.UserID).
+			WithValues(string(roleBefore), string(role)))
+	}
+
 	// Succeeded
 	return api_general.NewDomainUserUpdateNoContent()
 }
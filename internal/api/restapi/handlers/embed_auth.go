@@ -14,9 +14,28 @@ import (
 )
 
 func EmbedAuthLogin(params api_embed.EmbedAuthLoginParams) middleware.Responder {
+	// Find the domain
+	email := data.EmailPtrToString(params.Body.Email)
+	domain, err := svc.TheDomainService.FindByHost(string(params.Body.Host))
+	if errors.Is(err, svc.ErrNotFound) {
+		return respForbidden(exmodels.ErrorUnknownHost)
+	} else if err != nil {
+		return respServiceError(err)
+	}
+
+	// Verify the visitor isn't banned on the domain
+	if r := Verifier.VisitorNotBanned(&domain.ID, params.HTTPRequest, email); r != nil {
+		return r
+	}
+
+	// Verify the rate limit isn't exceeded
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionLogin, &domain.ID, params.HTTPRequest, nil); r != nil {
+		return r
+	}
+
 	// Log the user in
 	user, us, r := loginLocalUser(
-		data.EmailPtrToString(params.Body.Email),
+		email,
 		swag.StringValue(params.Body.Password),
 		string(params.Body.Host),
 		params.HTTPRequest)
@@ -120,8 +139,18 @@ func EmbedAuthSignup(params api_embed.EmbedAuthSignupParams) middleware.Responde
 		return r
 	}
 
-	// Verify no such email is registered yet
+	// Verify the visitor isn't banned on the domain
 	email := data.EmailPtrToString(params.Body.Email)
+	if r := Verifier.VisitorNotBanned(domainID, params.HTTPRequest, email); r != nil {
+		return r
+	}
+
+	// Verify the rate limit isn't exceeded
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionSignup, domainID, params.HTTPRequest, nil); r != nil {
+		return r
+	}
+
+	// Verify no such email is registered yet
 	if _, r := Verifier.UserCanSignupWithEmail(email); r != nil {
 		return r
 	}
@@ -188,11 +217,13 @@ func EmbedAuthCurUserUpdate(params api_embed.EmbedAuthCurUserUpdateParams, user
 	}
 
 	// Update the domain user, if the settings change
-	if du.NotifyReplies != params.Body.NotifyReplies || du.NotifyModerator != params.Body.NotifyModerator || du.NotifyCommentStatus != params.Body.NotifyCommentStatus {
+	if du.NotifyReplies != params.Body.NotifyReplies || du.NotifyModerator != params.Body.NotifyModerator ||
+		du.NotifyCommentStatus != params.Body.NotifyCommentStatus || du.NotifyMentions != params.Body.NotifyMentions {
 		if err := svc.TheDomainService.UserModify(du.
 			WithNotifyReplies(params.Body.NotifyReplies).
 			WithNotifyModerator(params.Body.NotifyModerator).
-			WithNotifyCommentStatus(params.Body.NotifyCommentStatus),
+			WithNotifyCommentStatus(params.Body.NotifyCommentStatus).
+			WithNotifyMentions(params.Body.NotifyMentions),
 		); err != nil {
 			return respServiceError(err)
 		}
@@ -15,6 +15,7 @@ import (
 	"gitlab.com/comentario/comentario/internal/util"
 	"maps"
 	"slices"
+	"strconv"
 	"time"
 )
 
@@ -45,6 +46,106 @@ func EmbedCommentDelete(params api_embed.EmbedCommentDeleteParams, user *data.Us
 	return api_embed.NewEmbedCommentDeleteNoContent()
 }
 
+func EmbedCommentFlag(params api_embed.EmbedCommentFlagParams) middleware.Responder {
+	// Try to authenticate the user
+	user, _, err := svc.TheAuthService.GetUserSessionBySessionHeader(params.HTTPRequest)
+	if err != nil {
+		// Failed, consider the user anonymous
+		user = data.AnonymousUser
+	}
+
+	// Find the comment and related objects
+	comment, page, domain, _, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Make sure flagging is enabled
+	if !svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentFlaggingEnabled) {
+		return respForbidden(exmodels.ErrorFeatureDisabled.WithDetails("comment flagging"))
+	}
+
+	// If the domain disallows anonymous flagging, verify the user is authenticated
+	if !svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentFlaggingAnonymous) {
+		if r := Verifier.UserIsAuthenticated(user); r != nil {
+			return r
+		}
+	}
+
+	// Only approved, visible comments can be flagged, as no one else sees the others anyway
+	if comment.IsDeleted || comment.IsPending || !comment.IsApproved || comment.IsShadowed {
+		return respNotFound(nil)
+	}
+
+	// Make sure the user is not flagging their own comment
+	if !user.IsAnonymous() && comment.UserCreated.UUID == user.ID {
+		return respForbidden(exmodels.ErrorSelfFlag)
+	}
+
+	// Verify the visitor isn't banned on the domain
+	if r := Verifier.VisitorNotBanned(&domain.ID, params.HTTPRequest, user.Email); r != nil {
+		return r
+	}
+
+	// Make sure the visitor isn't flagging too often
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionFlag, &domain.ID, params.HTTPRequest, &user.ID); r != nil {
+		return r
+	}
+
+	// Register the flag
+	added, cnt, err := svc.TheCommentFlagService.Create(
+		data.NewCommentFlag(
+			&comment.ID,
+			&user.ID,
+			*params.Body.Reason,
+			params.Body.Details,
+			util.UserIP(params.HTTPRequest),
+			!config.ServerConfig.LogFullIPs))
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// If the flag has been added and the domain's threshold has been reached, put the comment back into moderation
+	if added && cnt >= svc.TheDomainConfigService.GetInt(&domain.ID, data.DomainConfigKeyCommentFlaggingThreshold) {
+		// List the flags in the pending reason
+		fs, err := svc.TheCommentFlagService.ListByComment(&comment.ID)
+		if err != nil {
+			return respServiceError(err)
+		}
+
+		// Update the comment's state in the database
+		comment.WithModerated(nil, true, false, data.CommentFlagsPendingReason(fs))
+		if err := svc.TheCommentService.Moderated(comment); err != nil {
+			return respServiceError(err)
+		}
+
+		// Record the automatic status change in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentStatus, nil).
+			WithComment(page, comment).
+			WithValues("approved", comment.Status()).
+			WithReason(comment.PendingReason))
+
+		// Send an email notification to moderators, unless they don't want to be notified at all, in the background
+		if domain.ModNotifyPolicy != data.DomainModNotifyPolicyNone {
+			go func() {
+				author := data.AnonymousUser
+				if !comment.IsAnonymous() {
+					if u, err := svc.TheUserService.FindUserByID(&comment.UserCreated.UUID); err == nil {
+						author = u
+					}
+				}
+				_ = svc.TheCommentNotifyService.NotifyModerators(domain, page, comment, author)
+			}()
+		}
+
+		// Notify websocket subscribers
+		svc.TheCommentNotifyService.WebSocket(page, comment, "update")
+	}
+
+	// Succeeded
+	return api_embed.NewEmbedCommentFlagNoContent()
+}
+
 func EmbedCommentGet(params api_embed.EmbedCommentGetParams) middleware.Responder {
 	// Try to authenticate the user
 	user, _, err := svc.TheAuthService.GetUserSessionBySessionHeader(params.HTTPRequest)
@@ -92,9 +193,17 @@ func EmbedCommentGet(params api_embed.EmbedCommentGetParams) middleware.Responde
 		}
 	}
 
+	// Fetch reactions to the comment
+	reactions, err := svc.TheCommentReactionService.ListByComments(&user.ID, []uuid.UUID{comment.ID})
+	if err != nil {
+		return respServiceError(err)
+	}
+	cm := comment.CloneWithClearance(user, domainUser).ToDTO(domain.IsHTTPS, domain.Host, page.Path)
+	cm.Reactions = reactions[comment.ID]
+
 	// Succeeded
 	return api_embed.NewEmbedCommentGetOK().WithPayload(&api_embed.EmbedCommentGetOKBody{
-		Comment:   comment.CloneWithClearance(user, domainUser).ToDTO(domain.IsHTTPS, domain.Host, page.Path),
+		Comment:   cm,
 		Commenter: cr,
 	})
 }
@@ -130,8 +239,12 @@ func EmbedCommentList(params api_embed.EmbedCommentListParams) middleware.Respon
 		BaseDocsURL:              config.ServerConfig.BaseDocsURL,
 		CommentDeletionAuthor:    svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentDeletionAuthor),
 		CommentDeletionModerator: svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentDeletionModerator),
+		CommentEditHistory:       svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentEditHistory),
 		CommentEditingAuthor:     svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentEditingAuthor),
 		CommentEditingModerator:  svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentEditingModerator),
+		CommentFlagging:          svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentFlaggingEnabled),
+		CommentFlaggingAnonymous: svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentFlaggingAnonymous),
+		CommentReactions:         data.ParseCommentReactions(svc.TheDomainConfigService.GetString(&domain.ID, data.DomainConfigKeyCommentReactions)),
 		DefaultLangID:            util.DefaultLanguage.String(),
 		DefaultSort:              models.CommentSort(domain.DefaultSort),
 		DomainID:                 strfmt.UUID(domain.ID.String()),
@@ -181,6 +294,8 @@ func EmbedCommentList(params api_embed.EmbedCommentListParams) middleware.Respon
 		true,
 		false, // Don't include rejected: no one's interested in spam
 		svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyShowDeletedComments),
+		false,
+		false,
 		true, // Filter out orphans (they won't show up on the client anyway)
 		"",
 		"",
@@ -204,7 +319,7 @@ func EmbedCommentList(params api_embed.EmbedCommentListParams) middleware.Respon
 
 func EmbedCommentModerate(params api_embed.EmbedCommentModerateParams, user *data.User) middleware.Responder {
 	// Update the comment
-	if r := commentModerate(params.UUID, user, false, swag.BoolValue(params.Body.Approve)); r != nil {
+	if r := commentModerate(params.UUID, user, false, swag.BoolValue(params.Body.Approve), params.Body.Reason); r != nil {
 		return r
 	}
 
@@ -241,6 +356,16 @@ func EmbedCommentNew(params api_embed.EmbedCommentNewParams) middleware.Responde
 		}
 	}
 
+	// Verify the visitor isn't banned on the domain
+	if r := Verifier.VisitorNotBanned(&domain.ID, params.HTTPRequest, user.Email); r != nil {
+		return r
+	}
+
+	// Verify the rate limit isn't exceeded
+	if r := Verifier.RequestWithinRateLimit(svc.RateLimitActionComment, &domain.ID, params.HTTPRequest, &user.ID); r != nil {
+		return r
+	}
+
 	// Fetch the page: it must exist at this point, under the assumption that one has to list existing comments prior to
 	// adding a new one
 	page, err := svc.ThePageService.FindByDomainPath(&domain.ID, data.PathToString(params.Body.Path))
@@ -295,30 +420,16 @@ func EmbedCommentNew(params api_embed.EmbedCommentNewParams) middleware.Responde
 		comment.WithModerated(&user.ID, false, true, "")
 	}
 
+	// Comments by a shadow-banned user are only visible to them and moderators
+	comment.IsShadowed = domainUser != nil && domainUser.IsShadowBanned
+
 	// Persist a new comment record
 	if err := svc.TheCommentService.Create(comment); err != nil {
 		return respServiceError(err)
 	}
 
-	// Increment page/domain comment counts in the background, ignoring any error
-	go func() {
-		_ = svc.ThePageService.IncrementCounts(&page.ID, 1, 0)
-		_ = svc.TheDomainService.IncrementCounts(&domain.ID, 1, 0)
-	}()
-
-	// Send an email notification to moderators, if we notify about every comment or comments pending moderation and
-	// the comment isn't approved yet, in the background
-	if domain.ModNotifyPolicy == data.DomainModNotifyPolicyAll || comment.IsPending && domain.ModNotifyPolicy == data.DomainModNotifyPolicyPending {
-		go func() { _ = sendCommentModNotifications(domain, page, comment, user) }()
-	}
-
-	// If it's a reply and the comment is approved, send out a reply notifications, in the background
-	if !comment.IsRoot() && comment.IsApproved {
-		go func() { _ = sendCommentReplyNotifications(domain, page, comment, user) }()
-	}
-
-	// Notify websocket subscribers
-	commentWebSocketNotify(page, comment, "new")
+	// Update the counts and send out notifications
+	svc.TheCommentNotifyService.Created(domain, page, comment, user)
 
 	// Succeeded
 	return api_embed.NewEmbedCommentNewOK().WithPayload(&api_embed.EmbedCommentNewOKBody{
@@ -346,6 +457,95 @@ func EmbedCommentPreview(params api_embed.EmbedCommentPreviewParams) middleware.
 	return api_embed.NewEmbedCommentPreviewOK().WithPayload(&api_embed.EmbedCommentPreviewOKBody{HTML: c.HTML})
 }
 
+func EmbedCommentReact(params api_embed.EmbedCommentReactParams, user *data.User) middleware.Responder {
+	// Find the comment and the related objects
+	comment, page, domain, _, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Make sure reactions are enabled, and the reaction is allowed
+	allowed := data.ParseCommentReactions(svc.TheDomainConfigService.GetString(&domain.ID, data.DomainConfigKeyCommentReactions))
+	if len(allowed) == 0 {
+		return respForbidden(exmodels.ErrorFeatureDisabled.WithDetails("comment reactions"))
+	} else if !slices.Contains(allowed, params.Body.Reaction) {
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("reaction"))
+	}
+
+	// Only allow reacting to visible comments
+	if comment.IsDeleted || comment.IsPending || !comment.IsApproved || comment.IsShadowed {
+		return respNotFound(nil)
+	}
+
+	// Make sure the user isn't banned on the domain
+	if r := Verifier.VisitorNotBanned(&domain.ID, params.HTTPRequest, user.Email); r != nil {
+		return r
+	}
+
+	// Toggle the reaction
+	if _, err := svc.TheCommentReactionService.Toggle(&comment.ID, &user.ID, params.Body.Reaction); err != nil {
+		return respServiceError(err)
+	}
+
+	// Fetch the updated reactions
+	reactions, err := svc.TheCommentReactionService.ListByComments(&user.ID, []uuid.UUID{comment.ID})
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Notify websocket subscribers
+	svc.TheCommentNotifyService.WebSocket(page, comment, "react")
+
+	// Succeeded
+	return api_embed.NewEmbedCommentReactOK().
+		WithPayload(&api_embed.EmbedCommentReactOKBody{Reactions: reactions[comment.ID]})
+}
+
+func EmbedCommentRevisionList(params api_embed.EmbedCommentRevisionListParams) middleware.Responder {
+	// Try to authenticate the user
+	user, _, err := svc.TheAuthService.GetUserSessionBySessionHeader(params.HTTPRequest)
+	if err != nil {
+		// Failed, consider the user anonymous
+		user = data.AnonymousUser
+	}
+
+	// Find the comment and related objects
+	comment, _, domain, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
+	if r != nil {
+		return r
+	}
+
+	// Moderators can always see the history; other users only if it's enabled for the domain
+	moderator := user.IsSuperuser || domainUser.CanModerate()
+	if !moderator {
+		if !svc.TheDomainConfigService.GetBool(&domain.ID, data.DomainConfigKeyCommentEditHistory) {
+			return respForbidden(exmodels.ErrorFeatureDisabled.WithDetails("comment edit history"))
+		}
+
+		// Only the history of comments visible to everyone can be seen, or a shadowed comment of the user's own
+		ownComment := !user.IsAnonymous() && comment.UserCreated.Valid && comment.UserCreated.UUID == user.ID
+		if comment.IsDeleted || comment.IsPending || !comment.IsApproved || comment.IsShadowed && !ownComment {
+			return respNotFound(nil)
+		}
+	}
+
+	// Fetch the comment's revisions
+	rs, err := svc.TheCommentRevisionService.ListByComment(&comment.ID)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded. Revisions whose text has been replaced by a moderator are only visible to moderators
+	dtos := make([]*models.CommentRevision, 0, len(rs))
+	for _, rev := range rs {
+		if moderator || !rev.IsHidden {
+			dtos = append(dtos, rev.CloneWithClearance(moderator).ToDTO())
+		}
+	}
+	return api_embed.NewEmbedCommentRevisionListOK().
+		WithPayload(&api_embed.EmbedCommentRevisionListOKBody{Revisions: dtos})
+}
+
 func EmbedCommentSticky(params api_embed.EmbedCommentStickyParams, user *data.User) middleware.Responder {
 	// Find the comment and related objects
 	comment, page, _, domainUser, r := commentGetCommentPageDomainUser(params.UUID, &user.ID)
@@ -370,8 +570,13 @@ func EmbedCommentSticky(params api_embed.EmbedCommentStickyParams, user *data.Us
 			return respServiceError(err)
 		}
 
+		// Record the action in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentSticky, &user.ID).
+			WithComment(page, comment).
+			WithValues(strconv.FormatBool(comment.IsSticky), strconv.FormatBool(b)))
+
 		// Notify websocket subscribers
-		commentWebSocketNotify(page, comment, "sticky")
+		svc.TheCommentNotifyService.WebSocket(page, comment, "sticky")
 	}
 
 	// Succeeded or no change
@@ -390,35 +595,68 @@ func EmbedCommentUpdate(params api_embed.EmbedCommentUpdateParams, user *data.Us
 		return r
 	}
 
-	// If the comment was approved, check the need for moderation again
+	// Update the comment text/HTML
+	mdBefore := comment.Markdown
+	if err := svc.TheCommentService.SetMarkdown(comment, params.Body.Markdown, &domain.ID, &user.ID); err != nil {
+		return respServiceError(err)
+	}
+
+	// Run the approval rules against the new text, which can also mask it or reject the edit. If the comment was
+	// approved, check the need for moderation again
 	unapprove := false
-	if !comment.IsPending && comment.IsApproved {
-		// Run the approval rules
-		if b, s, err := svc.ThePerlustrationService.NeedsModeration(params.HTTPRequest, comment, domain, page, user, domainUser, true); err != nil {
-			return respServiceError(err)
-		} else if b {
-			unapprove = true
-			comment.WithModerated(&user.ID, true, false, s)
-		}
+	if b, s, err := svc.ThePerlustrationService.NeedsModeration(params.HTTPRequest, comment, domain, page, user, domainUser, true); err != nil {
+		return respServiceError(err)
+	} else if b && !comment.IsPending && comment.IsApproved {
+		unapprove = true
+		comment.WithModerated(&user.ID, true, false, s)
 	}
 
-	// Update the comment text/HTML
-	if err := svc.TheCommentService.SetMarkdown(comment, params.Body.Markdown, &domain.ID, &user.ID); err != nil {
+	// Fetch the users mentioned before the edit, so that only newly mentioned ones get notified
+	mentionedBefore, err := svc.TheCommentMentionService.ListByComment(&comment.ID)
+	if err != nil {
 		return respServiceError(err)
 	}
+
+	// Persist the changes
 	if err := svc.TheCommentService.Edited(comment); err != nil {
 		return respServiceError(err)
 	}
 
+	// If the comment is (still) approved and visible, notify newly mentioned users, in the background
+	if comment.IsApproved && !comment.IsShadowed {
+		var mentioned []uuid.UUID
+		for _, id := range comment.Mentions {
+			if !slices.Contains(mentionedBefore, id) {
+				mentioned = append(mentioned, id)
+			}
+		}
+		if len(mentioned) > 0 {
+			go func() { _ = svc.TheCommentNotifyService.NotifyMentions(domain, page, comment, user, mentioned) }()
+		}
+	}
+
+	// Record an edit by someone other than the author in the moderation log
+	if comment.UserCreated.UUID != user.ID {
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentEdit, &user.ID).
+			WithComment(page, comment).
+			WithValues(mdBefore, comment.Markdown))
+	}
+
 	// If the comment approval was revoked
 	if unapprove {
 		if err := svc.TheCommentService.Moderated(comment); err != nil {
 			return respServiceError(err)
 		}
+
+		// Record the automatic status change in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionCommentStatus, nil).
+			WithComment(page, comment).
+			WithValues("approved", comment.Status()).
+			WithReason(comment.PendingReason))
 	}
 
 	// Notify websocket subscribers
-	commentWebSocketNotify(page, comment, "update")
+	svc.TheCommentNotifyService.WebSocket(page, comment, "update")
 
 	// Succeeded
 	return api_embed.NewEmbedCommentUpdateOK().
@@ -452,7 +690,7 @@ func EmbedCommentVote(params api_embed.EmbedCommentVoteParams, user *data.User)
 	}
 
 	// Notify websocket subscribers
-	commentWebSocketNotify(page, comment, "vote")
+	svc.TheCommentNotifyService.WebSocket(page, comment, "vote")
 
 	// Succeeded
 	return api_embed.NewEmbedCommentVoteOK().WithPayload(&api_embed.EmbedCommentVoteOKBody{Score: int64(score)})
@@ -3,11 +3,36 @@ package handlers
 import (
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/go-openapi/swag"
+	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_embed"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
+	"strconv"
 )
 
+func EmbedPageMentionList(params api_embed.EmbedPageMentionListParams, _ *data.User) middleware.Responder {
+	// Extract page ID
+	pageID, r := parseUUID(params.UUID)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the page
+	page, err := svc.ThePageService.FindByID(pageID)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Fetch users who can be mentioned on the page's domain
+	users, err := svc.TheCommentMentionService.ListMentionable(&page.DomainID, swag.StringValue(params.Filter))
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_embed.NewEmbedPageMentionListOK().WithPayload(&api_embed.EmbedPageMentionListOKBody{Users: users})
+}
+
 func EmbedPageUpdate(params api_embed.EmbedPageUpdateParams, user *data.User) middleware.Responder {
 	// Fetch the page and the domain user
 	page, _, domainUser, r := domainPageGetDomainUser(params.UUID, user)
@@ -26,6 +51,11 @@ func EmbedPageUpdate(params api_embed.EmbedPageUpdateParams, user *data.User) mi
 		if err := svc.ThePageService.Update(page.WithIsReadonly(ro)); err != nil {
 			return respServiceError(err)
 		}
+
+		// Record the action in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionPageLock, &user.ID).
+			WithPage(page).
+			WithValues(strconv.FormatBool(!ro), strconv.FormatBool(ro)))
 	}
 
 	// Succeeded
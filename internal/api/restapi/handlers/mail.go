@@ -59,6 +59,11 @@ func MailUnsubscribe(params api_general.MailUnsubscribeParams) middleware.Respon
 	case svc.MailNotificationKindCommentStatus:
 		changed = domainUser.NotifyCommentStatus
 		domainUser.WithNotifyCommentStatus(false)
+
+	// Mention notifications
+	case svc.MailNotificationKindMention:
+		changed = domainUser.NotifyMentions
+		domainUser.WithNotifyMentions(false)
 	}
 
 	// Persist the changes, if any
@@ -73,84 +78,6 @@ func MailUnsubscribe(params api_general.MailUnsubscribeParams) middleware.Respon
 		WithLocation(svc.TheI18nService.FrontendURL(user.LangID, "", map[string]string{"unsubscribed": "true"}))
 }
 
-// sendCommentModNotifications sends a comment notification to all domain moderators
-func sendCommentModNotifications(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User) error {
-	// Fetch domain moderators to be notified
-	mods, err := svc.TheUserService.ListDomainModerators(&domain.ID, true)
-	if err != nil {
-		return err
-	}
-
-	// Iterate the moderator users
-	for _, mod := range mods {
-		// Do not email the commenting moderator their own comment
-		if mod.ID != commenter.ID {
-			_ = svc.TheMailService.SendCommentNotification(svc.MailNotificationKindModerator, mod, true, domain, page, comment, commenter.Name)
-		}
-	}
-
-	// Succeeded
-	return nil
-}
-
-// sendCommentReplyNotifications sends a comment reply notification
-func sendCommentReplyNotifications(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User) error {
-	// Fetch the parent comment
-	if parentComment, err := svc.TheCommentService.FindByID(&comment.ParentID.UUID); err != nil {
-		return err
-
-		// No reply notifications for anonymous users and self replies
-	} else if parentComment.IsAnonymous() || parentComment.UserCreated.UUID == commenter.ID {
-		return nil
-
-		// Find the parent commenter user and the corresponding domain user
-	} else if parentUser, parentDomainUser, err := svc.TheUserService.FindDomainUserByID(&parentComment.UserCreated.UUID, &domain.ID); err != nil {
-		return err
-
-		// Don't send notification if reply notifications are turned off
-	} else if parentDomainUser != nil && !parentDomainUser.NotifyReplies {
-		return nil
-
-		// Send a reply notification
-	} else {
-		return svc.TheMailService.SendCommentNotification(
-			svc.MailNotificationKindReply,
-			parentUser,
-			parentUser.IsSuperuser || parentDomainUser.CanModerate(),
-			domain,
-			page,
-			comment,
-			commenter.Name)
-	}
-}
-
-// sendCommentStatusNotifications sends a notification about comment status change
-func sendCommentStatusNotifications(domain *data.Domain, page *data.DomainPage, comment *data.Comment) error {
-	// No notifications for anonymous comments
-	if comment.IsAnonymous() {
-		return nil
-
-		// Find the commenter user and the corresponding domain user
-	} else if commenter, domainUser, err := svc.TheUserService.FindDomainUserByID(&comment.UserCreated.UUID, &domain.ID); err != nil {
-		return err
-
-		// Don't send notification if comment status notifications are turned off
-	} else if domainUser != nil && !domainUser.NotifyCommentStatus {
-		return nil
-
-		// Send a comment status notification
-	} else {
-		return svc.TheMailService.SendCommentNotification(
-			svc.MailNotificationKindCommentStatus,
-			commenter,
-			false,
-			domain,
-			page,
-			comment,
-			commenter.Name)
-	}
-}
-
 // sendConfirmationEmail sends an email containing a confirmation link to the given user
 func sendConfirmationEmail(user *data.User) middleware.Responder {
 	// Don't bother if the user is already confirmed
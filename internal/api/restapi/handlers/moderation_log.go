@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/svc"
+)
+
+func ModerationLogList(params api_general.ModerationLogListParams, user *data.User) middleware.Responder {
+	var domainID, commentID *uuid.UUID
+
+	// If a domain is specified, verify the user can moderate it
+	if params.Domain != nil {
+		domain, domainUser, r := domainGetWithUser(*params.Domain, user, false)
+		if r != nil {
+			return r
+		}
+		if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+			return r
+		}
+		domainID = &domain.ID
+	}
+
+	// If a comment is specified, verify the user can moderate its domain
+	if params.Comment != nil {
+		comment, _, _, domainUser, r := commentGetCommentPageDomainUser(*params.Comment, &user.ID)
+		if r != nil {
+			return r
+		}
+		if r := Verifier.UserCanModerateDomain(user, domainUser); r != nil {
+			return r
+		}
+		commentID = &comment.ID
+	}
+
+	// Entries not limited to a domain or a comment can only be viewed by a superuser
+	if domainID == nil && commentID == nil {
+		if r := Verifier.UserIsSuperuser(user); r != nil {
+			return r
+		}
+	}
+
+	// Parse the optional user ID
+	userID, r := parseUUIDPtr(params.User)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the entries
+	es, err := svc.TheModerationLogService.List(domainID, commentID, userID, data.PageIndex(params.Page))
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewModerationLogListOK().
+		WithPayload(&api_general.ModerationLogListOKBody{
+			Entries: data.SliceToDTOs[*data.ModerationLogEntry, *models.ModerationLogEntry](es),
+		})
+}
+
+// moderationLogAdd appends the given entry to the moderation log. Since the action has already been performed by then,
+// a failure is only logged by the service
+func moderationLogAdd(e *data.ModerationLogEntry) {
+	_ = svc.TheModerationLogService.Add(e)
+}
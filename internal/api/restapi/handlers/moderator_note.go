@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/exmodels"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/svc"
+)
+
+func ModeratorNoteDelete(params api_general.ModeratorNoteDeleteParams, user *data.User) middleware.Responder {
+	// Extract note ID
+	id, r := parseUUID(params.UUID)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the note
+	n, err := svc.TheModeratorNoteService.FindByID(id)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Verify the user can moderate the note's domain
+	if r := domainModerateVerifyByID(&n.DomainID, user); r != nil {
+		return r
+	}
+
+	// Delete the note
+	if err := svc.TheModeratorNoteService.DeleteByID(&n.ID); err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewModeratorNoteDeleteNoContent()
+}
+
+func ModeratorNoteList(params api_general.ModeratorNoteListParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	domain, r := domainModerateGetWithUser(params.Domain, user)
+	if r != nil {
+		return r
+	}
+
+	// Parse the user ID
+	userID, r := parseUUID(params.User)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the notes on the user
+	ns, err := svc.TheModeratorNoteService.List(&domain.ID, userID, nil)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewModeratorNoteListOK().
+		WithPayload(&api_general.ModeratorNoteListOKBody{
+			Notes: data.SliceToDTOs[*data.ModeratorNote, *models.ModeratorNote](ns),
+		})
+}
+
+func ModeratorNoteNew(params api_general.ModeratorNoteNewParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	domain, r := domainModerateGetWithUser(params.Domain, user)
+	if r != nil {
+		return r
+	}
+
+	// Create a new note, attached to either a domain user or a comment
+	n := data.NewModeratorNote(&domain.ID, &user.ID, swag.StringValue(params.Body.Text))
+	if n.Text == "" {
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("text"))
+	}
+	switch {
+	case params.Body.UserID != "" && params.Body.CommentID == "":
+		if userID, r := moderatorNoteDomainUserID(&domain.ID, params.Body.UserID); r != nil {
+			return r
+		} else {
+			n.WithUser(userID)
+		}
+
+	case params.Body.CommentID != "" && params.Body.UserID == "":
+		if commentID, r := moderatorNoteCommentID(&domain.ID, params.Body.CommentID); r != nil {
+			return r
+		} else {
+			n.WithComment(commentID)
+		}
+
+	default:
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("either userId or commentId must be provided"))
+	}
+
+	// Persist the note
+	if err := svc.TheModeratorNoteService.Create(n); err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	n.UserCreatedName = user.Name
+	return api_general.NewModeratorNoteNewOK().WithPayload(n.ToDTO())
+}
+
+// moderatorNoteCommentID parses a string UUID of a comment, verifying the comment belongs to the given domain
+func moderatorNoteCommentID(domainID *uuid.UUID, commentUUID strfmt.UUID) (*uuid.UUID, middleware.Responder) {
+	// Parse comment ID
+	if commentID, r := parseUUID(commentUUID); r != nil {
+		return nil, r
+
+		// Find the comment
+	} else if comment, err := svc.TheCommentService.FindByID(commentID); err != nil {
+		return nil, respServiceError(err)
+
+		// Find the domain page
+	} else if page, err := svc.ThePageService.FindByID(&comment.PageID); err != nil {
+		return nil, respServiceError(err)
+
+		// Make sure the comment is on the same domain
+	} else if page.DomainID != *domainID {
+		return nil, respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("commentId"))
+
+	} else {
+		// Succeeded
+		return commentID, nil
+	}
+}
+
+// moderatorNoteDomainUserID parses a string UUID of a user, verifying the user is registered on the given domain
+func moderatorNoteDomainUserID(domainID *uuid.UUID, userUUID strfmt.UUID) (*uuid.UUID, middleware.Responder) {
+	// Parse user ID
+	if userID, r := parseUUID(userUUID); r != nil {
+		return nil, r
+
+		// Find the domain user
+	} else if _, du, err := svc.TheUserService.FindDomainUserByID(userID, domainID); err != nil {
+		return nil, respServiceError(err)
+
+		// Make sure the domain user exists
+	} else if du == nil {
+		return nil, respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("userId"))
+
+	} else {
+		// Succeeded
+		return userID, nil
+	}
+}
@@ -76,8 +76,8 @@ func RssComments(params api_rss.RssCommentsParams) middleware.Responder {
 
 	// Fetch the comments
 	comments, commenterMap, err := svc.TheCommentService.ListWithCommenters(
-		data.AnonymousUser, nil, &domain.ID, pageID, authorUserID, replyToUserID, true, false, false, false, false, "",
-		"created", data.SortDesc, 0)
+		data.AnonymousUser, nil, &domain.ID, pageID, authorUserID, replyToUserID, true, false, false, false, false, false,
+		false, "", "created", data.SortDesc, 0)
 	if err != nil {
 		return respServiceError(err)
 	}
@@ -6,10 +6,12 @@ import (
 	"github.com/go-openapi/strfmt"
 	"github.com/go-openapi/swag"
 	"gitlab.com/comentario/comentario/internal/api/exmodels"
+	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
 	"io"
+	"strconv"
 	"strings"
 )
 
@@ -61,6 +63,16 @@ func UserBan(params api_general.UserBanParams, user *data.User) middleware.Respo
 		if err := svc.TheUserService.UpdateBanned(&user.ID, u, ban); err != nil {
 			return respServiceError(err)
 		}
+
+		// Record the action in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionUserBan, &user.ID).
+			WithUser(&u.ID).
+			WithValues(strconv.FormatBool(!ban), strconv.FormatBool(ban)))
+
+		// Notify webhooks about the ban, in the background
+		if ban {
+			go svc.TheWebhookService.NotifyUserBanned(u)
+		}
 	}
 
 	// When banning the user, all user's comments can also be deleted or purged
@@ -228,6 +240,11 @@ func UserUnlock(params api_general.UserUnlockParams, user *data.User) middleware
 		if err := svc.TheUserService.UpdateLoginLocked(u.WithLocked(false)); err != nil {
 			return respServiceError(err)
 		}
+
+		// Record the action in the moderation log
+		moderationLogAdd(data.NewModerationLogEntry(models.ModerationActionUserLock, &user.ID).
+			WithUser(&u.ID).
+			WithValues("true", "false"))
 	}
 
 	// Succeeded
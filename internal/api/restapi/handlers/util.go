@@ -16,6 +16,7 @@ import (
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
 	"gitlab.com/comentario/comentario/internal/util"
+	"math"
 	"net/http"
 	"time"
 )
@@ -172,12 +173,16 @@ func respNotFound(err *exmodels.Error) middleware.Responder {
 // any sensitive data (which is otherwise supposed to land in the logs) out of the response
 func respServiceError(err error) middleware.Responder {
 	switch {
+	case errors.Is(err, svc.ErrCommentRejected):
+		return api_general.NewGenericUnprocessableEntity().WithPayload(exmodels.ErrorCommentRejected)
 	case errors.Is(err, svc.ErrCommentTooLong):
 		return api_general.NewGenericUnprocessableEntity().WithPayload(exmodels.ErrorCommentTextTooLong)
 	case errors.Is(err, svc.ErrEmailSend):
 		return api_general.NewGenericBadGateway().WithPayload(exmodels.ErrorEmailSendFailure)
 	case errors.Is(err, svc.ErrResourceFetch):
 		return api_general.NewGenericBadGateway().WithPayload(exmodels.ErrorResourceFetchFailed)
+	case errors.Is(err, svc.ErrNotAllowed):
+		return respForbidden(exmodels.ErrorNotAllowed)
 	case errors.Is(err, svc.ErrNotFound):
 		return api_general.NewGenericNotFound()
 	}
@@ -187,6 +192,14 @@ func respServiceError(err error) middleware.Responder {
 	return respInternalError(nil)
 }
 
+// respTooManyRequests returns a responder that responds with HTTP Too Many Requests, advising the client to retry after
+// the given duration
+func respTooManyRequests(retryAfter time.Duration) middleware.Responder {
+	return api_general.NewGenericTooManyRequests().
+		WithRetryAfter(int64(math.Ceil(retryAfter.Seconds()))).
+		WithPayload(exmodels.ErrorTooManyRequests)
+}
+
 // respUnauthorized returns a responder that responds with HTTP Unauthorized error
 func respUnauthorized(err *exmodels.Error) middleware.Responder {
 	return api_general.NewGenericUnauthorized().WithPayload(err)
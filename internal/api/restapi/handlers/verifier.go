@@ -12,6 +12,7 @@ import (
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/svc"
 	"gitlab.com/comentario/comentario/internal/util"
+	"net/http"
 	"time"
 )
 
@@ -24,6 +25,8 @@ type VerifierService interface {
 	DomainConfigItems(items []*models.DynamicConfigItem) middleware.Responder
 	// DomainHostCanBeAdded verifies the given host is valid and not existing yet
 	DomainHostCanBeAdded(host string) middleware.Responder
+	// DomainLinkHosts verifies the given lists of link moderation hosts only contain valid hostnames or IP addresses
+	DomainLinkHosts(hostLists ...string) middleware.Responder
 	// DomainPageCanUpdatePathTo verifies the given domain page is allowed to change its path to the provided new value
 	DomainPageCanUpdatePathTo(page *data.DomainPage, newPath string) middleware.Responder
 	// DomainSSOConfig verifies the given domain is properly configured for SSO authentication
@@ -38,6 +41,9 @@ type VerifierService interface {
 	// LocalSignupEnabled checks if users are allowed to sign up locally. If domainID == nil, it's a frontend (Admin UI)
 	// sign-up
 	LocalSignupEnabled(domainID *uuid.UUID) middleware.Responder
+	// RequestWithinRateLimit verifies the given action, performed by the client making the given request, optionally on
+	// behalf of the given user on the given domain (both can be nil), doesn't exceed the configured rate limits
+	RequestWithinRateLimit(action svc.RateLimitAction, domainID *uuid.UUID, r *http.Request, userID *uuid.UUID) middleware.Responder
 	// UserCanAddDomain checks if the provided user is allowed to register a new domain (and become its owner)
 	UserCanAddDomain(user *data.User) middleware.Responder
 	// UserCanChangeEmailTo verifies the user can change their email to the new given value
@@ -67,6 +73,9 @@ type VerifierService interface {
 	UserIsNotSystem(user *data.User) middleware.Responder
 	// UserIsSuperuser verifies the given user is a superuser
 	UserIsSuperuser(user *data.User) middleware.Responder
+	// VisitorNotBanned verifies the visitor making the given request, with the given email (which can be empty), isn't
+	// banned on the specified domain
+	VisitorNotBanned(domainID *uuid.UUID, r *http.Request, email string) middleware.Responder
 }
 
 // ----------------------------------------------------------------------------------------------------------------------
@@ -125,6 +134,19 @@ func (v *verifier) DomainHostCanBeAdded(host string) middleware.Responder {
 	return nil
 }
 
+func (v *verifier) DomainLinkHosts(hostLists ...string) middleware.Responder {
+	for _, hl := range hostLists {
+		for _, h := range util.ParseHostList(hl) {
+			if !util.IsValidHostname(h) && !util.IsValidIP(h) {
+				return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails(h))
+			}
+		}
+	}
+
+	// Succeeded
+	return nil
+}
+
 func (v *verifier) DomainSSOConfig(domain *data.Domain) middleware.Responder {
 	// Verify SSO is at all enabled
 	if !domain.AuthSSO {
@@ -210,6 +232,13 @@ func (v *verifier) LocalSignupEnabled(domainID *uuid.UUID) middleware.Responder
 	return nil
 }
 
+func (v *verifier) RequestWithinRateLimit(action svc.RateLimitAction, domainID *uuid.UUID, r *http.Request, userID *uuid.UUID) middleware.Responder {
+	if ok, wait := svc.TheRateLimitService.Allow(action, domainID, util.UserIP(r), userID); !ok {
+		return respTooManyRequests(wait)
+	}
+	return nil
+}
+
 func (v *verifier) UserCanAddDomain(user *data.User) middleware.Responder {
 	// If the user isn't a superuser and no new owners are allowed
 	if !user.IsSuperuser && !svc.TheDynConfigService.GetBool(data.ConfigKeyOperationNewOwnerEnabled) {
@@ -368,3 +397,14 @@ func (v *verifier) UserIsSuperuser(user *data.User) middleware.Responder {
 	}
 	return nil
 }
+
+func (v *verifier) VisitorNotBanned(domainID *uuid.UUID, r *http.Request, email string) middleware.Responder {
+	// Match the visitor's unmasked IP address against the domain's ban rules
+	ip, country := util.UserIPCountry(r, false)
+	if b, err := svc.TheDomainBanService.FindActiveMatch(domainID, ip, country, email); err != nil {
+		return respServiceError(err)
+	} else if b != nil {
+		return respForbidden(exmodels.ErrorVisitorBanned)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"github.com/go-openapi/runtime/middleware"
+	"github.com/go-openapi/strfmt"
+	"gitlab.com/comentario/comentario/internal/api/exmodels"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/api/restapi/operations/api_general"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/svc"
+	"gitlab.com/comentario/comentario/internal/util"
+	"net/url"
+	"strings"
+)
+
+func WebhookDelete(params api_general.WebhookDeleteParams, user *data.User) middleware.Responder {
+	// Find the webhook and verify the user's privileges
+	if w, r := webhookGetWithUser(params.UUID, user); r != nil {
+		return r
+
+		// Delete the webhook
+	} else if err := svc.TheWebhookService.DeleteByID(&w.ID); err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewWebhookDeleteNoContent()
+}
+
+func WebhookDeliveryList(params api_general.WebhookDeliveryListParams, user *data.User) middleware.Responder {
+	// Find the webhook and verify the user's privileges
+	w, r := webhookGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the deliveries
+	ds, err := svc.TheWebhookService.ListDeliveries(&w.ID, data.PageIndex(params.Page))
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewWebhookDeliveryListOK().
+		WithPayload(&api_general.WebhookDeliveryListOKBody{
+			Deliveries: data.SliceToDTOs[*data.WebhookDelivery, *models.WebhookDelivery](ds),
+		})
+}
+
+func WebhookGet(params api_general.WebhookGetParams, user *data.User) middleware.Responder {
+	// Find the webhook and verify the user's privileges
+	w, r := webhookGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Succeeded
+	return api_general.NewWebhookGetOK().WithPayload(&api_general.WebhookGetOKBody{Webhook: w.ToDTO()})
+}
+
+func WebhookList(params api_general.WebhookListParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	domain, _, r := domainGetWithUser(params.Domain, user, true)
+	if r != nil {
+		return r
+	}
+
+	// Fetch the domain's webhooks
+	ws, err := svc.TheWebhookService.ListByDomain(&domain.ID)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewWebhookListOK().
+		WithPayload(&api_general.WebhookListOKBody{
+			Webhooks: data.SliceToDTOs[*data.Webhook, *models.Webhook](ws),
+		})
+}
+
+func WebhookNew(params api_general.WebhookNewParams, user *data.User) middleware.Responder {
+	// Find the domain and verify the user's privileges
+	domain, _, r := domainGetWithUser(params.Domain, user, true)
+	if r != nil {
+		return r
+	}
+
+	// Validate the URL
+	if r := webhookValidateURL(params.Body.URL); r != nil {
+		return r
+	}
+
+	// Create a new webhook
+	w, err := data.NewWebhook(&domain.ID, &user.ID)
+	if err != nil {
+		return respInternalError(nil)
+	}
+	w.FromDTO(params.Body)
+
+	// Persist the webhook
+	if err := svc.TheWebhookService.Create(w); err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewWebhookNewOK().WithPayload(w.ToDTO())
+}
+
+func WebhookSecretNew(params api_general.WebhookSecretNewParams, user *data.User) middleware.Responder {
+	// Find the webhook and verify the user's privileges
+	w, r := webhookGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Generate a new secret
+	if err := w.SecretNew(); err != nil {
+		return respInternalError(nil)
+	}
+
+	// Persist the webhook
+	if err := svc.TheWebhookService.Update(w); err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewWebhookSecretNewOK().WithPayload(&api_general.WebhookSecretNewOKBody{Secret: w.Secret})
+}
+
+func WebhookTest(params api_general.WebhookTestParams, user *data.User) middleware.Responder {
+	// Find the webhook and verify the user's privileges
+	w, r := webhookGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Attempt a test delivery
+	d, err := svc.TheWebhookService.Test(w)
+	if err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded. Only report whether the delivery went through, as the response details could be used for probing the
+	// server's network
+	return api_general.NewWebhookTestOK().
+		WithPayload(&api_general.WebhookTestOKBody{Succeeded: d.Status == models.WebhookDeliveryStatusSucceeded})
+}
+
+func WebhookUpdate(params api_general.WebhookUpdateParams, user *data.User) middleware.Responder {
+	// Find the webhook and verify the user's privileges
+	w, r := webhookGetWithUser(params.UUID, user)
+	if r != nil {
+		return r
+	}
+
+	// Validate the URL
+	if r := webhookValidateURL(params.Body.URL); r != nil {
+		return r
+	}
+
+	// Update the webhook
+	w.FromDTO(params.Body)
+	if err := svc.TheWebhookService.Update(w); err != nil {
+		return respServiceError(err)
+	}
+
+	// Succeeded
+	return api_general.NewWebhookUpdateNoContent()
+}
+
+// webhookGetWithUser parses a string UUID and fetches the corresponding webhook, verifying the user is allowed to manage
+// its domain
+func webhookGetWithUser(webhookID strfmt.UUID, user *data.User) (*data.Webhook, middleware.Responder) {
+	// Extract webhook ID
+	id, r := parseUUID(webhookID)
+	if r != nil {
+		return nil, r
+	}
+
+	// Fetch the webhook
+	w, err := svc.TheWebhookService.FindByID(id)
+	if err != nil {
+		return nil, respServiceError(err)
+	}
+
+	// Find the webhook's domain and user
+	_, domainUser, err := svc.TheDomainService.FindDomainUserByID(&w.DomainID, &user.ID, false)
+	if err != nil {
+		return nil, respServiceError(err)
+	}
+
+	// If no user record is present, the user isn't allowed to view the webhook at all (unless it's a superuser): respond
+	// with Not Found as if the webhook doesn't exist
+	if !user.IsSuperuser && domainUser == nil {
+		return nil, respNotFound(nil)
+	}
+
+	// Verify the user can manage the domain
+	if r := Verifier.UserCanManageDomain(user, domainUser); r != nil {
+		return nil, r
+	}
+
+	// Succeeded
+	return w, nil
+}
+
+// webhookValidateURL verifies the passed webhook URL is an absolute HTTP(S) URL not pointing to a local or private
+// address. Host names are only resolved on delivery, which is where non-public addresses are rejected, too
+func webhookValidateURL(s strfmt.URI) middleware.Responder {
+	if u, err := url.Parse(string(s)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("url"))
+	} else if h := strings.ToLower(u.Hostname()); h == "localhost" || strings.HasSuffix(h, ".localhost") ||
+		util.IsValidIP(h) && !util.IsPublicIP(h) {
+		return respBadRequest(exmodels.ErrorInvalidPropertyValue.WithDetails("url must not point to a local or private address"))
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/mail"
@@ -46,6 +47,8 @@ type ServerConfiguration struct {
 	DisableLiveUpdate    bool   `long:"no-live-update"      description:"Disable live updates via WebSockets"                                              env:"NO_LIVE_UPDATE"`
 	DisablePageViewStats bool   `long:"no-page-view-stats"  description:"Disable page view statistics gathering and reporting"                             env:"NO_PAGE_VIEW_STATS"`
 	WSMaxClients         uint32 `long:"ws-max-clients"      description:"Maximum number of WebSocket clients"        default:"10000"                       env:"WS_MAX_CLIENTS"`
+	RateLimitDB          bool   `long:"rate-limit-db"       description:"Keep rate limiting state in the database"                                         env:"RATE_LIMIT_DB"`
+	TrustedProxies       string `long:"trusted-proxies"     description:"Comma-separated IPs/networks of trusted reverse proxies" default:"127.0.0.0/8,::1,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7" env:"TRUSTED_PROXIES"`
 	E2e                  bool   `long:"e2e"                 description:"End-2-end testing mode"`
 
 	parsedBaseURL *url.URL // The parsed base URL
@@ -149,6 +152,11 @@ func (sc *ServerConfiguration) postProcess() error {
 		return fmt.Errorf("invalid CDN URL: %w", err)
 	}
 
+	// Parse trusted proxies
+	if err := util.SetTrustedProxies(sc.TrustedProxies); err != nil {
+		return err
+	}
+
 	// Load and post-process secrets
 	if err := UnmarshalConfigFile(sc.SecretsFile, SecretsConfig); err != nil {
 		return err
@@ -219,6 +227,58 @@ func UnmarshalConfigFile(filename string, out any) error {
 }
 
 func configureMailer() error {
+	cfg := &SecretsConfig.Mail
+	switch t := cfg.Transport; {
+	case t == MailTransportDefault || t == MailTransportSMTP:
+		return configureSMTPMailer()
+
+	case t == MailTransportSendmail:
+		if err := validateEmailFrom(); err != nil {
+			return err
+		}
+		path, args := cfg.Sendmail.Path, cfg.Sendmail.Args
+		if path == "" {
+			path = "/usr/sbin/sendmail"
+		}
+		if args == nil {
+			args = []string{"-t", "-i"}
+		}
+		util.TheMailer = util.NewSendmailMailer(path, args, ServerConfig.EmailFrom)
+		logger.Infof("Sendmail transport configured with binary %s", path)
+
+	case t == MailTransportDir:
+		if cfg.Dir.Path == "" {
+			return errors.New("mail directory path must be specified")
+		}
+		if err := validateEmailFrom(); err != nil {
+			return err
+		}
+		util.TheMailer = util.NewDirMailer(cfg.Dir.Path, cfg.Dir.Maildir, ServerConfig.EmailFrom)
+		logger.Infof("Directory mail transport configured with path %s%s", cfg.Dir.Path, util.If(cfg.Dir.Maildir, " (Maildir)", ""))
+
+	case t == MailTransportHTTP:
+		if u, err := url.Parse(cfg.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid mail API URL: %q", cfg.HTTP.URL)
+		}
+		if err := validateEmailFrom(); err != nil {
+			return err
+		}
+		util.TheMailer = util.NewHTTPMailer(cfg.HTTP.URL, cfg.HTTP.Headers, ServerConfig.EmailFrom, cfg.HTTP.Insecure)
+		logger.Infof("HTTP mail transport configured with URL %s%s", cfg.HTTP.URL, util.If(cfg.HTTP.Insecure, " (INSECURE)", ""))
+
+	case strings.Contains(string(t), "/"):
+		// Transport supplied by a plugin: it gets registered later, when plugins are initialised
+		util.TheMailer = util.NewRegistryMailer(string(t))
+		logger.Infof("Mail transport %q is expected to be provided by a plugin", t)
+
+	default:
+		return fmt.Errorf("invalid mail transport: %q", t)
+	}
+	return nil
+}
+
+// configureSMTPMailer sets up a mailer that uses an SMTP server
+func configureSMTPMailer() error {
 	// If SMTP host is available, use a corresponding mailer
 	cfg := &SecretsConfig.SMTPServer
 	if cfg.Host == "" {
@@ -254,8 +314,8 @@ func configureMailer() error {
 	}
 
 	// Validate the From email address
-	if _, err := mail.ParseAddress(ServerConfig.EmailFrom); err != nil {
-		return fmt.Errorf("invalid 'From' email address %q: %w", ServerConfig.EmailFrom, err)
+	if err := validateEmailFrom(); err != nil {
+		return err
 	}
 
 	// Create a mailer
@@ -271,3 +331,11 @@ func configureMailer() error {
 	logger.Infof("SMTP configured with server %s:%d%s", cfg.Host, cfg.Port, util.If(cfg.Insecure, " (INSECURE)", ""))
 	return nil
 }
+
+// validateEmailFrom verifies the configured From email address is valid
+func validateEmailFrom() error {
+	if _, err := mail.ParseAddress(ServerConfig.EmailFrom); err != nil {
+		return fmt.Errorf("invalid 'From' email address %q: %w", ServerConfig.EmailFrom, err)
+	}
+	return nil
+}
@@ -262,3 +262,50 @@ func Test_configureMailer(t *testing.T) {
 		})
 	}
 }
+
+func Test_configureMailerTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		emailFrom    string
+		transport    MailTransport
+		dirPath      string
+		httpURL      string
+		errText      string
+		wantMailerOp bool
+	}{
+		{"unknown transport    ", "foo@bar", "pigeon", "", "", `invalid mail transport: "pigeon"`, false},
+		{"sendmail + no email  ", "", MailTransportSendmail, "", "", `invalid 'From' email address "": mail: no address`, false},
+		{"sendmail + good email", "foo@bar", MailTransportSendmail, "", "", "", true},
+		{"dir      + no path   ", "foo@bar", MailTransportDir, "", "", "mail directory path must be specified", false},
+		{"dir      + good email", "foo@bar", MailTransportDir, "/tmp/mail", "", "", true},
+		{"http     + no URL    ", "foo@bar", MailTransportHTTP, "", "", `invalid mail API URL: ""`, false},
+		{"http     + bad URL   ", "foo@bar", MailTransportHTTP, "", "ftp://mail.api", `invalid mail API URL: "ftp://mail.api"`, false},
+		{"http     + good URL  ", "foo@bar", MailTransportHTTP, "", "https://mail.api/send", "", true},
+		{"plugin, unregistered ", "foo@bar", "acme/pigeon", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Init configs
+			util.TheMailer = &stubMailer{}
+			ServerConfig.EmailFrom = tt.emailFrom
+			SecretsConfig.Mail.Transport = tt.transport
+			SecretsConfig.Mail.Dir.Path = tt.dirPath
+			SecretsConfig.Mail.HTTP.URL = tt.httpURL
+			defer func() { SecretsConfig.Mail.Transport = MailTransportDefault }()
+
+			// Run and check for error
+			if err := configureMailer(); err == nil && tt.errText != "" {
+				t.Errorf("configureMailer() no error, wanted error %s", tt.errText)
+			} else if err != nil && tt.errText == "" {
+				t.Errorf("configureMailer() error = %v, wanted no error", err)
+			} else if err != nil && err.Error() != tt.errText {
+				t.Errorf("configureMailer() error = %q, wanted %q", err, tt.errText)
+			}
+
+			// Check the mailer
+			if mo := util.TheMailer.Operational(); mo != tt.wantMailerOp {
+				t.Errorf("TheMailer.Operational = %v, wanted %v", mo, tt.wantMailerOp)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/providers/facebook"
@@ -9,8 +10,11 @@ import (
 	"github.com/markbates/goth/providers/google"
 	"github.com/markbates/goth/providers/openidConnect"
 	"github.com/markbates/goth/providers/twitter"
+	"gitlab.com/comentario/comentario/extend/plugin"
 	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/data"
+	"golang.org/x/oauth2"
+	"regexp"
 	"strings"
 )
 
@@ -24,6 +28,9 @@ var FederatedIdProviders = map[models.FederatedIdpID]*data.FederatedIdentityProv
 	"twitter":  {ID: "twitter", Name: "Twitter", GothName: "twitter"},
 }
 
+// rePluginIdPID is a regular expression for validating IDs of federated identity providers supplied by plugins
+var rePluginIdPID = regexp.MustCompile(`^[-a-z0-9]{1,25}$`)
+
 // GetFederatedIdP returns whether federated identity provider is known and configured, and if yes, its Provider
 // interface
 func GetFederatedIdP(id models.FederatedIdpID) (known, configured bool, provider goth.Provider, fidp *data.FederatedIdentityProvider) {
@@ -38,6 +45,45 @@ func GetFederatedIdP(id models.FederatedIdpID) (known, configured bool, provider
 	return
 }
 
+// RegisterPluginFederatedIdP registers a federated identity provider supplied by a plugin. newProvider is invoked to
+// instantiate the provider given its callback URL
+func RegisterPluginFederatedIdP(id, name string, newProvider func(callbackURL string) (plugin.FederatedProvider, error)) error {
+	// Validate the ID
+	if !rePluginIdPID.MatchString(id) {
+		return fmt.Errorf("invalid plugin identity provider ID %q: must consist of 1 to 25 lowercase characters, digits, and dashes", id)
+	}
+
+	// Make sure the ID is unique
+	qid := "plugin:" + id
+	mid := models.FederatedIdpID(qid)
+	if _, ok := FederatedIdProviders[mid]; ok {
+		return fmt.Errorf("duplicate identity provider ID %q", qid)
+	}
+
+	// Instantiate the provider
+	if newProvider == nil {
+		return fmt.Errorf("identity provider (ID=%q) doesn't provide a constructor", qid)
+	}
+	p, err := newProvider(oauthCallbackURL(qid))
+	if err != nil {
+		return fmt.Errorf("failed to instantiate identity provider (ID=%q): %w", qid, err)
+	} else if p == nil {
+		return fmt.Errorf("identity provider (ID=%q) constructor returned nil", qid)
+	}
+
+	// Register the provider, named after its qualified ID so that it can be looked up by that
+	logger.Infof("Registering plugin identity provider (ID=%q)", qid)
+	goth.UseProviders(&pluginGothProvider{name: qid, p: p})
+
+	// Add it to the configured providers map
+	FederatedIdProviders[mid] = &data.FederatedIdentityProvider{
+		ID:       mid,
+		Name:     name,
+		GothName: qid,
+	}
+	return nil
+}
+
 // oauthConfigure configures federated (OAuth) authentication
 func oauthConfigure() error {
 	facebookOauthConfigure()
@@ -197,3 +243,91 @@ func oidcConfigure() error {
 	}
 	return nil
 }
+
+// pluginGothProvider adapts a federated identity provider supplied by a plugin to the goth.Provider interface
+type pluginGothProvider struct {
+	name string                   // Provider name, which is its qualified ID
+	p    plugin.FederatedProvider // Plugin's provider implementation
+}
+
+func (gp *pluginGothProvider) Name() string {
+	return gp.name
+}
+
+func (gp *pluginGothProvider) SetName(name string) {
+	gp.name = name
+}
+
+func (gp *pluginGothProvider) BeginAuth(state string) (goth.Session, error) {
+	return pluginGothSessionOf(gp.p.BeginAuth(state))
+}
+
+func (gp *pluginGothProvider) UnmarshalSession(data string) (goth.Session, error) {
+	return pluginGothSessionOf(gp.p.UnmarshalSession(data))
+}
+
+func (gp *pluginGothProvider) FetchUser(sess goth.Session) (goth.User, error) {
+	gs, ok := sess.(*pluginGothSession)
+	if !ok {
+		return goth.User{}, fmt.Errorf("unexpected session type %T", sess)
+	}
+
+	// Fetch the user from the plugin
+	u, err := gp.p.FetchUser(gs.s)
+	if err != nil {
+		return goth.User{}, err
+	} else if u == nil {
+		return goth.User{}, errors.New("identity provider returned no user")
+	}
+
+	// Convert the user into a goth one
+	return goth.User{
+		Provider:  gp.name,
+		UserID:    u.ID,
+		Email:     u.Email,
+		Name:      u.Name,
+		NickName:  u.NickName,
+		AvatarURL: u.AvatarURL,
+	}, nil
+}
+
+func (gp *pluginGothProvider) Debug(bool) {
+	// Not applicable
+}
+
+func (gp *pluginGothProvider) RefreshToken(string) (*oauth2.Token, error) {
+	return nil, errors.New("refresh token isn't supported by plugin identity providers")
+}
+
+func (gp *pluginGothProvider) RefreshTokenAvailable() bool {
+	return false
+}
+
+// pluginGothSession adapts an authentication session of a plugin's identity provider to the goth.Session interface
+type pluginGothSession struct {
+	s plugin.FederatedSession
+}
+
+// pluginGothSessionOf wraps the given plugin session, returned by a provider along with an error, into a
+// pluginGothSession
+func pluginGothSessionOf(s plugin.FederatedSession, err error) (goth.Session, error) {
+	if err != nil {
+		return nil, err
+	} else if s == nil {
+		return nil, errors.New("identity provider returned no session")
+	}
+	return &pluginGothSession{s: s}, nil
+}
+
+func (gs *pluginGothSession) GetAuthURL() (string, error) {
+	return gs.s.AuthURL()
+}
+
+func (gs *pluginGothSession) Marshal() string {
+	return gs.s.Marshal()
+}
+
+func (gs *pluginGothSession) Authorize(_ goth.Provider, params goth.Params) (string, error) {
+	// The access token isn't exposed by plugin sessions
+	return "", gs.s.Authorize(params)
+}
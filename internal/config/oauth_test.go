@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"github.com/markbates/goth"
+	"gitlab.com/comentario/comentario/extend/plugin"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/data"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// stubFederatedSession is a plugin.FederatedSession returning predefined values
+type stubFederatedSession struct {
+	authURL string
+	code    string
+}
+
+func (s *stubFederatedSession) AuthURL() (string, error) {
+	return s.authURL, nil
+}
+
+func (s *stubFederatedSession) Authorize(params plugin.FederatedParams) error {
+	if s.code = params.Get("code"); s.code == "" {
+		return errors.New("code missing")
+	}
+	return nil
+}
+
+func (s *stubFederatedSession) Marshal() string {
+	return s.authURL
+}
+
+// stubFederatedProvider is a plugin.FederatedProvider returning predefined values
+type stubFederatedProvider struct {
+	callbackURL string
+	user        *plugin.FederatedUser
+}
+
+func (p *stubFederatedProvider) BeginAuth(state string) (plugin.FederatedSession, error) {
+	return &stubFederatedSession{authURL: "https://idp.example.com/auth?state=" + state}, nil
+}
+
+func (p *stubFederatedProvider) UnmarshalSession(data string) (plugin.FederatedSession, error) {
+	if data == "" {
+		return nil, nil
+	}
+	return &stubFederatedSession{authURL: data}, nil
+}
+
+func (p *stubFederatedProvider) FetchUser(sess plugin.FederatedSession) (*plugin.FederatedUser, error) {
+	if sess.(*stubFederatedSession).code == "" {
+		return nil, errors.New("session not authorised")
+	}
+	return p.user, nil
+}
+
+// withPluginIdPs sets up a server base URL and restores the registered identity providers after the test
+func withPluginIdPs(t *testing.T) {
+	idps := make(map[models.FederatedIdpID]*data.FederatedIdentityProvider, len(FederatedIdProviders))
+	for id, idp := range FederatedIdProviders {
+		idps[id] = idp
+	}
+	var gps []goth.Provider
+	for _, gp := range goth.GetProviders() {
+		gps = append(gps, gp)
+	}
+	sc := ServerConfig
+	ServerConfig = ServerConfiguration{parsedBaseURL: mustParseURL("https://comentario.example.com/")}
+	t.Cleanup(func() {
+		FederatedIdProviders = idps
+		ServerConfig = sc
+		goth.ClearProviders()
+		goth.UseProviders(gps...)
+	})
+}
+
+func TestRegisterPluginFederatedIdP(t *testing.T) {
+	ctorErr := errors.New("boom")
+	tests := []struct {
+		name    string
+		id      string
+		ctor    func(string) (plugin.FederatedProvider, error)
+		wantErr bool
+	}{
+		{"valid             ", "acme-sso2", func(cb string) (plugin.FederatedProvider, error) { return &stubFederatedProvider{callbackURL: cb}, nil }, false},
+		{"empty ID          ", "", func(string) (plugin.FederatedProvider, error) { return &stubFederatedProvider{}, nil }, true},
+		{"uppercase ID      ", "Acme", func(string) (plugin.FederatedProvider, error) { return &stubFederatedProvider{}, nil }, true},
+		{"colon in ID       ", "acme:sso", func(string) (plugin.FederatedProvider, error) { return &stubFederatedProvider{}, nil }, true},
+		{"ID too long       ", "abcdefghijklmnopqrstuvwxyz", func(string) (plugin.FederatedProvider, error) { return &stubFederatedProvider{}, nil }, true},
+		{"no constructor    ", "acme", nil, true},
+		{"constructor error ", "acme", func(string) (plugin.FederatedProvider, error) { return nil, ctorErr }, true},
+		{"nil provider      ", "acme", func(string) (plugin.FederatedProvider, error) { return nil, nil }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPluginIdPs(t)
+			err := RegisterPluginFederatedIdP(tt.id, "Acme", tt.ctor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterPluginFederatedIdP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Verify the provider is known and configured under its qualified ID only if registered
+			known, configured, provider, fidp := GetFederatedIdP(models.FederatedIdpID("plugin:" + tt.id))
+			if known != !tt.wantErr || configured != !tt.wantErr {
+				t.Errorf("GetFederatedIdP() known = %v, configured = %v, want %v", known, configured, !tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if fidp.Name != "Acme" || fidp.GothName != "plugin:"+tt.id {
+				t.Errorf("GetFederatedIdP() fidp = %#v", fidp)
+			}
+			if provider.Name() != "plugin:"+tt.id {
+				t.Errorf("provider.Name() = %q, want %q", provider.Name(), "plugin:"+tt.id)
+			}
+		})
+	}
+}
+
+func TestRegisterPluginFederatedIdP_Duplicate(t *testing.T) {
+	withPluginIdPs(t)
+	ctor := func(string) (plugin.FederatedProvider, error) { return &stubFederatedProvider{}, nil }
+	if err := RegisterPluginFederatedIdP("acme", "Acme", ctor); err != nil {
+		t.Fatalf("RegisterPluginFederatedIdP() first call error = %v", err)
+	}
+	if err := RegisterPluginFederatedIdP("acme", "Acme Again", ctor); err == nil {
+		t.Errorf("RegisterPluginFederatedIdP() second call succeeded, want error")
+	}
+	if got := FederatedIdProviders["plugin:acme"].Name; got != "Acme" {
+		t.Errorf("FederatedIdProviders[plugin:acme].Name = %q, want %q", got, "Acme")
+	}
+}
+
+func TestRegisterPluginFederatedIdP_CallbackURL(t *testing.T) {
+	withPluginIdPs(t)
+	var p *stubFederatedProvider
+	err := RegisterPluginFederatedIdP("acme", "Acme", func(cb string) (plugin.FederatedProvider, error) {
+		p = &stubFederatedProvider{callbackURL: cb}
+		return p, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterPluginFederatedIdP() error = %v", err)
+	}
+	if want := "https://comentario.example.com/api/oauth/plugin:acme/callback"; p.callbackURL != want {
+		t.Errorf("callback URL = %q, want %q", p.callbackURL, want)
+	}
+}
+
+func Test_pluginGothProvider(t *testing.T) {
+	user := &plugin.FederatedUser{ID: "42", Email: "jane@example.com", Name: "Jane", NickName: "jd", AvatarURL: "https://idp.example.com/a.png"}
+	gp := &pluginGothProvider{name: "plugin:acme", p: &stubFederatedProvider{user: user}}
+
+	// Begin an auth session
+	sess, err := gp.BeginAuth("xyz")
+	if err != nil {
+		t.Fatalf("BeginAuth() error = %v", err)
+	}
+	if u, err := sess.GetAuthURL(); err != nil || u != "https://idp.example.com/auth?state=xyz" {
+		t.Errorf("GetAuthURL() = %q, %v", u, err)
+	}
+
+	// Restore it from its marshalled form
+	if sess, err = gp.UnmarshalSession(sess.Marshal()); err != nil {
+		t.Fatalf("UnmarshalSession() error = %v", err)
+	}
+
+	// Fetching the user requires authorisation
+	if _, err := gp.FetchUser(sess); err == nil {
+		t.Errorf("FetchUser() on an unauthorised session succeeded, want error")
+	}
+	if _, err := sess.Authorize(gp, url.Values{}); err == nil {
+		t.Errorf("Authorize() without code succeeded, want error")
+	}
+	if _, err := sess.Authorize(gp, url.Values{"code": {"abc"}}); err != nil {
+		t.Fatalf("Authorize() error = %v", err)
+	}
+
+	// Fetch the user
+	got, err := gp.FetchUser(sess)
+	if err != nil {
+		t.Fatalf("FetchUser() error = %v", err)
+	}
+	want := goth.User{Provider: "plugin:acme", UserID: "42", Email: "jane@example.com", Name: "Jane", NickName: "jd", AvatarURL: "https://idp.example.com/a.png"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FetchUser() = %#v, want %#v", got, want)
+	}
+}
+
+func Test_pluginGothProvider_Failures(t *testing.T) {
+	gp := &pluginGothProvider{name: "plugin:acme", p: &stubFederatedProvider{}}
+
+	// A nil session returned by the plugin is an error
+	if _, err := gp.UnmarshalSession(""); err == nil {
+		t.Errorf("UnmarshalSession() with nil session succeeded, want error")
+	}
+
+	// A nil user returned by the plugin is an error
+	sess, _ := gp.BeginAuth("xyz")
+	_, _ = sess.Authorize(gp, url.Values{"code": {"abc"}})
+	if _, err := gp.FetchUser(sess); err == nil {
+		t.Errorf("FetchUser() with nil user succeeded, want error")
+	}
+
+	// A session of another kind is an error
+	if _, err := gp.FetchUser(nil); err == nil {
+		t.Errorf("FetchUser() with foreign session succeeded, want error")
+	}
+}
@@ -20,6 +20,16 @@ const (
 	SMTPEncryptionTLS     SMTPEncryption = "tls"
 )
 
+type MailTransport string
+
+const (
+	MailTransportDefault  MailTransport = ""         // SMTP is used if SMTP host is configured
+	MailTransportSMTP     MailTransport = "smtp"     // Send emails via an SMTP server
+	MailTransportSendmail MailTransport = "sendmail" // Pipe emails into a local sendmail binary
+	MailTransportDir      MailTransport = "dir"      // Write emails as files into a directory
+	MailTransportHTTP     MailTransport = "http"     // Post emails as JSON to an HTTP API
+)
+
 // SecretsConfig is a configuration object for storing sensitive information
 var SecretsConfig = &SecretsConfiguration{}
 
@@ -125,6 +135,29 @@ type SecretsConfiguration struct {
 		Insecure   bool           `yaml:"insecure"`   // Skip SMTP server certificate verification
 	} `yaml:"smtpServer"`
 
+	// Mail transport settings
+	Mail struct {
+		// Transport to use for sending emails: "smtp", "sendmail", "dir", "http", or "<pluginId>/<name>" for a transport
+		// provided by a plugin. If omitted, SMTP is used
+		Transport MailTransport `yaml:"transport"`
+		// Settings for the sendmail transport
+		Sendmail struct {
+			Path string   `yaml:"path"` // Path to the sendmail binary, defaults to "/usr/sbin/sendmail"
+			Args []string `yaml:"args"` // Arguments to pass to the binary, defaults to "-t -i"
+		} `yaml:"sendmail"`
+		// Settings for the directory transport
+		Dir struct {
+			Path    string `yaml:"path"`    // Directory to write emails into
+			Maildir bool   `yaml:"maildir"` // Whether to use the Maildir layout instead of plain .eml files
+		} `yaml:"dir"`
+		// Settings for the HTTP API transport
+		HTTP struct {
+			URL      string            `yaml:"url"`      // URL of the API endpoint to POST emails to
+			Headers  map[string]string `yaml:"headers"`  // Additional request headers, e.g. for authorisation
+			Insecure bool              `yaml:"insecure"` // Skip API server certificate verification
+		} `yaml:"http"`
+	} `yaml:"mail"`
+
 	// Federated identity provider settings
 	IdP struct {
 		Facebook KeySecret      `yaml:"facebook"` // Facebook auth config
@@ -137,9 +170,12 @@ type SecretsConfiguration struct {
 
 	// Extension settings
 	Extensions struct {
-		Akismet             APIKey `yaml:"akismet"`
-		Perspective         APIKey `yaml:"perspective"`
-		APILayerSpamChecker APIKey `yaml:"apiLayerSpamChecker"`
+		Akismet             APIKey      `yaml:"akismet"`
+		Perspective         APIKey      `yaml:"perspective"`
+		APILayerSpamChecker APIKey      `yaml:"apiLayerSpamChecker"`
+		Blocklist           Disableable `yaml:"blocklist"`
+		FloodControl        Disableable `yaml:"floodControl"`
+		Bayes               Disableable `yaml:"bayes"`
 	} `yaml:"extensions"`
 
 	// Optional random string to generate XSRF key from
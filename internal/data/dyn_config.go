@@ -97,6 +97,33 @@ func (ci *DynConfigItem) ValidateValue(value string) error {
 	return nil
 }
 
+// AddDefaultDynConfigItem adds the given item to the default dynamic instance configuration. Must be called before the
+// configuration gets loaded
+func AddDefaultDynConfigItem(key DynConfigItemKey, item *DynConfigItem) error {
+	// Make sure the key is unique
+	if _, ok := DefaultDynInstanceConfig[key]; ok {
+		return fmt.Errorf("config key %q is already registered", key)
+	}
+
+	// Validate the datatype and the default value
+	switch item.Datatype {
+	case ConfigDatatypeBool, ConfigDatatypeInt, ConfigDatatypeString:
+		// OK
+	default:
+		return fmt.Errorf("invalid datatype of config item %q: %q", key, item.Datatype)
+	}
+	if item.Min > item.Max {
+		return fmt.Errorf("invalid range of config item %q: %d..%d", key, item.Min, item.Max)
+	}
+	if err := item.ValidateValue(item.DefaultValue); err != nil {
+		return fmt.Errorf("invalid default value of config item %q: %w", key, err)
+	}
+
+	// Succeeded
+	DefaultDynInstanceConfig[key] = item
+	return nil
+}
+
 // DynConfigDTOsToMap converts a slice of dynamic config item DTOs into a key-value map
 func DynConfigDTOsToMap(items []*models.DynamicConfigItem) map[DynConfigItemKey]string {
 	m := make(map[DynConfigItemKey]string, len(items))
@@ -116,8 +143,9 @@ func DynConfigMapToDTOs(config map[DynConfigItemKey]*DynConfigItem) []*models.Dy
 }
 
 const (
-	ConfigDatatypeBool DynConfigItemDatatype = "bool"
-	ConfigDatatypeInt  DynConfigItemDatatype = "int"
+	ConfigDatatypeBool   DynConfigItemDatatype = "bool"
+	ConfigDatatypeInt    DynConfigItemDatatype = "int"
+	ConfigDatatypeString DynConfigItemDatatype = "string"
 )
 
 // Item section keys
@@ -127,6 +155,8 @@ const (
 	DynConfigItemSectionIntegrations DynConfigItemSectionKey = "integrations"
 	DynConfigItemSectionMarkdown     DynConfigItemSectionKey = "markdown"
 	DynConfigItemSectionMisc         DynConfigItemSectionKey = "misc"
+	DynConfigItemSectionPlugins      DynConfigItemSectionKey = "plugins"
+	DynConfigItemSectionRateLimits   DynConfigItemSectionKey = "rateLimits"
 )
 
 // Instance (global) settings
@@ -138,6 +168,9 @@ const (
 	ConfigKeyAuthSignupEnabled          DynConfigItemKey = "auth.signup.enabled"
 	ConfigKeyIntegrationsUseGravatar    DynConfigItemKey = "integrations.useGravatar"
 	ConfigKeyOperationNewOwnerEnabled   DynConfigItemKey = "operation.newOwner.enabled"
+	ConfigKeyRateLimitLoginPerMinute    DynConfigItemKey = "ratelimit.login.perMinute"
+	ConfigKeyRateLimitPwdResetPerHour   DynConfigItemKey = "ratelimit.pwdReset.perHour"
+	ConfigKeyRateLimitSignupPerHour     DynConfigItemKey = "ratelimit.signup.perHour"
 )
 
 // Domain settings
@@ -156,11 +189,28 @@ const (
 	DomainConfigKeyLocalSignupEnabled       DynConfigItemKey = "signup.enableLocal"
 	DomainConfigKeyFederatedSignupEnabled   DynConfigItemKey = "signup.enableFederated"
 	DomainConfigKeySsoSignupEnabled         DynConfigItemKey = "signup.enableSso"
+
+	DomainConfigKeyRateLimitCommentPerMinute       DynConfigItemKey = "ratelimit.comment.perMinute"
+	DomainConfigKeyRateLimitCommentDomainPerMinute DynConfigItemKey = "ratelimit.comment.domainPerMinute"
+	DomainConfigKeyRateLimitFlagPerHour            DynConfigItemKey = "ratelimit.flag.perHour"
+	DomainConfigKeyRateLimitLoginPerMinute         DynConfigItemKey = "ratelimit.login.perMinute"
+	DomainConfigKeyRateLimitSignupPerHour          DynConfigItemKey = "ratelimit.signup.perHour"
+
+	DomainConfigKeyCommentFlaggingEnabled   DynConfigItemKey = "comments.flagging.enabled"
+	DomainConfigKeyCommentFlaggingAnonymous DynConfigItemKey = "comments.flagging.anonymous"
+	DomainConfigKeyCommentFlaggingThreshold DynConfigItemKey = "comments.flagging.threshold"
+
+	DomainConfigKeyCommentEditHistory DynConfigItemKey = "comments.editing.history"
+
+	DomainConfigKeyCommentReactions DynConfigItemKey = "comments.reactions.allowed"
 )
 
 // ConfigKeyDomainDefaultsPrefix is a prefix given to domain setting keys that turn them into global domain defaults keys
 const ConfigKeyDomainDefaultsPrefix = "domain.defaults."
 
+// ConfigKeyPluginPrefix is a prefix given to keys of config items declared by plugins
+const ConfigKeyPluginPrefix = "plugin."
+
 // DefaultDynInstanceConfig is the default dynamic instance configuration
 var DefaultDynInstanceConfig = map[DynConfigItemKey]*DynConfigItem{
 	ConfigKeyAuthEmailUpdateEnabled:                                         {DefaultValue: "false", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionAuth},
@@ -170,6 +220,9 @@ var DefaultDynInstanceConfig = map[DynConfigItemKey]*DynConfigItem{
 	ConfigKeyAuthSignupEnabled:                                              {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionAuth},
 	ConfigKeyIntegrationsUseGravatar:                                        {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionIntegrations},
 	ConfigKeyOperationNewOwnerEnabled:                                       {DefaultValue: "false", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionMisc},
+	ConfigKeyRateLimitLoginPerMinute:                                        {DefaultValue: "10", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
+	ConfigKeyRateLimitPwdResetPerHour:                                       {DefaultValue: "5", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
+	ConfigKeyRateLimitSignupPerHour:                                         {DefaultValue: "10", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
 	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentDeletionAuthor:    {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionComments},
 	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentDeletionModerator: {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionComments},
 	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentEditingAuthor:     {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionComments},
@@ -184,4 +237,18 @@ var DefaultDynInstanceConfig = map[DynConfigItemKey]*DynConfigItem{
 	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyLocalSignupEnabled:       {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionAuth},
 	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyFederatedSignupEnabled:   {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionAuth},
 	ConfigKeyDomainDefaultsPrefix + DomainConfigKeySsoSignupEnabled:         {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionAuth},
+
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyRateLimitCommentPerMinute:       {DefaultValue: "10", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyRateLimitCommentDomainPerMinute: {DefaultValue: "0", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 100000},
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyRateLimitFlagPerHour:            {DefaultValue: "20", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyRateLimitLoginPerMinute:         {DefaultValue: "10", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyRateLimitSignupPerHour:          {DefaultValue: "10", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionRateLimits, Min: 0, Max: 10000},
+
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentFlaggingEnabled:   {DefaultValue: "true", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionComments},
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentFlaggingAnonymous: {DefaultValue: "false", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionComments},
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentFlaggingThreshold: {DefaultValue: "3", Datatype: ConfigDatatypeInt, Section: DynConfigItemSectionComments, Min: 1, Max: 1000},
+
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentEditHistory: {DefaultValue: "false", Datatype: ConfigDatatypeBool, Section: DynConfigItemSectionComments},
+
+	ConfigKeyDomainDefaultsPrefix + DomainConfigKeyCommentReactions: {DefaultValue: "", Datatype: ConfigDatatypeString, Section: DynConfigItemSectionComments},
 }
@@ -55,3 +55,33 @@ func TestDynConfigItem_AsInt(t *testing.T) {
 		})
 	}
 }
+
+func TestAddDefaultDynConfigItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     DynConfigItemKey
+		item    *DynConfigItem
+		wantErr bool
+	}{
+		{"existing key        ", ConfigKeyAuthSignupEnabled, &DynConfigItem{DefaultValue: "true", Datatype: ConfigDatatypeBool}, true},
+		{"invalid datatype    ", "plugin.foo.a", &DynConfigItem{DefaultValue: "true", Datatype: "float"}, true},
+		{"invalid bool default", "plugin.foo.b", &DynConfigItem{DefaultValue: "yes", Datatype: ConfigDatatypeBool}, true},
+		{"invalid int range   ", "plugin.foo.c", &DynConfigItem{DefaultValue: "5", Datatype: ConfigDatatypeInt, Min: 10, Max: 1}, true},
+		{"int default > max   ", "plugin.foo.d", &DynConfigItem{DefaultValue: "50", Datatype: ConfigDatatypeInt, Min: 1, Max: 10}, true},
+		{"valid bool          ", "plugin.foo.e", &DynConfigItem{DefaultValue: "false", Datatype: ConfigDatatypeBool}, false},
+		{"valid int           ", "plugin.foo.f", &DynConfigItem{DefaultValue: "5", Datatype: ConfigDatatypeInt, Min: 1, Max: 10}, false},
+		{"valid string        ", "plugin.foo.g", &DynConfigItem{DefaultValue: "bar", Datatype: ConfigDatatypeString}, false},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.name), func(t *testing.T) {
+			if err := AddDefaultDynConfigItem(tt.key, tt.item); (err != nil) != tt.wantErr {
+				t.Errorf("AddDefaultDynConfigItem() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err == nil {
+				if DefaultDynInstanceConfig[tt.key] != tt.item {
+					t.Errorf("AddDefaultDynConfigItem() didn't add the item")
+				}
+				delete(DefaultDynInstanceConfig, tt.key)
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"gitlab.com/comentario/comentario/internal/util"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/text/language"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 )
@@ -382,6 +384,7 @@ func (u *User) ToPrincipal(attr plugin.AttrValues, du *DomainUser) *models.Princ
 		LangID:              u.LangID,
 		Name:                u.Name,
 		NotifyCommentStatus: du != nil && du.NotifyCommentStatus,
+		NotifyMentions:      du != nil && du.NotifyMentions,
 		NotifyModerator:     du != nil && du.NotifyModerator,
 		NotifyReplies:       du != nil && du.NotifyReplies,
 		WebsiteURL:          strfmt.URI(u.WebsiteURL),
@@ -708,8 +711,12 @@ type Domain struct {
 	ModUserAgeDays    int                   `db:"mod_user_age_days"`            // Number of first days since user has registered on this domain to require a moderator approval on their comments
 	ModLinks          bool                  `db:"mod_links"`                    // Whether all comments containing a link are to be approved by a moderator
 	ModImages         bool                  `db:"mod_images"`                   // Whether all comments containing an image are to be approved by a moderator
+	ModTrustedHosts   string                `db:"mod_trusted_hosts"`            // Hosts links and images to which don't require moderation, separated by whitespace or commas
+	ModBlockedHosts   string                `db:"mod_blocked_hosts"`            // Hosts links and images to which always require moderation, separated by whitespace or commas
 	ModNotifyPolicy   DomainModNotifyPolicy `db:"mod_notify_policy"`            // Moderator notification policy for domain: 'none', 'pending', 'all'
 	DefaultSort       string                `db:"default_sort"`                 // Default comment sorting for domain. 1st letter: s = score, t = timestamp; 2nd letter: a = asc, d = desc
+	AutoCloseDays     int                   `db:"auto_close_days"`              // Number of days after the first comment (or creation, if there's none) a page gets readonly. 0 means disabled
+	AutoClosePaths    string                `db:"auto_close_paths"`             // Path prefixes, separated by whitespace, automatic page closing is limited to. Empty means all pages
 	CountComments     int64                 `db:"count_comments"`               // Total number of comments
 	CountViews        int64                 `db:"count_views"`                  // Total number of views
 }
@@ -756,6 +763,8 @@ func (d *Domain) DisplayName() string {
 func (d *Domain) FromDTO(dto *models.Domain) {
 	d.AuthAnonymous = dto.AuthAnonymous
 	d.AuthLocal = dto.AuthLocal
+	d.AutoCloseDays = int(dto.AutoCloseDays)
+	d.AutoClosePaths = strings.TrimSpace(dto.AutoClosePaths)
 	d.AuthSSO = dto.AuthSso
 	d.DefaultSort = string(dto.DefaultSort)
 	d.Host = strings.ToLower(strings.TrimSpace(string(dto.Host)))
@@ -763,16 +772,26 @@ func (d *Domain) FromDTO(dto *models.Domain) {
 	d.IsReadonly = dto.IsReadonly
 	d.ModAnonymous = dto.ModAnonymous
 	d.ModAuthenticated = dto.ModAuthenticated
+	d.ModBlockedHosts = strings.TrimSpace(dto.ModBlockedHosts)
 	d.ModImages = dto.ModImages
 	d.ModLinks = dto.ModLinks
 	d.ModNotifyPolicy = DomainModNotifyPolicy(dto.ModNotifyPolicy)
 	d.ModNumComments = int(dto.ModNumComments)
+	d.ModTrustedHosts = strings.TrimSpace(dto.ModTrustedHosts)
 	d.ModUserAgeDays = int(dto.ModUserAgeDays)
 	d.Name = dto.Name
 	d.SSONonInteractive = dto.SsoNonInteractive
 	d.SSOURL = dto.SsoURL
 }
 
+// FromPluginDomain updates this domain model from the provided plugin model. Only the mutable properties get updated
+func (d *Domain) FromPluginDomain(pd *plugin.Domain) {
+	// ID, Host, and CreatedTime are immutable
+	d.Name = pd.Name
+	d.IsHTTPS = pd.IsHTTPS
+	d.IsReadonly = pd.IsReadonly
+}
+
 // RootURL returns the root URL of the domain, without the trailing slash
 func (d *Domain) RootURL() string {
 	return fmt.Sprintf("%s://%s", d.Scheme(), d.Host)
@@ -817,6 +836,8 @@ func (d *Domain) ToDTO() *models.Domain {
 		AuthAnonymous:       d.AuthAnonymous,
 		AuthLocal:           d.AuthLocal,
 		AuthSso:             d.AuthSSO,
+		AutoCloseDays:       uint64(d.AutoCloseDays),
+		AutoClosePaths:      d.AutoClosePaths,
 		CountComments:       d.CountComments,
 		CountViews:          d.CountViews,
 		CreatedTime:         strfmt.DateTime(d.CreatedTime),
@@ -827,10 +848,12 @@ func (d *Domain) ToDTO() *models.Domain {
 		IsReadonly:          d.IsReadonly,
 		ModAnonymous:        d.ModAnonymous,
 		ModAuthenticated:    d.ModAuthenticated,
+		ModBlockedHosts:     d.ModBlockedHosts,
 		ModImages:           d.ModImages,
 		ModLinks:            d.ModLinks,
 		ModNotifyPolicy:     models.DomainModNotifyPolicy(d.ModNotifyPolicy),
 		ModNumComments:      uint64(d.ModNumComments),
+		ModTrustedHosts:     d.ModTrustedHosts,
 		ModUserAgeDays:      uint64(d.ModUserAgeDays),
 		Name:                d.Name,
 		RootURL:             strfmt.URI(d.RootURL()),
@@ -840,6 +863,18 @@ func (d *Domain) ToDTO() *models.Domain {
 	}
 }
 
+// ToPluginDomain returns a new plugin.Domain instance for this domain
+func (d *Domain) ToPluginDomain() *plugin.Domain {
+	return &plugin.Domain{
+		ID:          d.ID,
+		Name:        d.Name,
+		Host:        d.Host,
+		CreatedTime: d.CreatedTime,
+		IsHTTPS:     d.IsHTTPS,
+		IsReadonly:  d.IsReadonly,
+	}
+}
+
 // ---------------------------------------------------------------------------------------------------------------------
 
 // DomainUser represents user configuration in a specific domain
@@ -852,6 +887,8 @@ type DomainUser struct {
 	NotifyReplies       bool      `db:"notify_replies"`               // Whether the user is to be notified about replies to their comments
 	NotifyModerator     bool      `db:"notify_moderator"`             // Whether the user is to receive moderator notifications (only when is_moderator is true)
 	NotifyCommentStatus bool      `db:"notify_comment_status"`        // Whether the user is to be notified about status changes (approved/rejected) of their comments
+	NotifyMentions      bool      `db:"notify_mentions"`              // Whether the user is to be notified about being mentioned in comments
+	IsShadowBanned      bool      `db:"is_shadow_banned"`             // Whether the user's new comments are only visible to them and moderators
 	CreatedTime         time.Time `db:"ts_created" goqu:"skipupdate"` // When the domain user was created
 }
 
@@ -866,6 +903,7 @@ func NewDomainUser(domainID, userID *uuid.UUID, owner, moderator, commenter bool
 		NotifyReplies:       true,
 		NotifyModerator:     true,
 		NotifyCommentStatus: true,
+		NotifyMentions:      true,
 		CreatedTime:         time.Now().UTC(),
 	}
 }
@@ -884,6 +922,19 @@ func (du *DomainUser) CanModerate() bool {
 	return du != nil && (du.IsOwner || du.IsModerator)
 }
 
+// FromPluginDomainUser updates this domain user model from the provided plugin model. Only the mutable properties get
+// updated
+func (du *DomainUser) FromPluginDomainUser(pdu *plugin.DomainUser) {
+	// DomainID, UserID, and CreatedTime are immutable
+	du.IsOwner = pdu.IsOwner
+	du.IsModerator = pdu.IsModerator
+	du.IsCommenter = pdu.IsCommenter
+	du.NotifyReplies = pdu.NotifyReplies
+	du.NotifyModerator = pdu.NotifyModerator
+	du.NotifyCommentStatus = pdu.NotifyCommentStatus
+	du.NotifyMentions = pdu.NotifyMentions
+}
+
 // IsACommenter returns whether the domain user is a commenter. Can be called against a nil receiver, which is
 // interpreted as no domain user has been created yet for this specific user, so it returns true, because the user is
 // assumed to have the default (commenter) role
@@ -934,7 +985,9 @@ func (du *DomainUser) ToDTO() *models.DomainUser {
 	return &models.DomainUser{
 		CreatedTime:         strfmt.DateTime(du.CreatedTime),
 		DomainID:            strfmt.UUID(du.DomainID.String()),
+		IsShadowBanned:      du.IsShadowBanned,
 		NotifyCommentStatus: du.NotifyCommentStatus,
+		NotifyMentions:      du.NotifyMentions,
 		NotifyModerator:     du.NotifyModerator,
 		NotifyReplies:       du.NotifyReplies,
 		Role:                du.Role(),
@@ -942,6 +995,22 @@ func (du *DomainUser) ToDTO() *models.DomainUser {
 	}
 }
 
+// ToPluginDomainUser returns a new plugin.DomainUser instance for this domain user
+func (du *DomainUser) ToPluginDomainUser() *plugin.DomainUser {
+	return &plugin.DomainUser{
+		DomainID:            du.DomainID,
+		UserID:              du.UserID,
+		IsOwner:             du.IsOwner,
+		IsModerator:         du.IsModerator,
+		IsCommenter:         du.IsCommenter,
+		NotifyReplies:       du.NotifyReplies,
+		NotifyModerator:     du.NotifyModerator,
+		NotifyCommentStatus: du.NotifyCommentStatus,
+		NotifyMentions:      du.NotifyMentions,
+		CreatedTime:         du.CreatedTime,
+	}
+}
+
 // WithCreated sets the CreatedTime value
 func (du *DomainUser) WithCreated(t time.Time) *DomainUser {
 	du.CreatedTime = t
@@ -954,6 +1023,12 @@ func (du *DomainUser) WithNotifyCommentStatus(b bool) *DomainUser {
 	return du
 }
 
+// WithNotifyMentions sets the NotifyMentions value
+func (du *DomainUser) WithNotifyMentions(b bool) *DomainUser {
+	du.NotifyMentions = b
+	return du
+}
+
 // WithNotifyModerator sets the NotifyModerator value
 func (du *DomainUser) WithNotifyModerator(b bool) *DomainUser {
 	du.NotifyModerator = b
@@ -987,6 +1062,12 @@ func (du *DomainUser) WithRole(r models.DomainUserRole) *DomainUser {
 	return du
 }
 
+// WithShadowBanned sets the IsShadowBanned value
+func (du *DomainUser) WithShadowBanned(b bool) *DomainUser {
+	du.IsShadowBanned = b
+	return du
+}
+
 // ---------------------------------------------------------------------------------------------------------------------
 
 // NullDomainUser is the same as DomainUser, but "optional", ie. having all fields nullable, and with the "du_" column
@@ -1000,6 +1081,8 @@ type NullDomainUser struct {
 	NotifyReplies       sql.NullBool  `db:"du_notify_replies"`
 	NotifyModerator     sql.NullBool  `db:"du_notify_moderator"`
 	NotifyCommentStatus sql.NullBool  `db:"du_notify_comment_status"`
+	NotifyMentions      sql.NullBool  `db:"du_notify_mentions"`
+	IsShadowBanned      sql.NullBool  `db:"du_is_shadow_banned"`
 	CreatedTime         sql.NullTime  `db:"du_ts_created"`
 }
 
@@ -1012,6 +1095,8 @@ func (n *NullDomainUser) ToDomainUser() *DomainUser {
 		WithNotifyReplies(n.NotifyReplies.Bool).
 		WithNotifyModerator(n.NotifyModerator.Bool).
 		WithNotifyCommentStatus(n.NotifyCommentStatus.Bool).
+		WithNotifyMentions(n.NotifyMentions.Bool).
+		WithShadowBanned(n.IsShadowBanned.Bool).
 		WithCreated(n.CreatedTime.Time)
 }
 
@@ -1024,6 +1109,7 @@ type DomainPage struct {
 	Path          string    `db:"path"`                             // Page path
 	Title         string    `db:"title"`                            // Page title
 	IsReadonly    bool      `db:"is_readonly"`                      // Whether the page is readonly (no new comments are allowed)
+	NoAutoClose   bool      `db:"no_auto_close"`                    // Whether the page is exempt from automatic closing
 	CreatedTime   time.Time `db:"ts_created"     goqu:"skipupdate"` // When the record was created
 	CountComments int64     `db:"count_comments" goqu:"skipupdate"` // Total number of comments
 	CountViews    int64     `db:"count_views"    goqu:"skipupdate"` // Total number of views
@@ -1043,6 +1129,7 @@ func (p *DomainPage) CloneWithClearance(isSuperuser, isOwner bool) *DomainPage {
 		ID:            p.ID,
 		DomainID:      p.DomainID,
 		IsReadonly:    p.IsReadonly,
+		NoAutoClose:   p.NoAutoClose,
 		Path:          p.Path,
 		Title:         p.Title,
 		CountComments: -1, // -1 indicates no count data is available
@@ -1058,6 +1145,13 @@ func (p *DomainPage) DisplayTitle(domain *Domain) string {
 	return domain.Host + p.Path
 }
 
+// FromPluginDomainPage updates this page model from the provided plugin model. Only the mutable properties get updated
+func (p *DomainPage) FromPluginDomainPage(pp *plugin.DomainPage) {
+	// ID, DomainID, Path, and CreatedTime are immutable
+	p.Title = util.TruncateStr(pp.Title, MaxPageTitleLength)
+	p.IsReadonly = pp.IsReadonly
+}
+
 // ToDTO converts this model into an API model
 func (p *DomainPage) ToDTO() *models.DomainPage {
 	return &models.DomainPage{
@@ -1067,17 +1161,41 @@ func (p *DomainPage) ToDTO() *models.DomainPage {
 		DomainID:      strfmt.UUID(p.DomainID.String()),
 		ID:            strfmt.UUID(p.ID.String()),
 		IsReadonly:    swag.Bool(p.IsReadonly),
+		NoAutoClose:   swag.Bool(p.NoAutoClose),
 		Path:          models.Path(p.Path),
 		Title:         p.Title,
 	}
 }
 
-// WithIsReadonly sets the IsReadonly value
+// ToPluginDomainPage returns a new plugin.DomainPage instance for this page
+func (p *DomainPage) ToPluginDomainPage() *plugin.DomainPage {
+	return &plugin.DomainPage{
+		ID:          p.ID,
+		DomainID:    p.DomainID,
+		Path:        p.Path,
+		Title:       p.Title,
+		IsReadonly:  p.IsReadonly,
+		CreatedTime: p.CreatedTime,
+	}
+}
+
+// WithIsReadonly sets the IsReadonly value. Reopening a readonly page also exempts it from automatic closing
 func (p *DomainPage) WithIsReadonly(b bool) *DomainPage {
+	if p.IsReadonly && !b {
+		p.NoAutoClose = true
+	}
 	p.IsReadonly = b
 	return p
 }
 
+// WithNoAutoClose sets the NoAutoClose value, if provided
+func (p *DomainPage) WithNoAutoClose(b *bool) *DomainPage {
+	if b != nil {
+		p.NoAutoClose = *b
+	}
+	return p
+}
+
 // WithPath sets the Path value
 func (p *DomainPage) WithPath(s string) *DomainPage {
 	p.Path = s
@@ -1126,6 +1244,10 @@ type Comment struct {
 	AuthorName    string        `db:"author_name"`    // Name of the author, in case the user isn't registered
 	AuthorIP      string        `db:"author_ip"`      // IP address of the author
 	AuthorCountry string        `db:"author_country"` // 2-letter country code matching the AuthorIP
+	CountFlags    int           `db:"count_flags"`    // Number of flags raised against the comment by readers
+	IsShadowed    bool          `db:"is_shadowed"`    // Whether the comment is only visible to its author and moderators
+	Scans         CommentScans  `db:"-"`              // Scans the comment has just undergone, to be persisted along with it
+	Mentions      []uuid.UUID   `db:"-"`              // IDs of users mentioned in the text, to be persisted along with it. nil unless the text has been (re)rendered
 }
 
 // CloneWithClearance returns a clone of the comment with a limited set of properties, depending on the specified
@@ -1179,6 +1301,19 @@ func (c *Comment) CloneWithClearance(user *User, domainUser *DomainUser) *Commen
 	return cc
 }
 
+// FromPluginComment updates this comment model from the provided plugin model. Only the mutable properties get updated
+func (c *Comment) FromPluginComment(pc *plugin.Comment) {
+	// ID, ParentID, PageID, CreatedTime, and UserCreated are immutable
+	c.Markdown = pc.Markdown
+	c.HTML = pc.HTML
+	c.IsSticky = pc.IsSticky
+	c.IsApproved = pc.IsApproved
+	c.IsPending = pc.IsPending
+	c.PendingReason = util.TruncateStr(pc.PendingReason, MaxPendingReasonLength)
+	c.AuthorName = pc.AuthorName
+	c.AuthorCountry = pc.AuthorCountry
+}
+
 // IsAnonymous returns whether the comment is authored by an anonymous or nonexistent (deleted) commenter
 func (c *Comment) IsAnonymous() bool {
 	return !c.UserCreated.Valid || c.UserCreated.UUID == AnonymousUser.ID
@@ -1189,6 +1324,17 @@ func (c *Comment) IsRoot() bool {
 	return !c.ParentID.Valid
 }
 
+// Status returns a textual representation of the comment's moderation status: "pending", "approved", or "rejected"
+func (c *Comment) Status() string {
+	switch {
+	case c.IsPending:
+		return "pending"
+	case c.IsApproved:
+		return "approved"
+	}
+	return "rejected"
+}
+
 // ToDTO converts this model into an API model:
 //   - https is true for "https", false for "http"
 //   - host is the domain host
@@ -1200,6 +1346,7 @@ func (c *Comment) ToDTO(https bool, host, path string) *models.Comment {
 		AuthorCountry: c.AuthorCountry,
 		AuthorIP:      c.AuthorIP,
 		AuthorName:    c.AuthorName,
+		CountFlags:    int64(c.CountFlags),
 		CreatedTime:   strfmt.DateTime(c.CreatedTime),
 		DeletedTime:   NullDateTime(c.DeletedTime),
 		EditedTime:    NullDateTime(c.EditedTime),
@@ -1208,6 +1355,7 @@ func (c *Comment) ToDTO(https bool, host, path string) *models.Comment {
 		IsApproved:    c.IsApproved,
 		IsDeleted:     c.IsDeleted,
 		IsPending:     c.IsPending,
+		IsShadowed:    c.IsShadowed,
 		IsSticky:      c.IsSticky,
 		Markdown:      c.Markdown,
 		ModeratedTime: NullDateTime(c.ModeratedTime),
@@ -1223,6 +1371,27 @@ func (c *Comment) ToDTO(https bool, host, path string) *models.Comment {
 	}
 }
 
+// ToPluginComment returns a new plugin.Comment instance for this comment
+func (c *Comment) ToPluginComment() *plugin.Comment {
+	return &plugin.Comment{
+		ID:            c.ID,
+		ParentID:      c.ParentID,
+		PageID:        c.PageID,
+		Markdown:      c.Markdown,
+		HTML:          c.HTML,
+		Score:         c.Score,
+		IsSticky:      c.IsSticky,
+		IsApproved:    c.IsApproved,
+		IsPending:     c.IsPending,
+		IsDeleted:     c.IsDeleted,
+		CreatedTime:   c.CreatedTime,
+		UserCreated:   c.UserCreated,
+		PendingReason: c.PendingReason,
+		AuthorName:    c.AuthorName,
+		AuthorCountry: c.AuthorCountry,
+	}
+}
+
 // URL returns the absolute URL of the comment
 func (c *Comment) URL(https bool, host, path string) string {
 	return fmt.Sprintf("%s://%s%s#comentario-%s", util.If(https, "https", "http"), host, path, c.ID)
@@ -1242,6 +1411,166 @@ func (c *Comment) WithModerated(userID *uuid.UUID, pending, approved bool, reaso
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+// CommentFlag represents a flag (report) raised by a reader against a comment
+type CommentFlag struct {
+	ID          uuid.UUID                `db:"id"`         // Unique record ID
+	CommentID   uuid.UUID                `db:"comment_id"` // Reference to the comment
+	UserID      uuid.UUID                `db:"user_id"`    // Reference to the user who flagged the comment
+	Reason      models.CommentFlagReason `db:"reason"`     // Reason category
+	Details     string                   `db:"details"`    // Optional details provided by the user
+	AuthorIP    string                   `db:"author_ip"`  // IP address of the user who flagged the comment, possibly masked
+	IPHash      string                   `db:"ip_hash"`    // Hash of the full IP address, telling anonymous flaggers apart
+	CreatedTime time.Time                `db:"ts_created"` // When the record was created
+}
+
+// NewCommentFlag instantiates a new CommentFlag. ip is the full IP address of the user, stored masked if maskIP is
+// true. Its hash, keyed with the comment ID, is always stored as it's needed for telling anonymous flaggers apart
+func NewCommentFlag(commentID, userID *uuid.UUID, reason models.CommentFlagReason, details, ip string, maskIP bool) *CommentFlag {
+	return &CommentFlag{
+		ID:          uuid.New(),
+		CommentID:   *commentID,
+		UserID:      *userID,
+		Reason:      reason,
+		Details:     strings.TrimSpace(details),
+		AuthorIP:    util.If(maskIP, util.MaskIP(ip), ip),
+		IPHash:      hex.EncodeToString(util.HMACSign([]byte(ip), commentID[:])),
+		CreatedTime: time.Now().UTC(),
+	}
+}
+
+// ToDTO converts this model into an API model
+func (f *CommentFlag) ToDTO() *models.CommentFlag {
+	return &models.CommentFlag{
+		CommentID:   strfmt.UUID(f.CommentID.String()),
+		CreatedTime: strfmt.DateTime(f.CreatedTime),
+		Details:     f.Details,
+		ID:          strfmt.UUID(f.ID.String()),
+		Reason:      f.Reason,
+		UserID:      strfmt.UUID(f.UserID.String()),
+	}
+}
+
+// CommentFlagsPendingReason returns a pending reason for a comment hidden due to the given flags, listing the number of
+// flags per reason category in the order of their first occurrence
+func CommentFlagsPendingReason(flags []*CommentFlag) string {
+	var reasons []models.CommentFlagReason
+	counts := map[models.CommentFlagReason]int{}
+	for _, f := range flags {
+		if counts[f.Reason] == 0 {
+			reasons = append(reasons, f.Reason)
+		}
+		counts[f.Reason]++
+	}
+	s := make([]string, len(reasons))
+	for i, r := range reasons {
+		s[i] = fmt.Sprintf("%s (%d)", r, counts[r])
+	}
+	return util.TruncateStr("Flagged by readers: "+strings.Join(s, ", "), MaxPendingReasonLength)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+const (
+	MaxCommentReactions      = 12 // Maximum number of reactions allowed per domain
+	MaxCommentReactionLength = 32 // Maximum length (in bytes) of a single reaction
+)
+
+// CommentReaction represents an (emoji) reaction of a user to a comment
+type CommentReaction struct {
+	CommentID   uuid.UUID `db:"comment_id"` // Reference to the comment
+	UserID      uuid.UUID `db:"user_id"`    // Reference to the user who reacted
+	Reaction    string    `db:"reaction"`   // Reaction (emoji)
+	CreatedTime time.Time `db:"ts_created"` // When the record was created
+}
+
+// ParseCommentReactions parses the given comma-separated reaction list, as configured for a domain, into a slice of
+// unique, non-empty reactions. Excessively long reactions are skipped, and the list is capped at MaxCommentReactions
+func ParseCommentReactions(s string) []string {
+	var res []string
+	for _, r := range strings.Split(s, ",") {
+		if r = strings.TrimSpace(r); r != "" && len(r) <= MaxCommentReactionLength && !slices.Contains(res, r) {
+			res = append(res, r)
+			if len(res) == MaxCommentReactions {
+				break
+			}
+		}
+	}
+	return res
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// CommentRevision is a snapshot of a comment's text, as it was before being edited
+type CommentRevision struct {
+	ID          uuid.UUID     `db:"id"           goqu:"skipupdate"` // Unique record ID
+	CommentID   uuid.UUID     `db:"comment_id"   goqu:"skipupdate"` // Reference to the comment
+	Markdown    string        `db:"markdown"     goqu:"skipupdate"` // Comment text in markdown
+	HTML        string        `db:"html"         goqu:"skipupdate"` // Rendered comment text in HTML
+	CreatedTime time.Time     `db:"ts_created"   goqu:"skipupdate"` // When the text was created
+	UserCreated uuid.NullUUID `db:"user_created" goqu:"skipupdate"` // Reference to the user who created the text
+	IsHidden    bool          `db:"is_hidden"`                      // Whether the revision is only visible to moderators
+}
+
+// NewCommentRevision instantiates a new CommentRevision capturing the current text of the given comment, along with the
+// time and the user of its creation or last edit
+func NewCommentRevision(c *Comment) *CommentRevision {
+	r := &CommentRevision{
+		ID:          uuid.New(),
+		CommentID:   c.ID,
+		Markdown:    c.Markdown,
+		HTML:        c.HTML,
+		CreatedTime: c.CreatedTime,
+		UserCreated: c.UserCreated,
+	}
+	if c.EditedTime.Valid {
+		r.CreatedTime = c.EditedTime.Time
+		r.UserCreated = c.UserEdited
+	}
+	return r
+}
+
+// CloneWithClearance returns a clone of the revision with a limited set of properties, depending on whether the user is
+// allowed to moderate the comment: other users don't see the source Markdown
+func (r *CommentRevision) CloneWithClearance(canModerate bool) *CommentRevision {
+	rc := *r
+	if !canModerate {
+		rc.Markdown = ""
+	}
+	return &rc
+}
+
+// ToDTO converts this model into an API model
+func (r *CommentRevision) ToDTO() *models.CommentRevision {
+	return &models.CommentRevision{
+		CommentID:   strfmt.UUID(r.CommentID.String()),
+		CreatedTime: strfmt.DateTime(r.CreatedTime),
+		HTML:        r.HTML,
+		ID:          strfmt.UUID(r.ID.String()),
+		IsHidden:    r.IsHidden,
+		Markdown:    r.Markdown,
+		UserCreated: NullUUIDStr(&r.UserCreated),
+	}
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// CommentScan records a verdict of a domain extension on a comment, along with the commenter's request details, which
+// allows to report moderator's decisions back to the extension
+type CommentScan struct {
+	CommentID   uuid.UUID                `db:"comment_id"   goqu:"skipupdate"` // Reference to the comment
+	ExtensionID models.DomainExtensionID `db:"extension_id" goqu:"skipupdate"` // ID of the extension that scanned the comment
+	IsPositive  bool                     `db:"is_positive"`                    // Whether the comment is deemed inappropriate, updated once a contradicting moderator's decision is reported
+	UserIP      string                   `db:"user_ip"`                        // IP address of the commenter
+	UserAgent   string                   `db:"user_agent"`                     // User agent of the commenter's browser
+	Referrer    string                   `db:"referrer"`                       // Referrer of the commenter's request
+	CreatedTime time.Time                `db:"ts_created"`                     // When the comment was (last) scanned
+}
+
+// CommentScans is a list of comment scans
+type CommentScans []*CommentScan
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 // CommentVote represents a comment vote database record
 type CommentVote struct {
 	CommentID  uuid.UUID `db:"comment_id" goqu:"skipupdate"` // Reference to the comment
@@ -1252,6 +1581,132 @@ type CommentVote struct {
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+// DomainBan represents a ban rule configured for a domain, which blocks visitors matching it from commenting, signing up,
+// and logging in on the domain
+type DomainBan struct {
+	ID          uuid.UUID            `db:"id"           goqu:"skipupdate"` // Unique record ID
+	DomainID    uuid.UUID            `db:"domain_id"    goqu:"skipupdate"` // Reference to the domain
+	Kind        models.DomainBanKind `db:"kind"         goqu:"skipupdate"` // Rule kind
+	Value       string               `db:"value"        goqu:"skipupdate"` // Value to match against, depending on the kind
+	Reason      string               `db:"reason"`                         // Optional reason for the ban
+	CreatedTime time.Time            `db:"ts_created"   goqu:"skipupdate"` // When the record was created
+	UserCreated uuid.NullUUID        `db:"user_created" goqu:"skipupdate"` // Reference to the user who created the rule
+	ExpiresTime sql.NullTime         `db:"ts_expires"`                     // When the rule expires, null if it never does
+}
+
+// NewDomainBan instantiates a new DomainBan
+func NewDomainBan(domainID, userID *uuid.UUID) *DomainBan {
+	return &DomainBan{
+		ID:          uuid.New(),
+		DomainID:    *domainID,
+		CreatedTime: time.Now().UTC(),
+		UserCreated: uuid.NullUUID{UUID: *userID, Valid: true},
+	}
+}
+
+// FromDTO updates this model from an API model, only copying the properties that are allowed to be updated
+func (b *DomainBan) FromDTO(dto *models.DomainBan) {
+	b.Reason = strings.TrimSpace(dto.Reason)
+	if t := time.Time(dto.ExpiresTime); t.IsZero() {
+		b.ExpiresTime = sql.NullTime{}
+	} else {
+		b.ExpiresTime = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+}
+
+// IsActive returns whether the rule isn't expired at the given time
+func (b *DomainBan) IsActive(t time.Time) bool {
+	return !b.ExpiresTime.Valid || b.ExpiresTime.Time.After(t)
+}
+
+// Matches returns whether the rule matches a visitor with the given IP address, country code, and email (any of them
+// can be empty)
+func (b *DomainBan) Matches(ip, country, email string) bool {
+	switch b.Kind {
+	case models.DomainBanKindIP:
+		pip := net.ParseIP(ip)
+		return pip != nil && pip.Equal(net.ParseIP(b.Value))
+
+	case models.DomainBanKindCidr:
+		pip := net.ParseIP(ip)
+		_, ipNet, err := net.ParseCIDR(b.Value)
+		return pip != nil && err == nil && ipNet.Contains(pip)
+
+	case models.DomainBanKindCountry:
+		return country != "" && strings.EqualFold(country, b.Value)
+
+	case models.DomainBanKindEmailDomain:
+		if i := strings.LastIndexByte(email, '@'); i >= 0 {
+			d := strings.ToLower(email[i+1:])
+			return d == b.Value || strings.HasSuffix(d, "."+b.Value)
+		}
+	}
+	return false
+}
+
+// Summary returns a human-readable summary of the rule, including its expiry time, if any
+func (b *DomainBan) Summary() string {
+	s := string(b.Kind) + " " + b.Value
+	if b.ExpiresTime.Valid {
+		s += ", expires " + b.ExpiresTime.Time.Format(time.RFC3339)
+	}
+	return s
+}
+
+// ToDTO converts this model into an API model
+func (b *DomainBan) ToDTO() *models.DomainBan {
+	return &models.DomainBan{
+		CreatedTime: strfmt.DateTime(b.CreatedTime),
+		DomainID:    strfmt.UUID(b.DomainID.String()),
+		ExpiresTime: NullDateTime(b.ExpiresTime),
+		ID:          strfmt.UUID(b.ID.String()),
+		Kind:        b.Kind.Pointer(),
+		Reason:      b.Reason,
+		UserCreated: NullUUIDStr(&b.UserCreated),
+		Value:       swag.String(b.Value),
+	}
+}
+
+// WithRule validates and sets the rule's kind and value, normalising the latter
+func (b *DomainBan) WithRule(kind models.DomainBanKind, value string) error {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case models.DomainBanKindIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address: %q", value)
+		}
+		value = ip.String()
+
+	case models.DomainBanKindCidr:
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR range: %q", value)
+		}
+		value = ipNet.String()
+
+	case models.DomainBanKindCountry:
+		if len(value) != 2 {
+			return fmt.Errorf("invalid country code: %q", value)
+		}
+		value = strings.ToUpper(value)
+
+	case models.DomainBanKindEmailDomain:
+		value = strings.ToLower(strings.TrimPrefix(value, "@"))
+		if !util.IsValidHostname(value) {
+			return fmt.Errorf("invalid email domain: %q", value)
+		}
+
+	default:
+		return fmt.Errorf("invalid ban rule kind: %q", kind)
+	}
+	b.Kind = kind
+	b.Value = value
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
 // DomainExtension represents a known domain extension
 type DomainExtension struct {
 	ID          models.DomainExtensionID // Extension ID
@@ -1301,26 +1756,59 @@ func (de *DomainExtension) ToDTO() *models.DomainExtension {
 	}
 }
 
+// Built-in domain extension IDs
+const (
+	DomainExtensionIDAkismet                models.DomainExtensionID = "akismet"
+	DomainExtensionIDPerspective            models.DomainExtensionID = "perspective"
+	DomainExtensionIDAPILayerDotSpamChecker models.DomainExtensionID = "apiLayer.spamChecker"
+	DomainExtensionIDBlocklist              models.DomainExtensionID = "blocklist"
+	DomainExtensionIDFloodControl           models.DomainExtensionID = "floodControl"
+	DomainExtensionIDBayes                  models.DomainExtensionID = "bayes"
+)
+
 // DomainExtensions is a map of known domain extensions and their default configurations. All disabled initially
 var DomainExtensions = map[models.DomainExtensionID]*DomainExtension{
-	models.DomainExtensionIDAkismet: {
-		ID:          models.DomainExtensionIDAkismet,
+	DomainExtensionIDAkismet: {
+		ID:          DomainExtensionIDAkismet,
 		Name:        "Akismet",
 		Config:      "#apiKey=...",
 		KeyRequired: true,
 	},
-	models.DomainExtensionIDPerspective: {
-		ID:          models.DomainExtensionIDPerspective,
+	DomainExtensionIDPerspective: {
+		ID:          DomainExtensionIDPerspective,
 		Name:        "Perspective",
 		Config:      "#apiKey=...\ntoxicity=0.5\nsevereToxicity=0.5\nidentityAttack=0.5\ninsult=0.5\nprofanity=0.5\nthreat=0.5",
 		KeyRequired: true,
 	},
-	models.DomainExtensionIDAPILayerDotSpamChecker: {
-		ID:          models.DomainExtensionIDAPILayerDotSpamChecker,
+	DomainExtensionIDAPILayerDotSpamChecker: {
+		ID:          DomainExtensionIDAPILayerDotSpamChecker,
 		Name:        "APILayer SpamChecker",
 		Config:      "#apiKey=...\nthreshold=5",
 		KeyRequired: true,
 	},
+	DomainExtensionIDBlocklist: {
+		ID:   DomainExtensionIDBlocklist,
+		Name: "Blocklist",
+		Config: "# One rule per line: <name>=<action> <type> <pattern>\n" +
+			"# Actions: moderate, reject, mask. Types: word, wildcard (* and ?), regex\n" +
+			"#spam=moderate word viagra\n" +
+			"#casino=reject regex (?i)casino\\d+\n" +
+			"#swearing=mask wildcard f*ck",
+	},
+	DomainExtensionIDFloodControl: {
+		ID:   DomainExtensionIDFloodControl,
+		Name: "Flood Control",
+		Config: "# Time window for duplicate detection, in minutes\nwindow=60\n" +
+			"# Min text similarity (0..1) for comments to be considered duplicates, 0 to disable\nsimilarity=0.8\n" +
+			"# Min number of words in a comment for duplicate detection to apply\nminWords=5\n" +
+			"# Max number of comments per author or IP within a minute, 0 to disable\nmaxPerMinute=3",
+	},
+	DomainExtensionIDBayes: {
+		ID:   DomainExtensionIDBayes,
+		Name: "Bayesian Filter",
+		Config: "# Spam probability (0..1) from which a comment is sent to moderation\nthreshold=0.9\n" +
+			"# Min number of both spam and legitimate comments learnt for the filter to apply\nminLearnt=20",
+	},
 }
 
 // ---------------------------------------------------------------------------------------------------------------------
@@ -1331,3 +1819,271 @@ type DomainExtensionConfig struct {
 	ExtensionID models.DomainExtensionID `db:"extension_id"` // Extension ID
 	Config      string                   `db:"config"`       // Extension configuration parameters
 }
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// ModerationLogEntry represents an append-only moderation log record, describing a single moderation action
+type ModerationLogEntry struct {
+	ID          uuid.UUID               `db:"id"`           // Unique record ID
+	Action      models.ModerationAction `db:"action"`       // Kind of moderation action
+	DomainID    uuid.NullUUID           `db:"domain_id"`    // Reference to the domain the action applies to, if any
+	PageID      uuid.NullUUID           `db:"page_id"`      // Reference to the page the action applies to, if any
+	CommentID   uuid.NullUUID           `db:"comment_id"`   // Reference to the comment the action applies to, if any
+	UserID      uuid.NullUUID           `db:"user_id"`      // Reference to the user the action applies to, if any
+	UserActor   uuid.NullUUID           `db:"user_actor"`   // Reference to the user who performed the action, null if it was automatic
+	ValueBefore string                  `db:"value_before"` // Value of the affected property before the action
+	ValueAfter  string                  `db:"value_after"`  // Value of the affected property after the action
+	Reason      string                  `db:"reason"`       // Reason for the action
+	CreatedTime time.Time               `db:"ts_created"`   // When the action was performed
+}
+
+// NewModerationLogEntry instantiates a new ModerationLogEntry. actorID can be nil for automatic actions
+func NewModerationLogEntry(action models.ModerationAction, actorID *uuid.UUID) *ModerationLogEntry {
+	return &ModerationLogEntry{
+		ID:          uuid.New(),
+		Action:      action,
+		UserActor:   *PtrToNullUUID(actorID),
+		CreatedTime: time.Now().UTC(),
+	}
+}
+
+// ToDTO converts this model into an API model
+func (e *ModerationLogEntry) ToDTO() *models.ModerationLogEntry {
+	return &models.ModerationLogEntry{
+		Action:      e.Action,
+		CommentID:   NullUUIDStr(&e.CommentID),
+		CreatedTime: strfmt.DateTime(e.CreatedTime),
+		DomainID:    NullUUIDStr(&e.DomainID),
+		ID:          strfmt.UUID(e.ID.String()),
+		PageID:      NullUUIDStr(&e.PageID),
+		Reason:      e.Reason,
+		UserActor:   NullUUIDStr(&e.UserActor),
+		UserID:      NullUUIDStr(&e.UserID),
+		ValueAfter:  e.ValueAfter,
+		ValueBefore: e.ValueBefore,
+	}
+}
+
+// WithComment sets the comment the action applies to, along with its page, domain, and author
+func (e *ModerationLogEntry) WithComment(page *DomainPage, c *Comment) *ModerationLogEntry {
+	e.CommentID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	e.UserID = c.UserCreated
+	return e.WithPage(page)
+}
+
+// WithDomain sets the domain the action applies to
+func (e *ModerationLogEntry) WithDomain(domainID *uuid.UUID) *ModerationLogEntry {
+	e.DomainID = *PtrToNullUUID(domainID)
+	return e
+}
+
+// WithPage sets the page the action applies to, along with its domain
+func (e *ModerationLogEntry) WithPage(page *DomainPage) *ModerationLogEntry {
+	e.PageID = uuid.NullUUID{UUID: page.ID, Valid: true}
+	return e.WithDomain(&page.DomainID)
+}
+
+// WithReason sets the reason for the action
+func (e *ModerationLogEntry) WithReason(reason string) *ModerationLogEntry {
+	e.Reason = util.TruncateStr(strings.TrimSpace(reason), MaxPendingReasonLength)
+	return e
+}
+
+// WithUser sets the user the action applies to
+func (e *ModerationLogEntry) WithUser(userID *uuid.UUID) *ModerationLogEntry {
+	e.UserID = *PtrToNullUUID(userID)
+	return e
+}
+
+// WithValues sets the values of the affected property before and after the action
+func (e *ModerationLogEntry) WithValues(before, after string) *ModerationLogEntry {
+	e.ValueBefore = before
+	e.ValueAfter = after
+	return e
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// ModeratorNote represents a moderator's note on a domain user or a comment, only visible to domain moderators
+type ModeratorNote struct {
+	ID              uuid.UUID     `db:"id"                goqu:"skipupdate"`            // Unique record ID
+	DomainID        uuid.UUID     `db:"domain_id"         goqu:"skipupdate"`            // Reference to the domain
+	UserID          uuid.NullUUID `db:"user_id"           goqu:"skipupdate"`            // Reference to the user the note is about, if any
+	CommentID       uuid.NullUUID `db:"comment_id"        goqu:"skipupdate"`            // Reference to the comment the note is about, if any
+	Text            string        `db:"text"`                                           // Note text
+	CreatedTime     time.Time     `db:"ts_created"        goqu:"skipupdate"`            // When the record was created
+	UserCreated     uuid.NullUUID `db:"user_created"      goqu:"skipupdate"`            // Reference to the user who created the note
+	UserCreatedName string        `db:"user_created_name" goqu:"skipinsert,skipupdate"` // Name of the user who created the note. Calculated field populated only while loading from the DB
+}
+
+// NewModeratorNote instantiates a new ModeratorNote
+func NewModeratorNote(domainID, userID *uuid.UUID, text string) *ModeratorNote {
+	return &ModeratorNote{
+		ID:          uuid.New(),
+		DomainID:    *domainID,
+		Text:        strings.TrimSpace(text),
+		CreatedTime: time.Now().UTC(),
+		UserCreated: uuid.NullUUID{UUID: *userID, Valid: true},
+	}
+}
+
+// ToDTO converts this model into an API model
+func (n *ModeratorNote) ToDTO() *models.ModeratorNote {
+	return &models.ModeratorNote{
+		CommentID:       NullUUIDStr(&n.CommentID),
+		CreatedTime:     strfmt.DateTime(n.CreatedTime),
+		DomainID:        strfmt.UUID(n.DomainID.String()),
+		ID:              strfmt.UUID(n.ID.String()),
+		Text:            swag.String(n.Text),
+		UserCreated:     NullUUIDStr(&n.UserCreated),
+		UserCreatedName: n.UserCreatedName,
+		UserID:          NullUUIDStr(&n.UserID),
+	}
+}
+
+// WithComment sets the comment the note is about
+func (n *ModeratorNote) WithComment(commentID *uuid.UUID) *ModeratorNote {
+	n.CommentID = *PtrToNullUUID(commentID)
+	return n
+}
+
+// WithUser sets the user the note is about
+func (n *ModeratorNote) WithUser(userID *uuid.UUID) *ModeratorNote {
+	n.UserID = *PtrToNullUUID(userID)
+	return n
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// Webhook represents an outgoing webhook configured for a domain
+type Webhook struct {
+	ID          uuid.UUID     `db:"id"           goqu:"skipupdate"` // Unique record ID
+	DomainID    uuid.UUID     `db:"domain_id"    goqu:"skipupdate"` // Reference to the domain
+	URL         string        `db:"url"`                            // URL to post payloads to
+	Secret      string        `db:"secret"`                         // Secret used for signing payloads, as a hex string
+	Events      string        `db:"events"`                         // Comma-separated list of events the webhook is subscribed to
+	IsEnabled   bool          `db:"is_enabled"`                     // Whether the webhook is enabled
+	CreatedTime time.Time     `db:"ts_created"   goqu:"skipupdate"` // When the record was created
+	UserCreated uuid.NullUUID `db:"user_created" goqu:"skipupdate"` // Reference to the user who created the webhook
+}
+
+// NewWebhook instantiates a new Webhook with a freshly generated secret
+func NewWebhook(domainID, userID *uuid.UUID) (*Webhook, error) {
+	w := &Webhook{
+		ID:          uuid.New(),
+		DomainID:    *domainID,
+		IsEnabled:   true,
+		CreatedTime: time.Now().UTC(),
+		UserCreated: uuid.NullUUID{UUID: *userID, Valid: true},
+	}
+	if err := w.SecretNew(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// EventList returns the list of events the webhook is subscribed to
+func (w *Webhook) EventList() []models.WebhookEvent {
+	var r []models.WebhookEvent
+	for _, s := range strings.Split(w.Events, ",") {
+		if s != "" {
+			r = append(r, models.WebhookEvent(s))
+		}
+	}
+	return r
+}
+
+// FromDTO updates this model from an API model, only copying the properties that are allowed to be updated
+func (w *Webhook) FromDTO(dto *models.Webhook) {
+	w.URL = string(dto.URL)
+	w.IsEnabled = dto.IsEnabled
+	w.WithEvents(dto.Events)
+}
+
+// HasEvent returns whether the webhook is subscribed to the given event
+func (w *Webhook) HasEvent(e models.WebhookEvent) bool {
+	return slices.Contains(w.EventList(), e)
+}
+
+// SecretBytes returns the signing secret as bytes
+func (w *Webhook) SecretBytes() ([]byte, error) {
+	if b, err := hex.DecodeString(w.Secret); err != nil {
+		return nil, err
+	} else if l := len(b); l != 32 {
+		return nil, fmt.Errorf("invalid webhook secret bytes length %d, want 32", l)
+	} else {
+		// Succeeded
+		return b, nil
+	}
+}
+
+// SecretNew generates a new signing secret for the webhook
+func (w *Webhook) SecretNew() error {
+	ws, err := util.RandomBytes(32)
+	if err != nil {
+		return err
+	}
+	w.Secret = hex.EncodeToString(ws)
+	return nil
+}
+
+// ToDTO converts this model into an API model
+func (w *Webhook) ToDTO() *models.Webhook {
+	return &models.Webhook{
+		CreatedTime: strfmt.DateTime(w.CreatedTime),
+		DomainID:    strfmt.UUID(w.DomainID.String()),
+		Events:      w.EventList(),
+		ID:          strfmt.UUID(w.ID.String()),
+		IsEnabled:   w.IsEnabled,
+		Secret:      w.Secret,
+		URL:         strfmt.URI(w.URL),
+		UserCreated: NullUUIDStr(&w.UserCreated),
+	}
+}
+
+// WithEvents sets the list of events the webhook is subscribed to
+func (w *Webhook) WithEvents(events []models.WebhookEvent) *Webhook {
+	var ss []string
+	for _, e := range events {
+		if s := string(e); !slices.Contains(ss, s) {
+			ss = append(ss, s)
+		}
+	}
+	w.Events = strings.Join(ss, ",")
+	return w
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// WebhookDelivery represents an event payload delivery to a webhook. It serves both as a delivery queue entry and a
+// delivery log record
+type WebhookDelivery struct {
+	ID              uuid.UUID                    `db:"id"              goqu:"skipupdate"` // Unique record ID
+	WebhookID       uuid.UUID                    `db:"webhook_id"      goqu:"skipupdate"` // Reference to the webhook
+	Event           string                       `db:"event"           goqu:"skipupdate"` // Event that triggered the delivery
+	Payload         string                       `db:"payload"         goqu:"skipupdate"` // JSON payload to deliver
+	Status          models.WebhookDeliveryStatus `db:"status"`                            // Delivery status
+	Attempts        int                          `db:"attempts"`                          // Number of delivery attempts made
+	CreatedTime     time.Time                    `db:"ts_created"      goqu:"skipupdate"` // When the record was created
+	NextAttemptTime sql.NullTime                 `db:"ts_next_attempt"`                   // When the next delivery attempt is due
+	LastAttemptTime sql.NullTime                 `db:"ts_last_attempt"`                   // When the last delivery attempt was made
+	ResponseStatus  int                          `db:"response_status"`                   // HTTP status code of the last response, 0 if no response was received
+	Error           string                       `db:"error"`                             // Error message of the last failed attempt
+}
+
+// ToDTO converts this model into an API model
+func (wd *WebhookDelivery) ToDTO() *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		Attempts:        int64(wd.Attempts),
+		CreatedTime:     strfmt.DateTime(wd.CreatedTime),
+		Error:           wd.Error,
+		Event:           wd.Event,
+		ID:              strfmt.UUID(wd.ID.String()),
+		LastAttemptTime: NullDateTime(wd.LastAttemptTime),
+		NextAttemptTime: NullDateTime(wd.NextAttemptTime),
+		Payload:         wd.Payload,
+		ResponseStatus:  int64(wd.ResponseStatus),
+		Status:          wd.Status,
+		WebhookID:       strfmt.UUID(wd.WebhookID.String()),
+	}
+}
@@ -3,7 +3,10 @@ package data
 import (
 	"database/sql"
 	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/extend/plugin"
+	"gitlab.com/comentario/comentario/internal/api/models"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -334,6 +337,134 @@ func TestDomain_CloneWithClearance(t *testing.T) {
 	}
 }
 
+func TestDomain_FromPluginDomain(t *testing.T) {
+	orig := Domain{
+		ID:          uuid.MustParse("477649e8-d122-480c-b183-c3e80e998276"),
+		Name:        "Example",
+		Host:        "example.com",
+		CreatedTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	tests := []struct {
+		name   string
+		modify func(pd *plugin.Domain)
+		want   func(d *Domain)
+	}{
+		{"unchanged        ", func(*plugin.Domain) {}, func(*Domain) {}},
+		{"name             ", func(pd *plugin.Domain) { pd.Name = "Other" }, func(d *Domain) { d.Name = "Other" }},
+		{"HTTPS            ", func(pd *plugin.Domain) { pd.IsHTTPS = true }, func(d *Domain) { d.IsHTTPS = true }},
+		{"readonly         ", func(pd *plugin.Domain) { pd.IsReadonly = true }, func(d *Domain) { d.IsReadonly = true }},
+		{
+			"immutable ignored",
+			func(pd *plugin.Domain) { pd.ID, pd.Host, pd.CreatedTime = uuid.New(), "other.org", time.Now() },
+			func(*Domain) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, want := orig, orig
+			e := &plugin.DomainUpdateEvent{}
+			e.SetDomain(d.ToPluginDomain())
+			tt.modify(e.Domain())
+			d.FromPluginDomain(e.Domain())
+			tt.want(&want)
+			if !reflect.DeepEqual(d, want) {
+				t.Errorf("FromPluginDomain() got %#v, want %#v", d, want)
+			}
+		})
+	}
+}
+
+func TestDomainUser_FromPluginDomainUser(t *testing.T) {
+	orig := DomainUser{
+		DomainID:    uuid.MustParse("477649e8-d122-480c-b183-c3e80e998276"),
+		UserID:      uuid.MustParse("5f1e2d3c-4b5a-4968-8776-a5b4c3d2e1f0"),
+		IsCommenter: true,
+		CreatedTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	tests := []struct {
+		name   string
+		modify func(pdu *plugin.DomainUser)
+		want   func(du *DomainUser)
+	}{
+		{"unchanged        ", func(*plugin.DomainUser) {}, func(*DomainUser) {}},
+		{
+			"roles            ",
+			func(pdu *plugin.DomainUser) { pdu.IsOwner, pdu.IsModerator, pdu.IsCommenter = true, true, false },
+			func(du *DomainUser) { du.IsOwner, du.IsModerator, du.IsCommenter = true, true, false },
+		},
+		{
+			"notifications    ",
+			func(pdu *plugin.DomainUser) {
+				pdu.NotifyReplies, pdu.NotifyModerator, pdu.NotifyCommentStatus, pdu.NotifyMentions = true, true, true, true
+			},
+			func(du *DomainUser) {
+				du.NotifyReplies, du.NotifyModerator, du.NotifyCommentStatus, du.NotifyMentions = true, true, true, true
+			},
+		},
+		{
+			"immutable ignored",
+			func(pdu *plugin.DomainUser) {
+				pdu.DomainID, pdu.UserID, pdu.CreatedTime = uuid.New(), uuid.New(), time.Now()
+			},
+			func(*DomainUser) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			du, want := orig, orig
+			e := &plugin.DomainUserUpdateEvent{}
+			e.SetDomainUser(du.ToPluginDomainUser())
+			tt.modify(e.DomainUser())
+			du.FromPluginDomainUser(e.DomainUser())
+			tt.want(&want)
+			if !reflect.DeepEqual(du, want) {
+				t.Errorf("FromPluginDomainUser() got %#v, want %#v", du, want)
+			}
+		})
+	}
+}
+
+func TestDomainPage_FromPluginDomainPage(t *testing.T) {
+	orig := DomainPage{
+		ID:          uuid.MustParse("477649e8-d122-480c-b183-c3e80e998276"),
+		DomainID:    uuid.MustParse("5f1e2d3c-4b5a-4968-8776-a5b4c3d2e1f0"),
+		Path:        "/blog/",
+		Title:       "Blog",
+		CreatedTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	long := strings.Repeat("x", MaxPageTitleLength+10)
+	tests := []struct {
+		name   string
+		modify func(pp *plugin.DomainPage)
+		want   func(p *DomainPage)
+	}{
+		{"unchanged        ", func(*plugin.DomainPage) {}, func(*DomainPage) {}},
+		{"title            ", func(pp *plugin.DomainPage) { pp.Title = "News" }, func(p *DomainPage) { p.Title = "News" }},
+		{"long title       ", func(pp *plugin.DomainPage) { pp.Title = long }, func(p *DomainPage) { p.Title = long[:MaxPageTitleLength-3] + "…" }},
+		{"readonly         ", func(pp *plugin.DomainPage) { pp.IsReadonly = true }, func(p *DomainPage) { p.IsReadonly = true }},
+		{
+			"immutable ignored",
+			func(pp *plugin.DomainPage) {
+				pp.ID, pp.DomainID, pp.Path, pp.CreatedTime = uuid.New(), uuid.New(), "/other", time.Now()
+			},
+			func(*DomainPage) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, want := orig, orig
+			e := &plugin.PageUpdateEvent{}
+			e.SetPage(p.ToPluginDomainPage())
+			tt.modify(e.Page())
+			p.FromPluginDomainPage(e.Page())
+			tt.want(&want)
+			if !reflect.DeepEqual(p, want) {
+				t.Errorf("FromPluginDomainPage() got %#v, want %#v", p, want)
+			}
+		})
+	}
+}
+
 func TestDomainPage_DisplayTitle(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -356,6 +487,154 @@ func TestDomainPage_DisplayTitle(t *testing.T) {
 	}
 }
 
+func TestDomainPage_WithIsReadonly_WithNoAutoClose(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name        string
+		readonly    bool
+		noAutoClose bool
+		setRO       bool
+		setNAC      *bool
+		wantNAC     bool
+	}{
+		{"closing             ", false, false, true, nil, false},
+		{"reopening           ", true, false, false, nil, true},
+		{"reopening, explicit ", true, false, false, &no, false},
+		{"staying open        ", false, false, false, nil, false},
+		{"staying exempt      ", false, true, false, nil, true},
+		{"exemption reset     ", false, true, false, &no, false},
+		{"exemption set       ", true, false, true, &yes, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &DomainPage{IsReadonly: tt.readonly, NoAutoClose: tt.noAutoClose}
+			p.WithIsReadonly(tt.setRO).WithNoAutoClose(tt.setNAC)
+			if p.IsReadonly != tt.setRO {
+				t.Errorf("IsReadonly = %v, want %v", p.IsReadonly, tt.setRO)
+			}
+			if p.NoAutoClose != tt.wantNAC {
+				t.Errorf("NoAutoClose = %v, want %v", p.NoAutoClose, tt.wantNAC)
+			}
+		})
+	}
+}
+
+func TestComment_CloneWithClearance_IsShadowed(t *testing.T) {
+	uid := uuid.MustParse("477649e8-d122-480c-b183-c3e80e998276")
+	tests := []struct {
+		name       string
+		user       *User
+		domainUser *DomainUser
+		want       bool
+	}{
+		{"superuser", &User{IsSuperuser: true}, nil, true},
+		{"moderator", &User{}, &DomainUser{IsModerator: true}, true},
+		{"author   ", &User{ID: uid}, &DomainUser{IsCommenter: true}, false},
+		{"commenter", &User{}, &DomainUser{IsCommenter: true}, false},
+		{"anonymous", AnonymousUser, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Comment{UserCreated: uuid.NullUUID{UUID: uid, Valid: true}, IsShadowed: true}
+			if got := c.CloneWithClearance(tt.user, tt.domainUser).IsShadowed; got != tt.want {
+				t.Errorf("CloneWithClearance().IsShadowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComment_FromPluginComment(t *testing.T) {
+	orig := Comment{
+		ID:            uuid.MustParse("477649e8-d122-480c-b183-c3e80e998276"),
+		ParentID:      uuid.NullUUID{UUID: uuid.MustParse("d5fa0e3d-5c0a-4a0a-9c0a-3c6cfd5a0c36"), Valid: true},
+		PageID:        uuid.MustParse("0a0f3a6e-0d0c-4a5b-8e5b-1e2e0c9f5b1a"),
+		Markdown:      "foo",
+		HTML:          "<p>foo</p>",
+		Score:         3,
+		IsPending:     true,
+		CreatedTime:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UserCreated:   uuid.NullUUID{UUID: uuid.MustParse("5f1e2d3c-4b5a-4968-8776-a5b4c3d2e1f0"), Valid: true},
+		PendingReason: "Contains links",
+		AuthorName:    "Alice",
+		AuthorCountry: "NL",
+	}
+	long := strings.Repeat("x", MaxPendingReasonLength+10)
+	tests := []struct {
+		name   string
+		modify func(pc *plugin.Comment)
+		want   func(c *Comment)
+	}{
+		{"unchanged        ", func(*plugin.Comment) {}, func(*Comment) {}},
+		{
+			"text             ",
+			func(pc *plugin.Comment) { pc.Markdown, pc.HTML = "bar", "<p>bar</p>" },
+			func(c *Comment) { c.Markdown, c.HTML = "bar", "<p>bar</p>" },
+		},
+		{
+			"status           ",
+			func(pc *plugin.Comment) { pc.IsApproved, pc.IsPending, pc.PendingReason = true, false, "" },
+			func(c *Comment) { c.IsApproved, c.IsPending, c.PendingReason = true, false, "" },
+		},
+		{"sticky           ", func(pc *plugin.Comment) { pc.IsSticky = true }, func(c *Comment) { c.IsSticky = true }},
+		{
+			"author           ",
+			func(pc *plugin.Comment) { pc.AuthorName, pc.AuthorCountry = "Bob", "DE" },
+			func(c *Comment) { c.AuthorName, c.AuthorCountry = "Bob", "DE" },
+		},
+		{
+			"long reason      ",
+			func(pc *plugin.Comment) { pc.PendingReason = long },
+			func(c *Comment) { c.PendingReason = long[:MaxPendingReasonLength-3] + "…" },
+		},
+		{
+			"immutable ignored",
+			func(pc *plugin.Comment) {
+				pc.ID = uuid.New()
+				pc.ParentID = uuid.NullUUID{}
+				pc.PageID = uuid.New()
+				pc.Score = 42
+				pc.IsDeleted = true
+				pc.CreatedTime = time.Now()
+				pc.UserCreated = uuid.NullUUID{}
+			},
+			func(*Comment) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Pass the comment through an event, like plugins get it
+			c, want := orig, orig
+			e := &plugin.CommentUpdateEvent{}
+			e.SetComment(c.ToPluginComment())
+			if got := *e.Comment(); got != *orig.ToPluginComment() {
+				t.Fatalf("Comment() = %#v, want %#v", got, *orig.ToPluginComment())
+			}
+
+			// Modify and apply the payload
+			tt.modify(e.Comment())
+			c.FromPluginComment(e.Comment())
+			tt.want(&want)
+			if !reflect.DeepEqual(c, want) {
+				t.Errorf("FromPluginComment() got %#v, want %#v", c, want)
+			}
+		})
+	}
+}
+
+func TestCommentEvent_SetPageDomain(t *testing.T) {
+	p := &DomainPage{ID: uuid.New(), DomainID: uuid.New(), Path: "/blog/", Title: "Blog", IsReadonly: true}
+	d := &Domain{ID: p.DomainID, Name: "Example", Host: "example.com", IsHTTPS: true}
+	e := &plugin.CommentCreateEvent{}
+	e.SetPage(p.ToPluginDomainPage())
+	e.SetDomain(d.ToPluginDomain())
+	if got := *e.Page(); got != *p.ToPluginDomainPage() {
+		t.Errorf("Page() = %#v, want %#v", got, *p.ToPluginDomainPage())
+	}
+	if got := *e.Domain(); got != *d.ToPluginDomain() {
+		t.Errorf("Domain() = %#v, want %#v", got, *d.ToPluginDomain())
+	}
+}
+
 func TestComment_IsAnonymous(t *testing.T) {
 	tests := []struct {
 		name string
@@ -395,3 +674,212 @@ func TestComment_IsRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestComment_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		pending  bool
+		approved bool
+		want     string
+	}{
+		{"pending         ", true, false, "pending"},
+		{"pending approved", true, true, "pending"},
+		{"approved        ", false, true, "approved"},
+		{"rejected        ", false, false, "rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Comment{IsPending: tt.pending, IsApproved: tt.approved}
+			if got := c.Status(); got != tt.want {
+				t.Errorf("Status() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCommentFlag(t *testing.T) {
+	c1, c2, uid := uuid.New(), uuid.New(), AnonymousUser.ID
+	tests := []struct {
+		name   string
+		ip     string
+		maskIP bool
+		wantIP string
+	}{
+		{"IPv4 full  ", "192.0.2.10", false, "192.0.2.10"},
+		{"IPv4 masked", "192.0.2.10", true, "192.0.x.x"},
+		{"IPv6 full  ", "2001:db8::1", false, "2001:db8::1"},
+		{"IPv6 masked", "2001:db8::1", true, "2001:db8:x:x:x:x:x:x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewCommentFlag(&c1, &uid, models.CommentFlagReasonSpam, "  details ", tt.ip, tt.maskIP)
+			if f.AuthorIP != tt.wantIP {
+				t.Errorf("NewCommentFlag() AuthorIP = %q, want %q", f.AuthorIP, tt.wantIP)
+			}
+			if f.Details != "details" {
+				t.Errorf("NewCommentFlag() Details = %q, want %q", f.Details, "details")
+			}
+			if len(f.IPHash) != 64 {
+				t.Errorf("NewCommentFlag() IPHash = %q, want 64 hex chars", f.IPHash)
+			}
+
+			// The hash must only depend on the full IP and the comment
+			if g := NewCommentFlag(&c1, &uid, models.CommentFlagReasonOther, "", tt.ip, !tt.maskIP); g.IPHash != f.IPHash {
+				t.Errorf("NewCommentFlag() IPHash = %q for the same IP and comment, want %q", g.IPHash, f.IPHash)
+			}
+			if g := NewCommentFlag(&c2, &uid, models.CommentFlagReasonSpam, "", tt.ip, tt.maskIP); g.IPHash == f.IPHash {
+				t.Errorf("NewCommentFlag() IPHash is the same for another comment")
+			}
+		})
+	}
+
+	// Addresses in the same masked subnet must still be told apart
+	f1 := NewCommentFlag(&c1, &uid, models.CommentFlagReasonSpam, "", "192.0.2.10", true)
+	f2 := NewCommentFlag(&c1, &uid, models.CommentFlagReasonSpam, "", "192.0.2.11", true)
+	if f1.AuthorIP != f2.AuthorIP || f1.IPHash == f2.IPHash {
+		t.Errorf("NewCommentFlag() got AuthorIP %q/%q, IPHash %q/%q, want equal IPs and distinct hashes", f1.AuthorIP, f2.AuthorIP, f1.IPHash, f2.IPHash)
+	}
+}
+
+func TestCommentFlagsPendingReason(t *testing.T) {
+	spam := &CommentFlag{Reason: models.CommentFlagReasonSpam}
+	abuse := &CommentFlag{Reason: models.CommentFlagReasonAbuse}
+	other := &CommentFlag{Reason: models.CommentFlagReasonOther}
+	tests := []struct {
+		name  string
+		flags []*CommentFlag
+		want  string
+	}{
+		{"single      ", []*CommentFlag{spam}, "Flagged by readers: spam (1)"},
+		{"same reason ", []*CommentFlag{spam, spam, spam}, "Flagged by readers: spam (3)"},
+		{"mixed       ", []*CommentFlag{abuse, spam, abuse, other}, "Flagged by readers: abuse (2), spam (1), other (1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CommentFlagsPendingReason(tt.flags); got != tt.want {
+				t.Errorf("CommentFlagsPendingReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommentReactions(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{"empty        ", "", nil},
+		{"blanks       ", " , ,", nil},
+		{"single       ", "👍", []string{"👍"}},
+		{"multiple     ", " 👍,❤️ , 😂", []string{"👍", "❤️", "😂"}},
+		{"duplicates   ", "👍,❤️,👍", []string{"👍", "❤️"}},
+		{"too long     ", "👍," + strings.Repeat("x", MaxCommentReactionLength+1), []string{"👍"}},
+		{"capped       ", "a,b,c,d,e,f,g,h,i,j,k,l,m,n", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseCommentReactions(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCommentReactions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCommentRevision(t *testing.T) {
+	author, editor := uuid.New(), uuid.New()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	edited := created.Add(time.Hour)
+	tests := []struct {
+		name     string
+		c        *Comment
+		wantTime time.Time
+		wantUser uuid.UUID
+	}{
+		{"never edited", &Comment{CreatedTime: created, UserCreated: uuid.NullUUID{UUID: author, Valid: true}}, created, author},
+		{"edited      ", &Comment{CreatedTime: created, UserCreated: uuid.NullUUID{UUID: author, Valid: true}, EditedTime: sql.NullTime{Time: edited, Valid: true}, UserEdited: uuid.NullUUID{UUID: editor, Valid: true}}, edited, editor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.c.ID = uuid.New()
+			tt.c.Markdown = "foo"
+			tt.c.HTML = "<p>foo</p>"
+			got := NewCommentRevision(tt.c)
+			if got.CommentID != tt.c.ID || got.Markdown != tt.c.Markdown || got.HTML != tt.c.HTML {
+				t.Errorf("NewCommentRevision() = %#v, doesn't match the comment %#v", got, tt.c)
+			}
+			if !got.CreatedTime.Equal(tt.wantTime) {
+				t.Errorf("NewCommentRevision() CreatedTime = %v, want %v", got.CreatedTime, tt.wantTime)
+			}
+			if got.UserCreated.UUID != tt.wantUser {
+				t.Errorf("NewCommentRevision() UserCreated = %v, want %v", got.UserCreated.UUID, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestDomainBan_Matches(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    models.DomainBanKind
+		value   string
+		ip      string
+		country string
+		email   string
+		want    bool
+	}{
+		{"IP match              ", models.DomainBanKindIP, "192.0.2.1", "192.0.2.1", "", "", true},
+		{"IP mismatch           ", models.DomainBanKindIP, "192.0.2.1", "192.0.2.2", "", "", false},
+		{"IP empty              ", models.DomainBanKindIP, "192.0.2.1", "", "", "", false},
+		{"IPv6 match            ", models.DomainBanKindIP, "2001:db8::1", "2001:0db8:0000::1", "", "", true},
+		{"CIDR match            ", models.DomainBanKindCidr, "192.0.2.0/24", "192.0.2.200", "", "", true},
+		{"CIDR mismatch         ", models.DomainBanKindCidr, "192.0.2.0/24", "192.0.3.1", "", "", false},
+		{"CIDR invalid IP       ", models.DomainBanKindCidr, "192.0.2.0/24", "foo", "", "", false},
+		{"country match         ", models.DomainBanKindCountry, "NL", "", "nl", "", true},
+		{"country mismatch      ", models.DomainBanKindCountry, "NL", "", "DE", "", false},
+		{"country empty         ", models.DomainBanKindCountry, "NL", "", "", "", false},
+		{"email domain match    ", models.DomainBanKindEmailDomain, "example.com", "", "", "joe@Example.com", true},
+		{"email subdomain match ", models.DomainBanKindEmailDomain, "example.com", "", "", "joe@mail.example.com", true},
+		{"email domain mismatch ", models.DomainBanKindEmailDomain, "example.com", "", "", "joe@notexample.com", false},
+		{"email empty           ", models.DomainBanKindEmailDomain, "example.com", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &DomainBan{Kind: tt.kind, Value: tt.value}
+			if got := b.Matches(tt.ip, tt.country, tt.email); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainBan_WithRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    models.DomainBanKind
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"IP                 ", models.DomainBanKindIP, " 192.0.2.1 ", "192.0.2.1", false},
+		{"IPv6               ", models.DomainBanKindIP, "2001:0db8::0001", "2001:db8::1", false},
+		{"IP invalid         ", models.DomainBanKindIP, "192.0.2", "", true},
+		{"CIDR               ", models.DomainBanKindCidr, "192.0.2.14/24", "192.0.2.0/24", false},
+		{"CIDR invalid       ", models.DomainBanKindCidr, "192.0.2.0", "", true},
+		{"country            ", models.DomainBanKindCountry, "nl", "NL", false},
+		{"country invalid    ", models.DomainBanKindCountry, "NLD", "", true},
+		{"email domain       ", models.DomainBanKindEmailDomain, "@Example.COM", "example.com", false},
+		{"email invalid      ", models.DomainBanKindEmailDomain, "foo bar", "", true},
+		{"unknown kind       ", models.DomainBanKind("foo"), "bar", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &DomainBan{}
+			if err := b.WithRule(tt.kind, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("WithRule() error = %v, wantErr %v", err, tt.wantErr)
+			} else if !tt.wantErr && (b.Kind != tt.kind || b.Value != tt.want) {
+				t.Errorf("WithRule() got kind = %v, value = %v, want %v, %v", b.Kind, b.Value, tt.kind, tt.want)
+			}
+		})
+	}
+}
@@ -374,6 +374,12 @@ func (db *Database) Version() string {
 	return db.version
 }
 
+// WithTx runs the given function in a database transaction, which gets committed if the function succeeds, and rolled
+// back otherwise. The function must only use the provided transaction for database access
+func (db *Database) WithTx(f func(tx *goqu.TxDatabase) error) error {
+	return db.goquDB().WithTx(f)
+}
+
 // connect establishes a database connection up to the configured number of attempts
 func (db *Database) connect() error {
 	logger.Infof("Connecting to database %s", db.getConnectString(true))
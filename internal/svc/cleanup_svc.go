@@ -2,6 +2,7 @@ package svc
 
 import (
 	"github.com/doug-martin/goqu/v9"
+	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/persistence"
 	"gitlab.com/comentario/comentario/internal/util"
 	"time"
@@ -18,13 +19,29 @@ type cleanupService struct{}
 
 func (svc *cleanupService) Init() error {
 	logger.Debugf("cleanupService: initialising")
+	go svc.autoClosePages()
 	go svc.cleanupExpiredAuthSessions()
+	go svc.cleanupExpiredDomainBans()
 	go svc.cleanupExpiredTokens()
 	go svc.cleanupExpiredUserSessions()
+	go svc.cleanupStaleCommentScans()
 	go svc.cleanupStalePageViews()
+	go svc.cleanupStaleRateLimits()
+	go svc.cleanupStaleWebhookDeliveries()
 	return nil
 }
 
+// autoClosePages makes pages readonly according to their domains' automatic closing policies
+func (svc *cleanupService) autoClosePages() {
+	logger.Debug("cleanupService.autoClosePages()")
+	for {
+		if i, err := ThePageService.AutoClose(); err == nil && i > 0 {
+			logger.Debugf("cleanupService: closed %d pages", i)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
 // cleanupExpiredAuthSessions removes all expired auth sessions from the database
 func (svc *cleanupService) cleanupExpiredAuthSessions() {
 	logger.Debug("cleanupService.cleanupExpiredAuthSessions()")
@@ -37,6 +54,18 @@ func (svc *cleanupService) cleanupExpiredAuthSessions() {
 	}
 }
 
+// cleanupExpiredDomainBans removes domain ban rules that expired longer than the retention period ago from the database
+func (svc *cleanupService) cleanupExpiredDomainBans() {
+	logger.Debug("cleanupService.cleanupExpiredDomainBans()")
+	for svc.runLogSleep(
+		util.OneDay,
+		"expired domain bans",
+		db.Delete("cm_domain_bans").
+			Where(goqu.I("ts_expires").Lt(time.Now().UTC().Add(-util.DomainBanRetentionPeriod))),
+	) == nil {
+	}
+}
+
 // cleanupExpiredTokens removes all expired tokens from the database
 func (svc *cleanupService) cleanupExpiredTokens() {
 	logger.Debug("cleanupService.cleanupExpiredTokens()")
@@ -61,6 +90,18 @@ func (svc *cleanupService) cleanupExpiredUserSessions() {
 	}
 }
 
+// cleanupStaleCommentScans removes stale comment scan records from the database
+func (svc *cleanupService) cleanupStaleCommentScans() {
+	logger.Debug("cleanupService.cleanupStaleCommentScans()")
+	for svc.runLogSleep(
+		util.OneDay,
+		"stale comment scans",
+		db.Delete("cm_comment_scans").
+			Where(goqu.I("ts_created").Lt(time.Now().UTC().Add(-util.CommentScanRetentionPeriod))),
+	) == nil {
+	}
+}
+
 // cleanupStalePageViews removes stale page view stats from the database
 func (svc *cleanupService) cleanupStalePageViews() {
 	logger.Debug("cleanupService.cleanupStalePageViews()")
@@ -73,6 +114,32 @@ func (svc *cleanupService) cleanupStalePageViews() {
 	}
 }
 
+// cleanupStaleRateLimits removes idle rate limit buckets from the database
+func (svc *cleanupService) cleanupStaleRateLimits() {
+	logger.Debug("cleanupService.cleanupStaleRateLimits()")
+	for svc.runLogSleep(
+		time.Hour,
+		"stale rate limits",
+		db.Delete("cm_rate_limits").
+			Where(goqu.I("ts_updated").Lt(time.Now().UTC().Add(-util.RateLimitBucketTTL))),
+	) == nil {
+	}
+}
+
+// cleanupStaleWebhookDeliveries removes stale, completed webhook deliveries from the database
+func (svc *cleanupService) cleanupStaleWebhookDeliveries() {
+	logger.Debug("cleanupService.cleanupStaleWebhookDeliveries()")
+	for svc.runLogSleep(
+		util.OneDay,
+		"stale webhook deliveries",
+		db.Delete("cm_webhook_deliveries").
+			Where(
+				goqu.I("status").Neq(models.WebhookDeliveryStatusPending),
+				goqu.I("ts_created").Lt(time.Now().UTC().Add(-util.WebhookDeliveryRetentionPeriod))),
+	) == nil {
+	}
+}
+
 // runLogSleep runs the provided cleanup query, logs the outcome, then sleeps for the given duration
 func (svc *cleanupService) runLogSleep(interval time.Duration, entity string, x persistence.Executable) error {
 	if res, err := x.Executor().Exec(); err != nil {
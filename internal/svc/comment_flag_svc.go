@@ -0,0 +1,108 @@
+package svc
+
+import (
+	"database/sql"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/data"
+)
+
+// TheCommentFlagService is a global CommentFlagService implementation
+var TheCommentFlagService CommentFlagService = &commentFlagService{}
+
+// CommentFlagService is a service interface for dealing with comment flags raised by readers
+type CommentFlagService interface {
+	// Create persists a new flag and increments the flag count of the flagged comment in a single transaction, unless
+	// the comment has already been flagged by the same user (or, in case of the anonymous user, from the same IP
+	// address, told by the IP hash). Returns whether the flag has been added, and the updated flag count of the comment
+	Create(f *data.CommentFlag) (bool, int, error)
+	// DeleteByComment deletes all flags raised against the given comment and resets its flag count
+	DeleteByComment(commentID *uuid.UUID) error
+	// ListByComment returns all flags raised against the given comment, most recent first
+	ListByComment(commentID *uuid.UUID) ([]*data.CommentFlag, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// commentFlagService is a blueprint CommentFlagService implementation
+type commentFlagService struct{}
+
+func (svc *commentFlagService) Create(f *data.CommentFlag) (bool, int, error) {
+	logger.Debugf("commentFlagService.Create(%#v)", f)
+
+	var added bool
+	var r struct {
+		CountFlags int `db:"count_flags"`
+	}
+	err := db.WithTx(func(tx *goqu.TxDatabase) error {
+		// Insert a new record unless there's already a flag by the same user (anonymous flags are told apart by the IP
+		// hash), which is enforced by unique indices
+		res, err := tx.Insert("cm_comment_flags").Rows(f).OnConflict(goqu.DoNothing()).Executor().Exec()
+		if err != nil {
+			return err
+		}
+		if cnt, err := res.RowsAffected(); err != nil {
+			return err
+		} else {
+			added = cnt > 0
+		}
+
+		// Increment the comment's flag count if the flag has been added
+		if added {
+			if err := db.ExecOne(
+				tx.Update("cm_comments").
+					Set(goqu.Record{"count_flags": goqu.L("? + 1", goqu.I("count_flags"))}).
+					Where(goqu.Ex{"id": &f.CommentID}),
+			); err != nil {
+				return err
+			}
+		}
+
+		// Fetch the updated flag count
+		if b, err := tx.From("cm_comments").Select("count_flags").Where(goqu.Ex{"id": &f.CommentID}).ScanStruct(&r); err != nil {
+			return err
+		} else if !b {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("commentFlagService.Create: WithTx() failed: %v", err)
+		return false, 0, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return added, r.CountFlags, nil
+}
+
+func (svc *commentFlagService) DeleteByComment(commentID *uuid.UUID) error {
+	logger.Debugf("commentFlagService.DeleteByComment(%s)", commentID)
+
+	// Delete the flags
+	if _, err := db.Delete("cm_comment_flags").Where(goqu.Ex{"comment_id": commentID}).Executor().Exec(); err != nil {
+		logger.Errorf("commentFlagService.DeleteByComment: Exec() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Reset the comment's flag count
+	if err := db.ExecOne(db.Update("cm_comments").Set(goqu.Record{"count_flags": 0}).Where(goqu.Ex{"id": commentID})); err != nil {
+		logger.Errorf("commentFlagService.DeleteByComment: ExecOne() failed for comment update: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *commentFlagService) ListByComment(commentID *uuid.UUID) ([]*data.CommentFlag, error) {
+	logger.Debugf("commentFlagService.ListByComment(%s)", commentID)
+
+	var fs []*data.CommentFlag
+	if err := db.From("cm_comment_flags").Where(goqu.Ex{"comment_id": commentID}).Order(goqu.I("ts_created").Desc()).ScanStructs(&fs); err != nil {
+		logger.Errorf("commentFlagService.ListByComment: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return fs, nil
+}
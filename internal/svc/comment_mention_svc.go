@@ -0,0 +1,148 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/util"
+	"strings"
+)
+
+// TheCommentMentionService is a global CommentMentionService implementation
+var TheCommentMentionService CommentMentionService = &commentMentionService{}
+
+// CommentMentionService is a service interface for dealing with mentions of users in comments
+type CommentMentionService interface {
+	// ListByComment returns IDs of users mentioned in the given comment
+	ListByComment(commentID *uuid.UUID) ([]uuid.UUID, error)
+	// ListMentionable returns users who can be mentioned on the given domain, i.e. authors of its visible comments,
+	// whose names contain the given filter string (case-insensitively), most recently commenting first
+	ListMentionable(domainID *uuid.UUID, filter string) ([]*models.MentionCandidate, error)
+	// Replace replaces users mentioned in the given comment with those having the given IDs
+	Replace(commentID *uuid.UUID, userIDs []uuid.UUID) error
+	// Resolve maps the given names onto IDs of users who can be mentioned on the given domain. Names must match
+	// exactly; if there are multiple users sharing a name, the one who commented most recently wins. Names that cannot
+	// be resolved are omitted from the result
+	Resolve(domainID *uuid.UUID, names []string) (map[string]uuid.UUID, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// commentMentionService is a blueprint CommentMentionService implementation
+type commentMentionService struct{}
+
+func (svc *commentMentionService) ListByComment(commentID *uuid.UUID) ([]uuid.UUID, error) {
+	logger.Debugf("commentMentionService.ListByComment(%s)", commentID)
+
+	var ids []uuid.UUID
+	if err := db.From("cm_comment_mentions").Select("user_id").Where(goqu.Ex{"comment_id": commentID}).ScanVals(&ids); err != nil {
+		logger.Errorf("commentMentionService.ListByComment: ScanVals() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return ids, nil
+}
+
+func (svc *commentMentionService) ListMentionable(domainID *uuid.UUID, filter string) ([]*models.MentionCandidate, error) {
+	logger.Debugf("commentMentionService.ListMentionable(%s, %q)", domainID, filter)
+
+	// Prepare a query
+	q := svc.mentionableQuery(domainID).Limit(util.MaxMentionSuggestions)
+	if filter != "" {
+		q = q.Where(goqu.L(`lower("u"."name")`).Like("%" + strings.ToLower(filter) + "%"))
+	}
+
+	// Fetch the users
+	var dbRecs []struct {
+		ID   uuid.UUID `db:"id"`
+		Name string    `db:"name"`
+	}
+	if err := q.ScanStructs(&dbRecs); err != nil {
+		logger.Errorf("commentMentionService.ListMentionable: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Convert the users into DTOs
+	res := make([]*models.MentionCandidate, len(dbRecs))
+	for i, r := range dbRecs {
+		res[i] = &models.MentionCandidate{ID: strfmt.UUID(r.ID.String()), Name: r.Name}
+	}
+
+	// Succeeded
+	return res, nil
+}
+
+func (svc *commentMentionService) Replace(commentID *uuid.UUID, userIDs []uuid.UUID) error {
+	logger.Debugf("commentMentionService.Replace(%s, %v)", commentID, userIDs)
+
+	// Remove existing mentions and add new ones in a single transaction
+	err := db.WithTx(func(tx *goqu.TxDatabase) error {
+		if _, err := tx.Delete("cm_comment_mentions").Where(goqu.Ex{"comment_id": commentID}).Executor().Exec(); err != nil {
+			return err
+		}
+		for _, id := range userIDs {
+			if err := db.ExecOne(tx.Insert("cm_comment_mentions").Rows(goqu.Record{"comment_id": commentID, "user_id": id})); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("commentMentionService.Replace: WithTx() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *commentMentionService) Resolve(domainID *uuid.UUID, names []string) (map[string]uuid.UUID, error) {
+	logger.Debugf("commentMentionService.Resolve(%s, %v)", domainID, names)
+
+	// Nothing to do if no names are provided
+	res := make(map[string]uuid.UUID)
+	if len(names) == 0 {
+		return res, nil
+	}
+
+	// Fetch the matching users
+	var dbRecs []struct {
+		ID   uuid.UUID `db:"id"`
+		Name string    `db:"name"`
+	}
+	if err := svc.mentionableQuery(domainID).Where(goqu.I("u.name").In(names)).ScanStructs(&dbRecs); err != nil {
+		logger.Errorf("commentMentionService.Resolve: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Map the names onto IDs, the most recent commenter first
+	for _, r := range dbRecs {
+		if _, ok := res[r.Name]; !ok {
+			res[r.Name] = r.ID
+		}
+	}
+
+	// Succeeded
+	return res, nil
+}
+
+// mentionableQuery returns a query for users who can be mentioned on the given domain: those (non-system and
+// non-banned) having authored visible comments there, most recently commenting first
+func (svc *commentMentionService) mentionableQuery(domainID *uuid.UUID) *goqu.SelectDataset {
+	return db.From(goqu.T("cm_users").As("u")).
+		Select("u.id", "u.name").
+		Join(goqu.T("cm_comments").As("c"), goqu.On(goqu.Ex{"c.user_created": goqu.I("u.id")})).
+		Join(goqu.T("cm_domain_pages").As("p"), goqu.On(goqu.Ex{"p.id": goqu.I("c.page_id")})).
+		Where(goqu.Ex{
+			"p.domain_id":      domainID,
+			"c.is_approved":    true,
+			"c.is_deleted":     false,
+			"c.is_shadowed":    false,
+			"u.banned":         false,
+			"u.system_account": false,
+		}).
+		GroupBy("u.id", "u.name").
+		Order(goqu.MAX("c.ts_created").Desc(), goqu.I("u.id").Asc())
+}
@@ -0,0 +1,302 @@
+package svc
+
+import (
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/data"
+	"time"
+)
+
+// TheCommentNotifyService is a global CommentNotifyService implementation
+var TheCommentNotifyService CommentNotifyService = &commentNotifyService{}
+
+// CommentNotifyService is a service interface for applying the side effects of comment changes: recording them in the
+// moderation log and notifying the users involved, websocket subscribers, and webhooks. The changes are expected to be
+// persisted already
+type CommentNotifyService interface {
+	// Created applies the side effects of the given user having added a new comment: updates the comment counts, and
+	// notifies moderators, the parent comment's author, mentioned users, websocket subscribers, and webhooks
+	Created(domain *data.Domain, page *data.DomainPage, comment *data.Comment, user *data.User)
+	// MentionsOnApproval notifies the users mentioned in the given comment, in the background, if the comment has just
+	// been approved after pending moderation. Shadowed and anonymous comments don't notify anyone
+	MentionsOnApproval(domain *data.Domain, page *data.DomainPage, comment *data.Comment, statusBefore string)
+	// Moderated applies the side effects of the given user having changed the comment's status from statusBefore:
+	// records the action in the moderation log, and notifies the comment author, websocket subscribers, and webhooks.
+	// reason is an optional reason for the decision
+	Moderated(domain *data.Domain, page *data.DomainPage, comment *data.Comment, user *data.User, statusBefore, reason string)
+	// NotifyMentions sends a mention notification to each of the given users mentioned in the comment. The commenter
+	// and the author of the parent comment, who gets a reply notification instead, aren't notified
+	NotifyMentions(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User, userIDs []uuid.UUID) error
+	// NotifyModerators sends a comment notification to all domain moderators, except the commenter
+	NotifyModerators(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User) error
+	// NotifyReply sends a reply notification to the author of the parent comment
+	NotifyReply(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User) error
+	// NotifyStatus sends a notification about the comment's status change to its author
+	NotifyStatus(domain *data.Domain, page *data.DomainPage, comment *data.Comment) error
+	// NotifyStatusSummary sends a single comment status notification to each author of the given comments, which have
+	// all been either approved or rejected at once. pages must contain the pages of all the comments
+	NotifyStatusSummary(domain *data.Domain, pages map[uuid.UUID]*data.DomainPage, comments []*data.Comment, approved bool)
+	// WebSocket notifies websocket subscribers about a change in the given comment, in the background. Shadowed
+	// comments are ignored
+	WebSocket(page *data.DomainPage, comment *data.Comment, action string)
+	// Webhook enqueues deliveries of the given comment event to the domain's webhooks, in the background. Shadowed
+	// comments are ignored
+	Webhook(domain *data.Domain, page *data.DomainPage, comment *data.Comment, event models.WebhookEvent)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// commentNotifyService is a blueprint CommentNotifyService implementation
+type commentNotifyService struct{}
+
+func (svc *commentNotifyService) Created(domain *data.Domain, page *data.DomainPage, comment *data.Comment, user *data.User) {
+	// Shadowed comments are neither counted nor notified about
+	if !comment.IsShadowed {
+		// Increment page/domain comment counts in the background, ignoring any error
+		go func() {
+			_ = ThePageService.IncrementCounts(&page.ID, 1, 0)
+			_ = TheDomainService.IncrementCounts(&domain.ID, 1, 0)
+		}()
+
+		// Send an email notification to moderators, if we notify about every comment or comments pending moderation
+		// and the comment isn't approved yet, in the background
+		if domain.ModNotifyPolicy == data.DomainModNotifyPolicyAll || comment.IsPending && domain.ModNotifyPolicy == data.DomainModNotifyPolicyPending {
+			go func() { _ = svc.NotifyModerators(domain, page, comment, user) }()
+		}
+
+		// If it's a reply and the comment is approved, send out a reply notifications, in the background
+		if !comment.IsRoot() && comment.IsApproved {
+			go func() { _ = svc.NotifyReply(domain, page, comment, user) }()
+		}
+
+		// If the comment is approved, notify the mentioned users, in the background. Anonymous comments don't notify
+		// anyone to prevent abuse
+		if comment.IsApproved && !comment.IsAnonymous() && len(comment.Mentions) > 0 {
+			go func() { _ = svc.NotifyMentions(domain, page, comment, user, comment.Mentions) }()
+		}
+	}
+
+	// Notify websocket subscribers
+	svc.WebSocket(page, comment, "new")
+
+	// Notify webhooks
+	svc.Webhook(domain, page, comment, models.WebhookEventCommentDotCreated)
+}
+
+func (svc *commentNotifyService) MentionsOnApproval(domain *data.Domain, page *data.DomainPage, comment *data.Comment, statusBefore string) {
+	if statusBefore != "pending" || comment.Status() != "approved" || comment.IsShadowed || comment.IsAnonymous() {
+		return
+	}
+	go func() {
+		// Fetch the mentioned users
+		ids, err := TheCommentMentionService.ListByComment(&comment.ID)
+		if err != nil || len(ids) == 0 {
+			return
+		}
+
+		// Find the comment author
+		commenter, err := TheUserService.FindUserByID(&comment.UserCreated.UUID)
+		if err != nil {
+			return
+		}
+
+		// Send out the notifications
+		if err := svc.NotifyMentions(domain, page, comment, commenter, ids); err != nil {
+			logger.Errorf("commentNotifyService.MentionsOnApproval: NotifyMentions() failed: %v", err)
+		}
+	}()
+}
+
+func (svc *commentNotifyService) Moderated(domain *data.Domain, page *data.DomainPage, comment *data.Comment, user *data.User, statusBefore, reason string) {
+	// Record the action in the moderation log
+	_ = TheModerationLogService.Add(data.NewModerationLogEntry(models.ModerationActionCommentStatus, &user.ID).
+		WithComment(page, comment).
+		WithValues(statusBefore, comment.Status()).
+		WithReason(reason))
+
+	// Notify the comment author about the status change, in the background
+	go func() { _ = svc.NotifyStatus(domain, page, comment) }()
+
+	// Notify the mentioned users if the comment has just been approved
+	svc.MentionsOnApproval(domain, page, comment, statusBefore)
+
+	// Notify websocket subscribers
+	svc.WebSocket(page, comment, "update")
+
+	// Notify webhooks, unless the comment is put back into moderation
+	if !comment.IsPending {
+		if comment.IsApproved {
+			svc.Webhook(domain, page, comment, models.WebhookEventCommentDotApproved)
+		} else {
+			svc.Webhook(domain, page, comment, models.WebhookEventCommentDotRejected)
+		}
+	}
+}
+
+func (svc *commentNotifyService) NotifyMentions(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User, userIDs []uuid.UUID) error {
+	// Figure out who gets a reply notification
+	var parentUserID uuid.UUID
+	if !comment.IsRoot() {
+		if parentComment, err := TheCommentService.FindByID(&comment.ParentID.UUID); err != nil {
+			return err
+		} else {
+			parentUserID = parentComment.UserCreated.UUID
+		}
+	}
+
+	// Iterate the mentioned users
+	for _, id := range userIDs {
+		if id == commenter.ID || id == parentUserID {
+			continue
+		}
+
+		// Find the user and the corresponding domain user
+		if user, domainUser, err := TheUserService.FindDomainUserByID(&id, &domain.ID); err != nil {
+			return err
+
+			// Send a notification unless mention notifications are turned off
+		} else if domainUser == nil || domainUser.NotifyMentions {
+			_ = TheMailService.SendCommentNotification(
+				MailNotificationKindMention,
+				user,
+				user.IsSuperuser || domainUser.CanModerate(),
+				domain,
+				page,
+				comment,
+				commenter.Name)
+		}
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *commentNotifyService) NotifyModerators(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User) error {
+	// Fetch domain moderators to be notified
+	mods, err := TheUserService.ListDomainModerators(&domain.ID, true)
+	if err != nil {
+		return err
+	}
+
+	// Iterate the moderator users
+	for _, mod := range mods {
+		// Do not email the commenting moderator their own comment
+		if mod.ID != commenter.ID {
+			_ = TheMailService.SendCommentNotification(MailNotificationKindModerator, mod, true, domain, page, comment, commenter.Name)
+		}
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *commentNotifyService) NotifyReply(domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenter *data.User) error {
+	// Fetch the parent comment
+	if parentComment, err := TheCommentService.FindByID(&comment.ParentID.UUID); err != nil {
+		return err
+
+		// No reply notifications for anonymous users and self replies
+	} else if parentComment.IsAnonymous() || parentComment.UserCreated.UUID == commenter.ID {
+		return nil
+
+		// Find the parent commenter user and the corresponding domain user
+	} else if parentUser, parentDomainUser, err := TheUserService.FindDomainUserByID(&parentComment.UserCreated.UUID, &domain.ID); err != nil {
+		return err
+
+		// Don't send notification if reply notifications are turned off
+	} else if parentDomainUser != nil && !parentDomainUser.NotifyReplies {
+		return nil
+
+		// Send a reply notification
+	} else {
+		return TheMailService.SendCommentNotification(
+			MailNotificationKindReply,
+			parentUser,
+			parentUser.IsSuperuser || parentDomainUser.CanModerate(),
+			domain,
+			page,
+			comment,
+			commenter.Name)
+	}
+}
+
+func (svc *commentNotifyService) NotifyStatus(domain *data.Domain, page *data.DomainPage, comment *data.Comment) error {
+	// No notifications for anonymous comments
+	if comment.IsAnonymous() {
+		return nil
+
+		// Find the commenter user and the corresponding domain user
+	} else if commenter, domainUser, err := TheUserService.FindDomainUserByID(&comment.UserCreated.UUID, &domain.ID); err != nil {
+		return err
+
+		// Don't send notification if comment status notifications are turned off
+	} else if domainUser != nil && !domainUser.NotifyCommentStatus {
+		return nil
+
+		// Send a comment status notification
+	} else {
+		return TheMailService.SendCommentNotification(
+			MailNotificationKindCommentStatus,
+			commenter,
+			false,
+			domain,
+			page,
+			comment,
+			commenter.Name)
+	}
+}
+
+func (svc *commentNotifyService) NotifyStatusSummary(domain *data.Domain, pages map[uuid.UUID]*data.DomainPage, comments []*data.Comment, approved bool) {
+	// Group the comments by author, skipping anonymous ones
+	var authorIDs []uuid.UUID
+	byAuthor := map[uuid.UUID][]*data.Comment{}
+	for _, c := range comments {
+		if !c.IsAnonymous() {
+			id := c.UserCreated.UUID
+			if _, ok := byAuthor[id]; !ok {
+				authorIDs = append(authorIDs, id)
+			}
+			byAuthor[id] = append(byAuthor[id], c)
+		}
+	}
+
+	// Notify each author
+	for _, id := range authorIDs {
+		cs := byAuthor[id]
+
+		// A single comment warrants a regular status notification
+		if len(cs) == 1 {
+			if err := svc.NotifyStatus(domain, pages[cs[0].PageID], cs[0]); err != nil {
+				logger.Errorf("commentNotifyService.NotifyStatusSummary: NotifyStatus() failed: %v", err)
+			}
+			continue
+		}
+
+		// Find the commenter user and the corresponding domain user
+		if commenter, domainUser, err := TheUserService.FindDomainUserByID(&id, &domain.ID); err != nil {
+			logger.Errorf("commentNotifyService.NotifyStatusSummary: FindDomainUserByID() failed: %v", err)
+
+			// Only send a notification if comment status notifications aren't turned off
+		} else if domainUser == nil || domainUser.NotifyCommentStatus {
+			if err := TheMailService.SendCommentStatusSummary(commenter, domain, approved, cs, pages); err != nil {
+				logger.Errorf("commentNotifyService.NotifyStatusSummary: SendCommentStatusSummary() failed: %v", err)
+			}
+		}
+	}
+}
+
+func (svc *commentNotifyService) WebSocket(page *data.DomainPage, comment *data.Comment, action string) {
+	if TheWebSocketsService.Active() && !comment.IsShadowed {
+		go func() {
+			// Postpone the update a bit to let the client finish the API call
+			time.Sleep(500 * time.Millisecond)
+			TheWebSocketsService.Send(&page.DomainID, &comment.ID, data.NullUUIDPtr(&comment.ParentID), page.Path, action)
+		}()
+	}
+}
+
+func (svc *commentNotifyService) Webhook(domain *data.Domain, page *data.DomainPage, comment *data.Comment, event models.WebhookEvent) {
+	if !comment.IsShadowed {
+		go TheWebhookService.NotifyComment(event, domain, page, comment)
+	}
+}
@@ -0,0 +1,106 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/data"
+	"time"
+)
+
+// TheCommentReactionService is a global CommentReactionService implementation
+var TheCommentReactionService CommentReactionService = &commentReactionService{}
+
+// CommentReactionService is a service interface for dealing with (emoji) reactions to comments
+type CommentReactionService interface {
+	// ListByComments returns reactions to the comments with the given IDs, aggregated per comment and reaction, in the
+	// order of their first occurrence. curUserID is the ID of the current user, used to tell whether they have reacted
+	ListByComments(curUserID *uuid.UUID, commentIDs []uuid.UUID) (map[uuid.UUID][]*models.CommentReaction, error)
+	// Toggle adds the given reaction of the user to the comment, or removes it if it's already there. Returns whether
+	// the reaction has been added
+	Toggle(commentID, userID *uuid.UUID, reaction string) (bool, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// commentReactionService is a blueprint CommentReactionService implementation
+type commentReactionService struct{}
+
+func (svc *commentReactionService) ListByComments(curUserID *uuid.UUID, commentIDs []uuid.UUID) (map[uuid.UUID][]*models.CommentReaction, error) {
+	logger.Debugf("commentReactionService.ListByComments(%s, [%d items])", curUserID, len(commentIDs))
+
+	// Nothing to do if no comments are provided
+	res := make(map[uuid.UUID][]*models.CommentReaction)
+	if len(commentIDs) == 0 {
+		return res, nil
+	}
+
+	// Fetch the reactions along with the names of reacted users
+	var dbRecs []struct {
+		CommentID uuid.UUID `db:"comment_id"`
+		UserID    uuid.UUID `db:"user_id"`
+		UserName  string    `db:"u_name"`
+		Reaction  string    `db:"reaction"`
+	}
+	if err := db.From(goqu.T("cm_comment_reactions").As("r")).
+		Select("r.comment_id", "r.user_id", goqu.I("u.name").As("u_name"), "r.reaction").
+		Join(goqu.T("cm_users").As("u"), goqu.On(goqu.Ex{"u.id": goqu.I("r.user_id")})).
+		Where(goqu.I("r.comment_id").In(commentIDs)).
+		Order(goqu.I("r.ts_created").Asc(), goqu.I("r.user_id").Asc()).
+		ScanStructs(&dbRecs); err != nil {
+		logger.Errorf("commentReactionService.ListByComments: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Aggregate the reactions per comment
+	for _, r := range dbRecs {
+		var cr *models.CommentReaction
+		for _, c := range res[r.CommentID] {
+			if c.Reaction == r.Reaction {
+				cr = c
+				break
+			}
+		}
+		if cr == nil {
+			cr = &models.CommentReaction{Reaction: r.Reaction, Users: []*models.CommentReactionUser{}}
+			res[r.CommentID] = append(res[r.CommentID], cr)
+		}
+		cr.Count++
+		cr.Reacted = cr.Reacted || curUserID != nil && r.UserID == *curUserID
+		cr.Users = append(cr.Users, &models.CommentReactionUser{ID: strfmt.UUID(r.UserID.String()), Name: r.UserName})
+	}
+
+	// Succeeded
+	return res, nil
+}
+
+func (svc *commentReactionService) Toggle(commentID, userID *uuid.UUID, reaction string) (bool, error) {
+	logger.Debugf("commentReactionService.Toggle(%s, %s, %q)", commentID, userID, reaction)
+
+	// Try to remove an existing reaction first
+	res, err := db.Delete("cm_comment_reactions").
+		Where(goqu.Ex{"comment_id": commentID, "user_id": userID, "reaction": reaction}).
+		Executor().Exec()
+	if err != nil {
+		logger.Errorf("commentReactionService.Toggle: Exec() failed: %v", err)
+		return false, translateDBErrors(err)
+	}
+	if cnt, err := res.RowsAffected(); err != nil {
+		logger.Errorf("commentReactionService.Toggle: RowsAffected() failed: %v", err)
+		return false, translateDBErrors(err)
+	} else if cnt > 0 {
+		// Reaction removed
+		return false, nil
+	}
+
+	// There was no reaction: add one
+	r := &data.CommentReaction{CommentID: *commentID, UserID: *userID, Reaction: reaction, CreatedTime: time.Now().UTC()}
+	if err := db.ExecOne(db.Insert("cm_comment_reactions").Rows(r)); err != nil {
+		logger.Errorf("commentReactionService.Toggle: ExecOne() failed: %v", err)
+		return false, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return true, nil
+}
@@ -0,0 +1,70 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/data"
+)
+
+// TheCommentRevisionService is a global CommentRevisionService implementation
+var TheCommentRevisionService CommentRevisionService = &commentRevisionService{}
+
+// CommentRevisionService is a service interface for dealing with revisions of comment text
+type CommentRevisionService interface {
+	// Create persists a new revision
+	Create(r *data.CommentRevision) error
+	// FindByID finds and returns a revision by its ID
+	FindByID(id *uuid.UUID) (*data.CommentRevision, error)
+	// ListByComment returns all revisions of the given comment, oldest first
+	ListByComment(commentID *uuid.UUID) ([]*data.CommentRevision, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// commentRevisionService is a blueprint CommentRevisionService implementation
+type commentRevisionService struct{}
+
+func (svc *commentRevisionService) Create(r *data.CommentRevision) error {
+	logger.Debugf("commentRevisionService.Create(%#v)", r)
+
+	// Insert a new record
+	if err := db.ExecOne(db.Insert("cm_comment_revisions").Rows(r)); err != nil {
+		logger.Errorf("commentRevisionService.Create: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *commentRevisionService) FindByID(id *uuid.UUID) (*data.CommentRevision, error) {
+	logger.Debugf("commentRevisionService.FindByID(%s)", id)
+
+	var r data.CommentRevision
+	if ok, err := db.From("cm_comment_revisions").Where(goqu.Ex{"id": id}).ScanStruct(&r); err != nil {
+		logger.Errorf("commentRevisionService.FindByID: ScanStruct() failed: %v", err)
+		return nil, translateDBErrors(err)
+	} else if !ok {
+		return nil, ErrNotFound
+	}
+
+	// Succeeded
+	return &r, nil
+}
+
+func (svc *commentRevisionService) ListByComment(commentID *uuid.UUID) ([]*data.CommentRevision, error) {
+	logger.Debugf("commentRevisionService.ListByComment(%s)", commentID)
+
+	// Fetch the revisions
+	var rs []*data.CommentRevision
+	if err := db.From("cm_comment_revisions").
+		Where(goqu.Ex{"comment_id": commentID}).
+		Order(goqu.I("ts_created").Asc()).
+		ScanStructs(&rs); err != nil {
+		logger.Errorf("commentRevisionService.ListByComment: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return rs, nil
+}
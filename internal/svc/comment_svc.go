@@ -6,9 +6,12 @@ import (
 	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/extend/plugin"
 	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/util"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +19,30 @@ import (
 // TheCommentService is a global CommentService implementation
 var TheCommentService CommentService = &commentService{}
 
+// CommentBulkFilter describes a selection of comments for a bulk moderation. All specified criteria must match
+type CommentBulkFilter struct {
+	IDs          []uuid.UUID // Comment IDs, if any
+	PageID       *uuid.UUID  // Optional page ID
+	AuthorUserID *uuid.UUID  // Optional comment author user ID
+	Status       string      // Optional comment status: "approved", "pending", or "rejected"
+	Text         string      // Optional case-insensitive substring of the comment text
+	CreatedFrom  time.Time   // Optional earliest creation time
+	CreatedTo    time.Time   // Optional latest creation time
+}
+
+// IsEmpty returns whether the filter specifies no criteria, and would therefore select every comment
+func (f *CommentBulkFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.PageID == nil && f.AuthorUserID == nil && f.Status == "" && f.Text == "" &&
+		f.CreatedFrom.IsZero() && f.CreatedTo.IsZero()
+}
+
+// CommentBulkChange describes a change applied to a comment by a bulk moderation
+type CommentBulkChange struct {
+	Comment     *data.Comment // The updated comment
+	ValueBefore string        // Value of the changed property before the change
+	ValueAfter  string        // Value of the changed property after the change
+}
+
 // CommentService is a service interface for dealing with comments
 type CommentService interface {
 	// Count returns number of comments for the given domain and, optionally, page.
@@ -35,13 +62,19 @@ type CommentService interface {
 	Create(comment *data.Comment) error
 	// DeleteByUser permanently deletes all comments by the specified user, returning the affected comment count
 	DeleteByUser(userID *uuid.UUID) (int64, error)
-	// Edited persists the text changes of the given comment in the database
+	// Edited persists the text changes of the given comment in the database, keeping the previous text as a revision
 	Edited(comment *data.Comment) error
 	// FindByID finds and returns a comment with the given ID
 	FindByID(id *uuid.UUID) (*data.Comment, error)
 	// ListByDomain returns a list of comments for the given domain. No comment property filtering is applied, so
 	// minimum access privileges are domain moderator
 	ListByDomain(domainID *uuid.UUID) ([]*models.Comment, error)
+	// ListByDomainPage returns a list of comment models for the given domain and, optionally, page, ordered by creation
+	// time. No comment property filtering is applied, so minimum access privileges are domain moderator
+	ListByDomainPage(domainID, pageID *uuid.UUID) ([]*data.Comment, error)
+	// ListRecentByDomain returns up to util.MaxRecentComments non-deleted comments for the given domain created no
+	// earlier than the specified time, latest first
+	ListRecentByDomain(domainID *uuid.UUID, since time.Time) ([]*data.Comment, error)
 	// ListWithCommenters returns a list of comments and related commenters for the given domain and, optionally, page
 	// and/or user.
 	//   - curUser is the current authenticated/anonymous user.
@@ -54,6 +87,8 @@ type CommentService interface {
 	//   - inclPending indicates whether to include comments pending moderation.
 	//   - inclRejected indicates whether to include rejected comments.
 	//   - inclDeleted indicates whether to include deleted comments.
+	//   - flaggedOnly indicates whether to only include comments flagged by readers (only applies to moderators).
+	//   - shadowedOnly indicates whether to only include comments by shadow-banned users (only applies to moderators).
 	//   - removeOrphans indicates whether to filter out non-root comments not having a parent comment on the same list,
 	//     recursively, ensuring a coherent tree structure. NB: should be used with care in conjunction with a positive
 	//     pageIndex or filter string (as they limit the result set).
@@ -63,17 +98,26 @@ type CommentService interface {
 	//   - pageIndex is the page index, if negative, no pagination is applied.
 	ListWithCommenters(
 		curUser *data.User, curDomainUser *data.DomainUser, domainID, pageID, authorUserID, replyToUserID *uuid.UUID,
-		inclApproved, inclPending, inclRejected, inclDeleted, removeOrphans bool, filter, sortBy string, dir data.SortDirection,
-		pageIndex int) ([]*models.Comment, map[uuid.UUID]*models.Commenter, error)
+		inclApproved, inclPending, inclRejected, inclDeleted, flaggedOnly, shadowedOnly, removeOrphans bool,
+		filter, sortBy string, dir data.SortDirection, pageIndex int) ([]*models.Comment, map[uuid.UUID]*models.Commenter, error)
 	// MarkDeleted marks a comment with the given ID deleted by the given user
 	MarkDeleted(commentID, userID *uuid.UUID) error
 	// MarkDeletedByUser deletes all comments by the specified user, returning the affected comment count
 	MarkDeletedByUser(curUserID, userID *uuid.UUID) (int64, error)
-	// Moderated persists the moderation status changes of the given comment in the database
+	// ModerateBulk applies the given action on behalf of the given user to non-deleted comments of the domain matching
+	// the filter, up to util.MaxBulkComments of them, oldest first, skipping comments the action wouldn't change. All
+	// changes are persisted in a single transaction, and plugins are only notified after it's been committed. Returns
+	// the applied changes, and whether there were more matching comments than could be processed
+	ModerateBulk(domainID, userID *uuid.UUID, f *CommentBulkFilter, action models.CommentBulkAction) ([]*CommentBulkChange, bool, error)
+	// Moderated persists the moderation status changes of the given comment in the database. Approving a flagged
+	// comment also clears its flags
 	Moderated(comment *data.Comment) error
 	// SetMarkdown updates the Markdown/HTML properties of the given comment in the specified domain. editedUserID
 	// should point to the user who edited the comment in case it's edited, otherwise nil
 	SetMarkdown(comment *data.Comment, markdown string, domainID, editedUserID *uuid.UUID) error
+	// UnshadowByUser makes all shadowed comments by the specified user in the given domain visible to everyone. Returns
+	// the number of affected non-deleted comments per page ID
+	UnshadowByUser(domainID, userID *uuid.UUID) (map[uuid.UUID]int, error)
 	// UpdateSticky updates the stickiness flag of a comment with the given ID in the database
 	UpdateSticky(commentID *uuid.UUID, sticky bool) error
 	// Vote sets a vote for the given comment and user and updates the comment, return the updated comment's score
@@ -122,14 +166,14 @@ func (svc *commentService) Count(
 		q = q.Where(goqu.Ex{"c.is_deleted": false})
 	}
 
-	// Add authorship filter. If anonymous user: only include approved
+	// Add authorship filter. If anonymous user: only include approved, non-shadowed
 	if curUser.IsAnonymous() {
-		q = q.Where(goqu.Ex{"c.is_pending": false, "c.is_approved": true})
+		q = q.Where(goqu.Ex{"c.is_pending": false, "c.is_approved": true, "c.is_shadowed": false})
 
 	} else if !curUser.IsSuperuser && !curDomainUser.CanModerate() {
-		// Authenticated, non-moderator user: show others' comments only if they are approved
+		// Authenticated, non-moderator user: show others' comments only if they are approved and not shadowed
 		q = q.Where(goqu.Or(
-			goqu.Ex{"c.is_pending": false, "c.is_approved": true},
+			goqu.Ex{"c.is_pending": false, "c.is_approved": true, "c.is_shadowed": false},
 			goqu.Ex{"c.user_created": &curUser.ID}))
 	}
 
@@ -145,13 +189,23 @@ func (svc *commentService) Count(
 
 func (svc *commentService) Create(c *data.Comment) error {
 	logger.Debugf("commentService.Create(%#v)", c)
+
+	// Notify plugins, letting them alter the comment
+	if _, err := handleCommentEvent(&plugin.CommentCreateEvent{}, c); err != nil {
+		return err
+	}
+
+	// Insert a new record
 	if err := db.ExecOne(db.Insert("cm_comments").Rows(c)); err != nil {
 		logger.Errorf("commentService.Create: ExecOne() failed: %v", err)
 		return translateDBErrors(err)
 	}
 
-	// Succeeded
-	return nil
+	// Persist any scans the comment has undergone and users it mentions
+	if err := svc.saveScans(c); err != nil {
+		return err
+	}
+	return svc.saveMentions(c)
 }
 
 func (svc *commentService) DeleteByUser(userID *uuid.UUID) (int64, error) {
@@ -173,23 +227,48 @@ func (svc *commentService) DeleteByUser(userID *uuid.UUID) (int64, error) {
 func (svc *commentService) Edited(comment *data.Comment) error {
 	logger.Debugf("commentService.Edited(%#v)", comment)
 
-	// Update the row in the database
-	if err := db.ExecOne(
-		db.Update("cm_comments").
-			Set(goqu.Record{
-				"markdown":    comment.Markdown,
-				"html":        comment.HTML,
-				"ts_edited":   comment.EditedTime,
-				"user_edited": comment.UserEdited,
-			}).
-			Where(goqu.Ex{"id": &comment.ID}),
-	); err != nil {
-		logger.Errorf("commentService.Edited: ExecOne() failed: %v", err)
+	// Notify plugins, letting them alter the comment text
+	if _, err := handleCommentEvent(&plugin.CommentUpdateEvent{}, comment); err != nil {
+		return err
+	}
+
+	// Fetch the stored comment
+	prev, err := svc.FindByID(&comment.ID)
+	if err != nil {
+		return err
+	}
+
+	// Keep the previous text as a revision, if it's changed, and update the comment in a single transaction
+	err = db.WithTx(func(tx *goqu.TxDatabase) error {
+		if prev.Markdown != comment.Markdown {
+			// If the text is replaced by someone other than the comment author (i.e. a moderator), the previous text
+			// may well be inappropriate, so hide it from readers
+			r := data.NewCommentRevision(prev)
+			r.IsHidden = !comment.UserEdited.Valid || comment.UserEdited.UUID != comment.UserCreated.UUID
+			if err := db.ExecOne(tx.Insert("cm_comment_revisions").Rows(r)); err != nil {
+				return err
+			}
+		}
+		return db.ExecOne(
+			tx.Update("cm_comments").
+				Set(goqu.Record{
+					"markdown":    comment.Markdown,
+					"html":        comment.HTML,
+					"ts_edited":   comment.EditedTime,
+					"user_edited": comment.UserEdited,
+				}).
+				Where(goqu.Ex{"id": &comment.ID}))
+	})
+	if err != nil {
+		logger.Errorf("commentService.Edited: WithTx() failed: %v", err)
 		return translateDBErrors(err)
 	}
 
-	// Succeeded
-	return nil
+	// Persist any scans the edited comment has undergone and users it mentions
+	if err := svc.saveScans(comment); err != nil {
+		return err
+	}
+	return svc.saveMentions(comment)
 }
 
 func (svc *commentService) FindByID(id *uuid.UUID) (*data.Comment, error) {
@@ -243,15 +322,70 @@ func (svc *commentService) ListByDomain(domainID *uuid.UUID) ([]*models.Comment,
 	return comments, nil
 }
 
+func (svc *commentService) ListByDomainPage(domainID, pageID *uuid.UUID) ([]*data.Comment, error) {
+	logger.Debugf("commentService.ListByDomainPage(%s, %s)", domainID, pageID)
+
+	// Prepare a query
+	q := db.From(goqu.T("cm_comments").As("c")).
+		Select("c.*").
+		// Join comment pages
+		Join(goqu.T("cm_domain_pages").As("p"), goqu.On(goqu.Ex{"p.id": goqu.I("c.page_id")})).
+		// Filter by page domain
+		Where(goqu.Ex{"p.domain_id": domainID}).
+		Order(goqu.I("c.ts_created").Asc())
+
+	// Add page filter, if required
+	if pageID != nil {
+		q = q.Where(goqu.Ex{"c.page_id": pageID})
+	}
+
+	// Fetch the comments
+	var cs []*data.Comment
+	if err := q.ScanStructs(&cs); err != nil {
+		logger.Errorf("commentService.ListByDomainPage: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return cs, nil
+}
+
+func (svc *commentService) ListRecentByDomain(domainID *uuid.UUID, since time.Time) ([]*data.Comment, error) {
+	logger.Debugf("commentService.ListRecentByDomain(%s, %s)", domainID, since)
+
+	// Prepare a query
+	q := db.From(goqu.T("cm_comments").As("c")).
+		Select("c.*").
+		// Join comment pages
+		Join(goqu.T("cm_domain_pages").As("p"), goqu.On(goqu.Ex{"p.id": goqu.I("c.page_id")})).
+		// Filter by page domain, creation time, and deleted flag
+		Where(
+			goqu.I("p.domain_id").Eq(domainID),
+			goqu.I("c.ts_created").Gte(since),
+			goqu.I("c.is_deleted").IsFalse()).
+		Order(goqu.I("c.ts_created").Desc()).
+		Limit(util.MaxRecentComments)
+
+	// Fetch the comments
+	var cs []*data.Comment
+	if err := q.ScanStructs(&cs); err != nil {
+		logger.Errorf("commentService.ListRecentByDomain: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return cs, nil
+}
+
 func (svc *commentService) ListWithCommenters(curUser *data.User, curDomainUser *data.DomainUser,
 	domainID, pageID, authorUserID, replyToUserID *uuid.UUID,
-	inclApproved, inclPending, inclRejected, inclDeleted, removeOrphans bool,
+	inclApproved, inclPending, inclRejected, inclDeleted, flaggedOnly, shadowedOnly, removeOrphans bool,
 	filter, sortBy string, dir data.SortDirection, pageIndex int,
 ) ([]*models.Comment, map[uuid.UUID]*models.Commenter, error) {
 	logger.Debugf(
-		"commentService.ListWithCommenters(%s, %#v, %s, %s, %s, %s, %v, %v, %v, %v, %v, %q, '%s', %s, %d)",
+		"commentService.ListWithCommenters(%s, %#v, %s, %s, %s, %s, %v, %v, %v, %v, %v, %v, %v, %q, '%s', %s, %d)",
 		&curUser.ID, curDomainUser, domainID, pageID, authorUserID, replyToUserID, inclApproved, inclPending, inclRejected, inclDeleted,
-		removeOrphans, filter, sortBy, dir, pageIndex)
+		flaggedOnly, shadowedOnly, removeOrphans, filter, sortBy, dir, pageIndex)
 
 	// Prepare a query
 	q := db.From(goqu.T("cm_comments").As("c")).
@@ -324,14 +458,25 @@ func (svc *commentService) ListWithCommenters(curUser *data.User, curDomainUser
 		q = q.Where(goqu.Ex{"c.is_deleted": false})
 	}
 
-	// Add authorship filter. If anonymous user: only include approved
+	// Add flag filter. Flags are only visible to moderators
+	canModerate := curUser.IsSuperuser || curDomainUser.CanModerate()
+	if flaggedOnly && canModerate {
+		q = q.Where(goqu.I("c.count_flags").Gt(0))
+	}
+
+	// Add shadow filter. Shadowed comments are only visible to moderators
+	if shadowedOnly && canModerate {
+		q = q.Where(goqu.Ex{"c.is_shadowed": true})
+	}
+
+	// Add authorship filter. If anonymous user: only include approved, non-shadowed
 	if curUser.IsAnonymous() {
-		q = q.Where(goqu.Ex{"c.is_pending": false, "c.is_approved": true})
+		q = q.Where(goqu.Ex{"c.is_pending": false, "c.is_approved": true, "c.is_shadowed": false})
 
 	} else if !curUser.IsSuperuser && !curDomainUser.CanModerate() {
-		// Authenticated, non-moderator user: show others' comments only if they are approved
+		// Authenticated, non-moderator user: show others' comments only if they are approved and not shadowed
 		q = q.Where(goqu.Or(
-			goqu.Ex{"c.is_pending": false, "c.is_approved": true},
+			goqu.Ex{"c.is_pending": false, "c.is_approved": true, "c.is_shadowed": false},
 			goqu.Ex{"c.user_created": &curUser.ID}))
 	}
 
@@ -352,6 +497,10 @@ func (svc *commentService) ListWithCommenters(curUser *data.User, curDomainUser
 	// Configure sorting
 	sortIdent := "c.ts_created"
 	switch sortBy {
+	case "flags":
+		if canModerate {
+			sortIdent = "c.count_flags"
+		}
 	case "score":
 		sortIdent = "c.score"
 	}
@@ -387,6 +536,16 @@ func (svc *commentService) ListWithCommenters(curUser *data.User, curDomainUser
 		return nil, nil, translateDBErrors(err)
 	}
 
+	// Fetch reactions to the comments
+	commentIDs := make([]uuid.UUID, len(dbRecs))
+	for i, r := range dbRecs {
+		commentIDs[i] = r.Comment.ID
+	}
+	reactions, err := TheCommentReactionService.ListByComments(&curUser.ID, commentIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Prepare commenter map: begin with only the "anonymous" one
 	commenterMap := map[uuid.UUID]*models.Commenter{data.AnonymousUser.ID: data.AnonymousUser.ToCommenter(true, false)}
 
@@ -432,6 +591,9 @@ func (svc *commentService) ListWithCommenters(curUser *data.User, curDomainUser
 			}
 		}
 
+		// Add the reactions, if any
+		cm.Reactions = reactions[r.Comment.ID]
+
 		// Append the comment to the list and a flag to the map
 		comments = append(comments, cm)
 		commentMap[cm.ID] = true
@@ -480,6 +642,11 @@ func (svc *commentService) ListWithCommenters(curUser *data.User, curDomainUser
 func (svc *commentService) MarkDeleted(commentID, userID *uuid.UUID) error {
 	logger.Debugf("commentService.MarkDeleted(%s, %s)", commentID, userID)
 
+	// Notify plugins
+	if _, _, err := svc.handleEventByID(&plugin.CommentDeleteEvent{UserID: *userID}, commentID, nil); err != nil {
+		return err
+	}
+
 	// Update the record in the database
 	if err := db.ExecOne(
 		db.Update("cm_comments").
@@ -525,24 +692,218 @@ func (svc *commentService) MarkDeletedByUser(curUserID, userID *uuid.UUID) (int6
 	}
 }
 
+func (svc *commentService) ModerateBulk(domainID, userID *uuid.UUID, f *CommentBulkFilter, action models.CommentBulkAction) ([]*CommentBulkChange, bool, error) {
+	logger.Debugf("commentService.ModerateBulk(%s, %s, %#v, %s)", domainID, userID, f, action)
+
+	// Prepare a query for non-deleted comments of the domain. Fetch one extra comment to find out whether the selection
+	// gets truncated
+	q := db.From(goqu.T("cm_comments").As("c")).
+		Select("c.*").
+		// Join comment pages
+		Join(goqu.T("cm_domain_pages").As("p"), goqu.On(goqu.Ex{"p.id": goqu.I("c.page_id")})).
+		// Filter by page domain
+		Where(goqu.Ex{"p.domain_id": domainID, "c.is_deleted": false}).
+		Order(goqu.I("c.ts_created").Asc(), goqu.I("c.id").Asc()).
+		Limit(util.MaxBulkComments + 1)
+
+	// Apply the filter
+	if len(f.IDs) > 0 {
+		q = q.Where(goqu.Ex{"c.id": f.IDs})
+	}
+	if f.PageID != nil {
+		q = q.Where(goqu.Ex{"c.page_id": f.PageID})
+	}
+	if f.AuthorUserID != nil {
+		q = q.Where(goqu.Ex{"c.user_created": f.AuthorUserID})
+	}
+	switch f.Status {
+	case "approved":
+		q = q.Where(goqu.Ex{"c.is_pending": false, "c.is_approved": true})
+	case "pending":
+		q = q.Where(goqu.Ex{"c.is_pending": true})
+	case "rejected":
+		q = q.Where(goqu.Ex{"c.is_pending": false, "c.is_approved": false})
+	case "":
+		// No status filter
+	default:
+		return nil, false, ErrNotAllowed
+	}
+	if f.Text != "" {
+		q = q.Where(goqu.L(`lower("c"."markdown") LIKE ? ESCAPE '\'`, "%"+util.EscapeLike(strings.ToLower(f.Text))+"%"))
+	}
+	if !f.CreatedFrom.IsZero() {
+		q = q.Where(goqu.I("c.ts_created").Gte(f.CreatedFrom))
+	}
+	if !f.CreatedTo.IsZero() {
+		q = q.Where(goqu.I("c.ts_created").Lte(f.CreatedTo))
+	}
+
+	// Skip comments the action wouldn't change
+	switch action {
+	case models.CommentBulkActionApprove:
+		q = q.Where(goqu.ExOr{"c.is_pending": true, "c.is_approved": false})
+	case models.CommentBulkActionReject:
+		q = q.Where(goqu.ExOr{"c.is_pending": true, "c.is_approved": true})
+	case models.CommentBulkActionSticky:
+		q = q.Where(goqu.Ex{"c.is_sticky": false, "c.parent_id": nil})
+	case models.CommentBulkActionUnsticky:
+		q = q.Where(goqu.Ex{"c.is_sticky": true})
+	}
+
+	// Fetch the comments
+	var cs []*data.Comment
+	if err := q.ScanStructs(&cs); err != nil {
+		logger.Errorf("commentService.ModerateBulk: ScanStructs() failed: %v", err)
+		return nil, false, translateDBErrors(err)
+	}
+
+	// Drop the extra comment, if any
+	truncated := len(cs) > util.MaxBulkComments
+	if truncated {
+		cs = cs[:util.MaxBulkComments]
+	}
+
+	// Apply the action to every comment
+	now := time.Now().UTC()
+	changes := make([]*CommentBulkChange, len(cs))
+	for i, c := range cs {
+		ch := &CommentBulkChange{Comment: c}
+		switch action {
+		case models.CommentBulkActionApprove, models.CommentBulkActionReject:
+			ch.ValueBefore = c.Status()
+			c.WithModerated(userID, false, action == models.CommentBulkActionApprove, "")
+			ch.ValueAfter = c.Status()
+
+		case models.CommentBulkActionDelete:
+			// The text is only cleared once plugins have been notified
+			ch.ValueBefore, ch.ValueAfter = c.Status(), "deleted"
+			c.DeletedTime = sql.NullTime{Time: now, Valid: true}
+			c.UserDeleted = uuid.NullUUID{UUID: *userID, Valid: true}
+
+		case models.CommentBulkActionSticky, models.CommentBulkActionUnsticky:
+			ch.ValueBefore = strconv.FormatBool(c.IsSticky)
+			c.IsSticky = action == models.CommentBulkActionSticky
+			ch.ValueAfter = strconv.FormatBool(c.IsSticky)
+		}
+		changes[i] = ch
+	}
+
+	// Persist all changes in a single transaction
+	err := db.WithTx(func(tx *goqu.TxDatabase) error {
+		for _, ch := range changes {
+			c := ch.Comment
+			if err := db.ExecOne(tx.Update("cm_comments").Set(commentBulkRecord(c, action)).Where(goqu.Ex{"id": &c.ID})); err != nil {
+				return err
+			}
+
+			// Clear the flags of approved comments, like when approving a single one
+			if commentApprovalClearsFlags(c) {
+				if _, err := tx.Delete("cm_comment_flags").Where(goqu.Ex{"comment_id": &c.ID}).Executor().Exec(); err != nil {
+					return err
+				}
+				c.CountFlags = 0
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("commentService.ModerateBulk: transaction failed: %v", err)
+		return nil, false, translateDBErrors(err)
+	}
+
+	// Notify plugins only once the changes have been committed. The changes are saved by now, so plugin errors are
+	// only logged, but any changes plugins make to the comment's moderation status or sticky flag are saved on top
+	res := make([]*CommentBulkChange, 0, len(changes))
+	for _, ch := range changes {
+		c := ch.Comment
+		var changed bool
+		switch action {
+		case models.CommentBulkActionApprove, models.CommentBulkActionReject:
+			if changed, err = handleCommentEvent(&plugin.CommentModerateEvent{}, c); err == nil && changed {
+				ch.ValueAfter = c.Status()
+			}
+		case models.CommentBulkActionDelete:
+			_, err = handleCommentEvent(&plugin.CommentDeleteEvent{UserID: *userID}, c)
+			c.IsDeleted = true
+			c.Markdown = ""
+			c.HTML = ""
+			c.PendingReason = ""
+		default:
+			if changed, err = handleCommentEvent(&plugin.CommentStickyEvent{}, c); err == nil && changed {
+				ch.ValueAfter = strconv.FormatBool(c.IsSticky)
+			}
+		}
+		if err != nil {
+			logger.Warningf("commentService.ModerateBulk: plugin event for comment %s failed: %v", &c.ID, err)
+		} else if changed && action != models.CommentBulkActionDelete {
+			if err := db.ExecOne(db.Update("cm_comments").Set(commentBulkRecord(c, action)).Where(goqu.Ex{"id": &c.ID})); err != nil {
+				logger.Errorf("commentService.ModerateBulk: ExecOne() failed for comment %s: %v", &c.ID, err)
+				return nil, false, translateDBErrors(err)
+			}
+		}
+
+		// Skip the comment if a plugin has reverted the change
+		if ch.ValueBefore != ch.ValueAfter {
+			res = append(res, ch)
+		}
+	}
+	changes = res
+
+	// Report moderation decisions to extensions
+	if action == models.CommentBulkActionApprove || action == models.CommentBulkActionReject {
+		cs := make([]*data.Comment, len(changes))
+		for i, ch := range changes {
+			cs[i] = ch.Comment
+		}
+		ThePerlustrationService.Feedback(cs...)
+	}
+
+	// Succeeded
+	return changes, truncated, nil
+}
+
 func (svc *commentService) Moderated(comment *data.Comment) error {
 	logger.Debugf("commentService.Moderated(%#v)", comment)
 
-	// Update the record in the database
-	if err := db.ExecOne(
-		db.Update("cm_comments").
-			Set(goqu.Record{
-				"is_pending":     comment.IsPending,
-				"is_approved":    comment.IsApproved,
-				"pending_reason": util.TruncateStr(comment.PendingReason, data.MaxPendingReasonLength),
-				"ts_moderated":   comment.ModeratedTime,
-				"user_moderated": comment.UserModerated,
-			}).
-			Where(goqu.Ex{"id": &comment.ID}),
-	); err != nil {
-		logger.Errorf("commentService.Moderated: ExecOne() failed: %v", err)
+	// Notify plugins, letting them alter the moderation status
+	if _, err := handleCommentEvent(&plugin.CommentModerateEvent{}, comment); err != nil {
+		return err
+	}
+
+	// Update the record in the database. Approval overrules any flags raised against the comment, so they get cleared,
+	// and it takes reaching the flag threshold anew to put the comment back into moderation
+	clearFlags := commentApprovalClearsFlags(comment)
+	err := db.WithTx(func(tx *goqu.TxDatabase) error {
+		r := goqu.Record{
+			"is_pending":     comment.IsPending,
+			"is_approved":    comment.IsApproved,
+			"pending_reason": util.TruncateStr(comment.PendingReason, data.MaxPendingReasonLength),
+			"ts_moderated":   comment.ModeratedTime,
+			"user_moderated": comment.UserModerated,
+		}
+		if clearFlags {
+			r["count_flags"] = 0
+		}
+		if err := db.ExecOne(tx.Update("cm_comments").Set(r).Where(goqu.Ex{"id": &comment.ID})); err != nil {
+			return err
+		}
+		if clearFlags {
+			if _, err := tx.Delete("cm_comment_flags").Where(goqu.Ex{"comment_id": &comment.ID}).Executor().Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("commentService.Moderated: transaction failed: %v", err)
 		return translateDBErrors(err)
 	}
+	if clearFlags {
+		comment.CountFlags = 0
+	}
+
+	// Report the decision to extensions
+	ThePerlustrationService.Feedback(comment)
 
 	// Succeeded
 	return nil
@@ -565,7 +926,8 @@ func (svc *commentService) SetMarkdown(comment *data.Comment, markdown string, d
 		md,
 		TheDomainConfigService.GetBool(domainID, data.DomainConfigKeyMarkdownLinksEnabled),
 		TheDomainConfigService.GetBool(domainID, data.DomainConfigKeyMarkdownImagesEnabled),
-		TheDomainConfigService.GetBool(domainID, data.DomainConfigKeyMarkdownTablesEnabled))
+		TheDomainConfigService.GetBool(domainID, data.DomainConfigKeyMarkdownTablesEnabled),
+		func(names []string) []string { return svc.resolveMentions(comment, domainID, names) })
 
 	// Update the audit fields, if required
 	if editedUserID != nil {
@@ -577,9 +939,77 @@ func (svc *commentService) SetMarkdown(comment *data.Comment, markdown string, d
 	return nil
 }
 
+// resolveMentions resolves the given mentioned names into users, storing their IDs in the comment's Mentions, and
+// returns the names that have been resolved. The comment's author can't be mentioned, and neither can more than
+// util.MaxCommentMentions users
+func (svc *commentService) resolveMentions(comment *data.Comment, domainID *uuid.UUID, names []string) []string {
+	comment.Mentions = []uuid.UUID{}
+	users, err := TheCommentMentionService.Resolve(domainID, names)
+	if err != nil {
+		// Not critical: render the mentions as plain text
+		return nil
+	}
+	var res []string
+	for _, name := range names {
+		if len(comment.Mentions) >= util.MaxCommentMentions {
+			break
+		} else if id, ok := users[name]; !ok || comment.UserCreated.Valid && id == comment.UserCreated.UUID {
+			continue
+		} else if !slices.Contains(comment.Mentions, id) {
+			comment.Mentions = append(comment.Mentions, id)
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
+func (svc *commentService) UnshadowByUser(domainID, userID *uuid.UUID) (map[uuid.UUID]int, error) {
+	logger.Debugf("commentService.UnshadowByUser(%s, %s)", domainID, userID)
+
+	// Prepare a condition for the user's shadowed comments on the domain's pages
+	cond := goqu.Ex{
+		"user_created": userID,
+		"is_shadowed":  true,
+		"page_id":      db.From("cm_domain_pages").Select("id").Where(goqu.Ex{"domain_id": domainID}),
+	}
+
+	// Fetch the page IDs of affected non-deleted comments
+	var pageIDs []uuid.UUID
+	if err := db.From("cm_comments").Select("page_id").Where(cond, goqu.Ex{"is_deleted": false}).ScanVals(&pageIDs); err != nil {
+		logger.Errorf("commentService.UnshadowByUser: ScanVals() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Update the comments
+	if _, err := db.Update("cm_comments").Set(goqu.Record{"is_shadowed": false}).Where(cond).Executor().Exec(); err != nil {
+		logger.Errorf("commentService.UnshadowByUser: Exec() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Count comments per page
+	res := map[uuid.UUID]int{}
+	for _, id := range pageIDs {
+		res[id]++
+	}
+
+	// Succeeded
+	return res, nil
+}
+
 func (svc *commentService) UpdateSticky(commentID *uuid.UUID, sticky bool) error {
 	logger.Debugf("commentService.UpdateSticky(%s, %v)", commentID, sticky)
 
+	// Notify plugins, letting them alter the stickiness
+	if c, changed, err := svc.handleEventByID(
+		&plugin.CommentStickyEvent{},
+		commentID,
+		func(c *data.Comment) { c.IsSticky = sticky },
+	); err != nil {
+		return err
+	} else if changed {
+		sticky = c.IsSticky
+	}
+
 	// Update the row in the database
 	if err := db.ExecOne(db.Update("cm_comments").Set(goqu.Record{"is_sticky": sticky}).Where(goqu.Ex{"id": commentID})); err != nil {
 		logger.Errorf("commentService.UpdateSticky: ExecOne() failed: %v", err)
@@ -622,7 +1052,12 @@ func (svc *commentService) Vote(commentID, userID *uuid.UUID, direction int8) (i
 		return r.Score, nil
 	}
 
-	// A change is necessary
+	// A change is necessary: notify plugins
+	if _, _, err := svc.handleEventByID(&plugin.CommentVoteEvent{VoterID: *userID, Direction: direction}, commentID, nil); err != nil {
+		return 0, err
+	}
+
+	// Apply the change
 	var op string
 	inc := 0
 	vote := &data.CommentVote{
@@ -665,3 +1100,137 @@ func (svc *commentService) Vote(commentID, userID *uuid.UUID, direction int8) (i
 	// Succeeded
 	return r.Score, nil
 }
+
+// handleEventByID fires the given comment event for a comment with the given ID, which is only looked up if the plugin
+// manager is active. prep is an optional function to modify the comment before firing the event. Returns the comment
+// (nil if no event was fired) and whether the comment was changed by event handling
+func (svc *commentService) handleEventByID(e plugin.CommentPayload, id *uuid.UUID, prep func(c *data.Comment)) (*data.Comment, bool, error) {
+	// Skip unless the plugin manager is active
+	if !ThePluginManager.Active() {
+		return nil, false, nil
+	}
+
+	// Find the comment
+	c, err := svc.FindByID(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Prepare the comment, if necessary
+	if prep != nil {
+		prep(c)
+	}
+
+	// Fire the event
+	changed, err := handleCommentEvent(e, c)
+	if err != nil {
+		return nil, false, err
+	}
+	return c, changed, nil
+}
+
+// saveMentions persists the users mentioned in the given comment, if its text has been rendered, replacing any earlier
+// mentions
+func (svc *commentService) saveMentions(c *data.Comment) error {
+	if c.Mentions == nil {
+		return nil
+	}
+	return TheCommentMentionService.Replace(&c.ID, c.Mentions)
+}
+
+// saveScans persists the scans the given comment has undergone, replacing any earlier ones by the same extensions
+func (svc *commentService) saveScans(c *data.Comment) error {
+	for _, s := range c.Scans {
+		if err := db.ExecOne(
+			db.Insert("cm_comment_scans").Rows(s).OnConflict(goqu.DoUpdate("comment_id, extension_id", s)),
+		); err != nil {
+			logger.Errorf("commentService.saveScans: ExecOne() failed: %v", err)
+			return translateDBErrors(err)
+		}
+	}
+
+	// Succeeded
+	c.Scans = nil
+	return nil
+}
+
+// commentApprovalClearsFlags returns whether persisting the moderation status of the given comment clears the flags
+// raised against it, which is the case when a flagged comment gets approved
+func commentApprovalClearsFlags(c *data.Comment) bool {
+	return c.IsApproved && !c.IsPending && c.CountFlags > 0
+}
+
+// commentBulkRecord returns a database record with the comment's properties affected by the given bulk action
+func commentBulkRecord(c *data.Comment, action models.CommentBulkAction) goqu.Record {
+	switch action {
+	case models.CommentBulkActionApprove, models.CommentBulkActionReject:
+		r := goqu.Record{
+			"is_pending":     c.IsPending,
+			"is_approved":    c.IsApproved,
+			"pending_reason": util.TruncateStr(c.PendingReason, data.MaxPendingReasonLength),
+			"ts_moderated":   c.ModeratedTime,
+			"user_moderated": c.UserModerated,
+		}
+		if commentApprovalClearsFlags(c) {
+			r["count_flags"] = 0
+		}
+		return r
+	case models.CommentBulkActionDelete:
+		return goqu.Record{
+			"is_deleted":     true,
+			"markdown":       "",
+			"html":           "",
+			"pending_reason": "",
+			"ts_deleted":     c.DeletedTime,
+			"user_deleted":   c.UserDeleted,
+		}
+	default:
+		return goqu.Record{"is_sticky": c.IsSticky}
+	}
+}
+
+// handleCommentEvent fires the given comment event with the comment and its page and domain as a payload, and applies
+// any comment changes made by plugins back to the passed comment
+func handleCommentEvent[E plugin.CommentPayload](e E, c *data.Comment) (changed bool, err error) {
+	// Skip unless the plugin manager is active
+	if !ThePluginManager.Active() {
+		return
+	}
+
+	// Find the comment's page and domain
+	page, err := ThePageService.FindByID(&c.PageID)
+	if err != nil {
+		return
+	}
+	domain, err := TheDomainService.FindByID(&page.DomainID)
+	if err != nil {
+		return
+	}
+
+	// Set the event's payload
+	e.SetComment(c.ToPluginComment())
+	e.SetPage(page.ToPluginDomainPage())
+	e.SetDomain(domain.ToPluginDomain())
+
+	// Make a clone of the original comment
+	cc := c.ToPluginComment()
+
+	// Fire an event
+	if err = ThePluginManager.HandleEvent(e); err != nil {
+		return
+	}
+
+	// Make sure no plugin discarded the comment or replaced it with another one
+	if pc := e.Comment(); pc == nil || pc.ID != cc.ID {
+		logger.Errorf("handleCommentEvent: invalid comment payload after handling %T", e)
+		err = ErrPluginPayload
+		return
+	}
+
+	// If event handling changed the comment, update the working model
+	if *e.Comment() != *cc {
+		c.FromPluginComment(e.Comment())
+		changed = true
+	}
+	return
+}
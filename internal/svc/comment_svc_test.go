@@ -0,0 +1,33 @@
+package svc
+
+import (
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestCommentBulkFilter_IsEmpty(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name string
+		f    CommentBulkFilter
+		want bool
+	}{
+		{"Zero", CommentBulkFilter{}, true},
+		{"Empty IDs", CommentBulkFilter{IDs: []uuid.UUID{}}, true},
+		{"IDs", CommentBulkFilter{IDs: []uuid.UUID{id}}, false},
+		{"Page", CommentBulkFilter{PageID: &id}, false},
+		{"Author", CommentBulkFilter{AuthorUserID: &id}, false},
+		{"Status", CommentBulkFilter{Status: "pending"}, false},
+		{"Text", CommentBulkFilter{Text: "spam"}, false},
+		{"Created from", CommentBulkFilter{CreatedFrom: time.Now()}, false},
+		{"Created to", CommentBulkFilter{CreatedTo: time.Now()}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.IsEmpty(); got != tt.want {
+				t.Errorf("IsEmpty() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/data"
+	"time"
+)
+
+// TheDomainBanService is a global DomainBanService implementation
+var TheDomainBanService DomainBanService = &domainBanService{}
+
+// DomainBanService is a service interface for dealing with domain ban rules
+type DomainBanService interface {
+	// Create persists a new ban rule
+	Create(b *data.DomainBan) error
+	// DeleteByID deletes a ban rule by its ID
+	DeleteByID(id *uuid.UUID) error
+	// FindActiveMatch returns the first active ban rule of the given domain matching a visitor with the given IP
+	// address, country code, and email (any of them can be empty), or nil if there's none
+	FindActiveMatch(domainID *uuid.UUID, ip, country, email string) (*data.DomainBan, error)
+	// FindByID finds and returns a ban rule by its ID
+	FindByID(id *uuid.UUID) (*data.DomainBan, error)
+	// ListByDomain returns all ban rules configured for the given domain, including expired ones
+	ListByDomain(domainID *uuid.UUID) ([]*data.DomainBan, error)
+	// Update persists the changes of the given ban rule
+	Update(b *data.DomainBan) error
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// domainBanService is a blueprint DomainBanService implementation
+type domainBanService struct{}
+
+func (svc *domainBanService) Create(b *data.DomainBan) error {
+	logger.Debugf("domainBanService.Create(%#v)", b)
+
+	// Insert a new record
+	if err := db.ExecOne(db.Insert("cm_domain_bans").Rows(b)); err != nil {
+		logger.Errorf("domainBanService.Create: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *domainBanService) DeleteByID(id *uuid.UUID) error {
+	logger.Debugf("domainBanService.DeleteByID(%s)", id)
+
+	// Delete the record
+	if err := db.ExecOne(db.Delete("cm_domain_bans").Where(goqu.Ex{"id": id})); err != nil {
+		logger.Errorf("domainBanService.DeleteByID: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *domainBanService) FindActiveMatch(domainID *uuid.UUID, ip, country, email string) (*data.DomainBan, error) {
+	logger.Debugf("domainBanService.FindActiveMatch(%s, '%s', '%s', '%s')", domainID, ip, country, email)
+
+	// Fetch all active rules of the domain
+	now := time.Now().UTC()
+	var bs []*data.DomainBan
+	if err := db.From("cm_domain_bans").
+		Where(
+			goqu.Ex{"domain_id": domainID},
+			goqu.Or(goqu.I("ts_expires").IsNull(), goqu.I("ts_expires").Gt(now))).
+		ScanStructs(&bs); err != nil {
+		logger.Errorf("domainBanService.FindActiveMatch: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Look for a matching rule
+	for _, b := range bs {
+		if b.IsActive(now) && b.Matches(ip, country, email) {
+			return b, nil
+		}
+	}
+
+	// No match
+	return nil, nil
+}
+
+func (svc *domainBanService) FindByID(id *uuid.UUID) (*data.DomainBan, error) {
+	logger.Debugf("domainBanService.FindByID(%s)", id)
+
+	var b data.DomainBan
+	if ok, err := db.From("cm_domain_bans").Where(goqu.Ex{"id": id}).ScanStruct(&b); err != nil {
+		logger.Errorf("domainBanService.FindByID: ScanStruct() failed: %v", err)
+		return nil, translateDBErrors(err)
+	} else if !ok {
+		return nil, ErrNotFound
+	}
+
+	// Succeeded
+	return &b, nil
+}
+
+func (svc *domainBanService) ListByDomain(domainID *uuid.UUID) ([]*data.DomainBan, error) {
+	logger.Debugf("domainBanService.ListByDomain(%s)", domainID)
+
+	var bs []*data.DomainBan
+	if err := db.From("cm_domain_bans").Where(goqu.Ex{"domain_id": domainID}).Order(goqu.I("ts_created").Desc()).ScanStructs(&bs); err != nil {
+		logger.Errorf("domainBanService.ListByDomain: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return bs, nil
+}
+
+func (svc *domainBanService) Update(b *data.DomainBan) error {
+	logger.Debugf("domainBanService.Update(%#v)", b)
+
+	// Update the record
+	if err := db.ExecOne(db.Update("cm_domain_bans").Set(b).Where(goqu.Ex{"id": &b.ID})); err != nil {
+		logger.Errorf("domainBanService.Update: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
@@ -25,6 +25,8 @@ type DomainConfigService interface {
 	GetBool(domainID *uuid.UUID, key data.DynConfigItemKey) bool
 	// GetInt returns the int value of a configuration item by its key, or the default value on error
 	GetInt(domainID *uuid.UUID, key data.DynConfigItemKey) int
+	// GetString returns the string value of a configuration item by its key, or the default value on error
+	GetString(domainID *uuid.UUID, key data.DynConfigItemKey) string
 	// ResetCache empties the config cache
 	ResetCache()
 	// Update the values of the configuration items with the given keys and persist the changes. curUserID can be nil
@@ -140,6 +142,16 @@ func (svc *domainConfigService) GetInt(domainID *uuid.UUID, key data.DynConfigIt
 	return TheDynConfigService.GetInt(data.ConfigKeyDomainDefaultsPrefix + key)
 }
 
+func (svc *domainConfigService) GetString(domainID *uuid.UUID, key data.DynConfigItemKey) string {
+	// First try to fetch the actual value
+	if i, err := svc.Get(domainID, key); err == nil {
+		return i.Value
+	}
+
+	// Fall back to the instance default on error
+	return TheDynConfigService.GetString(data.ConfigKeyDomainDefaultsPrefix + key)
+}
+
 func (svc *domainConfigService) ResetCache() {
 	svc.cache.DeleteAll()
 }
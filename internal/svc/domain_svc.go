@@ -94,6 +94,11 @@ type domainService struct{}
 func (svc *domainService) ClearByID(id *uuid.UUID) error {
 	logger.Debugf("domainService.ClearByID(%s)", id)
 
+	// Notify plugins
+	if _, _, err := svc.handleEventByID(&plugin.DomainClearEvent{}, id, nil); err != nil {
+		return err
+	}
+
 	// Remove all domain's pages, which will also cause the removal of all comments, votes, and view stats
 	if _, err := db.Delete("cm_domain_pages").Where(goqu.Ex{"domain_id": id}).Executor().Exec(); err != nil {
 		logger.Errorf("domainService.ClearByID: Exec() for page removal failed: %v", err)
@@ -135,6 +140,11 @@ func (svc *domainService) CountForUser(userID *uuid.UUID, owner, moderator bool)
 func (svc *domainService) Create(userID *uuid.UUID, domain *data.Domain) error {
 	logger.Debugf("domainService.Create(%s, %#v)", userID, domain)
 
+	// Notify plugins, letting them alter the domain
+	if _, err := handleDomainEvent(&plugin.DomainCreateEvent{OwnerUserID: *userID}, domain); err != nil {
+		return err
+	}
+
 	// Insert a new domain record
 	if err := db.ExecOne(db.Insert("cm_domains").Rows(domain)); err != nil {
 		logger.Errorf("domainService.Create: ExecOne() failed: %v", err)
@@ -152,11 +162,21 @@ func (svc *domainService) Create(userID *uuid.UUID, domain *data.Domain) error {
 
 func (svc *domainService) DeleteByID(id *uuid.UUID) error {
 	logger.Debugf("domainService.DeleteByID(%s)", id)
+
+	// Notify plugins
+	if _, _, err := svc.handleEventByID(&plugin.DomainDeleteEvent{}, id, nil); err != nil {
+		return err
+	}
+
+	// Delete the domain record
 	if err := db.ExecOne(db.Delete("cm_domains").Where(goqu.Ex{"id": id})); err != nil {
 		logger.Errorf("domainService.DeleteByID: ExecOne() failed: %v", err)
 		return translateDBErrors(err)
 	}
 
+	// Drop the domain's compiled blocklist, if any
+	ThePerlustrationService.ResetBlocklist(id)
+
 	// Succeeded
 	return nil
 }
@@ -211,6 +231,8 @@ func (svc *domainService) FindDomainUserByHost(host string, userID *uuid.UUID, c
 				goqu.I("du.notify_replies").As("du_notify_replies"),
 				goqu.I("du.notify_moderator").As("du_notify_moderator"),
 				goqu.I("du.notify_comment_status").As("du_notify_comment_status"),
+				goqu.I("du.notify_mentions").As("du_notify_mentions"),
+				goqu.I("du.is_shadow_banned").As("du_is_shadow_banned"),
 				goqu.I("du.ts_created").As("du_ts_created")).
 			LeftJoin(
 				goqu.T("cm_domains_users").As("du"),
@@ -238,6 +260,8 @@ func (svc *domainService) FindDomainUserByID(domainID, userID *uuid.UUID, create
 				goqu.I("du.notify_replies").As("du_notify_replies"),
 				goqu.I("du.notify_moderator").As("du_notify_moderator"),
 				goqu.I("du.notify_comment_status").As("du_notify_comment_status"),
+				goqu.I("du.notify_mentions").As("du_notify_mentions"),
+				goqu.I("du.is_shadow_banned").As("du_is_shadow_banned"),
 				goqu.I("du.ts_created").As("du_ts_created")).
 			LeftJoin(
 				goqu.T("cm_domains_users").As("du"),
@@ -304,6 +328,8 @@ func (svc *domainService) ListByDomainUser(userID, curUserID *uuid.UUID, superus
 			goqu.I("du.notify_replies").As("du_notify_replies"),
 			goqu.I("du.notify_moderator").As("du_notify_moderator"),
 			goqu.I("du.notify_comment_status").As("du_notify_comment_status"),
+			goqu.I("du.notify_mentions").As("du_notify_mentions"),
+			goqu.I("du.is_shadow_banned").As("du_is_shadow_banned"),
 			goqu.I("du.ts_created").As("du_ts_created"),
 			// Domain user fields for curUserID
 			goqu.I("duc.is_owner").As("duc_is_owner"))
@@ -456,6 +482,11 @@ func (svc *domainService) PurgeByID(id *uuid.UUID, deleted, userDeleted bool) (i
 		return 0, nil
 	}
 
+	// Notify plugins
+	if _, _, err := svc.handleEventByID(&plugin.DomainPurgeEvent{Deleted: deleted, UserDeleted: userDeleted}, id, nil); err != nil {
+		return 0, err
+	}
+
 	// Prepare filter
 	var filter []exp.Expression
 	if deleted {
@@ -513,6 +544,9 @@ func (svc *domainService) SaveExtensions(domainID *uuid.UUID, extensions []*data
 		}
 	}
 
+	// Drop the blocklist compiled from the previous configuration
+	ThePerlustrationService.ResetBlocklist(domainID)
+
 	// Succeeded
 	return nil
 }
@@ -548,6 +582,17 @@ func (svc *domainService) SaveIdPs(domainID *uuid.UUID, idps []models.FederatedI
 func (svc *domainService) SetReadonly(domainID *uuid.UUID, readonly bool) error {
 	logger.Debugf("domainService.SetReadonly(%s, %v)", domainID, readonly)
 
+	// Notify plugins, letting them alter the readonly status
+	if d, changed, err := svc.handleEventByID(
+		&plugin.DomainReadonlyEvent{},
+		domainID,
+		func(d *data.Domain) { d.IsReadonly = readonly },
+	); err != nil {
+		return err
+	} else if changed {
+		readonly = d.IsReadonly
+	}
+
 	// Update the domain record
 	if err := db.ExecOne(db.Update("cm_domains").Set(goqu.Record{"is_readonly": readonly}).Where(goqu.Ex{"id": domainID})); err != nil {
 		logger.Errorf("domainService.SetReadonly: ExecOne() failed: %v", err)
@@ -561,6 +606,11 @@ func (svc *domainService) SetReadonly(domainID *uuid.UUID, readonly bool) error
 func (svc *domainService) Update(domain *data.Domain) error {
 	logger.Debugf("domainService.Update(%#v)", domain)
 
+	// Notify plugins, letting them alter the domain
+	if _, err := handleDomainEvent(&plugin.DomainUpdateEvent{}, domain); err != nil {
+		return err
+	}
+
 	// Update the domain record
 	if err := db.ExecOne(db.Update("cm_domains").Set(domain).Where(goqu.Ex{"id": &domain.ID})); err != nil {
 		logger.Errorf("domainService.Update: ExecOne() failed: %v", err)
@@ -579,6 +629,11 @@ func (svc *domainService) UserAdd(du *data.DomainUser) error {
 		return nil
 	}
 
+	// Notify plugins, letting them alter the domain user
+	if _, err := handleDomainUserEvent(&plugin.DomainUserAddEvent{}, du); err != nil {
+		return err
+	}
+
 	// Fire a new owner event, if necessary
 	if err := svc.checkFireNewOwnerEvent(du); err != nil {
 		return err
@@ -602,6 +657,11 @@ func (svc *domainService) UserModify(du *data.DomainUser) error {
 		return nil
 	}
 
+	// Notify plugins, letting them alter the domain user
+	if _, err := handleDomainUserEvent(&plugin.DomainUserUpdateEvent{}, du); err != nil {
+		return err
+	}
+
 	// Fire a new owner event, if necessary
 	if err := svc.checkFireNewOwnerEvent(du); err != nil {
 		return err
@@ -622,6 +682,17 @@ func (svc *domainService) UserRemove(userID, domainID *uuid.UUID) error {
 
 	// Don't bother if the user is an anonymous one
 	if *userID != data.AnonymousUser.ID {
+		// Notify plugins, if the domain user exists
+		if ThePluginManager.Active() {
+			if _, du, err := svc.FindDomainUserByID(domainID, userID, false); err != nil {
+				return err
+			} else if du != nil {
+				if _, err := handleDomainUserEvent(&plugin.DomainUserRemoveEvent{}, du); err != nil {
+					return err
+				}
+			}
+		}
+
 		// Delete the domain-user link record
 		if err := db.ExecOne(db.Delete("cm_domains_users").Where(goqu.Ex{"domain_id": domainID, "user_id": userID})); err != nil {
 			logger.Errorf("domainService.UserRemove: ExecOne() failed: %v", err)
@@ -666,6 +737,34 @@ func (svc *domainService) checkFireNewOwnerEvent(du *data.DomainUser) error {
 	return nil
 }
 
+// handleEventByID fires the given domain event for a domain with the given ID, which is only looked up if the plugin
+// manager is active. prep is an optional function to modify the domain before firing the event. Returns the domain (nil
+// if no event was fired) and whether the domain was changed by event handling
+func (svc *domainService) handleEventByID(e plugin.DomainPayload, id *uuid.UUID, prep func(d *data.Domain)) (*data.Domain, bool, error) {
+	// Skip unless the plugin manager is active
+	if !ThePluginManager.Active() {
+		return nil, false, nil
+	}
+
+	// Find the domain
+	d, err := svc.FindByID(id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Prepare the domain, if necessary
+	if prep != nil {
+		prep(d)
+	}
+
+	// Fire the event
+	changed, err := handleDomainEvent(e, d)
+	if err != nil {
+		return nil, false, err
+	}
+	return d, changed, nil
+}
+
 // fetchDomainUser retrieves a domain and a domain user from the provided dataset, creating a domain user if necessary
 func (svc *domainService) fetchDomainUser(q *goqu.SelectDataset, userID *uuid.UUID, createIfMissing bool) (*data.Domain, *data.DomainUser, error) {
 	var r struct {
@@ -692,3 +791,71 @@ func (svc *domainService) fetchDomainUser(q *goqu.SelectDataset, userID *uuid.UU
 	// Succeeded
 	return &r.Domain, du, nil
 }
+
+// handleDomainEvent fires the given domain event with the domain as a payload, and applies any domain changes made by
+// plugins back to the passed domain
+func handleDomainEvent[E plugin.DomainPayload](e E, d *data.Domain) (changed bool, err error) {
+	// Skip unless the plugin manager is active
+	if !ThePluginManager.Active() {
+		return
+	}
+
+	// Set the event's payload
+	e.SetDomain(d.ToPluginDomain())
+
+	// Make a clone of the original domain
+	dc := d.ToPluginDomain()
+
+	// Fire an event
+	if err = ThePluginManager.HandleEvent(e); err != nil {
+		return
+	}
+
+	// Make sure no plugin discarded the domain or replaced it with another one
+	if pd := e.Domain(); pd == nil || pd.ID != dc.ID {
+		logger.Errorf("handleDomainEvent: invalid domain payload after handling %T", e)
+		err = ErrPluginPayload
+		return
+	}
+
+	// If event handling changed the domain, update the working model
+	if *e.Domain() != *dc {
+		d.FromPluginDomain(e.Domain())
+		changed = true
+	}
+	return
+}
+
+// handleDomainUserEvent fires the given domain user event with the domain user as a payload, and applies any changes
+// made by plugins back to the passed domain user
+func handleDomainUserEvent[E plugin.DomainUserPayload](e E, du *data.DomainUser) (changed bool, err error) {
+	// Skip unless the plugin manager is active
+	if !ThePluginManager.Active() {
+		return
+	}
+
+	// Set the event's payload
+	e.SetDomainUser(du.ToPluginDomainUser())
+
+	// Make a clone of the original domain user
+	duc := du.ToPluginDomainUser()
+
+	// Fire an event
+	if err = ThePluginManager.HandleEvent(e); err != nil {
+		return
+	}
+
+	// Make sure no plugin discarded the domain user or replaced it with another one
+	if pdu := e.DomainUser(); pdu == nil || pdu.DomainID != duc.DomainID || pdu.UserID != duc.UserID {
+		logger.Errorf("handleDomainUserEvent: invalid domain user payload after handling %T", e)
+		err = ErrPluginPayload
+		return
+	}
+
+	// If event handling changed the domain user, update the working model
+	if *e.DomainUser() != *duc {
+		du.FromPluginDomainUser(e.DomainUser())
+		changed = true
+	}
+	return
+}
@@ -23,6 +23,8 @@ type DynConfigService interface {
 	GetBool(key data.DynConfigItemKey) bool
 	// GetInt returns the int value of a configuration item by its key, or the default value on error
 	GetInt(key data.DynConfigItemKey) int
+	// GetString returns the string value of a configuration item by its key, or the default value on error
+	GetString(key data.DynConfigItemKey) string
 	// Load configuration data from the database
 	Load() error
 	// Reset all configuration data to its defaults, then persist the data
@@ -296,6 +298,21 @@ func (svc *dynConfigService) GetInt(key data.DynConfigItemKey) int {
 	return -1
 }
 
+func (svc *dynConfigService) GetString(key data.DynConfigItemKey) string {
+	// First try to fetch the actual value
+	if i, err := svc.Get(key); err == nil {
+		return i.Value
+	}
+
+	// Fall back to the item's default value on error
+	if item, ok := data.DefaultDynInstanceConfig[key]; ok {
+		return item.DefaultValue
+	}
+
+	// Invalid key passed
+	return ""
+}
+
 func (svc *dynConfigService) Load() error {
 	logger.Debug("dynConfigService.Load()")
 	return svc.s.Load()
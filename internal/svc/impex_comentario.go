@@ -64,10 +64,12 @@ const AnonymousCommenterHexIDV1 = HexIDV1("0000000000000000000000000000000000000
 //----------------------------------------------------------------------------------------------------------------------
 
 type comentarioExportV3 struct {
-	Version    int                  `json:"version"`
-	Pages      []*models.DomainPage `json:"pages"`
-	Comments   []*models.Comment    `json:"comments"`
-	Commenters []*models.Commenter  `json:"commenters"`
+	Version       int                          `json:"version"`
+	Pages         []*models.DomainPage         `json:"pages"`
+	Comments      []*models.Comment            `json:"comments"`
+	Commenters    []*models.Commenter          `json:"commenters"`
+	ModerationLog []*models.ModerationLogEntry `json:"moderationLog,omitempty"`  // Only exported, ignored on import
+	ModNotes      []*models.ModeratorNote      `json:"moderatorNotes,omitempty"` // Only exported, ignored on import
 }
 
 func comentarioExport(domainID *uuid.UUID) ([]byte, error) {
@@ -89,7 +91,7 @@ func comentarioExport(domainID *uuid.UUID) ([]byte, error) {
 	}
 
 	// Fetch commenters
-	if um, dus, err := TheUserService.ListByDomain(domainID, false, "", "", data.SortAsc, -1); err != nil {
+	if um, dus, err := TheUserService.ListByDomain(domainID, false, false, "", "", data.SortAsc, -1); err != nil {
 		return nil, err
 	} else {
 		cs := make([]*models.Commenter, 0, len(dus))
@@ -103,6 +105,20 @@ func comentarioExport(domainID *uuid.UUID) ([]byte, error) {
 		exp.Commenters = cs
 	}
 
+	// Fetch the moderation log
+	if es, err := TheModerationLogService.List(domainID, nil, nil, -1); err != nil {
+		return nil, err
+	} else {
+		exp.ModerationLog = data.SliceToDTOs[*data.ModerationLogEntry, *models.ModerationLogEntry](es)
+	}
+
+	// Fetch moderator notes
+	if ns, err := TheModeratorNoteService.List(domainID, nil, nil); err != nil {
+		return nil, err
+	} else {
+		exp.ModNotes = data.SliceToDTOs[*data.ModeratorNote, *models.ModeratorNote](ns)
+	}
+
 	// Convert the data into JSON
 	jsonData, err := json.Marshal(exp)
 	if err != nil {
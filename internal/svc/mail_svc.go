@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"github.com/google/uuid"
 	"gitlab.com/comentario/comentario/internal/config"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/util"
@@ -25,12 +26,16 @@ const (
 	MailNotificationKindReply         = MailNotificationKind("reply")
 	MailNotificationKindModerator     = MailNotificationKind("moderator")
 	MailNotificationKindCommentStatus = MailNotificationKind("commentStatus")
+	MailNotificationKindMention       = MailNotificationKind("mention")
 )
 
 // MailService is a service interface for sending mails
 type MailService interface {
 	// SendCommentNotification sends an email notification about a comment to the given recipient
 	SendCommentNotification(kind MailNotificationKind, recipient *data.User, canModerate bool, domain *data.Domain, page *data.DomainPage, comment *data.Comment, commenterName string) error
+	// SendCommentStatusSummary sends a single email notification to the given recipient about multiple of their comments
+	// having been approved or rejected at once. pages must contain the pages of all the comments
+	SendCommentStatusSummary(recipient *data.User, domain *data.Domain, approved bool, comments []*data.Comment, pages map[uuid.UUID]*data.DomainPage) error
 	// SendConfirmEmail sends an email with a confirmation link
 	SendConfirmEmail(user *data.User, token *data.Token) error
 	// SendEmailUpdateConfirmEmail sends an email for changing the given user's email address
@@ -53,9 +58,12 @@ func (svc *mailService) SendCommentNotification(kind MailNotificationKind, recip
 
 	// Figure out the email title/subject
 	var subject string
-	if kind == MailNotificationKindCommentStatus {
+	switch kind {
+	case MailNotificationKindCommentStatus:
 		subject = t("commentStatusChanged")
-	} else {
+	case MailNotificationKindMention:
+		subject = t("mentionedOn", reflect.ValueOf(page.DisplayTitle(domain)))
+	default:
 		subject = t("newCommentOn", reflect.ValueOf(page.DisplayTitle(domain)))
 	}
 
@@ -66,6 +74,8 @@ func (svc *mailService) SendCommentNotification(kind MailNotificationKind, recip
 		reason = t("notificationNewReply")
 	case kind == MailNotificationKindCommentStatus:
 		reason = t("notificationCommentStatus")
+	case kind == MailNotificationKindMention:
+		reason = t("notificationMention")
 	case comment.IsPending:
 		reason = t("notificationModPending")
 	default:
@@ -116,6 +126,49 @@ func (svc *mailService) SendCommentNotification(kind MailNotificationKind, recip
 	return svc.sendFromTemplate(lang, "", recipient.Email, subject, "comment-notification.gohtml", params)
 }
 
+func (svc *mailService) SendCommentStatusSummary(recipient *data.User, domain *data.Domain, approved bool, comments []*data.Comment, pages map[uuid.UUID]*data.DomainPage) error {
+	lang := recipient.LangID
+	t := func(id string) string { return TheI18nService.Translate(lang, id) }
+
+	// Collect comment params
+	cs := make([]map[string]any, 0, len(comments))
+	for _, c := range comments {
+		if page, ok := pages[c.PageID]; ok {
+			cs = append(cs, map[string]any{
+				"CommentURL": c.URL(domain.IsHTTPS, domain.Host, page.Path),
+				"HTML":       template.HTML(c.HTML),
+				"PageTitle":  page.DisplayTitle(domain),
+				"PageURL":    domain.RootURL() + page.Path,
+			})
+		}
+	}
+
+	// Send out a notification email
+	kind := MailNotificationKindCommentStatus
+	return svc.sendFromTemplate(
+		lang,
+		"",
+		recipient.Email,
+		t("commentStatusChanged"),
+		"comment-status-summary.gohtml",
+		map[string]any{
+			"Comments":    cs,
+			"Count":       len(cs),
+			"EmailReason": t("notificationCommentStatus"),
+			"IsApproved":  approved,
+			"Lang":        lang,
+			"Title":       t("commentStatusChanged"),
+			"UnsubscribeURL": config.ServerConfig.URLForAPI(
+				"mail/unsubscribe",
+				map[string]string{
+					"domain": domain.ID.String(),
+					"user":   recipient.ID.String(),
+					"secret": recipient.SecretToken.String(),
+					"kind":   string(kind),
+				}),
+		})
+}
+
 func (svc *mailService) SendConfirmEmail(user *data.User, token *data.Token) error {
 	t := func(id string) string { return TheI18nService.Translate(user.LangID, id) }
 	return svc.sendFromTemplate(
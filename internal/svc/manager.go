@@ -92,6 +92,19 @@ func (m *manager) Run() {
 		logger.Fatalf("Failed to initialise cleanup service: %v", err)
 	}
 
+	// Start the rate limiting service
+	if err := TheRateLimitService.Init(); err != nil {
+		logger.Fatalf("Failed to initialise rate limiting service: %v", err)
+	}
+
+	// Start the webhook delivery service
+	if err := TheWebhookService.Init(); err != nil {
+		logger.Fatalf("Failed to initialise webhook service: %v", err)
+	}
+
+	// Start plugin background jobs
+	ThePluginManager.StartJobs()
+
 	// Start the websockets service, if enabled
 	if config.ServerConfig.DisableLiveUpdate {
 		logger.Info("Live update is disabled")
@@ -0,0 +1,70 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/util"
+)
+
+// TheModerationLogService is a global ModerationLogService implementation
+var TheModerationLogService ModerationLogService = &moderationLogService{}
+
+// ModerationLogService is a service interface for dealing with the moderation log
+type ModerationLogService interface {
+	// Add appends a new entry to the moderation log
+	Add(e *data.ModerationLogEntry) error
+	// List returns moderation log entries, latest first, optionally filtered by domain, comment, and user (the latter
+	// matching both the user performing and undergoing the action); any of the filters can be nil. If pageIndex is
+	// negative, no pagination is applied
+	List(domainID, commentID, userID *uuid.UUID, pageIndex int) ([]*data.ModerationLogEntry, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// moderationLogService is a blueprint ModerationLogService implementation
+type moderationLogService struct{}
+
+func (svc *moderationLogService) Add(e *data.ModerationLogEntry) error {
+	logger.Debugf("moderationLogService.Add(%#v)", e)
+
+	// Insert a new record
+	if err := db.ExecOne(db.Insert("cm_moderation_log").Rows(e)); err != nil {
+		logger.Errorf("moderationLogService.Add: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *moderationLogService) List(domainID, commentID, userID *uuid.UUID, pageIndex int) ([]*data.ModerationLogEntry, error) {
+	logger.Debugf("moderationLogService.List(%s, %s, %s, %d)", domainID, commentID, userID, pageIndex)
+
+	// Prepare a query
+	q := db.From("cm_moderation_log").Order(goqu.I("ts_created").Desc())
+	if domainID != nil {
+		q = q.Where(goqu.Ex{"domain_id": domainID})
+	}
+	if commentID != nil {
+		q = q.Where(goqu.Ex{"comment_id": commentID})
+	}
+	if userID != nil {
+		q = q.Where(goqu.ExOr{"user_id": userID, "user_actor": userID})
+	}
+
+	// Paginate if required
+	if pageIndex >= 0 {
+		q = q.Limit(util.ResultPageSize).Offset(uint(pageIndex) * util.ResultPageSize)
+	}
+
+	// Fetch the entries
+	var es []*data.ModerationLogEntry
+	if err := q.ScanStructs(&es); err != nil {
+		logger.Errorf("moderationLogService.List: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return es, nil
+}
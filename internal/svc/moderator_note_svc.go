@@ -0,0 +1,99 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/data"
+)
+
+// TheModeratorNoteService is a global ModeratorNoteService implementation
+var TheModeratorNoteService ModeratorNoteService = &moderatorNoteService{}
+
+// ModeratorNoteService is a service interface for dealing with moderator notes
+type ModeratorNoteService interface {
+	// Create persists a new note
+	Create(n *data.ModeratorNote) error
+	// DeleteByID deletes a note by its ID
+	DeleteByID(id *uuid.UUID) error
+	// FindByID finds and returns a note by its ID
+	FindByID(id *uuid.UUID) (*data.ModeratorNote, error)
+	// List returns notes, latest first, for the given domain, optionally filtered by the user and/or the comment they're
+	// about. If userID and commentID are both nil, returns all notes of the domain
+	List(domainID, userID, commentID *uuid.UUID) ([]*data.ModeratorNote, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// moderatorNoteService is a blueprint ModeratorNoteService implementation
+type moderatorNoteService struct{}
+
+func (svc *moderatorNoteService) Create(n *data.ModeratorNote) error {
+	logger.Debugf("moderatorNoteService.Create(%#v)", n)
+
+	// Insert a new record
+	if err := db.ExecOne(db.Insert("cm_moderator_notes").Rows(n)); err != nil {
+		logger.Errorf("moderatorNoteService.Create: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *moderatorNoteService) DeleteByID(id *uuid.UUID) error {
+	logger.Debugf("moderatorNoteService.DeleteByID(%s)", id)
+
+	// Delete the record
+	if err := db.ExecOne(db.Delete("cm_moderator_notes").Where(goqu.Ex{"id": id})); err != nil {
+		logger.Errorf("moderatorNoteService.DeleteByID: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *moderatorNoteService) FindByID(id *uuid.UUID) (*data.ModeratorNote, error) {
+	logger.Debugf("moderatorNoteService.FindByID(%s)", id)
+
+	var n data.ModeratorNote
+	if ok, err := svc.query().Where(goqu.Ex{"n.id": id}).ScanStruct(&n); err != nil {
+		logger.Errorf("moderatorNoteService.FindByID: ScanStruct() failed: %v", err)
+		return nil, translateDBErrors(err)
+	} else if !ok {
+		return nil, ErrNotFound
+	}
+
+	// Succeeded
+	return &n, nil
+}
+
+func (svc *moderatorNoteService) List(domainID, userID, commentID *uuid.UUID) ([]*data.ModeratorNote, error) {
+	logger.Debugf("moderatorNoteService.List(%s, %s, %s)", domainID, userID, commentID)
+
+	// Prepare a query
+	q := svc.query().Where(goqu.Ex{"n.domain_id": domainID}).Order(goqu.I("n.ts_created").Desc())
+	if userID != nil {
+		q = q.Where(goqu.Ex{"n.user_id": userID})
+	}
+	if commentID != nil {
+		q = q.Where(goqu.Ex{"n.comment_id": commentID})
+	}
+
+	// Fetch the notes
+	var ns []*data.ModeratorNote
+	if err := q.ScanStructs(&ns); err != nil {
+		logger.Errorf("moderatorNoteService.List: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return ns, nil
+}
+
+// query returns a query selecting notes along with their authors' names
+func (svc *moderatorNoteService) query() *goqu.SelectDataset {
+	return db.From(goqu.T("cm_moderator_notes").As("n")).
+		Select("n.*", goqu.COALESCE(goqu.I("u.name"), "").As("user_created_name")).
+		LeftJoin(goqu.T("cm_users").As("u"), goqu.On(goqu.Ex{"u.id": goqu.I("n.user_created")}))
+}
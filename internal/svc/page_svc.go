@@ -3,9 +3,12 @@ package svc
 import (
 	"container/list"
 	"database/sql"
+	"fmt"
 	"github.com/avct/uasurfer"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/extend/plugin"
+	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/config"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/util"
@@ -21,6 +24,9 @@ var ThePageService PageService = &pageService{}
 
 // PageService is a service interface for dealing with pages
 type PageService interface {
+	// AutoClose makes pages readonly according to their domains' automatic closing policies, returning the number of
+	// closed pages
+	AutoClose() (int64, error)
 	// CommentCounts returns a map of comment counts by page path, for the specified host and multiple paths
 	CommentCounts(domainID *uuid.UUID, paths []string) (map[string]int, error)
 	// FetchUpdatePageTitle fetches and updates the title of the provided page based on its URL, returning if there was
@@ -67,6 +73,82 @@ type pageService struct {
 	ptfMu sync.Mutex        // Mutex for ptf
 }
 
+func (svc *pageService) AutoClose() (int64, error) {
+	logger.Debug("pageService.AutoClose()")
+
+	// Fetch domains having an automatic closing policy
+	var domains []*data.Domain
+	if err := db.From("cm_domains").Where(goqu.I("auto_close_days").Gt(0)).ScanStructs(&domains); err != nil {
+		logger.Errorf("pageService.AutoClose: ScanStructs() failed: %v", err)
+		return 0, translateDBErrors(err)
+	}
+
+	// Iterate the domains
+	var cnt int64
+	now := time.Now().UTC()
+	for _, d := range domains {
+		// Fetch candidate pages along with their first comment time. A page created after the cutoff can't have been
+		// commented on before it either, so such pages are skipped right away
+		var recs []struct {
+			data.DomainPage
+			FirstCommentTime sql.NullTime `db:"ts_first_comment"`
+		}
+		err := db.From("cm_domain_pages").
+			Select(
+				goqu.T("cm_domain_pages").All(),
+				db.From("cm_comments").
+					Select(goqu.MIN("ts_created")).
+					Where(goqu.I("page_id").Eq(goqu.I("cm_domain_pages.id"))).
+					As("ts_first_comment")).
+			Where(
+				goqu.Ex{"domain_id": &d.ID, "is_readonly": false, "no_auto_close": false},
+				goqu.I("cm_domain_pages.ts_created").Lt(now.AddDate(0, 0, -d.AutoCloseDays))).
+			ScanStructs(&recs)
+		if err != nil {
+			logger.Errorf("pageService.AutoClose: ScanStructs() failed for domain %s: %v", &d.ID, err)
+			return cnt, translateDBErrors(err)
+		}
+
+		// Close the pages that are due
+		for _, r := range recs {
+			page := &r.DomainPage
+			if !pageAutoCloseDue(d, page, r.FirstCommentTime.Time, now) {
+				continue
+			}
+
+			// Notify plugins the same way a regular page update does, skipping the page if any of them objects
+			if ok, err := pageAutoCloseNotify(d, page); err != nil {
+				logger.Warningf("pageService.AutoClose: plugins failed to handle closing page %s: %v", &page.ID, err)
+				continue
+			} else if !ok {
+				continue
+			}
+
+			// Only update the page if it's still writable: it might have been closed in the meantime
+			res, err := db.Update("cm_domain_pages").
+				Set(page).
+				Where(goqu.Ex{"id": &page.ID, "is_readonly": false}).
+				Executor().Exec()
+			if err != nil {
+				logger.Errorf("pageService.AutoClose: Exec() failed for page %s: %v", &page.ID, err)
+				return cnt, translateDBErrors(err)
+			} else if i, err := res.RowsAffected(); err != nil || i == 0 {
+				continue
+			}
+			cnt++
+
+			// Record the automatic closing in the moderation log, without an actor
+			_ = TheModerationLogService.Add(data.NewModerationLogEntry(models.ModerationActionPageLock, nil).
+				WithPage(page).
+				WithValues("false", "true").
+				WithReason(fmt.Sprintf("Closed automatically after %d days", d.AutoCloseDays)))
+		}
+	}
+
+	// Succeeded
+	return cnt, nil
+}
+
 func (svc *pageService) CommentCounts(domainID *uuid.UUID, paths []string) (map[string]int, error) {
 	logger.Debugf("pageService.CommentCounts(%s, [%d items])", domainID, len(paths))
 
@@ -293,6 +375,11 @@ func (svc *pageService) QueueFetchUpdatePageTitle(domain *data.Domain, page *dat
 func (svc *pageService) Update(page *data.DomainPage) error {
 	logger.Debugf("pageService.Update(%#v)", page)
 
+	// Notify plugins, letting them alter the page
+	if _, err := handlePageEvent(&plugin.PageUpdateEvent{}, page, nil); err != nil {
+		return err
+	}
+
 	// Update the page record
 	if err := db.ExecOne(db.Update("cm_domain_pages").Set(page).Where(goqu.Ex{"id": &page.ID})); err != nil {
 		logger.Errorf("pageService.Update: ExecOne() failed: %v", err)
@@ -336,8 +423,23 @@ func (svc *pageService) UpsertByDomainPath(domain *data.Domain, path, title stri
 	if added {
 		logger.Debug("pageService.UpsertByDomainPath: page didn't exist, created a new one with ID=%s", &pResult.ID)
 
+		// Notify plugins, letting them alter the page
+		if changed, err := handlePageEvent(&plugin.PageCreateEvent{}, &pResult, domain); err != nil {
+			return nil, false, err
+		} else if changed {
+			// The page was modified while handling the event: we need to save it
+			if err := db.ExecOne(
+				db.Update("cm_domain_pages").
+					Set(goqu.Record{"title": pResult.Title, "is_readonly": pResult.IsReadonly}).
+					Where(goqu.Ex{"id": &pResult.ID}),
+			); err != nil {
+				logger.Errorf("pageService.UpsertByDomainPath: ExecOne() failed: %v", err)
+				return nil, false, translateDBErrors(err)
+			}
+		}
+
 		// If no title was provided, fetch it in the background, ignoring possible errors
-		if title == "" {
+		if pResult.Title == "" {
 			svc.QueueFetchUpdatePageTitle(domain, &pResult)
 		}
 	}
@@ -379,6 +481,48 @@ func (svc *pageService) insertPageView(pageID *uuid.UUID, req *http.Request) {
 	}
 }
 
+// handlePageEvent fires the given page event with the page and its domain as a payload, and applies any page changes
+// made by plugins back to the passed page. domain is optional and gets looked up if nil
+func handlePageEvent[E plugin.PagePayload](e E, p *data.DomainPage, domain *data.Domain) (changed bool, err error) {
+	// Skip unless the plugin manager is active
+	if !ThePluginManager.Active() {
+		return
+	}
+
+	// Find the page's domain, if necessary
+	if domain == nil {
+		if domain, err = TheDomainService.FindByID(&p.DomainID); err != nil {
+			return
+		}
+	}
+
+	// Set the event's payload
+	e.SetPage(p.ToPluginDomainPage())
+	e.SetDomain(domain.ToPluginDomain())
+
+	// Make a clone of the original page
+	pc := p.ToPluginDomainPage()
+
+	// Fire an event
+	if err = ThePluginManager.HandleEvent(e); err != nil {
+		return
+	}
+
+	// Make sure no plugin discarded the page or replaced it with another one
+	if pp := e.Page(); pp == nil || pp.ID != pc.ID {
+		logger.Errorf("handlePageEvent: invalid page payload after handling %T", e)
+		err = ErrPluginPayload
+		return
+	}
+
+	// If event handling changed the page, update the working model
+	if *e.Page() != *pc {
+		p.FromPluginDomainPage(e.Page())
+		changed = true
+	}
+	return
+}
+
 //----------------------------------------------------------------------------------------------------------------------
 
 // newPageTitleFetcher creates a new PageTitleFetcher instance
@@ -447,3 +591,45 @@ func (p *pageTitleFetcher) run() {
 		}
 	}
 }
+
+// pageAutoCloseNotify marks the given page, due for automatic closing, readonly and fires a PageUpdateEvent for it,
+// just like Update does. Returns whether the page is still to be closed, i.e. no plugin reverted its readonly flag
+func pageAutoCloseNotify(d *data.Domain, page *data.DomainPage) (bool, error) {
+	page.IsReadonly = true
+	if _, err := handlePageEvent(&plugin.PageUpdateEvent{}, page, d); err != nil {
+		return false, err
+	}
+	return page.IsReadonly, nil
+}
+
+// pageAutoCloseDue returns whether the given page is due for closing under the domain's automatic closing policy as of
+// now. A page is due once the configured number of days has passed since its first comment (firstComment), or since
+// its creation if it has no comments (firstComment is zero), provided its path matches one of the configured prefixes,
+// if any
+func pageAutoCloseDue(d *data.Domain, page *data.DomainPage, firstComment, now time.Time) bool {
+	// Skip if the policy is disabled, or the page is already closed or exempt
+	if d.AutoCloseDays <= 0 || page.IsReadonly || page.NoAutoClose {
+		return false
+	}
+
+	// Check the path against the prefixes, if any
+	if prefixes := strings.Fields(d.AutoClosePaths); len(prefixes) > 0 {
+		matched := false
+		for _, p := range prefixes {
+			if strings.HasPrefix(page.Path, p) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	// Check the cutoff against the first comment, falling back to the page's creation time
+	t := firstComment
+	if t.IsZero() {
+		t = page.CreatedTime
+	}
+	return t.Before(now.AddDate(0, 0, -d.AutoCloseDays))
+}
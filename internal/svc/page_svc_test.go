@@ -0,0 +1,92 @@
+package svc
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/extend/plugin"
+	"gitlab.com/comentario/comentario/internal/data"
+	"testing"
+	"time"
+)
+
+func Test_pageAutoCloseNotify(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(e *plugin.PageUpdateEvent)
+		want    bool
+		wantRO  bool
+		wantErr error
+	}{
+		{"unchanged ", func(*plugin.PageUpdateEvent) {}, true, true, nil},
+		{"reverted  ", func(e *plugin.PageUpdateEvent) { e.Page().IsReadonly = false }, false, false, nil},
+		{"discarded ", func(e *plugin.PageUpdateEvent) { e.SetPage(nil) }, false, true, ErrPluginPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fired bool
+			withStubPluginManager(t, func(event any) {
+				e := event.(*plugin.PageUpdateEvent)
+				fired = e.Page() != nil && e.Page().IsReadonly
+				tt.modify(e)
+			})
+			d := &data.Domain{ID: uuid.New(), AutoCloseDays: 10}
+			p := &data.DomainPage{ID: uuid.New(), DomainID: d.ID, Path: "/blog/"}
+			got, err := pageAutoCloseNotify(d, p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("pageAutoCloseNotify() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("pageAutoCloseNotify() = %v, want %v", got, tt.want)
+			}
+			if !fired {
+				t.Errorf("pageAutoCloseNotify() didn't fire PageUpdateEvent for a readonly page")
+			}
+			if p.IsReadonly != tt.wantRO {
+				t.Errorf("pageAutoCloseNotify(): IsReadonly = %v, want %v", p.IsReadonly, tt.wantRO)
+			}
+		})
+	}
+}
+
+func Test_pageAutoCloseDue(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -11)
+	recent := now.AddDate(0, 0, -9)
+	tests := []struct {
+		name         string
+		days         int
+		paths        string
+		path         string
+		readonly     bool
+		noAutoClose  bool
+		created      time.Time
+		firstComment time.Time
+		want         bool
+	}{
+		{"Policy disabled             ", 0, "", "/", false, false, old, old, false},
+		{"Already readonly            ", 10, "", "/", true, false, old, old, false},
+		{"Exempt                      ", 10, "", "/", false, true, old, old, false},
+		{"No comments, created old    ", 10, "", "/", false, false, old, time.Time{}, true},
+		{"No comments, created recent ", 10, "", "/", false, false, recent, time.Time{}, false},
+		{"Old comment                 ", 10, "", "/", false, false, old, old, true},
+		{"Recent comment on old page  ", 10, "", "/", false, false, old, recent, false},
+		{"Exactly at cutoff           ", 10, "", "/", false, false, old, now.AddDate(0, 0, -10), false},
+		{"Prefix matched              ", 10, "/blog/", "/blog/post", false, false, old, old, true},
+		{"Prefix matched exactly      ", 10, "/blog/", "/blog/", false, false, old, old, true},
+		{"Second prefix matched       ", 10, "/news/  /blog/", "/blog/post", false, false, old, old, true},
+		{"Prefix not matched          ", 10, "/blog/", "/about", false, false, old, old, false},
+		{"Prefix longer than path     ", 10, "/blog/", "/blog", false, false, old, old, false},
+		{"Prefix is case-sensitive    ", 10, "/blog/", "/Blog/post", false, false, old, old, false},
+		{"Prefix matched, recent      ", 10, "/blog/", "/blog/post", false, false, old, recent, false},
+		{"Blank paths mean all pages  ", 10, " \t ", "/anything", false, false, old, old, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &data.Domain{AutoCloseDays: tt.days, AutoClosePaths: tt.paths}
+			p := &data.DomainPage{Path: tt.path, IsReadonly: tt.readonly, NoAutoClose: tt.noAutoClose, CreatedTime: tt.created}
+			if got := pageAutoCloseDue(d, p, tt.firstComment, now); got != tt.want {
+				t.Errorf("pageAutoCloseDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
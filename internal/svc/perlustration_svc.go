@@ -5,13 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/jellydator/ttlcache/v3"
+	"gitlab.com/comentario/comentario/extend/plugin"
 	"gitlab.com/comentario/comentario/internal/api/models"
 	"gitlab.com/comentario/comentario/internal/config"
 	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/util"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -20,9 +26,15 @@ import (
 // ThePerlustrationService is a global PerlustrationService implementation
 var ThePerlustrationService PerlustrationService = &perlustrationService{}
 
+// reDomainExtensionID is a regular expression for validating IDs of plugin-provided domain extensions
+var reDomainExtensionID = regexp.MustCompile(`^[a-zA-Z0-9][-_.a-zA-Z0-9]{0,31}$`)
+
 // commentScanningContext is a context for scanning a comment
 type commentScanningContext struct {
-	Request    *http.Request    // HTTP request sent by the commenter
+	Request    *http.Request    // HTTP request sent by the commenter, nil when reporting feedback
+	UserIP     string           // IP address of the commenter
+	UserAgent  string           // User agent of the commenter's browser
+	Referrer   string           // Referrer of the commenter's request
 	Comment    *data.Comment    // Comment being submitted
 	Domain     *data.Domain     // Comment's domain
 	Page       *data.DomainPage // Comment's domain page
@@ -41,25 +53,71 @@ type CommentScanner interface {
 	Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error)
 }
 
+// CommentScannerFeedback is a CommentScanner that can learn from moderators' decisions
+type CommentScannerFeedback interface {
+	CommentScanner
+	// Feedback reports a moderator's decision contradicting the scanner's verdict on a comment: positive indicates
+	// whether the comment is inappropriate
+	Feedback(config map[string]string, ctx *commentScanningContext, positive bool) error
+}
+
+// CommentScannerLearner is a CommentScanner that learns from all moderators' decisions on comments in the domains it's
+// enabled for, whether or not it scanned those comments
+type CommentScannerLearner interface {
+	CommentScanner
+	// Learn reports a moderator's decision on the given comment: positive indicates whether the comment is
+	// inappropriate
+	Learn(config map[string]string, domain *data.Domain, comment *data.Comment, positive bool) error
+}
+
 // PerlustrationService is a collection of CommentScanners that allows to scan comments against those of them enabled
 // for the given domain
 type PerlustrationService interface {
+	// Feedback asynchronously reports the moderation status of the given comments, skipping pending ones, to the
+	// learning extensions enabled for the domain, and to the extensions that scanned them and support feedback, provided
+	// the status contradicts their verdict
+	Feedback(comments ...*data.Comment)
 	// Init the service
 	Init()
-	// NeedsModeration returns whether the given comment needs to be moderated, and if so, the reason for that
+	// NeedsModeration returns whether the given comment needs to be moderated, and if so, the reason for that. If the
+	// domain's blocklist requires so, the comment's text gets masked, or ErrCommentRejected is returned. req is the
+	// commenter's request, nil if the comment doesn't come from a client (for instance, it's created by a plugin)
 	NeedsModeration(
 		req *http.Request, comment *data.Comment, domain *data.Domain, page *data.DomainPage, user *data.User,
 		domainUser *data.DomainUser, isEdit bool) (bool, string, error)
+	// ResetBlocklist drops the compiled blocklist cached for the domain with the given ID, which must be done whenever
+	// the domain's extension configuration changes
+	ResetBlocklist(domainID *uuid.UUID)
 }
 
 //----------------------------------------------------------------------------------------------------------------------
 
 // perlustrationService is a blueprint PerlustrationService implementation
 type perlustrationService struct {
-	scanners []CommentScanner
+	scanners  []CommentScanner
+	blocklist *blocklistScanner // Blocklist scanner, nil if disabled
 }
 
 func (svc *perlustrationService) Init() {
+	// Blocklist. It isn't registered among the scanners as it needs to be applied before any policy checks
+	if !config.SecretsConfig.Extensions.Blocklist.Disable {
+		logger.Info("Registering Blocklist extension")
+		svc.blocklist = newBlocklistScanner()
+		x := data.DomainExtensions[svc.blocklist.ID()]
+		x.Enabled = true
+		x.KeyProvided = svc.blocklist.KeyProvided()
+	}
+
+	// Flood Control and Bayesian Filter. Registered first as they work offline
+	if !config.SecretsConfig.Extensions.FloodControl.Disable {
+		logger.Info("Registering Flood Control extension")
+		svc.scanners = append(svc.scanners, &floodControlScanner{})
+	}
+	if !config.SecretsConfig.Extensions.Bayes.Disable {
+		logger.Info("Registering Bayesian Filter extension")
+		svc.scanners = append(svc.scanners, &bayesScanner{})
+	}
+
 	// Akismet
 	ak := config.SecretsConfig.Extensions.Akismet
 	if !ak.Disable {
@@ -81,6 +139,13 @@ func (svc *perlustrationService) Init() {
 		svc.scanners = append(svc.scanners, &apiLayerSpamCheckerScanner{apiScanner{apiKey: asck.Key}})
 	}
 
+	// Plugin-provided scanners
+	for pluginID, cfg := range ThePluginManager.PluginConfigs() {
+		for _, ps := range cfg.CommentScanners {
+			svc.registerPluginScanner(pluginID, ps)
+		}
+	}
+
 	// Enable/update corresponding extensions in the config
 	for _, scanner := range svc.scanners {
 		x := data.DomainExtensions[scanner.ID()]
@@ -89,6 +154,26 @@ func (svc *perlustrationService) Init() {
 	}
 }
 
+func (svc *perlustrationService) Feedback(comments ...*data.Comment) {
+	// Make copies of the comments as they're going to be used in a goroutine. Pending comments aren't decided upon yet
+	var cs []data.Comment
+	for _, c := range comments {
+		if !c.IsPending {
+			cs = append(cs, *c)
+		}
+	}
+	if len(cs) == 0 {
+		return
+	}
+
+	// Process the comments one by one
+	go func() {
+		for i := range cs {
+			svc.feedback(&cs[i], !cs[i].IsApproved)
+		}
+	}()
+}
+
 func (svc *perlustrationService) NeedsModeration(
 	req *http.Request, comment *data.Comment, domain *data.Domain, page *data.DomainPage, user *data.User,
 	domainUser *data.DomainUser, isEdit bool) (bool, string, error) {
@@ -98,6 +183,33 @@ func (svc *perlustrationService) NeedsModeration(
 		return false, "", nil
 	}
 
+	// Prepare a scanning context, capturing the client details if there's a request
+	ctx := &commentScanningContext{
+		Request:    req,
+		Comment:    comment,
+		Domain:     domain,
+		Page:       page,
+		User:       user,
+		DomainUser: domainUser,
+		IsEdit:     isEdit,
+	}
+	if req != nil {
+		ctx.UserIP = util.UserIP(req)
+		ctx.UserAgent = util.UserAgent(req)
+		ctx.Referrer = req.Header.Get("Referer")
+	}
+
+	// Fetch domain extensions
+	extensions, err := TheDomainService.ListDomainExtensions(&domain.ID)
+	if err != nil {
+		return false, "", err
+	}
+
+	// Apply the blocklist first, so that masking and rejection happen regardless of the domain policy
+	if b, reason, err := svc.applyBlocklist(extensions, ctx); err != nil || b {
+		return b, reason, err
+	}
+
 	// If it's a new comment, check domain moderation policy
 	if !isEdit {
 		switch user.IsAnonymous() {
@@ -132,24 +244,12 @@ func (svc *perlustrationService) NeedsModeration(
 	}
 
 	// Check link/image moderation policy
-	html := strings.ToLower(comment.HTML)
-	if domain.ModLinks && strings.Contains(html, "<a") {
-		return true, "Comment contains a link", nil
-	} else if domain.ModImages && strings.Contains(html, "<img") {
-		return true, "Comment contains an image", nil
+	if reason := linkModerationReason(domain, comment.HTML); reason != "" {
+		return true, reason, nil
 	}
 
 	// Test the comment against online checkers
-	ctx := &commentScanningContext{
-		Request:    req,
-		Comment:    comment,
-		Domain:     domain,
-		Page:       page,
-		User:       user,
-		DomainUser: domainUser,
-		IsEdit:     isEdit,
-	}
-	if b, reason, err := svc.scan(ctx); b && err == nil {
+	if b, reason, err := svc.scan(extensions, ctx); b && err == nil {
 		// Don't consider inappropriate if an error occurred
 		return true, reason, nil
 	}
@@ -158,33 +258,79 @@ func (svc *perlustrationService) NeedsModeration(
 	return false, "", nil
 }
 
-// Scan scans the provided comment for inappropriate content and returns whether it was found
-func (svc *perlustrationService) scan(ctx *commentScanningContext) (bool, string, error) {
-	// Fetch domain extensions
-	extensions, err := TheDomainService.ListDomainExtensions(&ctx.Domain.ID)
-	if err != nil {
-		return false, "", err
+func (svc *perlustrationService) ResetBlocklist(domainID *uuid.UUID) {
+	if svc.blocklist != nil {
+		svc.blocklist.cache.Delete(*domainID)
+	}
+}
+
+// registerPluginScanner registers a domain extension for the given comment scanner provided by the plugin with the
+// specified ID, skipping the scanner if its ID is invalid or already taken
+func (svc *perlustrationService) registerPluginScanner(pluginID string, ps plugin.CommentScanner) {
+	id := models.DomainExtensionID(ps.ID())
+	if !reDomainExtensionID.MatchString(string(id)) {
+		logger.Warningf("Plugin %q provided a comment scanner with invalid ID %q, skipping", pluginID, id)
+		return
+	} else if _, ok := data.DomainExtensions[id]; ok {
+		logger.Warningf("Plugin %q provided a comment scanner with duplicate ID %q, skipping", pluginID, id)
+		return
+	}
+
+	// Register a new domain extension for the scanner
+	logger.Infof("Registering extension %q provided by plugin %q", id, pluginID)
+	data.DomainExtensions[id] = &data.DomainExtension{ID: id, Name: ps.Name(), Config: ps.DefaultConfig()}
+	if fs, ok := ps.(plugin.CommentScannerFeedback); ok {
+		svc.scanners = append(svc.scanners, &pluginFeedbackScanner{pluginScanner{ps: ps}, fs})
+	} else {
+		svc.scanners = append(svc.scanners, &pluginScanner{ps: ps})
+	}
+}
+
+// applyBlocklist checks the provided comment against the domain's blocklist, if it's enabled for the domain. Masks the
+// comment text if needed, and returns whether the comment needs moderation and the reason for that, or
+// ErrCommentRejected if it must be rejected
+func (svc *perlustrationService) applyBlocklist(extensions []*data.DomainExtension, ctx *commentScanningContext) (bool, string, error) {
+	if svc.blocklist == nil {
+		return false, "", nil
 	}
+	if ex := findDomainExtension(extensions, svc.blocklist.ID()); ex != nil {
+		return svc.blocklist.Scan(ex.ConfigParams(), ctx)
+	}
+	return false, "", nil
+}
 
+// scan scans the provided comment for inappropriate content and returns whether it was found
+func (svc *perlustrationService) scan(extensions []*data.DomainExtension, ctx *commentScanningContext) (bool, string, error) {
 	// Iterate known comment scanners
 	var lastErr error
 	for _, cs := range svc.scanners {
-		// Check if the scanner is enabled for the domain by searching for the corresponding extension
-		var ex *data.DomainExtension
-		for _, _ex := range extensions {
-			if _ex.ID == cs.ID() {
-				ex = _ex
-				break
-			}
-		}
-
-		// Scan and skip over a failed scanner
-		if ex != nil {
+		// Scan if the scanner is enabled for the domain, and skip over a failed scanner
+		if ex := findDomainExtension(extensions, cs.ID()); ex != nil {
 			if b, reason, err := cs.Scan(ex.ConfigParams(), ctx); err != nil {
 				lastErr = err
-			} else if b {
+			} else {
+				// Record the verdict if the scanner can learn from moderators' decisions. The IP address is stored
+				// masked, unless IP addresses are logged in full
+				if _, ok := cs.(CommentScannerFeedback); ok {
+					ip := ctx.UserIP
+					if !config.ServerConfig.LogFullIPs {
+						ip = util.MaskIP(ip)
+					}
+					ctx.Comment.Scans = append(ctx.Comment.Scans, &data.CommentScan{
+						CommentID:   ctx.Comment.ID,
+						ExtensionID: cs.ID(),
+						IsPositive:  b,
+						UserIP:      ip,
+						UserAgent:   util.TruncateStr(ctx.UserAgent, 1024),
+						Referrer:    util.TruncateStr(ctx.Referrer, 2083),
+						CreatedTime: time.Now().UTC(),
+					})
+				}
+
 				// Exit on a first positive
-				return true, reason, nil
+				if b {
+					return true, reason, nil
+				}
 			}
 		}
 	}
@@ -193,6 +339,153 @@ func (svc *perlustrationService) scan(ctx *commentScanningContext) (bool, string
 	return false, "", lastErr
 }
 
+// feedback lets the extensions enabled for the comment's domain learn from the given moderator's decision on the
+// comment, and reports the decision to the extensions whose recorded verdict contradicts it, updating the verdict
+// accordingly
+func (svc *perlustrationService) feedback(comment *data.Comment, positive bool) {
+	logger.Debugf("perlustrationService.feedback(%s, %v)", comment.ID, positive)
+
+	// Fetch the comment's page, domain, and its extensions
+	page, err := ThePageService.FindByID(&comment.PageID)
+	if err != nil {
+		return
+	}
+	domain, err := TheDomainService.FindByID(&page.DomainID)
+	if err != nil {
+		return
+	}
+	extensions, err := TheDomainService.ListDomainExtensions(&domain.ID)
+	if err != nil {
+		return
+	}
+
+	// Let enabled learning scanners learn from the decision
+	for _, cs := range svc.scanners {
+		if l, ok := cs.(CommentScannerLearner); ok {
+			if ex := findDomainExtension(extensions, cs.ID()); ex != nil {
+				if err := l.Learn(ex.ConfigParams(), domain, comment, positive); err != nil {
+					logger.Warningf("perlustrationService.feedback: extension %q failed to learn: %v", cs.ID(), err)
+				}
+			}
+		}
+	}
+
+	// Fetch contradicting scans of the comment
+	var scans []*data.CommentScan
+	if err := db.From("cm_comment_scans").
+		Where(goqu.Ex{"comment_id": &comment.ID, "is_positive": !positive}).
+		ScanStructs(&scans); err != nil {
+		logger.Errorf("perlustrationService.feedback: ScanStructs() failed: %v", err)
+		return
+	} else if len(scans) == 0 {
+		return
+	}
+
+	// Fetch the comment author, falling back to anonymous if there's none
+	user := data.AnonymousUser
+	if comment.UserCreated.Valid {
+		if user, err = TheUserService.FindUserByID(&comment.UserCreated.UUID); err != nil {
+			return
+		}
+	}
+
+	// Iterate the scans
+	for _, scan := range scans {
+		// Make sure the scanner is (still) registered and enabled for the domain
+		ex := findDomainExtension(extensions, scan.ExtensionID)
+		fs := svc.feedbackScanner(scan.ExtensionID)
+		if ex == nil || fs == nil {
+			continue
+		}
+
+		// Report the decision
+		ctx := &commentScanningContext{
+			UserIP:    scan.UserIP,
+			UserAgent: scan.UserAgent,
+			Referrer:  scan.Referrer,
+			Comment:   comment,
+			Domain:    domain,
+			Page:      page,
+			User:      user,
+		}
+		if err := fs.Feedback(ex.ConfigParams(), ctx, positive); err != nil {
+			logger.Warningf("perlustrationService.feedback: reporting to extension %q failed: %v", scan.ExtensionID, err)
+			continue
+		}
+
+		// Update the verdict so that the decision isn't reported again
+		if err := db.ExecOne(
+			db.Update("cm_comment_scans").
+				Set(goqu.Record{"is_positive": positive}).
+				Where(goqu.Ex{"comment_id": &comment.ID, "extension_id": scan.ExtensionID}),
+		); err != nil {
+			logger.Errorf("perlustrationService.feedback: ExecOne() failed: %v", err)
+		}
+	}
+}
+
+// feedbackScanner returns a registered scanner with the given ID if it supports feedback, otherwise nil
+func (svc *perlustrationService) feedbackScanner(id models.DomainExtensionID) CommentScannerFeedback {
+	for _, cs := range svc.scanners {
+		if fs, ok := cs.(CommentScannerFeedback); ok && cs.ID() == id {
+			return fs
+		}
+	}
+	return nil
+}
+
+// findDomainExtension returns an extension with the given ID from the list, or nil if there's none
+func findDomainExtension(extensions []*data.DomainExtension, id models.DomainExtensionID) *data.DomainExtension {
+	for _, ex := range extensions {
+		if ex.ID == id {
+			return ex
+		}
+	}
+	return nil
+}
+
+// linkModerationReason checks the links and images in the given comment HTML against the domain's link moderation
+// policy, and returns the reason for moderation, or an empty string if none is needed. Links and images pointing to a
+// blocked host always require moderation; otherwise, only those not pointing to a trusted host are subject to the
+// ModLinks/ModImages settings. Relative URLs are considered pointing to the domain's own host
+func linkModerationReason(domain *data.Domain, html string) string {
+	links, images := util.HTMLLinkHosts(html)
+	if len(links) == 0 && len(images) == 0 {
+		return ""
+	}
+
+	// Check for blocked hosts first
+	ownHost := util.StripPort(domain.Host)
+	blocked, trusted := util.ParseHostList(domain.ModBlockedHosts), util.ParseHostList(domain.ModTrustedHosts)
+	for _, h := range append(links, images...) {
+		if h == "" {
+			h = ownHost
+		}
+		if util.HostMatches(h, blocked) {
+			return fmt.Sprintf("Comment contains a link to blocked host %s", h)
+		}
+	}
+
+	// Returns whether any of the hosts isn't trusted
+	anyUntrusted := func(hosts []string) bool {
+		for _, h := range hosts {
+			if h == "" {
+				h = ownHost
+			}
+			if !util.HostMatches(h, trusted) {
+				return true
+			}
+		}
+		return false
+	}
+	if domain.ModLinks && anyUntrusted(links) {
+		return "Comment contains a link"
+	} else if domain.ModImages && anyUntrusted(images) {
+		return "Comment contains an image"
+	}
+	return ""
+}
+
 //----------------------------------------------------------------------------------------------------------------------
 
 // apiScanner is a base generic CommentScanner that requires an API key
@@ -206,32 +499,307 @@ func (s *apiScanner) KeyProvided() bool {
 
 //----------------------------------------------------------------------------------------------------------------------
 
+// pluginScanner is a CommentScanner that delegates comment content checking to a plugin-provided scanner
+type pluginScanner struct {
+	ps plugin.CommentScanner
+}
+
+func (s *pluginScanner) ID() models.DomainExtensionID {
+	return models.DomainExtensionID(s.ps.ID())
+}
+
+func (s *pluginScanner) KeyProvided() bool {
+	// Plugin scanners are configured by the plugin itself
+	return true
+}
+
+func (s *pluginScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
+	// Hand over to the plugin
+	return s.ps.Scan(config, s.pluginContext(ctx))
+}
+
+// pluginContext converts the given scanning context into a plugin one
+func (s *pluginScanner) pluginContext(ctx *commentScanningContext) *plugin.CommentScanContext {
+	pctx := &plugin.CommentScanContext{
+		Request:   ctx.Request,
+		UserIP:    ctx.UserIP,
+		UserAgent: ctx.UserAgent,
+		Referrer:  ctx.Referrer,
+		Comment:   ctx.Comment.ToPluginComment(),
+		Domain:    ctx.Domain.ToPluginDomain(),
+		Page:      ctx.Page.ToPluginDomainPage(),
+		User:      ctx.User.ToPluginUser(),
+		IsEdit:    ctx.IsEdit,
+	}
+	if ctx.DomainUser != nil {
+		pctx.DomainUser = ctx.DomainUser.ToPluginDomainUser()
+	}
+	return pctx
+}
+
+// pluginFeedbackScanner is a pluginScanner whose plugin-provided scanner can learn from moderators' decisions
+type pluginFeedbackScanner struct {
+	pluginScanner
+	fs plugin.CommentScannerFeedback
+}
+
+func (s *pluginFeedbackScanner) Feedback(config map[string]string, ctx *commentScanningContext, positive bool) error {
+	return s.fs.Feedback(config, s.pluginContext(ctx), positive)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// blocklistScanner is a CommentScanner that checks comments against a list of words and patterns configured for the
+// domain, entirely offline
+type blocklistScanner struct {
+	cache *ttlcache.Cache[uuid.UUID, *blocklistCacheEntry] // Compiled blocklists per domain ID
+}
+
+// blocklistCacheEntry is a blocklist compiled from the given rules, or the error compiling them produced
+type blocklistCacheEntry struct {
+	rules map[string]string
+	b     *util.Blocklist
+	err   error
+}
+
+// newBlocklistScanner creates a new blocklistScanner
+func newBlocklistScanner() *blocklistScanner {
+	s := &blocklistScanner{
+		cache: ttlcache.New[uuid.UUID, *blocklistCacheEntry](
+			ttlcache.WithTTL[uuid.UUID, *blocklistCacheEntry](util.ConfigCacheTTL),
+		),
+	}
+
+	// Start the cache cleaner
+	go s.cache.Start()
+	return s
+}
+
+func (s *blocklistScanner) ID() models.DomainExtensionID {
+	return data.DomainExtensionIDBlocklist
+}
+
+func (s *blocklistScanner) KeyProvided() bool {
+	// No key is needed
+	return true
+}
+
+func (s *blocklistScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
+	// Get the compiled rules. An invalid config must not block commenting, so only log the error
+	b, err := s.blocklist(&ctx.Domain.ID, config)
+	if err != nil {
+		logger.Warningf("blocklistScanner.Scan: invalid blocklist on domain %s: %v", ctx.Domain.ID, err)
+		return false, "", nil
+	}
+
+	// Check the comment text
+	action, rule, masked := b.Check(ctx.Comment.Markdown)
+	if action == util.BlocklistActionReject {
+		logger.Debugf("blocklistScanner.Scan: comment rejected by rule %q", rule)
+		return false, "", ErrCommentRejected
+	}
+
+	// Apply any masking, re-rendering the comment
+	if masked != ctx.Comment.Markdown {
+		if err := TheCommentService.SetMarkdown(ctx.Comment, masked, &ctx.Domain.ID, nil); err != nil {
+			return false, "", err
+		}
+	}
+
+	// Check if moderation is needed
+	if action == util.BlocklistActionModerate {
+		return true, fmt.Sprintf("Comment matches blocklist rule %q", rule), nil
+	}
+	return false, "", nil
+}
+
+// blocklist returns the blocklist compiled from the given rules of the domain with the given ID, reusing a cached one
+// unless the rules have changed since it was compiled
+func (s *blocklistScanner) blocklist(domainID *uuid.UUID, rules map[string]string) (*util.Blocklist, error) {
+	// Try to find a cached blocklist compiled from the same rules
+	if ci := s.cache.Get(*domainID); ci != nil && maps.Equal(ci.Value().rules, rules) {
+		return ci.Value().b, ci.Value().err
+	}
+
+	// Cache miss: compile the rules, caching any error as well, so that invalid rules don't get recompiled either
+	logger.Debugf("blocklistScanner.blocklist: cache miss for %s", domainID)
+	e := &blocklistCacheEntry{rules: rules}
+	e.b, e.err = util.ParseBlocklist(rules)
+	s.cache.Set(*domainID, e, ttlcache.DefaultTTL)
+	return e.b, e.err
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// floodControlShingleSize is the number of words in a shingle used for comparing comment texts
+const floodControlShingleSize = 3
+
+// floodControlScanner is a CommentScanner that detects near-duplicate comments across the domain and authors posting
+// comments too often, entirely offline
+type floodControlScanner struct{}
+
+func (s *floodControlScanner) ID() models.DomainExtensionID {
+	return data.DomainExtensionIDFloodControl
+}
+
+func (s *floodControlScanner) KeyProvided() bool {
+	// No key is needed
+	return true
+}
+
+func (s *floodControlScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
+	window := time.Duration(util.StrToFloatDef(config["window"], 60) * float64(time.Minute))
+	similarity := util.StrToFloatDef(config["similarity"], 0.8)
+	minWords := int(util.StrToFloatDef(config["minWords"], 5))
+	maxPerMinute := int(util.StrToFloatDef(config["maxPerMinute"], 3))
+
+	// Duplicates only matter for long enough texts, and flooding only for new comments
+	words, shingles := util.TextShingles(ctx.Comment.Markdown, floodControlShingleSize)
+	checkDup := similarity > 0 && window > 0 && words >= minWords
+	checkFlood := maxPerMinute > 0 && !ctx.IsEdit
+	if !checkDup && !checkFlood {
+		return false, "", nil
+	}
+
+	// Fetch comments recent enough for either check
+	now := time.Now().UTC()
+	dupSince, floodSince := now.Add(-window), now.Add(-time.Minute)
+	since := now
+	if checkFlood {
+		since = floodSince
+	}
+	if checkDup && dupSince.Before(since) {
+		since = dupSince
+	}
+	cs, err := TheCommentService.ListRecentByDomain(&ctx.Domain.ID, since)
+	if err != nil {
+		return false, "", err
+	}
+
+	// Iterate the comments, skipping over the one being edited
+	cnt := 0
+	for _, c := range cs {
+		if c.ID == ctx.Comment.ID {
+			continue
+		}
+
+		// Count comments by the same author
+		if checkFlood && c.CreatedTime.After(floodSince) && floodControlSameAuthor(ctx.User, ctx.Comment, c) {
+			if cnt++; cnt >= maxPerMinute {
+				return true, fmt.Sprintf("Author posted over %d comments within a minute", maxPerMinute), nil
+			}
+		}
+
+		// Compare the texts
+		if checkDup && !c.CreatedTime.Before(dupSince) {
+			_, cShingles := util.TextShingles(c.Markdown, floodControlShingleSize)
+			if sim := util.ShingleSimilarity(shingles, cShingles); sim >= similarity {
+				return true, fmt.Sprintf("Comment is %.0f%% similar to another comment posted on the domain", sim*100), nil
+			}
+		}
+	}
+	return false, "", nil
+}
+
+// floodControlSameAuthor returns whether the other comment is by the same author as the given comment by the given user.
+// Registered users are identified by their user ID, anonymous ones by the IP address, so that registered users sharing
+// an IP (e.g. behind a corporate NAT) don't count against each other
+func floodControlSameAuthor(user *data.User, comment, other *data.Comment) bool {
+	if user.IsAnonymous() {
+		return comment.AuthorIP != "" && other.AuthorIP == comment.AuthorIP
+	}
+	return other.UserCreated.Valid && other.UserCreated.UUID == user.ID
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// bayesScanner is a CommentScanner that classifies comments with a naive Bayesian classifier, trained from moderators'
+// decisions on the domain, entirely offline
+type bayesScanner struct{}
+
+func (s *bayesScanner) ID() models.DomainExtensionID {
+	return data.DomainExtensionIDBayes
+}
+
+func (s *bayesScanner) KeyProvided() bool {
+	// No key is needed
+	return true
+}
+
+func (s *bayesScanner) Learn(_ map[string]string, domain *data.Domain, comment *data.Comment, positive bool) error {
+	return TheSpamClassifierService.Learn(&domain.ID, &comment.ID, comment.Markdown, positive)
+}
+
+func (s *bayesScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
+	threshold := util.StrToFloatDef(config["threshold"], 0.9)
+	minLearnt := int(util.StrToFloatDef(config["minLearnt"], 20))
+
+	// Classify the comment
+	p, cntSpam, cntHam, err := TheSpamClassifierService.Classify(&ctx.Domain.ID, ctx.Comment.Markdown)
+	if err != nil {
+		return false, "", err
+	}
+
+	// The classifier can't be relied upon until it has learnt enough of both spam and legitimate comments
+	if cntSpam < minLearnt || cntHam < minLearnt {
+		return false, "", nil
+	}
+	if p >= threshold {
+		return true, fmt.Sprintf("Bayesian filter spam threshold (%v) exceeded (actual value %.3f)", threshold, p), nil
+	}
+	return false, "", nil
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
 // akismetScanner is a CommentScanner that uses Akismet for comment content checking
 type akismetScanner struct {
 	apiScanner
 }
 
 func (s *akismetScanner) ID() models.DomainExtensionID {
-	return models.DomainExtensionIDAkismet
+	return data.DomainExtensionIDAkismet
+}
+
+func (s *akismetScanner) Feedback(config map[string]string, ctx *commentScanningContext, positive bool) error {
+	_, err := s.call(config, ctx, util.If(positive, "submit-spam", "submit-ham"))
+	return err
 }
 
 func (s *akismetScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
+	resp, err := s.call(config, ctx, "comment-check")
+	if err != nil {
+		return false, "", err
+	}
+
+	// Check the content
+	switch resp {
+	case "true":
+		return true, "Akismet identified the comment as spam", nil
+	case "false":
+		return false, "", nil
+	}
+	return false, "", fmt.Errorf("failed to call Akismet API: %s", resp)
+}
+
+// call submits the comment to the given Akismet API method and returns the response body
+func (s *akismetScanner) call(config map[string]string, ctx *commentScanningContext, method string) (string, error) {
 	// Check if the service is usable: the locally configured API key takes precedence
 	apiKey := config["apiKey"]
 	if apiKey == "" {
 		apiKey = s.apiKey
 	}
 	if apiKey == "" {
-		return false, "", errors.New("no Akismet API key configured")
+		return "", errors.New("no Akismet API key configured")
 	}
 
 	// Prepare a request
 	d := url.Values{
 		"api_key":              {apiKey},
 		"blog":                 {ctx.Domain.RootURL()},
-		"user_ip":              {util.UserIP(ctx.Request)},
-		"user_agent":           {util.UserAgent(ctx.Request)},
-		"referrer":             {ctx.Request.Header.Get("Referer")},
+		"user_ip":              {ctx.UserIP},
+		"user_agent":           {ctx.UserAgent},
+		"referrer":             {ctx.Referrer},
 		"permalink":            {ctx.Domain.RootURL() + ctx.Page.Path},
 		"comment_type":         {"comment"},
 		"comment_author":       {ctx.User.Name},
@@ -248,34 +816,31 @@ func (s *akismetScanner) Scan(config map[string]string, ctx *commentScanningCont
 	// Submit the form to Akismet
 	client := &http.Client{}
 	dataStr := d.Encode()
-	logger.Debugf("Submitting comment to Akismet: %s", dataStr)
-	rq, err := http.NewRequest("POST", "https://rest.akismet.com/1.1/comment-check", strings.NewReader(dataStr))
+	logger.Debugf("Submitting comment to Akismet %s: %s", method, dataStr)
+	rq, err := http.NewRequest("POST", "https://rest.akismet.com/1.1/"+method, strings.NewReader(dataStr))
 	if err != nil {
-		return false, "", err
+		return "", err
 	}
 	rq.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	rq.Header.Add("Content-Length", strconv.Itoa(len(dataStr)))
 	resp, err := client.Do(rq)
 	if err != nil {
-		return false, "", err
+		return "", err
 	}
-	defer util.LogError(resp.Body.Close, "akismetScanner.Scan, resp.Body.Close()")
+	defer util.LogError(resp.Body.Close, "akismetScanner.call, resp.Body.Close()")
 
 	// Fetch the response
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return false, "", err
+		return "", err
 	}
 	logger.Debugf("Akismet response: %s", respBody)
 
-	// Check the content
-	switch string(respBody) {
-	case "true":
-		return true, "Akismet identified the comment as spam", nil
-	case "false":
-		return false, "", nil
+	// Check the status
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("call to Akismet %s failed with status %d: %s", method, resp.StatusCode, respBody)
 	}
-	return false, "", fmt.Errorf("failed to call Akismet API: %s", respBody)
+	return string(respBody), nil
 }
 
 //----------------------------------------------------------------------------------------------------------------------
@@ -303,7 +868,7 @@ type perspectiveResponse struct {
 }
 
 func (s *perspectiveScanner) ID() models.DomainExtensionID {
-	return models.DomainExtensionIDPerspective
+	return data.DomainExtensionIDPerspective
 }
 
 func (s *perspectiveScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
@@ -427,7 +992,7 @@ type apiLayerSpamCheckerResponse struct {
 }
 
 func (s *apiLayerSpamCheckerScanner) ID() models.DomainExtensionID {
-	return models.DomainExtensionIDAPILayerDotSpamChecker
+	return data.DomainExtensionIDAPILayerDotSpamChecker
 }
 
 func (s *apiLayerSpamCheckerScanner) Scan(config map[string]string, ctx *commentScanningContext) (bool, string, error) {
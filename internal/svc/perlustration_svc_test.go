@@ -0,0 +1,491 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/extend/plugin"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/config"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/util"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_linkModerationReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain data.Domain
+		html   string
+		want   string
+	}{
+		{"No links", data.Domain{ModLinks: true, ModImages: true}, "<p>Hi</p>", ""},
+		{"Link, no policy", data.Domain{}, `<a href="https://spam.com">x</a>`, ""},
+		{"Link, ModLinks", data.Domain{ModLinks: true}, `<a href="https://spam.com">x</a>`, "Comment contains a link"},
+		{"Link, trusted", data.Domain{ModLinks: true, ModTrustedHosts: "example.com, wiki.org"}, `<a href="https://en.wiki.org/x">x</a>`, ""},
+		{"Links, one untrusted", data.Domain{ModLinks: true, ModTrustedHosts: "wiki.org"}, `<a href="https://wiki.org">x</a><a href="http://spam.com">y</a>`, "Comment contains a link"},
+		{"Relative link, own host", data.Domain{Host: "example.com:8080", ModLinks: true, ModTrustedHosts: "example.com"}, `<a href="/page">x</a>`, ""},
+		{"Image, ModImages", data.Domain{ModImages: true}, `<img src="https://img.com/a.png">`, "Comment contains an image"},
+		{"Image, trusted", data.Domain{ModImages: true, ModTrustedHosts: "img.com"}, `<img src="https://img.com/a.png">`, ""},
+		{"Blocked, no policy", data.Domain{ModBlockedHosts: "spam.com"}, `<a href="https://www.spam.com">x</a>`, "Comment contains a link to blocked host www.spam.com"},
+		{"Blocked image", data.Domain{ModBlockedHosts: "spam.com"}, `<img src="https://spam.com/a.png">`, "Comment contains a link to blocked host spam.com"},
+		{"Blocked wins trusted", data.Domain{ModTrustedHosts: "spam.com", ModBlockedHosts: "spam.com"}, `<a href="https://spam.com">x</a>`, "Comment contains a link to blocked host spam.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkModerationReason(&tt.domain, tt.html); got != tt.want {
+				t.Errorf("linkModerationReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_floodControlSameAuthor(t *testing.T) {
+	user := &data.User{ID: uuid.MustParse("3f6dc70b-3f4e-4a4b-9b4e-1d0b8d6c3c5a")}
+	other := &data.User{ID: uuid.MustParse("d6a4a2f5-6a6e-4a8e-8f3c-6a4b7d2e1f0c")}
+	by := func(u *data.User, ip string) *data.Comment {
+		return &data.Comment{UserCreated: uuid.NullUUID{UUID: u.ID, Valid: true}, AuthorIP: ip}
+	}
+	tests := []struct {
+		name    string
+		user    *data.User
+		comment *data.Comment
+		other   *data.Comment
+		want    bool
+	}{
+		{"registered, same user, same IP    ", user, by(user, "1.2.3.4"), by(user, "1.2.3.4"), true},
+		{"registered, same user, other IP   ", user, by(user, "1.2.3.4"), by(user, "5.6.7.8"), true},
+		{"registered, other user, same IP   ", user, by(user, "1.2.3.4"), by(other, "1.2.3.4"), false},
+		{"registered, anonymous, same IP    ", user, by(user, "1.2.3.4"), by(data.AnonymousUser, "1.2.3.4"), false},
+		{"registered, no author             ", user, by(user, "1.2.3.4"), &data.Comment{AuthorIP: "1.2.3.4"}, false},
+		{"anonymous, same IP                ", data.AnonymousUser, by(data.AnonymousUser, "1.2.3.4"), by(data.AnonymousUser, "1.2.3.4"), true},
+		{"anonymous, registered, same IP    ", data.AnonymousUser, by(data.AnonymousUser, "1.2.3.4"), by(user, "1.2.3.4"), true},
+		{"anonymous, other IP               ", data.AnonymousUser, by(data.AnonymousUser, "1.2.3.4"), by(data.AnonymousUser, "5.6.7.8"), false},
+		{"anonymous, no IP                  ", data.AnonymousUser, by(data.AnonymousUser, ""), by(data.AnonymousUser, ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := floodControlSameAuthor(tt.user, tt.comment, tt.other); got != tt.want {
+				t.Errorf("floodControlSameAuthor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubScanner is a CommentScanner returning a predefined verdict
+type stubScanner struct {
+	id       models.DomainExtensionID
+	positive bool
+	err      error
+	scanned  bool
+}
+
+func (s *stubScanner) ID() models.DomainExtensionID {
+	return s.id
+}
+
+func (s *stubScanner) KeyProvided() bool {
+	return true
+}
+
+func (s *stubScanner) Scan(map[string]string, *commentScanningContext) (bool, string, error) {
+	s.scanned = true
+	return s.positive, string(s.id) + " says so", s.err
+}
+
+// stubFeedbackScanner is a stubScanner that supports feedback
+type stubFeedbackScanner struct {
+	stubScanner
+}
+
+func (s *stubFeedbackScanner) Feedback(map[string]string, *commentScanningContext, bool) error {
+	return nil
+}
+
+func Test_perlustrationService_scan(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name       string
+		logFullIPs bool
+		scanners   []CommentScanner
+		enabled    []models.DomainExtensionID
+		wantB      bool
+		wantReason string
+		wantErr    error
+		wantScans  []string // "<extension ID>:<verdict>"
+		wantIP     string
+	}{
+		{
+			"No scanners enabled                 ",
+			false,
+			[]CommentScanner{&stubFeedbackScanner{stubScanner{id: "fb", positive: true}}},
+			nil,
+			false, "", nil, nil, "",
+		},
+		{
+			"Non-feedback scanner records nothing",
+			false,
+			[]CommentScanner{&stubScanner{id: "plain"}},
+			[]models.DomainExtensionID{"plain"},
+			false, "", nil, nil, "",
+		},
+		{
+			"Negative verdict recorded, masked IP",
+			false,
+			[]CommentScanner{&stubFeedbackScanner{stubScanner{id: "fb"}}},
+			[]models.DomainExtensionID{"fb"},
+			false, "", nil, []string{"fb:false"}, "192.168.x.x",
+		},
+		{
+			"Negative verdict recorded, full IP  ",
+			true,
+			[]CommentScanner{&stubFeedbackScanner{stubScanner{id: "fb"}}},
+			[]models.DomainExtensionID{"fb"},
+			false, "", nil, []string{"fb:false"}, "192.168.1.42",
+		},
+		{
+			"Positive verdict stops scanning     ",
+			false,
+			[]CommentScanner{
+				&stubFeedbackScanner{stubScanner{id: "fb1", positive: true}},
+				&stubFeedbackScanner{stubScanner{id: "fb2"}},
+			},
+			[]models.DomainExtensionID{"fb1", "fb2"},
+			true, "fb1 says so", nil, []string{"fb1:true"}, "192.168.x.x",
+		},
+		{
+			"Failed scan isn't recorded          ",
+			false,
+			[]CommentScanner{
+				&stubFeedbackScanner{stubScanner{id: "fb1", err: failed}},
+				&stubFeedbackScanner{stubScanner{id: "fb2"}},
+			},
+			[]models.DomainExtensionID{"fb1", "fb2"},
+			false, "", failed, []string{"fb2:false"}, "192.168.x.x",
+		},
+		{
+			"Disabled scanner skipped            ",
+			false,
+			[]CommentScanner{
+				&stubFeedbackScanner{stubScanner{id: "fb1", positive: true}},
+				&stubFeedbackScanner{stubScanner{id: "fb2"}},
+			},
+			[]models.DomainExtensionID{"fb2"},
+			false, "", nil, []string{"fb2:false"}, "192.168.x.x",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFull := config.ServerConfig.LogFullIPs
+			config.ServerConfig.LogFullIPs = tt.logFullIPs
+			t.Cleanup(func() { config.ServerConfig.LogFullIPs = logFull })
+
+			var exts []*data.DomainExtension
+			for _, id := range tt.enabled {
+				exts = append(exts, &data.DomainExtension{ID: id})
+			}
+			c := &data.Comment{ID: uuid.New()}
+			ctx := &commentScanningContext{
+				UserIP:    "192.168.1.42",
+				UserAgent: strings.Repeat("a", 1100),
+				Referrer:  "https://example.com/",
+				Comment:   c,
+			}
+			svc := &perlustrationService{scanners: tt.scanners}
+			b, reason, err := svc.scan(exts, ctx)
+			if b != tt.wantB || reason != tt.wantReason || !errors.Is(err, tt.wantErr) {
+				t.Errorf("scan() = (%v, %q, %v), want (%v, %q, %v)", b, reason, err, tt.wantB, tt.wantReason, tt.wantErr)
+			}
+
+			// Verify the recorded scans
+			var got []string
+			for _, s := range c.Scans {
+				got = append(got, fmt.Sprintf("%s:%v", s.ExtensionID, s.IsPositive))
+				if s.CommentID != c.ID {
+					t.Errorf("scan CommentID = %v, want %v", s.CommentID, c.ID)
+				}
+				if s.UserIP != tt.wantIP {
+					t.Errorf("scan UserIP = %q, want %q", s.UserIP, tt.wantIP)
+				}
+				if len(s.UserAgent) > 1024 {
+					t.Errorf("scan UserAgent is %d bytes long, want at most 1024", len(s.UserAgent))
+				}
+				if s.Referrer != ctx.Referrer {
+					t.Errorf("scan Referrer = %q, want %q", s.Referrer, ctx.Referrer)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantScans) {
+				t.Errorf("scan() recorded scans %v, want %v", got, tt.wantScans)
+			}
+		})
+	}
+}
+
+func Test_perlustrationService_feedbackScanner(t *testing.T) {
+	plain := &stubScanner{id: "plain"}
+	fb := &stubFeedbackScanner{stubScanner{id: "fb"}}
+	svc := &perlustrationService{scanners: []CommentScanner{plain, fb}}
+	tests := []struct {
+		name string
+		id   models.DomainExtensionID
+		want CommentScannerFeedback
+	}{
+		{"feedback scanner    ", "fb", fb},
+		{"non-feedback scanner", "plain", nil},
+		{"unknown scanner     ", "other", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := svc.feedbackScanner(tt.id); got != tt.want {
+				t.Errorf("feedbackScanner() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubPluginScanner is a plugin.CommentScanner that records the contexts it's handed over
+type stubPluginScanner struct {
+	id       string
+	positive bool
+	ctx      *plugin.CommentScanContext
+	config   map[string]string
+}
+
+func (s *stubPluginScanner) ID() string {
+	return s.id
+}
+
+func (s *stubPluginScanner) Name() string {
+	return "Stub " + s.id
+}
+
+func (s *stubPluginScanner) DefaultConfig() string {
+	return "level=3"
+}
+
+func (s *stubPluginScanner) Scan(config map[string]string, ctx *plugin.CommentScanContext) (bool, string, error) {
+	s.config, s.ctx = config, ctx
+	return s.positive, "stub verdict", nil
+}
+
+// stubPluginFeedbackScanner is a stubPluginScanner that supports feedback
+type stubPluginFeedbackScanner struct {
+	stubPluginScanner
+	fedPositive *bool
+}
+
+func (s *stubPluginFeedbackScanner) Feedback(config map[string]string, ctx *plugin.CommentScanContext, positive bool) error {
+	s.config, s.ctx, s.fedPositive = config, ctx, &positive
+	return nil
+}
+
+func Test_pluginFeedbackScanner_Feedback(t *testing.T) {
+	ps := &stubPluginFeedbackScanner{stubPluginScanner: stubPluginScanner{id: "acme"}}
+	s := &pluginFeedbackScanner{pluginScanner{ps: ps}, ps}
+
+	// Report a decision the way perlustrationService.feedback does, using the details stored with the scan
+	scan := &data.CommentScan{ExtensionID: "acme", UserIP: "192.168.x.x", UserAgent: "Firefox", Referrer: "https://example.com/"}
+	ctx := &commentScanningContext{
+		UserIP:    scan.UserIP,
+		UserAgent: scan.UserAgent,
+		Referrer:  scan.Referrer,
+		Comment:   &data.Comment{ID: uuid.New(), Markdown: "Buy now"},
+		Domain:    &data.Domain{ID: uuid.New()},
+		Page:      &data.DomainPage{ID: uuid.New(), Path: "/"},
+		User:      data.AnonymousUser,
+	}
+	if err := s.Feedback(map[string]string{"level": "5"}, ctx, true); err != nil {
+		t.Fatalf("Feedback() error = %v", err)
+	}
+
+	// Verify the plugin got the stored details, the configuration, and the decision
+	if ps.fedPositive == nil || !*ps.fedPositive {
+		t.Errorf("Feedback() reported positive = %v, want true", ps.fedPositive)
+	}
+	if ps.config["level"] != "5" {
+		t.Errorf("Feedback() config = %v, want level=5", ps.config)
+	}
+	pc := ps.ctx
+	if pc.Request != nil {
+		t.Errorf("Feedback() ctx.Request = %v, want nil", pc.Request)
+	}
+	if pc.UserIP != "192.168.x.x" || pc.UserAgent != "Firefox" || pc.Referrer != "https://example.com/" {
+		t.Errorf("Feedback() ctx = (%q, %q, %q), want the scan's details", pc.UserIP, pc.UserAgent, pc.Referrer)
+	}
+	if pc.Comment.ID != ctx.Comment.ID || pc.Comment.Markdown != "Buy now" {
+		t.Errorf("Feedback() ctx.Comment = %#v, want comment %v", pc.Comment, ctx.Comment.ID)
+	}
+	if pc.DomainUser != nil {
+		t.Errorf("Feedback() ctx.DomainUser = %#v, want nil", pc.DomainUser)
+	}
+}
+
+func Test_perlustrationService_registerPluginScanner(t *testing.T) {
+	tests := []struct {
+		name         string
+		ps           plugin.CommentScanner
+		wantExt      bool
+		wantFeedback bool
+	}{
+		{"valid ID                ", &stubPluginScanner{id: "acme.spam-filter_2"}, true, false},
+		{"valid ID, feedback      ", &stubPluginFeedbackScanner{stubPluginScanner: stubPluginScanner{id: "acme"}}, true, true},
+		{"empty ID                ", &stubPluginScanner{id: ""}, false, false},
+		{"ID starting with a dot  ", &stubPluginScanner{id: ".acme"}, false, false},
+		{"ID with invalid chars   ", &stubPluginScanner{id: "acme/spam"}, false, false},
+		{"ID too long             ", &stubPluginScanner{id: strings.Repeat("a", 33)}, false, false},
+		{"ID of built-in extension", &stubPluginScanner{id: string(data.DomainExtensionIDAkismet)}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := models.DomainExtensionID(tt.ps.ID())
+			orig, existed := data.DomainExtensions[id]
+			t.Cleanup(func() {
+				if existed {
+					data.DomainExtensions[id] = orig
+				} else {
+					delete(data.DomainExtensions, id)
+				}
+			})
+
+			svc := &perlustrationService{}
+			svc.registerPluginScanner("stub-plugin", tt.ps)
+
+			// Verify the domain extension
+			ex := data.DomainExtensions[id]
+			if tt.wantExt {
+				if ex == nil {
+					t.Fatalf("registerPluginScanner() didn't register extension %q", id)
+				}
+				want := &data.DomainExtension{ID: id, Name: "Stub " + string(id), Config: "level=3"}
+				if !reflect.DeepEqual(ex, want) {
+					t.Errorf("registerPluginScanner() registered extension %#v, want %#v", ex, want)
+				}
+			} else if existed && ex != orig {
+				t.Errorf("registerPluginScanner() replaced existing extension %q", id)
+			} else if !existed && ex != nil {
+				t.Errorf("registerPluginScanner() registered extension %q, want none", id)
+			}
+
+			// Verify the scanner
+			if !tt.wantExt {
+				if len(svc.scanners) != 0 {
+					t.Errorf("registerPluginScanner() registered %d scanners, want none", len(svc.scanners))
+				}
+				return
+			}
+			if len(svc.scanners) != 1 {
+				t.Fatalf("registerPluginScanner() registered %d scanners, want 1", len(svc.scanners))
+			}
+			cs := svc.scanners[0]
+			if cs.ID() != id || !cs.KeyProvided() {
+				t.Errorf("registered scanner ID = %q, KeyProvided = %v, want %q, true", cs.ID(), cs.KeyProvided(), id)
+			}
+			if _, ok := cs.(CommentScannerFeedback); ok != tt.wantFeedback {
+				t.Errorf("registered scanner supports feedback = %v, want %v", ok, tt.wantFeedback)
+			}
+		})
+	}
+}
+
+func Test_pluginScanner_Scan(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name       string
+		positive   bool
+		domainUser *data.DomainUser
+	}{
+		{"negative, no domain user", false, nil},
+		{"positive, domain user   ", true, &data.DomainUser{UserID: userID, IsCommenter: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := &stubPluginScanner{id: "acme", positive: tt.positive}
+			s := &pluginScanner{ps: ps}
+			ctx := &commentScanningContext{
+				Request:    httptest.NewRequest(http.MethodPut, "/api/embed/comments", nil),
+				UserIP:     "10.0.0.1",
+				UserAgent:  "Firefox",
+				Referrer:   "https://example.com/",
+				Comment:    &data.Comment{ID: uuid.New(), Markdown: "Hi"},
+				Domain:     &data.Domain{ID: uuid.New(), Host: "example.com"},
+				Page:       &data.DomainPage{ID: uuid.New(), Path: "/"},
+				User:       &data.User{ID: userID, Name: "Jane"},
+				DomainUser: tt.domainUser,
+				IsEdit:     true,
+			}
+			b, reason, err := s.Scan(map[string]string{"level": "5"}, ctx)
+			if b != tt.positive || reason != "stub verdict" || err != nil {
+				t.Errorf("Scan() = (%v, %q, %v), want (%v, %q, nil)", b, reason, err, tt.positive, "stub verdict")
+			}
+
+			// Verify the plugin got the configuration and the converted context
+			if ps.config["level"] != "5" {
+				t.Errorf("Scan() config = %v, want level=5", ps.config)
+			}
+			pc := ps.ctx
+			if pc.Request != ctx.Request || pc.UserIP != "10.0.0.1" || pc.UserAgent != "Firefox" || pc.Referrer != "https://example.com/" || !pc.IsEdit {
+				t.Errorf("Scan() ctx = %#v, want the request details", pc)
+			}
+			if pc.Comment.ID != ctx.Comment.ID || pc.Domain.Host != "example.com" || pc.Page.ID != ctx.Page.ID || pc.User.Name != "Jane" {
+				t.Errorf("Scan() ctx models don't match the scanned comment: %#v", pc)
+			}
+			if (pc.DomainUser != nil) != (tt.domainUser != nil) {
+				t.Errorf("Scan() ctx.DomainUser = %#v, want presence %v", pc.DomainUser, tt.domainUser != nil)
+			}
+		})
+	}
+}
+
+func Test_blocklistScanner_blocklist(t *testing.T) {
+	s := newBlocklistScanner()
+	svc := &perlustrationService{blocklist: s}
+	d1, d2 := uuid.New(), uuid.New()
+	rules := map[string]string{"spam": "moderate word spam"}
+
+	// The first call compiles the rules, subsequent ones reuse them
+	b1, err := s.blocklist(&d1, rules)
+	if err != nil || b1 == nil {
+		t.Fatalf("blocklist() = (%v, %v), want a blocklist", b1, err)
+	}
+	if b, _ := s.blocklist(&d1, map[string]string{"spam": "moderate word spam"}); b != b1 {
+		t.Errorf("blocklist() with the same rules recompiled them")
+	}
+
+	// Another domain gets its own blocklist
+	if b, _ := s.blocklist(&d2, rules); b == b1 {
+		t.Errorf("blocklist() returned a blocklist of another domain")
+	}
+
+	// Changed rules get recompiled
+	b2, err := s.blocklist(&d1, map[string]string{"spam": "reject word spam"})
+	if err != nil || b2 == b1 {
+		t.Errorf("blocklist() with changed rules = (%p, %v), want a new blocklist", b2, err)
+	} else if action, _, _ := b2.Check("Buy spam"); action != util.BlocklistActionReject {
+		t.Errorf("recompiled blocklist action = %q, want %q", action, util.BlocklistActionReject)
+	}
+
+	// Resetting drops the cached blocklist
+	svc.ResetBlocklist(&d1)
+	if s.cache.Get(d1) != nil {
+		t.Errorf("ResetBlocklist() didn't drop the cached blocklist")
+	}
+	if s.cache.Get(d2) == nil {
+		t.Errorf("ResetBlocklist() dropped a blocklist of another domain")
+	}
+	if b, _ := s.blocklist(&d1, map[string]string{"spam": "reject word spam"}); b == b2 {
+		t.Errorf("blocklist() after reset returned the dropped blocklist")
+	}
+
+	// Invalid rules yield an error, which is cached as well
+	bad := map[string]string{"bad": "moderate regex ("}
+	if _, err := s.blocklist(&d1, bad); err == nil {
+		t.Errorf("blocklist() with invalid rules succeeded, want error")
+	}
+	if ci := s.cache.Get(d1); ci == nil || ci.Value().err == nil {
+		t.Errorf("blocklist() didn't cache the error")
+	}
+}
@@ -1,18 +1,26 @@
 package svc
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/doug-martin/goqu/v9"
 	"github.com/google/uuid"
 	"github.com/op/go-logging"
 	cplugin "gitlab.com/comentario/comentario/extend/plugin"
 	"gitlab.com/comentario/comentario/internal/config"
+	"gitlab.com/comentario/comentario/internal/data"
 	"gitlab.com/comentario/comentario/internal/util"
 	"iter"
 	"net/http"
 	"os"
 	"path"
 	"plugin"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
 // PluginManager is a service interface for managing plugins
@@ -29,11 +37,14 @@ type PluginManager interface {
 	ServeHandler(next http.Handler) http.Handler
 	// Shutdown the manager
 	Shutdown()
+	// StartJobs starts running the background jobs scheduled by plugins
+	StartJobs()
 }
 
 // ThePluginManager is a global plugin manager instance
 var ThePluginManager PluginManager = &pluginManager{
 	plugs: map[string]*pluginEntry{},
+	jobs:  map[string]*pluginJob{},
 }
 
 //----------------------------------------------------------------------------------------------------------------------
@@ -86,18 +97,22 @@ func (p *pluginLogger) Debugf(format string, args ...any) {
 
 // pluginConnector implements PluginConnector
 type pluginConnector struct {
-	pluginID        string            // ID of the plugin the connector is created for
-	userAttrStore   cplugin.AttrStore // User attribute store
-	domainAttrStore cplugin.AttrStore // Domain attribute store
+	pluginID        string                 // ID of the plugin the connector is created for
+	userAttrStore   cplugin.AttrStore      // User attribute store
+	domainAttrStore cplugin.AttrStore      // Domain attribute store
+	dynConfigStore  cplugin.DynConfigStore // Dynamic config store
+	scheduler       cplugin.Scheduler      // Background job scheduler
 }
 
 // newPluginConnector returns a new PluginConnector instance
-func newPluginConnector(pluginID string) PluginConnector {
+func newPluginConnector(pluginID string, pm *pluginManager) PluginConnector {
 	prefix := pluginID + "/"
 	return &pluginConnector{
 		pluginID:        pluginID,
 		domainAttrStore: &pluginAttrStore{p: prefix, s: TheDomainAttrService},
 		userAttrStore:   &pluginAttrStore{p: prefix, s: TheUserAttrService},
+		dynConfigStore:  &pluginDynConfigStore{p: pluginDynConfigKeyPrefix(pluginID)},
+		scheduler:       &pluginScheduler{p: prefix, pm: pm},
 	}
 }
 
@@ -110,6 +125,10 @@ func (c *pluginConnector) AuthenticateBySessionCookie(value string) (*cplugin.Us
 	return u.ToPluginUser(), nil
 }
 
+func (c *pluginConnector) CommentStore() cplugin.CommentStore {
+	return &commentStore{}
+}
+
 func (c *pluginConnector) Config() *cplugin.HostConfig {
 	return &cplugin.HostConfig{
 		BaseURL:       config.ServerConfig.ParsedBaseURL(),
@@ -125,6 +144,29 @@ func (c *pluginConnector) DomainAttrStore() cplugin.AttrStore {
 	return c.domainAttrStore
 }
 
+func (c *pluginConnector) DomainStore() cplugin.DomainStore {
+	return &domainStore{}
+}
+
+func (c *pluginConnector) DynConfigStore() cplugin.DynConfigStore {
+	return c.dynConfigStore
+}
+
+func (c *pluginConnector) PageStore() cplugin.PageStore {
+	return &pageStore{}
+}
+
+func (c *pluginConnector) RegisterMailTransport(name string, t cplugin.MailTransport) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid mail transport name: %q", name)
+	}
+	return util.RegisterMailTransport(c.pluginID+"/"+name, &pluginMailer{t: t})
+}
+
+func (c *pluginConnector) Scheduler() cplugin.Scheduler {
+	return c.scheduler
+}
+
 func (c *pluginConnector) UserAttrStore() cplugin.AttrStore {
 	return c.userAttrStore
 }
@@ -178,6 +220,33 @@ func (as *pluginAttrStore) Set(ownerID *uuid.UUID, attr cplugin.AttrValues) erro
 
 //----------------------------------------------------------------------------------------------------------------------
 
+// pluginScheduler is a Scheduler implementation scoped to a specific plugin
+type pluginScheduler struct {
+	p  string         // Prefix derived from the plugin ID
+	pm *pluginManager // Reference to the plugin manager running the jobs
+}
+
+func (ps *pluginScheduler) ScheduleCron(name, spec string, f cplugin.JobFunc) error {
+	// Parse the schedule
+	cs, err := util.ParseCronSchedule(spec)
+	if err != nil {
+		return err
+	}
+	return ps.pm.scheduleJob(ps.p+name, cs.Next, f)
+}
+
+func (ps *pluginScheduler) ScheduleInterval(name string, interval time.Duration, f cplugin.JobFunc) error {
+	// Validate the interval
+	if interval < util.PluginJobMinInterval {
+		return fmt.Errorf("job interval must be at least %s, got %s", util.PluginJobMinInterval, interval)
+	}
+
+	// Align runs to interval multiples, so that all instances agree on the run times
+	return ps.pm.scheduleJob(ps.p+name, func(t time.Time) time.Time { return t.Truncate(interval).Add(interval) }, f)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
 // userStore is an implementation of plugin.UserStore
 type userStore struct{}
 
@@ -191,6 +260,322 @@ func (us *userStore) FindUserByID(id *uuid.UUID) (*cplugin.User, error) {
 
 //----------------------------------------------------------------------------------------------------------------------
 
+// pluginDynConfigStore is a DynConfigStore implementation scoped to a specific plugin
+type pluginDynConfigStore struct {
+	p string // Key prefix derived from the plugin ID
+}
+
+func (cs *pluginDynConfigStore) Get(key string) (string, error) {
+	if ci, err := TheDynConfigService.Get(data.DynConfigItemKey(cs.p + key)); err != nil {
+		return "", err
+	} else {
+		return ci.Value, nil
+	}
+}
+
+func (cs *pluginDynConfigStore) GetForDomain(domainID *uuid.UUID, key string) (string, error) {
+	if ci, err := TheDomainConfigService.Get(domainID, data.DynConfigItemKey(cs.p+key)); err != nil {
+		return "", err
+	} else {
+		return ci.Value, nil
+	}
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// pluginMailer is an implementation of intf.Mailer that hands emails over to a plugin's mail transport
+type pluginMailer struct {
+	t cplugin.MailTransport
+}
+
+func (m *pluginMailer) Operational() bool {
+	return true
+}
+
+func (m *pluginMailer) Mail(replyTo, recipient, subject, htmlMessage string, embedFiles ...string) error {
+	return m.t.Send(&cplugin.MailMessage{
+		From:       config.ServerConfig.EmailFrom,
+		ReplyTo:    replyTo,
+		To:         recipient,
+		Subject:    subject,
+		HTML:       htmlMessage,
+		EmbedFiles: embedFiles,
+	})
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// commentStore is an implementation of plugin.CommentStore
+type commentStore struct{}
+
+func (cs *commentStore) CreateComment(userID, pageID, parentID *uuid.UUID, markdown string) (*cplugin.Comment, error) {
+	// Find the page and verify the user's rights
+	page, err := ThePageService.FindByID(pageID)
+	if err != nil {
+		return nil, err
+	}
+	user, domain, domainUser, err := pluginStoreDomainUser(userID, &page.DomainID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify the domain, the page, and the user aren't readonly
+	if domain.IsReadonly || page.IsReadonly || domainUser.IsReadonly() {
+		return nil, ErrNotAllowed
+	}
+
+	// Verify the user isn't banned, either globally or on the domain
+	if user.Banned {
+		return nil, ErrNotAllowed
+	} else if b, err := TheDomainBanService.FindActiveMatch(&domain.ID, "", "", user.Email); err != nil {
+		return nil, err
+	} else if b != nil {
+		return nil, ErrNotAllowed
+	}
+
+	// Prepare a comment
+	c := &data.Comment{
+		ID:          uuid.New(),
+		PageID:      page.ID,
+		CreatedTime: time.Now().UTC(),
+		UserCreated: uuid.NullUUID{UUID: user.ID, Valid: true},
+	}
+
+	// Verify the parent comment, if any, belongs to the same page and isn't deleted
+	if parentID != nil {
+		if parent, err := TheCommentService.FindByID(parentID); err != nil {
+			return nil, err
+		} else if parent.PageID != page.ID {
+			return nil, ErrNotFound
+		} else if parent.IsDeleted {
+			return nil, ErrNotAllowed
+		}
+		c.ParentID = uuid.NullUUID{UUID: *parentID, Valid: true}
+	}
+
+	// Render the text
+	if err := TheCommentService.SetMarkdown(c, markdown, &domain.ID, nil); err != nil {
+		return nil, err
+	}
+
+	// Determine the comment's state the same way as for comments submitted via the API: the blocklist, link, and spam
+	// checks, and the domain's moderation policy all apply. There's no client request behind the comment, though
+	if b, reason, err := ThePerlustrationService.NeedsModeration(nil, c, domain, page, user, domainUser, false); err != nil {
+		return nil, err
+	} else if b {
+		c.WithModerated(nil, true, false, reason)
+	} else {
+		c.WithModerated(&user.ID, false, true, "")
+	}
+
+	// Comments by a shadow-banned user are only visible to them and moderators
+	c.IsShadowed = domainUser != nil && domainUser.IsShadowBanned
+
+	// Persist a new comment record
+	if err := TheCommentService.Create(c); err != nil {
+		return nil, err
+	}
+
+	// Update the counts and send out notifications
+	TheCommentNotifyService.Created(domain, page, c, user)
+
+	// Succeeded
+	return c.ToPluginComment(), nil
+}
+
+func (cs *commentStore) FindCommentByID(userID, id *uuid.UUID) (*cplugin.Comment, error) {
+	// Find the comment and verify the user's rights
+	c, _, _, _, err := cs.findComment(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.ToPluginComment(), nil
+}
+
+func (cs *commentStore) ListComments(userID, domainID, pageID *uuid.UUID) ([]*cplugin.Comment, error) {
+	// Verify the user's rights
+	if _, _, _, err := pluginStoreDomainUser(userID, domainID, true); err != nil {
+		return nil, err
+	}
+
+	// Fetch the comments
+	comments, err := TheCommentService.ListByDomainPage(domainID, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the comments into plugin models
+	r := make([]*cplugin.Comment, len(comments))
+	for i, c := range comments {
+		r[i] = c.ToPluginComment()
+	}
+	return r, nil
+}
+
+func (cs *commentStore) ModerateComment(userID, id *uuid.UUID, pending, approved bool, reason string) error {
+	// Find the comment and verify the user's rights
+	c, page, domain, user, err := cs.findComment(userID, id)
+	if err != nil {
+		return err
+	}
+
+	// Deleted comments can't be moderated
+	if c.IsDeleted {
+		return ErrNotAllowed
+	}
+
+	// Update the comment's state in the database
+	statusBefore := c.Status()
+	if err := TheCommentService.Moderated(c.WithModerated(userID, pending, approved, reason)); err != nil {
+		return err
+	}
+
+	// Log the action and send out notifications
+	TheCommentNotifyService.Moderated(domain, page, c, user, statusBefore, reason)
+
+	// Succeeded
+	return nil
+}
+
+// findComment finds a comment, its page, and its domain by the comment ID, verifying the user is allowed to moderate it
+func (cs *commentStore) findComment(userID, id *uuid.UUID) (*data.Comment, *data.DomainPage, *data.Domain, *data.User, error) {
+	// Find the comment and its page
+	c, err := TheCommentService.FindByID(id)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	page, err := ThePageService.FindByID(&c.PageID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// Verify the user's rights
+	user, domain, _, err := pluginStoreDomainUser(userID, &page.DomainID, true)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return c, page, domain, user, nil
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// domainStore is an implementation of plugin.DomainStore
+type domainStore struct{}
+
+func (ds *domainStore) FindDomainByID(userID, id *uuid.UUID) (*cplugin.Domain, error) {
+	// Find the domain and verify the user's rights
+	_, d, _, err := pluginStoreDomainUser(userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	return d.ToPluginDomain(), nil
+}
+
+func (ds *domainStore) ListDomains(userID *uuid.UUID) ([]*cplugin.Domain, error) {
+	// Find the user
+	u, err := TheUserService.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the domains the user has access to
+	domains, _, err := TheDomainService.ListByDomainUser(userID, userID, u.IsSuperuser, false, "", "", data.SortAsc, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the domains into plugin models
+	r := make([]*cplugin.Domain, len(domains))
+	for i, d := range domains {
+		r[i] = d.ToPluginDomain()
+	}
+	return r, nil
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// pageStore is an implementation of plugin.PageStore
+type pageStore struct{}
+
+func (ps *pageStore) FindPageByID(userID, id *uuid.UUID) (*cplugin.DomainPage, error) {
+	// Find the page and verify the user's rights
+	p, err := ThePageService.FindByID(id)
+	if err != nil {
+		return nil, err
+	} else if _, _, _, err := pluginStoreDomainUser(userID, &p.DomainID, false); err != nil {
+		return nil, err
+	}
+	return p.ToPluginDomainPage(), nil
+}
+
+func (ps *pageStore) ListPages(userID, domainID *uuid.UUID) ([]*cplugin.DomainPage, error) {
+	// Verify the user's rights
+	u, _, _, err := pluginStoreDomainUser(userID, domainID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the pages
+	pages, err := ThePageService.ListByDomainUser(userID, domainID, u.IsSuperuser, "", "", data.SortAsc, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert the pages into plugin models
+	r := make([]*cplugin.DomainPage, len(pages))
+	for i, p := range pages {
+		r[i] = p.ToPluginDomainPage()
+	}
+	return r, nil
+}
+
+func (ps *pageStore) UpdatePage(userID *uuid.UUID, page *cplugin.DomainPage) error {
+	// Find the page and verify the user's rights
+	p, err := ThePageService.FindByID(&page.ID)
+	if err != nil {
+		return err
+	} else if _, _, _, err := pluginStoreDomainUser(userID, &p.DomainID, true); err != nil {
+		return err
+	}
+
+	// Update the page
+	p.FromPluginDomainPage(page)
+	return ThePageService.Update(p)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// pluginStoreDomainUser finds and returns a user, a domain, and a domain user (if any) by the given user and domain
+// IDs, verifying the user has any role on the domain, or is a moderator of it if moderator is true. Superusers are
+// always allowed
+func pluginStoreDomainUser(userID, domainID *uuid.UUID, moderator bool) (*data.User, *data.Domain, *data.DomainUser, error) {
+	// Find the user
+	u, err := TheUserService.FindUserByID(userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Find the domain and the domain user
+	d, du, err := TheDomainService.FindDomainUserByID(domainID, userID, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Verify the user's rights
+	switch {
+	case u.IsSuperuser:
+		// Superuser can do anything
+	case du == nil:
+		// No domain user record: the user can't access the domain at all, pretend it doesn't exist
+		return nil, nil, nil, ErrNotFound
+	case moderator && !du.CanModerate():
+		return nil, nil, nil, ErrNotAllowed
+	}
+	return u, d, du, nil
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
 // pluginEntry groups a loaded plugin's info
 type pluginEntry struct {
 	id string                   // Unique plugin ID
@@ -198,9 +583,22 @@ type pluginEntry struct {
 	c  *cplugin.Config          // Configuration obtained from the plugin
 }
 
+// pluginJob is a background job scheduled by a plugin
+type pluginJob struct {
+	name string                      // Job name, prefixed with the plugin ID
+	next func(t time.Time) time.Time // Function returning the next run time after the given one
+	f    cplugin.JobFunc             // Job implementation
+}
+
 // pluginManager is a blueprint PluginManager implementation
 type pluginManager struct {
-	plugs map[string]*pluginEntry // Map of loaded plugin entries by ID
+	plugs       map[string]*pluginEntry // Map of loaded plugin entries by ID
+	jobs        map[string]*pluginJob   // Map of scheduled plugin jobs by name
+	jobsMu      sync.Mutex              // Mutex for jobs and jobsStarted
+	jobsStarted bool                    // Whether the jobs have been started
+	jobsWG      sync.WaitGroup          // Wait group for running job loops
+	ctx         context.Context         // Context passed to jobs, cancelled on shutdown
+	cancel      context.CancelFunc      // Function for cancelling the context
 }
 
 func (pm *pluginManager) Active() bool {
@@ -223,6 +621,9 @@ func (pm *pluginManager) HandleEvent(event any) error {
 }
 
 func (pm *pluginManager) Init() error {
+	// Prepare a context for background jobs
+	pm.ctx, pm.cancel = context.WithCancel(context.Background())
+
 	// Don't bother if plugin dir not provided
 	if config.ServerConfig.PluginPath == "" {
 		logger.Info("Plugin directory isn't specified, not looking for plugins")
@@ -238,6 +639,12 @@ func (pm *pluginManager) Init() error {
 		logger.Infof("Loaded %d plugins", cnt)
 	}
 
+	// Register identity providers supplied by plugins
+	pm.registerFederatedIdPs()
+
+	// Register config items declared by plugins
+	pm.registerDynConfigItems()
+
 	// Succeeded
 	return nil
 }
@@ -285,12 +692,74 @@ func (pm *pluginManager) ServeHandler(next http.Handler) http.Handler {
 }
 
 func (pm *pluginManager) Shutdown() {
+	// Stop background jobs, giving the running ones a chance to finish
+	if pm.cancel != nil {
+		pm.cancel()
+		util.GoTimeout(util.PluginJobShutdownTimeout, pm.jobsWG.Wait)
+	}
+
 	// Shutdown all known plugins
 	for _, pe := range pm.plugs {
 		pe.p.Shutdown()
 	}
 }
 
+func (pm *pluginManager) StartJobs() {
+	pm.jobsMu.Lock()
+	defer pm.jobsMu.Unlock()
+
+	// Start all jobs scheduled so far. Those scheduled later will be started right away
+	pm.jobsStarted = true
+	for _, j := range pm.jobs {
+		pm.startJob(j)
+	}
+}
+
+// claimJobRun tries to claim the run of the given job scheduled for the given time, returning whether it succeeded.
+// Only one instance sharing the database can successfully claim each run
+func (pm *pluginManager) claimJobRun(j *pluginJob, t time.Time) (bool, error) {
+	// Make sure the job record exists
+	_, err := db.Insert("cm_plugin_jobs").
+		Rows(goqu.Record{"name": j.name, "ts_last_run": time.Unix(0, 0).UTC()}).
+		OnConflict(goqu.DoNothing()).
+		Executor().Exec()
+	if err != nil {
+		return false, err
+	}
+
+	// Try to advance the last run time, which only succeeds if no other instance has already done that
+	err = db.ExecOne(
+		db.Update("cm_plugin_jobs").
+			Set(goqu.Record{"ts_last_run": t}).
+			Where(goqu.Ex{"name": j.name}, goqu.C("ts_last_run").Lt(t)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// Succeeded
+	return true, nil
+}
+
+// execJob executes the given job once, recovering from a panic, if any
+func (pm *pluginManager) execJob(j *pluginJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("Plugin job %q panicked: %v", j.name, r)
+		}
+	}()
+
+	// Run the job
+	logger.Debugf("Running plugin job %q", j.name)
+	start := time.Now()
+	if err := j.f(pm.ctx); err != nil {
+		logger.Warningf("Plugin job %q failed: %v", j.name, err)
+	} else {
+		logger.Debugf("Plugin job %q completed in %s", j.name, time.Since(start))
+	}
+}
+
 // findByPath returns a plugin whose path (with the optional prefix) starts the provided path, or nil if nothing found
 func (pm *pluginManager) findByPath(requestPath, prefix string) *pluginEntry {
 	for _, pe := range pm.plugs {
@@ -305,7 +774,7 @@ func (pm *pluginManager) findByPath(requestPath, prefix string) *pluginEntry {
 // initPlugin initialises the given plugin and fetches its config
 func (pm *pluginManager) initPlugin(p cplugin.ComentarioPlugin, secrets cplugin.YAMLDecoder) (*cplugin.Config, error) {
 	// Initialise the plugin
-	if err := p.Init(newPluginConnector(p.ID()), secrets); err != nil {
+	if err := p.Init(newPluginConnector(p.ID(), pm), secrets); err != nil {
 		return nil, err
 	}
 
@@ -362,6 +831,57 @@ func (pm *pluginManager) loadPlugin(filename string) (*pluginEntry, error) {
 	return &pluginEntry{id: id, p: *hPtr, c: cfg}, nil
 }
 
+// registerDynConfigItems registers dynamic configuration items declared by the loaded plugins, skipping any invalid ones
+func (pm *pluginManager) registerDynConfigItems() {
+	for _, pe := range pm.plugs {
+		for _, item := range pe.c.DynConfigItems {
+			if err := registerPluginDynConfigItem(pe.id, &item); err != nil {
+				logger.Warningf("Plugin %q: skipping config item: %v", pe.id, err)
+			}
+		}
+	}
+}
+
+// registerFederatedIdPs registers federated identity providers supplied by the loaded plugins, skipping any invalid ones
+func (pm *pluginManager) registerFederatedIdPs() {
+	for _, pe := range pm.plugs {
+		for _, idp := range pe.c.FederatedIdPs {
+			if err := config.RegisterPluginFederatedIdP(idp.ID, idp.Name, idp.NewProvider); err != nil {
+				logger.Warningf("Plugin %q: skipping identity provider: %v", pe.id, err)
+			}
+		}
+	}
+}
+
+// runJob runs the given job in a loop according to its schedule, until the context is cancelled
+func (pm *pluginManager) runJob(j *pluginJob) {
+	defer pm.jobsWG.Done()
+	for {
+		// Determine the next run time
+		t := j.next(time.Now().UTC())
+		if t.IsZero() {
+			logger.Warningf("Plugin job %q has no next run time, stopping", j.name)
+			return
+		}
+
+		// Wait until it's time to run, or until the context is cancelled
+		timer := time.NewTimer(time.Until(t))
+		select {
+		case <-pm.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Run the job if this instance claims the run
+		if ok, err := pm.claimJobRun(j, t); err != nil {
+			logger.Errorf("pluginManager.runJob: failed to claim run of job %q: %v", j.name, err)
+		} else if ok {
+			pm.execJob(j)
+		}
+	}
+}
+
 // scanDir scans the plugin directory recursively, loading every discovered plugin and returning the number of plugins
 // found
 func (pm *pluginManager) scanDir(dir string) (int, error) {
@@ -417,3 +937,75 @@ func (pm *pluginManager) scanDir(dir string) (int, error) {
 	// Succeeded
 	return cnt, nil
 }
+
+// scheduleJob registers a new job with the given name and schedule, starting it if jobs are already running
+func (pm *pluginManager) scheduleJob(name string, next func(t time.Time) time.Time, f cplugin.JobFunc) error {
+	pm.jobsMu.Lock()
+	defer pm.jobsMu.Unlock()
+
+	// Verify the job name is unique
+	if _, ok := pm.jobs[name]; ok {
+		return fmt.Errorf("job %q is already scheduled", name)
+	}
+
+	// Register the job
+	j := &pluginJob{name: name, next: next, f: f}
+	pm.jobs[name] = j
+	logger.Debugf("Scheduled plugin job %q", name)
+
+	// Start it right away if jobs are already running
+	if pm.jobsStarted {
+		pm.startJob(j)
+	}
+	return nil
+}
+
+// startJob starts the loop of the given job in the background
+func (pm *pluginManager) startJob(j *pluginJob) {
+	pm.jobsWG.Add(1)
+	go pm.runJob(j)
+}
+
+// pluginDynConfigKeyPrefix returns the prefix for keys of dynamic config items declared by the plugin with the given ID
+func pluginDynConfigKeyPrefix(pluginID string) string {
+	return data.ConfigKeyPluginPrefix + pluginID + "."
+}
+
+var rePluginDynConfigKey = regexp.MustCompile(`^[a-zA-Z0-9]+([-.][a-zA-Z0-9]+)*$`)
+
+// registerPluginDynConfigItem validates and registers a dynamic configuration item declared by the given plugin
+func registerPluginDynConfigItem(pluginID string, item *cplugin.DynConfigItem) error {
+	// Validate the key
+	if len(item.Key) > 64 || !rePluginDynConfigKey.MatchString(item.Key) {
+		return fmt.Errorf("invalid config item key: %q", item.Key)
+	}
+	key := data.DynConfigItemKey(pluginDynConfigKeyPrefix(pluginID) + item.Key)
+
+	// Domain items are registered as domain defaults
+	switch item.Scope {
+	case cplugin.DynConfigItemScopeInstance:
+		// Use the key as is
+	case cplugin.DynConfigItemScopeDomain:
+		key = data.ConfigKeyDomainDefaultsPrefix + key
+	default:
+		return fmt.Errorf("invalid scope of config item %q: %q", item.Key, item.Scope)
+	}
+
+	// Default to the plugins section
+	ci := &data.DynConfigItem{
+		Datatype:     data.DynConfigItemDatatype(item.Datatype),
+		DefaultValue: item.DefaultValue,
+		Section:      data.DynConfigItemSectionKey(item.Section),
+		Min:          item.Min,
+		Max:          item.Max,
+	}
+	if ci.Section == "" {
+		ci.Section = data.DynConfigItemSectionPlugins
+	}
+
+	// Allow any non-negative int if no range is given
+	if ci.Datatype == data.ConfigDatatypeInt && ci.Min == 0 && ci.Max == 0 {
+		ci.Max = 1<<31 - 1
+	}
+	return data.AddDefaultDynConfigItem(key, ci)
+}
@@ -0,0 +1,126 @@
+package svc
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/extend/plugin"
+	"gitlab.com/comentario/comentario/internal/data"
+	"testing"
+)
+
+// stubPluginManager is a PluginManager that passes events to the given handler function
+type stubPluginManager struct {
+	PluginManager
+	handle func(event any)
+}
+
+func (m *stubPluginManager) Active() bool {
+	return true
+}
+
+func (m *stubPluginManager) HandleEvent(event any) error {
+	m.handle(event)
+	return nil
+}
+
+// withStubPluginManager installs a stub plugin manager with the given event handler for the duration of the test
+func withStubPluginManager(t *testing.T, handle func(event any)) {
+	pm := ThePluginManager
+	ThePluginManager = &stubPluginManager{handle: handle}
+	t.Cleanup(func() { ThePluginManager = pm })
+}
+
+func Test_handleDomainEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(e *plugin.DomainUpdateEvent)
+		wantChanged bool
+		wantName    string
+		wantErr     error
+	}{
+		{"unchanged ", func(*plugin.DomainUpdateEvent) {}, false, "Example", nil},
+		{"renamed   ", func(e *plugin.DomainUpdateEvent) { e.Domain().Name = "Other" }, true, "Other", nil},
+		{"discarded ", func(e *plugin.DomainUpdateEvent) { e.SetDomain(nil) }, false, "Example", ErrPluginPayload},
+		{"ID changed", func(e *plugin.DomainUpdateEvent) { e.Domain().ID = uuid.New() }, false, "Example", ErrPluginPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStubPluginManager(t, func(event any) { tt.modify(event.(*plugin.DomainUpdateEvent)) })
+			d := &data.Domain{ID: uuid.New(), Name: "Example", Host: "example.com"}
+			changed, err := handleDomainEvent(&plugin.DomainUpdateEvent{}, d)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("handleDomainEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("handleDomainEvent() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if d.Name != tt.wantName {
+				t.Errorf("handleDomainEvent() Name = %q, want %q", d.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_handleDomainUserEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(e *plugin.DomainUserUpdateEvent)
+		wantChanged   bool
+		wantModerator bool
+		wantErr       error
+	}{
+		{"unchanged        ", func(*plugin.DomainUserUpdateEvent) {}, false, false, nil},
+		{"made moderator   ", func(e *plugin.DomainUserUpdateEvent) { e.DomainUser().IsModerator = true }, true, true, nil},
+		{"discarded        ", func(e *plugin.DomainUserUpdateEvent) { e.SetDomainUser(nil) }, false, false, ErrPluginPayload},
+		{"domain ID changed", func(e *plugin.DomainUserUpdateEvent) { e.DomainUser().DomainID = uuid.New() }, false, false, ErrPluginPayload},
+		{"user ID changed  ", func(e *plugin.DomainUserUpdateEvent) { e.DomainUser().UserID = uuid.New() }, false, false, ErrPluginPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStubPluginManager(t, func(event any) { tt.modify(event.(*plugin.DomainUserUpdateEvent)) })
+			du := &data.DomainUser{DomainID: uuid.New(), UserID: uuid.New(), IsCommenter: true}
+			changed, err := handleDomainUserEvent(&plugin.DomainUserUpdateEvent{}, du)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("handleDomainUserEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("handleDomainUserEvent() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if du.IsModerator != tt.wantModerator {
+				t.Errorf("handleDomainUserEvent() IsModerator = %v, want %v", du.IsModerator, tt.wantModerator)
+			}
+		})
+	}
+}
+
+func Test_handlePageEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(e *plugin.PageUpdateEvent)
+		wantChanged bool
+		wantTitle   string
+		wantErr     error
+	}{
+		{"unchanged ", func(*plugin.PageUpdateEvent) {}, false, "Blog", nil},
+		{"retitled  ", func(e *plugin.PageUpdateEvent) { e.Page().Title = "News" }, true, "News", nil},
+		{"discarded ", func(e *plugin.PageUpdateEvent) { e.SetPage(nil) }, false, "Blog", ErrPluginPayload},
+		{"ID changed", func(e *plugin.PageUpdateEvent) { e.Page().ID = uuid.New() }, false, "Blog", ErrPluginPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStubPluginManager(t, func(event any) { tt.modify(event.(*plugin.PageUpdateEvent)) })
+			d := &data.Domain{ID: uuid.New(), Host: "example.com"}
+			p := &data.DomainPage{ID: uuid.New(), DomainID: d.ID, Path: "/blog/", Title: "Blog"}
+			changed, err := handlePageEvent(&plugin.PageUpdateEvent{}, p, d)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("handlePageEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("handlePageEvent() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if p.Title != tt.wantTitle {
+				t.Errorf("handlePageEvent() Title = %q, want %q", p.Title, tt.wantTitle)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/config"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/util"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TheRateLimitService is a global RateLimitService implementation
+var TheRateLimitService RateLimitService = &rateLimitService{mem: &rateLimitMemStore{buckets: map[string]*rateLimitBucket{}}}
+
+// RateLimitAction is an action subject to rate limiting
+type RateLimitAction string
+
+const (
+	RateLimitActionComment  RateLimitAction = "comment"  // Posting a comment
+	RateLimitActionFlag     RateLimitAction = "flag"     // Flagging a comment
+	RateLimitActionLogin    RateLimitAction = "login"    // Logging in with email and password
+	RateLimitActionPwdReset RateLimitAction = "pwdReset" // Requesting a password reset email
+	RateLimitActionSignup   RateLimitAction = "signup"   // Signing up with email and password
+)
+
+// RateLimitService is a service interface for limiting the rate of client requests
+type RateLimitService interface {
+	// Allow checks whether the given action, performed by a client with the given IP address, optionally on behalf of
+	// the given user on the given domain (both can be nil), is within the limits configured for it. If it is, a token is
+	// consumed from every applicable bucket; otherwise no token is consumed, and it also returns how long the client
+	// should wait before retrying
+	Allow(action RateLimitAction, domainID *uuid.UUID, ip string, userID *uuid.UUID) (bool, time.Duration)
+	// CountBlocked returns the number of requests blocked by this instance since its start
+	CountBlocked() int64
+	// Init the service, starting the background purge of idle in-memory buckets
+	Init() error
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// rateLimitBucket is a token bucket, which holds up to a limit of tokens, refilled evenly over a period
+type rateLimitBucket struct {
+	Key         string    `db:"key"`        // Bucket key
+	Tokens      float64   `db:"tokens"`     // Number of tokens available in the bucket
+	UpdatedTime time.Time `db:"ts_updated"` // When the number of tokens was last updated
+}
+
+// refill refills the bucket according to the time elapsed since its last update
+func (b *rateLimitBucket) refill(limit int, period time.Duration, now time.Time) {
+	if elapsed := now.Sub(b.UpdatedTime).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(limit), b.Tokens+elapsed*float64(limit)/period.Seconds())
+	}
+	b.UpdatedTime = now
+}
+
+// take refills the bucket according to the time elapsed since its last update and attempts to consume a single token
+// from it. If no token is available, returns false and the duration until one becomes available
+func (b *rateLimitBucket) take(limit int, period time.Duration, now time.Time) (bool, time.Duration) {
+	b.refill(limit, period, now)
+	if w := b.wait(limit, period); w > 0 {
+		return false, w
+	}
+	b.Tokens--
+	return true, 0
+}
+
+// wait returns the duration until a token becomes available in the bucket, or zero if there's one already
+func (b *rateLimitBucket) wait(limit int, period time.Duration) time.Duration {
+	if b.Tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.Tokens) / (float64(limit) / period.Seconds()) * float64(time.Second))
+}
+
+// rateLimitTakeAll refills the given buckets, one per limit, and consumes a token from each of them, but only if every
+// bucket has one available. Otherwise, returns false and the duration until all of them have a token
+func rateLimitTakeAll(bs []*rateLimitBucket, ls []rateLimit, now time.Time) (bool, time.Duration) {
+	var wait time.Duration
+	for i, b := range bs {
+		b.refill(ls[i].limit, ls[i].period, now)
+		wait = max(wait, b.wait(ls[i].limit, ls[i].period))
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, b := range bs {
+		b.Tokens--
+	}
+	return true, 0
+}
+
+// rateLimitStore is a storage of token buckets
+type rateLimitStore interface {
+	// take attempts to consume a token from the bucket of each of the given limits, creating full buckets where they
+	// don't exist. No token is consumed unless every bucket has one
+	take(ls []rateLimit, now time.Time) (bool, time.Duration)
+}
+
+// rateLimitMemStore is a rateLimitStore keeping buckets in memory
+type rateLimitMemStore struct {
+	mu      sync.Mutex
+	buckets map[string]*rateLimitBucket
+}
+
+func (s *rateLimitMemStore) take(ls []rateLimit, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs := make([]*rateLimitBucket, len(ls))
+	for i, l := range ls {
+		b, ok := s.buckets[l.key]
+		if !ok {
+			s.makeRoom(now)
+			b = &rateLimitBucket{Key: l.key, Tokens: float64(l.limit), UpdatedTime: now}
+			s.buckets[l.key] = b
+		}
+		bs[i] = b
+	}
+	return rateLimitTakeAll(bs, ls, now)
+}
+
+// makeRoom ensures there's room for a new bucket, first by removing idle buckets and, if that isn't sufficient, the
+// least recently updated one. Must be called with the mutex locked
+func (s *rateLimitMemStore) makeRoom(now time.Time) {
+	if len(s.buckets) < util.RateLimitMaxBuckets {
+		return
+	}
+
+	// Remove idle buckets
+	s.removeIdle(now.Add(-util.RateLimitBucketTTL))
+	if len(s.buckets) < util.RateLimitMaxBuckets {
+		return
+	}
+
+	// Still full: evict the least recently updated bucket
+	logger.Debugf("rateLimitMemStore.makeRoom: bucket limit (%d) reached, evicting the oldest bucket", util.RateLimitMaxBuckets)
+	var oldest *rateLimitBucket
+	for _, b := range s.buckets {
+		if oldest == nil || b.UpdatedTime.Before(oldest.UpdatedTime) {
+			oldest = b
+		}
+	}
+	delete(s.buckets, oldest.Key)
+}
+
+// purge removes buckets that haven't been updated since the given time
+func (s *rateLimitMemStore) purge(before time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeIdle(before)
+}
+
+// removeIdle removes buckets that haven't been updated since the given time. Must be called with the mutex locked
+func (s *rateLimitMemStore) removeIdle(before time.Time) {
+	for k, b := range s.buckets {
+		if b.UpdatedTime.Before(before) {
+			delete(s.buckets, k)
+		}
+	}
+}
+
+// rateLimitDBStore is a rateLimitStore keeping buckets in the database, which allows multiple instances to share them.
+// Since the read-update cycle isn't atomic, concurrent requests hitting different instances may slightly exceed the
+// limit
+type rateLimitDBStore struct{}
+
+func (s *rateLimitDBStore) take(ls []rateLimit, now time.Time) (bool, time.Duration) {
+	// Fetch the buckets, starting with full ones where there's none
+	bs := make([]*rateLimitBucket, len(ls))
+	for i, l := range ls {
+		bs[i] = &rateLimitBucket{Key: l.key, Tokens: float64(l.limit), UpdatedTime: now}
+		if _, err := db.From("cm_rate_limits").Where(goqu.Ex{"key": l.key}).ScanStruct(bs[i]); err != nil {
+			// Let the request through on a database failure
+			logger.Errorf("rateLimitDBStore.take: ScanStruct() failed: %v", err)
+			return true, 0
+		}
+	}
+
+	// Consume the tokens. Buckets only need to be persisted if they've changed, as the refill is time-based anyway
+	ok, wait := rateLimitTakeAll(bs, ls, now)
+	if ok {
+		for _, b := range bs {
+			if err := db.ExecOne(db.Insert("cm_rate_limits").Rows(b).OnConflict(goqu.DoUpdate("key", b))); err != nil {
+				logger.Errorf("rateLimitDBStore.take: ExecOne() failed: %v", err)
+			}
+		}
+	}
+	return ok, wait
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// rateLimitService is a blueprint RateLimitService implementation
+type rateLimitService struct {
+	mem          *rateLimitMemStore // In-memory bucket store
+	countBlocked atomic.Int64       // Number of blocked requests
+}
+
+func (svc *rateLimitService) Allow(action RateLimitAction, domainID *uuid.UUID, ip string, userID *uuid.UUID) (bool, time.Duration) {
+	// Check every bucket applicable to the action, consuming a token from each of them only if they all allow it. This
+	// way a client exceeding its own limit doesn't drain the shared (domain-wide) bucket
+	ls := svc.limits(action, domainID, ip, userID)
+	if len(ls) == 0 {
+		return true, 0
+	}
+	ok, wait := svc.store().take(ls, time.Now().UTC())
+
+	// Count blocked requests
+	if !ok {
+		logger.Debugf("rateLimitService.Allow: %s request from %s blocked, retry in %s", action, ip, wait)
+		svc.countBlocked.Add(1)
+	}
+	return ok, wait
+}
+
+func (svc *rateLimitService) CountBlocked() int64 {
+	return svc.countBlocked.Load()
+}
+
+func (svc *rateLimitService) Init() error {
+	logger.Debug("rateLimitService: initialising")
+	go svc.run()
+	return nil
+}
+
+// rateLimit describes a limit applying to a specific bucket
+type rateLimit struct {
+	key    string        // Bucket key
+	limit  int           // Max number of requests per period
+	period time.Duration // Period the limit applies to
+}
+
+// limits returns limits applicable to the given action, omitting the disabled ones
+func (svc *rateLimitService) limits(action RateLimitAction, domainID *uuid.UUID, ip string, userID *uuid.UUID) []rateLimit {
+	var ls []rateLimit
+	add := func(key string, limit int, period time.Duration) {
+		if limit > 0 {
+			ls = append(ls, rateLimit{key: string(action) + "|" + key, limit: limit, period: period})
+		}
+	}
+
+	// Instance-wide actions
+	if domainID == nil {
+		switch action {
+		case RateLimitActionLogin:
+			add("ip:"+ip, TheDynConfigService.GetInt(data.ConfigKeyRateLimitLoginPerMinute), time.Minute)
+		case RateLimitActionPwdReset:
+			add("ip:"+ip, TheDynConfigService.GetInt(data.ConfigKeyRateLimitPwdResetPerHour), time.Hour)
+		case RateLimitActionSignup:
+			add("ip:"+ip, TheDynConfigService.GetInt(data.ConfigKeyRateLimitSignupPerHour), time.Hour)
+		}
+		return ls
+	}
+
+	// Domain actions
+	dk := "d:" + domainID.String()
+	switch action {
+	case RateLimitActionComment:
+		n := TheDomainConfigService.GetInt(domainID, data.DomainConfigKeyRateLimitCommentPerMinute)
+		add(dk+"|ip:"+ip, n, time.Minute)
+		if userID != nil && *userID != data.AnonymousUser.ID {
+			add(dk+"|u:"+userID.String(), n, time.Minute)
+		}
+		add(dk, TheDomainConfigService.GetInt(domainID, data.DomainConfigKeyRateLimitCommentDomainPerMinute), time.Minute)
+	case RateLimitActionFlag:
+		n := TheDomainConfigService.GetInt(domainID, data.DomainConfigKeyRateLimitFlagPerHour)
+		add(dk+"|ip:"+ip, n, time.Hour)
+		if userID != nil && *userID != data.AnonymousUser.ID {
+			add(dk+"|u:"+userID.String(), n, time.Hour)
+		}
+	case RateLimitActionLogin:
+		add(dk+"|ip:"+ip, TheDomainConfigService.GetInt(domainID, data.DomainConfigKeyRateLimitLoginPerMinute), time.Minute)
+	case RateLimitActionSignup:
+		add(dk+"|ip:"+ip, TheDomainConfigService.GetInt(domainID, data.DomainConfigKeyRateLimitSignupPerHour), time.Hour)
+	}
+	return ls
+}
+
+// run periodically purges idle in-memory buckets
+func (svc *rateLimitService) run() {
+	for {
+		time.Sleep(util.RateLimitSweepInterval)
+		svc.mem.purge(time.Now().UTC().Add(-util.RateLimitBucketTTL))
+	}
+}
+
+// store returns the bucket store in use
+func (svc *rateLimitService) store() rateLimitStore {
+	if config.ServerConfig.RateLimitDB {
+		return &rateLimitDBStore{}
+	}
+	return svc.mem
+}
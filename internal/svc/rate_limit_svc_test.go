@@ -0,0 +1,117 @@
+package svc
+
+import (
+	"fmt"
+	"gitlab.com/comentario/comentario/internal/util"
+	"testing"
+	"time"
+)
+
+func Test_rateLimitBucket_take(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		tokens   float64
+		elapsed  time.Duration
+		want     bool
+		wantWait time.Duration
+		wantLeft float64
+	}{
+		{"full bucket          ", 10, 0, true, 0, 9},
+		{"last token           ", 1, 0, true, 0, 0},
+		{"empty bucket         ", 0, 0, false, 6 * time.Second, 0},
+		{"half token           ", 0.5, 0, false, 3 * time.Second, 0.5},
+		{"refilled partially   ", 0, 6 * time.Second, true, 0, 0},
+		{"refill capped        ", 5, time.Hour, true, 0, 9},
+		{"refill not sufficient", 0, 3 * time.Second, false, 3 * time.Second, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &rateLimitBucket{Tokens: tt.tokens, UpdatedTime: t0}
+			got, wait := b.take(10, time.Minute, t0.Add(tt.elapsed))
+			if got != tt.want {
+				t.Errorf("take() got = %v, want %v", got, tt.want)
+			}
+			if wait.Round(time.Millisecond) != tt.wantWait {
+				t.Errorf("take() wait = %v, want %v", wait, tt.wantWait)
+			}
+			if d := b.Tokens - tt.wantLeft; d < -1e-9 || d > 1e-9 {
+				t.Errorf("take() tokens left = %v, want %v", b.Tokens, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func Test_rateLimitTakeAll(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ls := []rateLimit{
+		{key: "ip", limit: 10, period: time.Minute},
+		{key: "domain", limit: 100, period: time.Minute},
+	}
+	tests := []struct {
+		name     string
+		tokens   []float64
+		want     bool
+		wantWait time.Duration
+		wantLeft []float64
+	}{
+		{"all allow        ", []float64{10, 100}, true, 0, []float64{9, 99}},
+		{"narrow refuses   ", []float64{0, 100}, false, 6 * time.Second, []float64{0, 100}},
+		{"wide refuses     ", []float64{10, 0}, false, 600 * time.Millisecond, []float64{10, 0}},
+		{"both refuse      ", []float64{0, 0}, false, 6 * time.Second, []float64{0, 0}},
+		{"last tokens taken", []float64{1, 1}, true, 0, []float64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := make([]*rateLimitBucket, len(tt.tokens))
+			for i, tokens := range tt.tokens {
+				bs[i] = &rateLimitBucket{Tokens: tokens, UpdatedTime: t0}
+			}
+			got, wait := rateLimitTakeAll(bs, ls, t0)
+			if got != tt.want {
+				t.Errorf("rateLimitTakeAll() got = %v, want %v", got, tt.want)
+			}
+			if wait.Round(time.Millisecond) != tt.wantWait {
+				t.Errorf("rateLimitTakeAll() wait = %v, want %v", wait, tt.wantWait)
+			}
+			for i, b := range bs {
+				if d := b.Tokens - tt.wantLeft[i]; d < -1e-9 || d > 1e-9 {
+					t.Errorf("rateLimitTakeAll() tokens left in bucket %d = %v, want %v", i, b.Tokens, tt.wantLeft[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_rateLimitMemStore_makeRoom(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		idle      int
+		wantCount int
+	}{
+		{"idle buckets purged  ", 10, util.RateLimitMaxBuckets - 10},
+		{"oldest bucket evicted", 0, util.RateLimitMaxBuckets - 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fill the store up, the first bucket being the oldest one
+			s := &rateLimitMemStore{buckets: map[string]*rateLimitBucket{}}
+			for i := 0; i < util.RateLimitMaxBuckets; i++ {
+				ts := t0.Add(time.Duration(i) * time.Millisecond)
+				if i < tt.idle {
+					ts = t0.Add(-2 * util.RateLimitBucketTTL)
+				}
+				k := fmt.Sprintf("key%d", i)
+				s.buckets[k] = &rateLimitBucket{Key: k, UpdatedTime: ts}
+			}
+			s.makeRoom(t0.Add(time.Minute))
+			if len(s.buckets) != tt.wantCount {
+				t.Errorf("makeRoom() left %d buckets, want %d", len(s.buckets), tt.wantCount)
+			}
+			if _, ok := s.buckets["key0"]; ok {
+				t.Errorf("makeRoom() didn't remove the oldest bucket")
+			}
+		})
+	}
+}
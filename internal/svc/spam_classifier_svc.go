@@ -0,0 +1,316 @@
+package svc
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/util"
+	"math"
+	"sort"
+	"time"
+)
+
+// TheSpamClassifierService is a global SpamClassifierService implementation
+var TheSpamClassifierService SpamClassifierService = &spamClassifierService{}
+
+// SpamClassifierService is a service interface for dealing with per-domain Bayesian spam classifier models
+type SpamClassifierService interface {
+	// Classify returns the probability (0..1) of the given text being spam according to the model of the given domain,
+	// along with the number of spam and legitimate comments the model has learnt from
+	Classify(domainID *uuid.UUID, text string) (float64, int, int, error)
+	// Learn updates the model of the given domain with the comment with the given ID and text, classified as spam or
+	// legitimate. If the comment was learnt before with the opposite class, it's unlearnt first
+	Learn(domainID, commentID *uuid.UUID, text string, isSpam bool) error
+	// Retrain discards the model of the given domain and trains it anew from all moderated, non-deleted comments in the
+	// domain: rejected ones are considered spam, approved ones legitimate. Returns the number of learnt spam and
+	// legitimate comments
+	Retrain(domainID *uuid.UUID) (int, int, error)
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+const (
+	bayesMaxTokens         = 1000 // Max number of distinct tokens taken from a single text
+	bayesMaxTokenLength    = 64   // Max token length, in bytes; longer tokens are ignored
+	bayesInterestingTokens = 15   // Max number of the most telling tokens used for classification
+	bayesInsertBatchSize   = 500  // Max number of rows inserted in a single statement
+)
+
+// bayesModel is a per-domain Bayesian spam classifier model
+type bayesModel struct {
+	DomainID    uuid.UUID `db:"domain_id" goqu:"skipupdate"` // Reference to the domain
+	CountSpam   int       `db:"count_spam"`                  // Number of learnt spam comments
+	CountHam    int       `db:"count_ham"`                   // Number of learnt legitimate comments
+	UpdatedTime time.Time `db:"ts_updated"`                  // When the model was last updated
+}
+
+// bayesToken holds the number of learnt spam and legitimate comments containing a token
+type bayesToken struct {
+	DomainID  uuid.UUID `db:"domain_id"`  // Reference to the domain
+	Token     string    `db:"token"`      // Token (lowercase word)
+	CountSpam int       `db:"count_spam"` // Number of learnt spam comments containing the token
+	CountHam  int       `db:"count_ham"`  // Number of learnt legitimate comments containing the token
+}
+
+// bayesComment records a comment learnt by a domain's model
+type bayesComment struct {
+	CommentID uuid.UUID `db:"comment_id" goqu:"skipupdate"` // Reference to the learnt comment
+	DomainID  uuid.UUID `db:"domain_id"`                    // Reference to the domain
+	IsSpam    bool      `db:"is_spam"`                      // Whether the comment was learnt as spam
+}
+
+// spamClassifierService is a blueprint SpamClassifierService implementation
+type spamClassifierService struct{}
+
+func (svc *spamClassifierService) Classify(domainID *uuid.UUID, text string) (float64, int, int, error) {
+	logger.Debugf("spamClassifierService.Classify(%s, ...)", domainID)
+
+	// Fetch the model. If there's none, nothing has been learnt yet
+	var m bayesModel
+	if ok, err := db.From("cm_bayes_models").Where(goqu.Ex{"domain_id": domainID}).ScanStruct(&m); err != nil {
+		logger.Errorf("spamClassifierService.Classify: ScanStruct() failed: %v", err)
+		return 0, 0, 0, translateDBErrors(err)
+	} else if !ok {
+		return 0.5, 0, 0, nil
+	}
+
+	// Fetch the counts for the text's tokens
+	var tokens []bayesToken
+	if ts := bayesTokens(text); len(ts) > 0 {
+		if err := db.From("cm_bayes_tokens").
+			Where(goqu.Ex{"domain_id": domainID, "token": ts}).
+			ScanStructs(&tokens); err != nil {
+			logger.Errorf("spamClassifierService.Classify: ScanStructs() failed: %v", err)
+			return 0, 0, 0, translateDBErrors(err)
+		}
+	}
+
+	// Succeeded
+	return bayesSpamProbability(m.CountSpam, m.CountHam, tokens), m.CountSpam, m.CountHam, nil
+}
+
+func (svc *spamClassifierService) Learn(domainID, commentID *uuid.UUID, text string, isSpam bool) error {
+	logger.Debugf("spamClassifierService.Learn(%s, %s, ..., %v)", domainID, commentID, isSpam)
+
+	tokens := bayesTokens(text)
+	err := db.WithTx(func(tx *goqu.TxDatabase) error {
+		// Check if the comment has been learnt before
+		var wasSpam bool
+		if ok, err := tx.From("cm_bayes_comments").
+			Select("is_spam").
+			Where(goqu.Ex{"comment_id": commentID}).
+			ScanVal(&wasSpam); err != nil {
+			return err
+		} else if ok {
+			// Nothing to do if it was learnt with the same class
+			if wasSpam == isSpam {
+				return nil
+			}
+
+			// Unlearn the comment. Its text might have changed since, so only decrement the counts that are positive
+			col := bayesCountColumn(wasSpam)
+			if len(tokens) > 0 {
+				if _, err := tx.Update("cm_bayes_tokens").
+					Set(goqu.Record{col: goqu.L(col + " - 1")}).
+					Where(goqu.Ex{"domain_id": domainID, "token": tokens}, goqu.C(col).Gt(0)).
+					Executor().Exec(); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.Update("cm_bayes_models").
+				Set(goqu.Record{col: goqu.L(col + " - 1")}).
+				Where(goqu.Ex{"domain_id": domainID}, goqu.C(col).Gt(0)).
+				Executor().Exec(); err != nil {
+				return err
+			}
+		}
+
+		// Learn the comment's tokens
+		col := bayesCountColumn(isSpam)
+		cntSpam, cntHam := util.If(isSpam, 1, 0), util.If(isSpam, 0, 1)
+		var rows []*bayesToken
+		for _, t := range tokens {
+			rows = append(rows, &bayesToken{DomainID: *domainID, Token: t, CountSpam: cntSpam, CountHam: cntHam})
+		}
+		if err := bayesInsertTokens(tx, rows, goqu.Record{col: goqu.L("t." + col + " + 1")}); err != nil {
+			return err
+		}
+
+		// Update the model's totals
+		m := bayesModel{DomainID: *domainID, CountSpam: cntSpam, CountHam: cntHam, UpdatedTime: time.Now().UTC()}
+		if _, err := tx.Insert(goqu.T("cm_bayes_models").As("m")).
+			Rows(&m).
+			OnConflict(goqu.DoUpdate("domain_id", goqu.Record{col: goqu.L("m." + col + " + 1"), "ts_updated": m.UpdatedTime})).
+			Executor().Exec(); err != nil {
+			return err
+		}
+
+		// Record the comment as learnt
+		bc := bayesComment{CommentID: *commentID, DomainID: *domainID, IsSpam: isSpam}
+		_, err := tx.Insert("cm_bayes_comments").Rows(&bc).OnConflict(goqu.DoUpdate("comment_id", &bc)).Executor().Exec()
+		return err
+	})
+	if err != nil {
+		logger.Errorf("spamClassifierService.Learn: transaction failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *spamClassifierService) Retrain(domainID *uuid.UUID) (int, int, error) {
+	logger.Debugf("spamClassifierService.Retrain(%s)", domainID)
+
+	// Fetch all moderated, non-deleted comments in the domain
+	var cs []struct {
+		ID         uuid.UUID `db:"id"`
+		Markdown   string    `db:"markdown"`
+		IsApproved bool      `db:"is_approved"`
+	}
+	if err := db.From(goqu.T("cm_comments").As("c")).
+		Select("c.id", "c.markdown", "c.is_approved").
+		Join(goqu.T("cm_domain_pages").As("p"), goqu.On(goqu.Ex{"p.id": goqu.I("c.page_id")})).
+		Where(
+			goqu.I("p.domain_id").Eq(domainID),
+			goqu.I("c.is_pending").IsFalse(),
+			goqu.I("c.is_deleted").IsFalse()).
+		ScanStructs(&cs); err != nil {
+		logger.Errorf("spamClassifierService.Retrain: ScanStructs() failed: %v", err)
+		return 0, 0, translateDBErrors(err)
+	}
+
+	// Count the tokens in memory
+	m := bayesModel{DomainID: *domainID, UpdatedTime: time.Now().UTC()}
+	tokenMap := make(map[string]*bayesToken)
+	var bcs []*bayesComment
+	for _, c := range cs {
+		isSpam := !c.IsApproved
+		if isSpam {
+			m.CountSpam++
+		} else {
+			m.CountHam++
+		}
+		for _, t := range bayesTokens(c.Markdown) {
+			bt, ok := tokenMap[t]
+			if !ok {
+				bt = &bayesToken{DomainID: *domainID, Token: t}
+				tokenMap[t] = bt
+			}
+			if isSpam {
+				bt.CountSpam++
+			} else {
+				bt.CountHam++
+			}
+		}
+		bcs = append(bcs, &bayesComment{CommentID: c.ID, DomainID: *domainID, IsSpam: isSpam})
+	}
+	tokens := make([]*bayesToken, 0, len(tokenMap))
+	for _, bt := range tokenMap {
+		tokens = append(tokens, bt)
+	}
+
+	// Replace the model in a single transaction
+	err := db.WithTx(func(tx *goqu.TxDatabase) error {
+		// Discard the existing model
+		for _, table := range []string{"cm_bayes_comments", "cm_bayes_tokens", "cm_bayes_models"} {
+			if _, err := tx.Delete(table).Where(goqu.Ex{"domain_id": domainID}).Executor().Exec(); err != nil {
+				return err
+			}
+		}
+
+		// Insert the new one
+		if _, err := tx.Insert("cm_bayes_models").Rows(&m).Executor().Exec(); err != nil {
+			return err
+		}
+		if err := bayesInsertTokens(tx, tokens, nil); err != nil {
+			return err
+		}
+		for i := 0; i < len(bcs); i += bayesInsertBatchSize {
+			batch := bcs[i:min(i+bayesInsertBatchSize, len(bcs))]
+			if _, err := tx.Insert("cm_bayes_comments").Rows(batch).Executor().Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("spamClassifierService.Retrain: transaction failed: %v", err)
+		return 0, 0, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return m.CountSpam, m.CountHam, nil
+}
+
+// bayesCountColumn returns the name of the count column for the given class
+func bayesCountColumn(isSpam bool) string {
+	return util.If(isSpam, "count_spam", "count_ham")
+}
+
+// bayesInsertTokens inserts the given token rows in batches, applying the provided update to rows that already exist
+// (the existing row is aliased as "t"). If update is nil, the rows must not exist yet
+func bayesInsertTokens(tx *goqu.TxDatabase, tokens []*bayesToken, update goqu.Record) error {
+	for i := 0; i < len(tokens); i += bayesInsertBatchSize {
+		q := tx.Insert(goqu.T("cm_bayes_tokens").As("t")).Rows(tokens[i:min(i+bayesInsertBatchSize, len(tokens))])
+		if update != nil {
+			q = q.OnConflict(goqu.DoUpdate("domain_id, token", update))
+		}
+		if _, err := q.Executor().Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bayesSpamProbability returns the probability of a text being spam, given the number of learnt spam and legitimate
+// comments and the counts of the text's tokens, by combining the spam probabilities of the most telling tokens.
+// Returns 0.5 if there's nothing to judge by
+func bayesSpamProbability(countSpam, countHam int, tokens []bayesToken) float64 {
+	if countSpam == 0 || countHam == 0 {
+		return 0.5
+	}
+
+	// Calculate the spam probability of each known token, shifted towards neutral for rarely seen ones
+	var ps []float64
+	for _, t := range tokens {
+		n := float64(t.CountSpam + t.CountHam)
+		if n == 0 {
+			continue
+		}
+		fSpam, fHam := float64(t.CountSpam)/float64(countSpam), float64(t.CountHam)/float64(countHam)
+		ps = append(ps, (0.5+n*fSpam/(fSpam+fHam))/(1+n))
+	}
+	if len(ps) == 0 {
+		return 0.5
+	}
+
+	// Only take the most telling tokens, i.e. those with a probability the furthest from neutral
+	sort.Slice(ps, func(i, j int) bool { return math.Abs(ps[i]-0.5) > math.Abs(ps[j]-0.5) })
+	if len(ps) > bayesInterestingTokens {
+		ps = ps[:bayesInterestingTokens]
+	}
+
+	// Combine the probabilities, using logarithms to avoid underflows
+	var logSpam, logHam float64
+	for _, p := range ps {
+		logSpam += math.Log(p)
+		logHam += math.Log(1 - p)
+	}
+	return 1 / (1 + math.Exp(logHam-logSpam))
+}
+
+// bayesTokens splits the given text into a list of distinct tokens for classification
+func bayesTokens(text string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, w := range util.TextWords(text) {
+		if len(w) < 2 || len(w) > bayesMaxTokenLength || seen[w] {
+			continue
+		}
+		seen[w] = true
+		if res = append(res, w); len(res) >= bayesMaxTokens {
+			break
+		}
+	}
+	return res
+}
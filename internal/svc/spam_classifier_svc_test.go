@@ -0,0 +1,55 @@
+package svc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_bayesSpamProbability(t *testing.T) {
+	tests := []struct {
+		name      string
+		countSpam int
+		countHam  int
+		tokens    []bayesToken
+		wantMin   float64
+		wantMax   float64
+	}{
+		{"Nothing learnt", 0, 0, []bayesToken{{Token: "viagra", CountSpam: 1}}, 0.5, 0.5},
+		{"No spam learnt", 0, 10, []bayesToken{{Token: "hello", CountHam: 5}}, 0.5, 0.5},
+		{"No tokens", 10, 10, nil, 0.5, 0.5},
+		{"Unknown tokens", 10, 10, []bayesToken{{Token: "foo"}}, 0.5, 0.5},
+		{"Spammy", 10, 10, []bayesToken{{Token: "viagra", CountSpam: 9}, {Token: "cheap", CountSpam: 7, CountHam: 1}}, 0.95, 1},
+		{"Hammy", 10, 10, []bayesToken{{Token: "article", CountHam: 8}, {Token: "thanks", CountSpam: 1, CountHam: 9}}, 0, 0.01},
+		{"Neutral token", 10, 10, []bayesToken{{Token: "the", CountSpam: 10, CountHam: 10}}, 0.5, 0.5},
+		{"Rare token", 10, 10, []bayesToken{{Token: "casino", CountSpam: 1}}, 0.7, 0.8},
+		{"Unbalanced", 5, 50, []bayesToken{{Token: "casino", CountSpam: 5, CountHam: 5}}, 0.85, 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bayesSpamProbability(tt.countSpam, tt.countHam, tt.tokens); got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("bayesSpamProbability() = %v, want in range [%v, %v]", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func Test_bayesTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"Empty", "", nil},
+		{"Distinct", "Buy cheap pills, buy NOW!", []string{"buy", "cheap", "pills", "now"}},
+		{"Short words", "I a x am ok", []string{"am", "ok"}},
+		{"Long word", "hi " + strings.Repeat("z", 65), []string{"hi"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bayesTokens(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bayesTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
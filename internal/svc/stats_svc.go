@@ -242,18 +242,19 @@ func (svc *statsService) GetTopPages(isSuperuser bool, prop string, userID, doma
 
 func (svc *statsService) GetTotals(curUser *data.User) (*StatsTotals, error) {
 	logger.Debugf("statsService.GetTotals(%s)", &curUser.ID)
-	totals := &StatsTotals{CountUsersTotal: -1, CountUsersBanned: -1, CountUsersNonBanned: -1}
+	totals := &StatsTotals{CountUsersTotal: -1, CountUsersBanned: -1, CountUsersNonBanned: -1, CountRequestsRateLimited: -1}
 
 	// Collect stats for domains, domain pages, and domain users
 	if err := svc.fillDomainPageUserStats(curUser, totals); err != nil {
 		return nil, translateDBErrors(err)
 	}
 
-	// If the current user is a superuser, query numbers of users
+	// If the current user is a superuser, query numbers of users and rate-limited requests
 	if curUser.IsSuperuser {
 		if err := svc.fillUserStats(totals); err != nil {
 			return nil, translateDBErrors(err)
 		}
+		totals.CountRequestsRateLimited = TheRateLimitService.CountBlocked()
 	}
 
 	// Collect stats for comments and commenters
@@ -553,36 +554,38 @@ func getStatsStartDate(numDays int) (int, time.Time) {
 
 // StatsTotals groups total statistical figures
 type StatsTotals struct {
-	CountUsersTotal       int64 // Total number of users the current user can manage (superuser only)
-	CountUsersBanned      int64 // Number of banned users the current user can manage (superuser only)
-	CountUsersNonBanned   int64 // Number of non-banned users the current user can manage (superuser only)
-	CountDomainsOwned     int64 // Number of domains the current user owns
-	CountDomainsModerated int64 // Number of domains the current user is a moderator on
-	CountDomainsCommenter int64 // Number of domains the current user is a commenter on
-	CountDomainsReadonly  int64 // Number of domains the current user has the readonly status on
-	CountPagesModerated   int64 // Number of pages the current user can moderate
-	CountDomainUsers      int64 // Number of domain users the current user can manage
-	CountComments         int64 // Number of comments the current user can moderate
-	CountCommenters       int64 // Number of authors of comment the current user can moderate
-	CountPagesCommented   int64 // Number of pages the current user commented on
-	CountOwnComments      int64 // Number of comments the current user authored
+	CountUsersTotal          int64 // Total number of users the current user can manage (superuser only)
+	CountUsersBanned         int64 // Number of banned users the current user can manage (superuser only)
+	CountUsersNonBanned      int64 // Number of non-banned users the current user can manage (superuser only)
+	CountDomainsOwned        int64 // Number of domains the current user owns
+	CountDomainsModerated    int64 // Number of domains the current user is a moderator on
+	CountDomainsCommenter    int64 // Number of domains the current user is a commenter on
+	CountDomainsReadonly     int64 // Number of domains the current user has the readonly status on
+	CountPagesModerated      int64 // Number of pages the current user can moderate
+	CountDomainUsers         int64 // Number of domain users the current user can manage
+	CountComments            int64 // Number of comments the current user can moderate
+	CountCommenters          int64 // Number of authors of comment the current user can moderate
+	CountPagesCommented      int64 // Number of pages the current user commented on
+	CountOwnComments         int64 // Number of comments the current user authored
+	CountRequestsRateLimited int64 // Number of requests blocked by rate limiting since the server start (superuser only)
 }
 
 // ToDTO converts the object into an API model
 func (t *StatsTotals) ToDTO() *models.StatsTotals {
 	return &models.StatsTotals{
-		CountCommenters:       t.CountCommenters,
-		CountComments:         t.CountComments,
-		CountDomainUsers:      t.CountDomainUsers,
-		CountDomainsCommenter: t.CountDomainsCommenter,
-		CountDomainsModerated: t.CountDomainsModerated,
-		CountDomainsOwned:     t.CountDomainsOwned,
-		CountDomainsReadonly:  t.CountDomainsReadonly,
-		CountOwnComments:      t.CountOwnComments,
-		CountPagesCommented:   t.CountPagesCommented,
-		CountPagesModerated:   t.CountPagesModerated,
-		CountUsersBanned:      t.CountUsersBanned,
-		CountUsersNonBanned:   t.CountUsersNonBanned,
-		CountUsersTotal:       t.CountUsersTotal,
+		CountCommenters:          t.CountCommenters,
+		CountComments:            t.CountComments,
+		CountDomainUsers:         t.CountDomainUsers,
+		CountDomainsCommenter:    t.CountDomainsCommenter,
+		CountDomainsModerated:    t.CountDomainsModerated,
+		CountDomainsOwned:        t.CountDomainsOwned,
+		CountDomainsReadonly:     t.CountDomainsReadonly,
+		CountOwnComments:         t.CountOwnComments,
+		CountPagesCommented:      t.CountPagesCommented,
+		CountPagesModerated:      t.CountPagesModerated,
+		CountRequestsRateLimited: t.CountRequestsRateLimited,
+		CountUsersBanned:         t.CountUsersBanned,
+		CountUsersNonBanned:      t.CountUsersNonBanned,
+		CountUsersTotal:          t.CountUsersTotal,
 	}
 }
@@ -11,12 +11,15 @@ import (
 var logger = logging.MustGetLogger("svc")
 
 var (
-	ErrBadToken       = errors.New("services: invalid token")
-	ErrDB             = errors.New("services: database error")
-	ErrCommentTooLong = errors.New("services: comment text too long")
-	ErrEmailSend      = errors.New("services: failed to send email")
-	ErrNotFound       = errors.New("services: object not found")
-	ErrResourceFetch  = errors.New("services: failed to fetch resource")
+	ErrBadToken        = errors.New("services: invalid token")
+	ErrDB              = errors.New("services: database error")
+	ErrCommentTooLong  = errors.New("services: comment text too long")
+	ErrCommentRejected = errors.New("services: comment rejected")
+	ErrEmailSend       = errors.New("services: failed to send email")
+	ErrNotAllowed      = errors.New("services: action not allowed")
+	ErrNotFound        = errors.New("services: object not found")
+	ErrPluginPayload   = errors.New("services: invalid event payload returned by plugin")
+	ErrResourceFetch   = errors.New("services: failed to fetch resource")
 )
 
 // translateDBErrors "translates" database errors into a service error, picking the first non-nil error
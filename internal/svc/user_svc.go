@@ -61,11 +61,12 @@ type UserService interface {
 	// ListByDomain fetches and returns a list of domain users for the domain with the given ID, and the corresponding
 	// users as a UUID-indexed map. Minimum access level: domain owner
 	//   - superuser indicates whether the current user is a superuser
+	//   - shadowBannedOnly indicates whether to only include shadow-banned users.
 	//   - filter is an optional substring to filter the result by.
 	//   - sortBy is an optional property name to sort the result by. If empty, sorts by the host.
 	//   - dir is the sort direction.
 	//   - pageIndex is the page index, if negative, no pagination is applied.
-	ListByDomain(domainID *uuid.UUID, superuser bool, filter, sortBy string, dir data.SortDirection, pageIndex int) (map[uuid.UUID]*data.User, []*data.DomainUser, error)
+	ListByDomain(domainID *uuid.UUID, superuser, shadowBannedOnly bool, filter, sortBy string, dir data.SortDirection, pageIndex int) (map[uuid.UUID]*data.User, []*data.DomainUser, error)
 	// ListDomainModerators fetches and returns a list of moderator users for the domain with the given ID. If
 	// enabledNotifyOnly is true, only includes users who have moderator notifications enabled for that domain
 	ListDomainModerators(domainID *uuid.UUID, enabledNotifyOnly bool) ([]*data.User, error)
@@ -304,6 +305,8 @@ func (svc *userService) FindDomainUserByID(userID, domainID *uuid.UUID) (*data.U
 			goqu.I("du.notify_replies").As("du_notify_replies"),
 			goqu.I("du.notify_moderator").As("du_notify_moderator"),
 			goqu.I("du.notify_comment_status").As("du_notify_comment_status"),
+			goqu.I("du.notify_mentions").As("du_notify_mentions"),
+			goqu.I("du.is_shadow_banned").As("du_is_shadow_banned"),
 			goqu.I("du.ts_created").As("du_ts_created")).
 		LeftJoin(
 			goqu.T("cm_domains_users").As("du"),
@@ -542,8 +545,8 @@ func (svc *userService) List(filter, sortBy string, dir data.SortDirection, page
 	return users, nil
 }
 
-func (svc *userService) ListByDomain(domainID *uuid.UUID, superuser bool, filter, sortBy string, dir data.SortDirection, pageIndex int) (map[uuid.UUID]*data.User, []*data.DomainUser, error) {
-	logger.Debugf("userService.ListByDomain(%s, %v, '%s', '%s', %s, %d)", domainID, superuser, filter, sortBy, dir, pageIndex)
+func (svc *userService) ListByDomain(domainID *uuid.UUID, superuser, shadowBannedOnly bool, filter, sortBy string, dir data.SortDirection, pageIndex int) (map[uuid.UUID]*data.User, []*data.DomainUser, error) {
+	logger.Debugf("userService.ListByDomain(%s, %v, %v, '%s', '%s', %s, %d)", domainID, superuser, shadowBannedOnly, filter, sortBy, dir, pageIndex)
 
 	// Prepare a query
 	q := db.From(goqu.T("cm_domains_users").As("du")).
@@ -561,11 +564,18 @@ func (svc *userService) ListByDomain(domainID *uuid.UUID, superuser bool, filter
 			goqu.I("du.notify_replies").As("du_notify_replies"),
 			goqu.I("du.notify_moderator").As("du_notify_moderator"),
 			goqu.I("du.notify_comment_status").As("du_notify_comment_status"),
+			goqu.I("du.notify_mentions").As("du_notify_mentions"),
+			goqu.I("du.is_shadow_banned").As("du_is_shadow_banned"),
 			goqu.I("du.ts_created").As("du_ts_created")).
 		Join(goqu.T("cm_users").As("u"), goqu.On(goqu.Ex{"u.id": goqu.I("du.user_id")})).
 		LeftJoin(goqu.T("cm_user_avatars").As("a"), goqu.On(goqu.Ex{"a.user_id": goqu.I("du.user_id")})).
 		Where(goqu.Ex{"du.domain_id": domainID})
 
+	// Add shadow-ban filter
+	if shadowBannedOnly {
+		q = q.Where(goqu.Ex{"du.is_shadow_banned": true})
+	}
+
 	// Add substring filter
 	if filter != "" {
 		pattern := "%" + strings.ToLower(filter) + "%"
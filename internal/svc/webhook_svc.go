@@ -0,0 +1,496 @@
+package svc
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/util"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// TheWebhookService is a global WebhookService implementation
+var TheWebhookService WebhookService = &webhookService{kick: make(chan struct{}, 1)}
+
+// webhookEventPing is a pseudo-event used for testing webhooks
+const webhookEventPing = "ping"
+
+// webhookClient is an HTTP client for delivering webhook payloads. It refuses to connect to non-public addresses, to
+// prevent webhooks from reaching internal services. Since the check happens on connect, after the host name has been
+// resolved, it also covers DNS rebinding and redirects. Proxies aren't used for the same reason
+var webhookClient = &http.Client{
+	Timeout: util.WebhookDeliveryTimeout,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: util.WebhookDeliveryTimeout, Control: webhookDialControl}).DialContext,
+		TLSHandshakeTimeout: util.WebhookDeliveryTimeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     time.Minute,
+	},
+}
+
+// webhookDialControl verifies the webhook client is about to connect to a public IP address
+func webhookDialControl(_, address string, _ syscall.RawConn) error {
+	if host, _, err := net.SplitHostPort(address); err != nil {
+		return err
+	} else if !util.IsPublicIP(host) {
+		return fmt.Errorf("connecting to non-public address %s is not allowed", host)
+	}
+	return nil
+}
+
+// WebhookService is a service interface for dealing with domain webhooks and their deliveries
+type WebhookService interface {
+	// Create persists a new webhook
+	Create(w *data.Webhook) error
+	// DeleteByID deletes a webhook by its ID
+	DeleteByID(id *uuid.UUID) error
+	// FindByID finds and returns a webhook by its ID
+	FindByID(id *uuid.UUID) (*data.Webhook, error)
+	// Init the service, starting the background delivery queue processor
+	Init() error
+	// ListByDomain returns all webhooks configured for the given domain
+	ListByDomain(domainID *uuid.UUID) ([]*data.Webhook, error)
+	// ListDeliveries returns deliveries of the given webhook, latest first. If pageIndex is negative, no pagination is
+	// applied
+	ListDeliveries(webhookID *uuid.UUID, pageIndex int) ([]*data.WebhookDelivery, error)
+	// NotifyComment enqueues a delivery of the given comment event to every enabled webhook of the domain subscribed to
+	// it. Errors are only logged
+	NotifyComment(event models.WebhookEvent, domain *data.Domain, page *data.DomainPage, comment *data.Comment)
+	// NotifyUserBanned enqueues a delivery of the "user banned" event to every enabled webhook subscribed to it, on
+	// every domain the user is registered on. Errors are only logged
+	NotifyUserBanned(user *data.User)
+	// Test synchronously delivers a test event to the given webhook, logs, and returns the delivery outcome. The outcome
+	// includes the response status and error details, which must not be exposed to the user as is
+	Test(w *data.Webhook) (*data.WebhookDelivery, error)
+	// Update persists the changes of the given webhook
+	Update(w *data.Webhook) error
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+
+// webhookEnvelope is a payload delivered to a webhook
+type webhookEnvelope struct {
+	ID          uuid.UUID `json:"id"`          // Delivery ID
+	Event       string    `json:"event"`       // Event name
+	CreatedTime time.Time `json:"createdTime"` // When the event occurred
+	DomainID    uuid.UUID `json:"domainId"`    // ID of the domain the event occurred on
+	Data        any       `json:"data"`        // Event data
+}
+
+// webhookService is a blueprint WebhookService implementation
+type webhookService struct {
+	kick chan struct{} // Channel for triggering an immediate queue run
+}
+
+func (svc *webhookService) Create(w *data.Webhook) error {
+	logger.Debugf("webhookService.Create(%#v)", w)
+
+	// Insert a new record
+	if err := db.ExecOne(db.Insert("cm_webhooks").Rows(w)); err != nil {
+		logger.Errorf("webhookService.Create: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *webhookService) DeleteByID(id *uuid.UUID) error {
+	logger.Debugf("webhookService.DeleteByID(%s)", id)
+
+	// Delete the record, which also removes all its deliveries
+	if err := db.ExecOne(db.Delete("cm_webhooks").Where(goqu.Ex{"id": id})); err != nil {
+		logger.Errorf("webhookService.DeleteByID: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+func (svc *webhookService) FindByID(id *uuid.UUID) (*data.Webhook, error) {
+	logger.Debugf("webhookService.FindByID(%s)", id)
+
+	var w data.Webhook
+	if b, err := db.From("cm_webhooks").Where(goqu.Ex{"id": id}).ScanStruct(&w); err != nil {
+		logger.Errorf("webhookService.FindByID: ScanStruct() failed: %v", err)
+		return nil, translateDBErrors(err)
+	} else if !b {
+		return nil, ErrNotFound
+	}
+
+	// Succeeded
+	return &w, nil
+}
+
+func (svc *webhookService) Init() error {
+	logger.Debug("webhookService: initialising")
+	go svc.run()
+	return nil
+}
+
+func (svc *webhookService) ListByDomain(domainID *uuid.UUID) ([]*data.Webhook, error) {
+	logger.Debugf("webhookService.ListByDomain(%s)", domainID)
+
+	var ws []*data.Webhook
+	if err := db.From("cm_webhooks").Where(goqu.Ex{"domain_id": domainID}).Order(goqu.I("ts_created").Asc()).ScanStructs(&ws); err != nil {
+		logger.Errorf("webhookService.ListByDomain: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return ws, nil
+}
+
+func (svc *webhookService) ListDeliveries(webhookID *uuid.UUID, pageIndex int) ([]*data.WebhookDelivery, error) {
+	logger.Debugf("webhookService.ListDeliveries(%s, %d)", webhookID, pageIndex)
+
+	// Prepare a query
+	q := db.From("cm_webhook_deliveries").Where(goqu.Ex{"webhook_id": webhookID}).Order(goqu.I("ts_created").Desc())
+
+	// Paginate if required
+	if pageIndex >= 0 {
+		q = q.Limit(util.ResultPageSize).Offset(uint(pageIndex) * util.ResultPageSize)
+	}
+
+	// Fetch the deliveries
+	var ds []*data.WebhookDelivery
+	if err := q.ScanStructs(&ds); err != nil {
+		logger.Errorf("webhookService.ListDeliveries: ScanStructs() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return ds, nil
+}
+
+func (svc *webhookService) NotifyComment(event models.WebhookEvent, domain *data.Domain, page *data.DomainPage, comment *data.Comment) {
+	logger.Debugf("webhookService.NotifyComment(%s, [%s], [%s], [%s])", event, &domain.ID, &page.ID, &comment.ID)
+
+	// Don't expose the author's IP address
+	dto := comment.ToDTO(domain.IsHTTPS, domain.Host, page.Path)
+	dto.AuthorIP = ""
+
+	// Enqueue deliveries
+	svc.enqueue(&domain.ID, event, map[string]any{
+		"comment": dto,
+		"page":    page.CloneWithClearance(false, false).ToDTO(),
+	})
+}
+
+func (svc *webhookService) NotifyUserBanned(user *data.User) {
+	logger.Debugf("webhookService.NotifyUserBanned([%s])", &user.ID)
+
+	// Find all domain users of the user on domains having a webhook
+	var dus []*data.DomainUser
+	err := db.From("cm_domains_users").
+		Where(
+			goqu.Ex{"user_id": &user.ID},
+			goqu.I("domain_id").In(db.From("cm_webhooks").Select("domain_id").Where(goqu.Ex{"is_enabled": true}))).
+		ScanStructs(&dus)
+	if err != nil {
+		logger.Errorf("webhookService.NotifyUserBanned: ScanStructs() failed: %v", err)
+		return
+	}
+
+	// Enqueue deliveries for every domain. The user is visible to the webhook as to a domain owner
+	for _, du := range dus {
+		svc.enqueue(&du.DomainID, models.WebhookEventUserDotBanned, map[string]any{
+			"user": user.CloneWithClearance(false, true, true).ToCommenter(du.IsCommenter, du.IsModerator),
+		})
+	}
+}
+
+func (svc *webhookService) Test(w *data.Webhook) (*data.WebhookDelivery, error) {
+	logger.Debugf("webhookService.Test(%#v)", w)
+
+	// Prepare a delivery
+	d, err := svc.newDelivery(w, webhookEventPing, map[string]any{"message": "This is a test event from " + util.ApplicationName})
+	if err != nil {
+		return nil, err
+	}
+
+	// Try to deliver it right away, with no retries
+	svc.deliver(d, w)
+	webhookScrubTestDelivery(d)
+
+	// Log the delivery
+	if err := db.ExecOne(db.Insert("cm_webhook_deliveries").Rows(d)); err != nil {
+		logger.Errorf("webhookService.Test: ExecOne() failed: %v", err)
+		return nil, translateDBErrors(err)
+	}
+
+	// Succeeded
+	return d, nil
+}
+
+func (svc *webhookService) Update(w *data.Webhook) error {
+	logger.Debugf("webhookService.Update(%#v)", w)
+
+	// Update the record
+	if err := db.ExecOne(db.Update("cm_webhooks").Set(w).Where(goqu.Ex{"id": &w.ID})); err != nil {
+		logger.Errorf("webhookService.Update: ExecOne() failed: %v", err)
+		return translateDBErrors(err)
+	}
+
+	// Succeeded
+	return nil
+}
+
+// deliver attempts to deliver the payload of the given delivery to the webhook, updating the delivery's status, but not
+// persisting it
+func (svc *webhookService) deliver(d *data.WebhookDelivery, w *data.Webhook) {
+	now := time.Now().UTC()
+	d.Attempts++
+	d.LastAttemptTime = sql.NullTime{Time: now, Valid: true}
+	d.ResponseStatus = 0
+	d.Error = ""
+
+	// Post the payload and register the outcome
+	if status, err := svc.post(d, w); err != nil {
+		d.ResponseStatus = status
+		d.Error = util.TruncateStr(err.Error(), 1024)
+
+		// Schedule a retry with an exponential backoff, unless the attempts are exhausted
+		if d.Attempts < util.WebhookMaxAttempts {
+			d.Status = models.WebhookDeliveryStatusPending
+			d.NextAttemptTime = sql.NullTime{Time: now.Add(webhookRetryDelay(d.Attempts)), Valid: true}
+		} else {
+			d.Status = models.WebhookDeliveryStatusFailed
+			d.NextAttemptTime = sql.NullTime{}
+		}
+
+	} else {
+		d.ResponseStatus = status
+		d.Status = models.WebhookDeliveryStatusSucceeded
+		d.NextAttemptTime = sql.NullTime{}
+	}
+}
+
+// enqueue creates a pending delivery of the given event for each enabled webhook of the domain subscribed to the event,
+// and triggers a queue run
+func (svc *webhookService) enqueue(domainID *uuid.UUID, event models.WebhookEvent, payload any) {
+	// Fetch enabled webhooks of the domain
+	var ws []*data.Webhook
+	if err := db.From("cm_webhooks").Where(goqu.Ex{"domain_id": domainID, "is_enabled": true}).ScanStructs(&ws); err != nil {
+		logger.Errorf("webhookService.enqueue: ScanStructs() failed: %v", err)
+		return
+	}
+
+	// Create a delivery for every subscribed webhook
+	cnt := 0
+	for _, w := range ws {
+		if !w.HasEvent(event) {
+			continue
+		}
+		if d, err := svc.newDelivery(w, string(event), payload); err != nil {
+			logger.Errorf("webhookService.enqueue: failed to create delivery: %v", err)
+		} else if err := db.ExecOne(db.Insert("cm_webhook_deliveries").Rows(d)); err != nil {
+			logger.Errorf("webhookService.enqueue: ExecOne() failed: %v", err)
+		} else {
+			cnt++
+		}
+	}
+
+	// Trigger a queue run, non-blocking
+	if cnt > 0 {
+		select {
+		case svc.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// newDelivery creates and returns a new, pending delivery of the given event for the given webhook
+func (svc *webhookService) newDelivery(w *data.Webhook, event string, payload any) (*data.WebhookDelivery, error) {
+	now := time.Now().UTC()
+	d := &data.WebhookDelivery{
+		ID:              uuid.New(),
+		WebhookID:       w.ID,
+		Event:           event,
+		Status:          models.WebhookDeliveryStatusPending,
+		CreatedTime:     now,
+		NextAttemptTime: sql.NullTime{Time: now, Valid: true},
+	}
+
+	// Serialise the payload
+	b, err := json.Marshal(&webhookEnvelope{
+		ID:          d.ID,
+		Event:       event,
+		CreatedTime: now,
+		DomainID:    w.DomainID,
+		Data:        payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = string(b)
+	return d, nil
+}
+
+// post signs and posts the payload of the given delivery to the webhook, returning the response status code, if any
+func (svc *webhookService) post(d *data.WebhookDelivery, w *data.Webhook) (int, error) {
+	// Prepare a request
+	rq, err := webhookRequest(d, w)
+	if err != nil {
+		return 0, err
+	}
+
+	// Submit the request
+	resp, err := webhookClient.Do(rq)
+	if err != nil {
+		return 0, err
+	}
+	defer util.LogError(resp.Body.Close, "webhookService.post, resp.Body.Close()")
+
+	// Drain the response body to allow connection reuse
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	// Any 2xx status is considered a success
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with HTTP status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// claim tries to claim the given due delivery for this instance by postponing its next attempt, which only succeeds if
+// no other instance has already done that. Should the instance fail to complete the delivery, it becomes due again
+// after the claim times out
+func (svc *webhookService) claim(d *data.WebhookDelivery, now time.Time) (bool, error) {
+	err := db.ExecOne(
+		db.Update("cm_webhook_deliveries").
+			Set(goqu.Record{"ts_next_attempt": now.Add(util.WebhookClaimTimeout)}).
+			Where(
+				goqu.Ex{"id": &d.ID, "status": models.WebhookDeliveryStatusPending},
+				goqu.C("ts_next_attempt").Lte(now)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	// Succeeded
+	return true, nil
+}
+
+// processQueue attempts to deliver all due pending deliveries
+func (svc *webhookService) processQueue() {
+	// Fetch due deliveries along with their webhooks
+	var recs []struct {
+		data.WebhookDelivery
+		URL       string    `db:"url"`
+		Secret    string    `db:"secret"`
+		DomainID  uuid.UUID `db:"domain_id"`
+		IsEnabled bool      `db:"is_enabled"`
+	}
+	err := db.From(goqu.T("cm_webhook_deliveries").As("d")).
+		Select("d.*", "w.url", "w.secret", "w.domain_id", "w.is_enabled").
+		Join(goqu.T("cm_webhooks").As("w"), goqu.On(goqu.Ex{"w.id": goqu.I("d.webhook_id")})).
+		Where(
+			goqu.Ex{"d.status": models.WebhookDeliveryStatusPending},
+			goqu.I("d.ts_next_attempt").Lte(time.Now().UTC())).
+		Order(goqu.I("d.ts_next_attempt").Asc()).
+		Limit(100).
+		ScanStructs(&recs)
+	if err != nil {
+		logger.Errorf("webhookService.processQueue: ScanStructs() failed: %v", err)
+		return
+	}
+
+	// Iterate the deliveries
+	for _, r := range recs {
+		d := &r.WebhookDelivery
+
+		// Claim the delivery, skipping it if another instance has already done so
+		if ok, err := svc.claim(d, time.Now().UTC()); err != nil {
+			logger.Errorf("webhookService.processQueue: claim() failed: %v", err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		// Deliver the payload
+		if r.IsEnabled {
+			svc.deliver(d, &data.Webhook{ID: d.WebhookID, DomainID: r.DomainID, URL: r.URL, Secret: r.Secret})
+		} else {
+			// The webhook has been disabled in the meantime
+			d.Status = models.WebhookDeliveryStatusFailed
+			d.NextAttemptTime = sql.NullTime{}
+			d.Error = "Webhook is disabled"
+		}
+
+		// Update the delivery record
+		if err := db.ExecOne(db.Update("cm_webhook_deliveries").Set(d).Where(goqu.Ex{"id": &d.ID})); err != nil {
+			logger.Errorf("webhookService.processQueue: ExecOne() failed: %v", err)
+		}
+	}
+}
+
+// run processes the delivery queue continuously
+func (svc *webhookService) run() {
+	logger.Debug("webhookService: starting delivery queue processor")
+	for {
+		svc.processQueue()
+
+		// Wait for a trigger or until the next scheduled run
+		select {
+		case <-svc.kick:
+		case <-time.After(util.WebhookQueueInterval):
+		}
+	}
+}
+
+// webhookRequest creates and returns a new request for posting the payload of the given delivery to the webhook, signed
+// with the webhook's secret
+func webhookRequest(d *data.WebhookDelivery, w *data.Webhook) (*http.Request, error) {
+	// Sign the payload
+	secret, err := w.SecretBytes()
+	if err != nil {
+		return nil, err
+	}
+	body := []byte(d.Payload)
+	sig := util.HMACSign(body, secret)
+
+	// Prepare a request
+	rq, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	rq.Header.Set("Content-Type", "application/json")
+	rq.Header.Set("User-Agent", util.ApplicationName)
+	rq.Header.Set(util.HeaderWebhookDelivery, d.ID.String())
+	rq.Header.Set(util.HeaderWebhookEvent, d.Event)
+	rq.Header.Set(util.HeaderWebhookSignature, "sha256="+hex.EncodeToString(sig))
+	return rq, nil
+}
+
+// webhookRetryDelay returns the delay before retrying a delivery that has failed the given number of attempts, which
+// grows exponentially
+func webhookRetryDelay(attempts int) time.Duration {
+	return util.WebhookRetryDelay << (attempts - 1)
+}
+
+// webhookScrubTestDelivery finalises the given test delivery, which is never retried, and drops the response status
+// and the error details from it: unlike those of real events, test deliveries can be triggered at will, so the details
+// could be used for probing hosts and ports
+func webhookScrubTestDelivery(d *data.WebhookDelivery) {
+	d.NextAttemptTime = sql.NullTime{}
+	d.ResponseStatus = 0
+	switch d.Status {
+	case models.WebhookDeliveryStatusSucceeded:
+		d.Error = ""
+	default:
+		d.Status = models.WebhookDeliveryStatusFailed
+		d.Error = "Test delivery failed"
+	}
+}
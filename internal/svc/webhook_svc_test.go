@@ -0,0 +1,149 @@
+package svc
+
+import (
+	"database/sql"
+	"github.com/google/uuid"
+	"gitlab.com/comentario/comentario/internal/api/models"
+	"gitlab.com/comentario/comentario/internal/data"
+	"gitlab.com/comentario/comentario/internal/util"
+	"io"
+	"testing"
+	"time"
+)
+
+func Test_webhookRequest(t *testing.T) {
+	const secret = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	id := uuid.MustParse("4c63f372-8d3f-98d9-cc04-c082898f55a5")
+	tests := []struct {
+		name    string
+		secret  string
+		payload string
+		wantSig string
+		wantErr bool
+	}{
+		{"payload      ", secret, `{"event":"ping"}`, "sha256=f870aae17e0f7ee971c16e7c1d4376f9a8e4c6ebcee70b47f32985004d7a8f62", false},
+		{"empty payload", secret, "", "sha256=d38b42096d80f45f826b44a9d5607de72496a415d3f4a1a8c88e3bb9da8dc1cb", false},
+		{"bad secret   ", "zz", `{}`, "", true},
+		{"short secret ", "0001", `{}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &data.WebhookDelivery{ID: id, Event: "ping", Payload: tt.payload}
+			w := &data.Webhook{URL: "https://example.com/hook", Secret: tt.secret}
+			rq, err := webhookRequest(d, w)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("webhookRequest() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+			if got := rq.Header.Get(util.HeaderWebhookSignature); got != tt.wantSig {
+				t.Errorf("webhookRequest() signature = %v, want %v", got, tt.wantSig)
+			}
+			if got := rq.Header.Get(util.HeaderWebhookDelivery); got != id.String() {
+				t.Errorf("webhookRequest() delivery = %v, want %v", got, id)
+			}
+			if got := rq.Header.Get(util.HeaderWebhookEvent); got != "ping" {
+				t.Errorf("webhookRequest() event = %v, want ping", got)
+			}
+			if b, err := io.ReadAll(rq.Body); err != nil {
+				t.Errorf("webhookRequest() failed to read body: %v", err)
+			} else if string(b) != tt.payload {
+				t.Errorf("webhookRequest() body = %v, want %v", string(b), tt.payload)
+			}
+		})
+	}
+}
+
+func Test_webhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{"first  ", 1, 30 * time.Second},
+		{"second ", 2, time.Minute},
+		{"third  ", 3, 2 * time.Minute},
+		{"seventh", 7, 32 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookRetryDelay(tt.attempts); got != tt.want {
+				t.Errorf("webhookRetryDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webhookService_deliver(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempts   int
+		wantStatus models.WebhookDeliveryStatus
+		wantDelay  time.Duration
+	}{
+		{"first failure    ", 0, models.WebhookDeliveryStatusPending, 30 * time.Second},
+		{"second failure   ", 1, models.WebhookDeliveryStatusPending, time.Minute},
+		{"next to last     ", util.WebhookMaxAttempts - 2, models.WebhookDeliveryStatusPending, webhookRetryDelay(util.WebhookMaxAttempts - 1)},
+		{"attempts exceeded", util.WebhookMaxAttempts - 1, models.WebhookDeliveryStatusFailed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An invalid secret makes the delivery fail without any network activity
+			d := &data.WebhookDelivery{Attempts: tt.attempts, Status: models.WebhookDeliveryStatusPending}
+			(&webhookService{}).deliver(d, &data.Webhook{URL: "https://example.com/hook", Secret: "invalid"})
+			if d.Attempts != tt.attempts+1 {
+				t.Errorf("deliver() attempts = %v, want %v", d.Attempts, tt.attempts+1)
+			}
+			if d.Status != tt.wantStatus {
+				t.Errorf("deliver() status = %v, want %v", d.Status, tt.wantStatus)
+			}
+			if d.Error == "" {
+				t.Errorf("deliver() error is empty")
+			}
+			if tt.wantDelay == 0 {
+				if d.NextAttemptTime.Valid {
+					t.Errorf("deliver() next attempt = %v, want none", d.NextAttemptTime.Time)
+				}
+			} else if got := d.NextAttemptTime.Time.Sub(d.LastAttemptTime.Time); !d.NextAttemptTime.Valid || got != tt.wantDelay {
+				t.Errorf("deliver() retry delay = %v, want %v", got, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func Test_webhookScrubTestDelivery(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    models.WebhookDeliveryStatus
+		err       string
+		want      models.WebhookDeliveryStatus
+		wantError string
+	}{
+		{"succeeded", models.WebhookDeliveryStatusSucceeded, "", models.WebhookDeliveryStatusSucceeded, ""},
+		{"to retry ", models.WebhookDeliveryStatusPending, "dial tcp 203.0.113.1:22: connection refused", models.WebhookDeliveryStatusFailed, "Test delivery failed"},
+		{"failed   ", models.WebhookDeliveryStatusFailed, "unexpected HTTP status 404", models.WebhookDeliveryStatusFailed, "Test delivery failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &data.WebhookDelivery{
+				Status:          tt.status,
+				Error:           tt.err,
+				ResponseStatus:  404,
+				NextAttemptTime: sql.NullTime{Time: time.Now(), Valid: true},
+			}
+			webhookScrubTestDelivery(d)
+			if d.Status != tt.want {
+				t.Errorf("webhookScrubTestDelivery() status = %v, want %v", d.Status, tt.want)
+			}
+			if d.Error != tt.wantError {
+				t.Errorf("webhookScrubTestDelivery() error = %q, want %q", d.Error, tt.wantError)
+			}
+			if d.ResponseStatus != 0 {
+				t.Errorf("webhookScrubTestDelivery() response status = %v, want 0", d.ResponseStatus)
+			}
+			if d.NextAttemptTime.Valid {
+				t.Errorf("webhookScrubTestDelivery() next attempt = %v, want none", d.NextAttemptTime.Time)
+			}
+		})
+	}
+}
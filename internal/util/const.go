@@ -23,7 +23,17 @@ const (
 
 	ResultPageSize = 25 // Max number of database rows to return
 
+	MaxBulkComments = 1000 // Max number of comments processed by a single bulk moderation request
+
+	MaxRecentComments = 500 // Max number of recent comments to check a new comment against for duplicates and flooding
+
 	MaxNumberStatsDays = 30 // Max number of days to get statistics for
+
+	MaxMentionLength      = 255 // Max length (in bytes) of a user name in a mention
+	MaxCommentMentions    = 10  // Max number of users that can be mentioned (and notified) in a single comment
+	MaxMentionSuggestions = 10  // Max number of users suggested for a mention
+
+	WebhookMaxAttempts = 8 // Max number of attempts to deliver a webhook payload
 )
 
 // Cookie names
@@ -41,6 +51,10 @@ const (
 const (
 	HeaderUserSession = "X-User-Session" // Name of the header that contains the session of the authenticated user
 	HeaderXSRFToken   = "X-Xsrf-Token"   // Header name that the request should provide the XSRF token in #nosec G101
+
+	HeaderWebhookDelivery  = "X-Comentario-Delivery"  // Name of the header that contains the ID of a webhook delivery
+	HeaderWebhookEvent     = "X-Comentario-Event"     // Name of the header that contains the event of a webhook delivery
+	HeaderWebhookSignature = "X-Comentario-Signature" // Name of the header that contains the HMAC signature of a webhook payload
 )
 
 // Durations
@@ -55,6 +69,24 @@ const (
 	AvatarFetchTimeout       = 5 * time.Second  // Timeout for fetching external avatars
 	ConfigCacheTTL           = 30 * time.Second // TTL for cached configs
 	AttrCacheTTL             = 10 * time.Second // TTL for cached attributes
+	MailSendTimeout          = 30 * time.Second // Timeout for sending an email via an HTTP API
+
+	WebhookDeliveryTimeout         = 10 * time.Second // Timeout for delivering a webhook payload
+	WebhookQueueInterval           = 10 * time.Second // Interval between webhook delivery queue runs
+	WebhookRetryDelay              = 30 * time.Second // Delay before the first webhook delivery retry, doubled on every next attempt
+	WebhookClaimTimeout            = time.Minute      // How long a webhook delivery claimed by an instance is kept from other instances
+	WebhookDeliveryRetentionPeriod = 30 * OneDay      // How long a completed webhook delivery record is retained
+
+	DomainBanRetentionPeriod = 30 * OneDay // How long an expired domain ban rule is retained
+
+	CommentScanRetentionPeriod = 30 * OneDay // How long a comment scan record, containing the commenter's request details, is retained
+
+	RateLimitBucketTTL     = time.Hour        // How long an idle rate limit bucket is retained (it's full by then anyway)
+	RateLimitSweepInterval = 10 * time.Minute // Interval between purges of idle in-memory rate limit buckets
+	RateLimitMaxBuckets    = 100_000          // Max number of in-memory rate limit buckets
+
+	PluginJobMinInterval     = 10 * time.Second // Minimal interval between runs of a plugin's scheduled job
+	PluginJobShutdownTimeout = 10 * time.Second // How long to wait for running plugin jobs to finish on shutdown
 )
 
 var (
@@ -0,0 +1,266 @@
+package util
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gitlab.com/comentario/comentario/internal/intf"
+	"gopkg.in/gomail.v2"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// mailTransports is a registry of named Mailer implementations, supplied by plugins
+var mailTransports sync.Map
+
+// RegisterMailTransport registers a named Mailer implementation, which can then be chosen as the mail transport
+func RegisterMailTransport(name string, m intf.Mailer) error {
+	if _, loaded := mailTransports.LoadOrStore(name, m); loaded {
+		return fmt.Errorf("mail transport %q is already registered", name)
+	}
+	return nil
+}
+
+// newMailMessage composes and returns a new email message
+func newMailMessage(emailFrom, replyTo, recipient, subject, htmlMessage string, embedFiles ...string) *gomail.Message {
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", emailFrom)
+	msg.SetHeader("To", recipient)
+	msg.SetHeader("Subject", subject)
+	msg.SetBody("text/html", htmlMessage)
+	if replyTo != "" {
+		msg.SetHeader("Reply-To", replyTo)
+	}
+
+	// Embed files
+	for _, file := range embedFiles {
+		msg.Embed(file)
+	}
+	return msg
+}
+
+// uniqueMailName returns a new unique name suitable for a mail file
+func uniqueMailName() (string, error) {
+	b, err := RandomBytes(8)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%s", time.Now().UnixNano(), hex.EncodeToString(b)), nil
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// NewSendmailMailer instantiates a new Mailer that pipes emails into a local sendmail-compatible binary
+func NewSendmailMailer(path string, args []string, emailFrom string) intf.Mailer {
+	return &sendmailMailer{path: path, args: args, emailFrom: emailFrom}
+}
+
+// sendmailMailer is a Mailer implementation that sends emails using a local sendmail binary
+type sendmailMailer struct {
+	path      string
+	args      []string
+	emailFrom string
+}
+
+func (m *sendmailMailer) Operational() bool {
+	return true
+}
+
+func (m *sendmailMailer) Mail(replyTo, recipient, subject, htmlMessage string, embedFiles ...string) error {
+	// Render the message
+	var buf bytes.Buffer
+	if _, err := newMailMessage(m.emailFrom, replyTo, recipient, subject, htmlMessage, embedFiles...).WriteTo(&buf); err != nil {
+		return err
+	}
+
+	// Pipe it into sendmail
+	cmd := exec.Command(m.path, m.args...)
+	cmd.Stdin = &buf
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sendmail failed: %w (output: %s)", err, TruncateStr(string(out), 1024))
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// NewDirMailer instantiates a new Mailer that writes emails as files into the given directory, either as plain .eml
+// files, or using the Maildir layout
+func NewDirMailer(dir string, maildir bool, emailFrom string) intf.Mailer {
+	return &dirMailer{dir: dir, maildir: maildir, emailFrom: emailFrom}
+}
+
+// dirMailer is a Mailer implementation that writes emails into a directory
+type dirMailer struct {
+	dir       string
+	maildir   bool
+	emailFrom string
+}
+
+func (m *dirMailer) Operational() bool {
+	return true
+}
+
+func (m *dirMailer) Mail(replyTo, recipient, subject, htmlMessage string, embedFiles ...string) error {
+	// Render the message
+	var buf bytes.Buffer
+	if _, err := newMailMessage(m.emailFrom, replyTo, recipient, subject, htmlMessage, embedFiles...).WriteTo(&buf); err != nil {
+		return err
+	}
+
+	// Generate a unique file name
+	name, err := uniqueMailName()
+	if err != nil {
+		return err
+	}
+
+	// Plain directory: write an .eml file
+	if !m.maildir {
+		if err := os.MkdirAll(m.dir, 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(m.dir, name+".eml"), buf.Bytes(), 0o644)
+	}
+
+	// Maildir: write into tmp/, then atomically move into new/
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(m.dir, sub), 0o755); err != nil {
+			return err
+		}
+	}
+	tmpName := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmpName, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, filepath.Join(m.dir, "new", name))
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// HTTPMailerAttachment is an inline attachment of an email posted to an HTTP API
+type HTTPMailerAttachment struct {
+	Filename    string `json:"filename"`    // Attachment file name, which also serves as its content ID
+	ContentType string `json:"contentType"` // MIME type of the attachment
+	Content     string `json:"content"`     // Base64-encoded attachment content
+}
+
+// HTTPMailerPayload is a JSON payload posted to an HTTP API
+type HTTPMailerPayload struct {
+	From        string                 `json:"from"`                  // Sender address
+	To          string                 `json:"to"`                    // Recipient address
+	ReplyTo     string                 `json:"replyTo,omitempty"`     // Optional Reply-To address
+	Subject     string                 `json:"subject"`               // Email subject
+	HTML        string                 `json:"html"`                  // HTML body
+	Attachments []HTTPMailerAttachment `json:"attachments,omitempty"` // Inline attachments, referenced in the body as "cid:<filename>"
+}
+
+// NewHTTPMailer instantiates a new Mailer that posts emails as JSON to the given HTTP API endpoint, adding the given
+// headers to each request
+func NewHTTPMailer(url string, headers map[string]string, emailFrom string, insecure bool) intf.Mailer {
+	m := &httpMailer{url: url, headers: headers, emailFrom: emailFrom, client: &http.Client{Timeout: MailSendTimeout}}
+	if insecure {
+		m.client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	return m
+}
+
+// httpMailer is a Mailer implementation that sends emails using an HTTP API
+type httpMailer struct {
+	url       string
+	headers   map[string]string
+	emailFrom string
+	client    *http.Client
+}
+
+func (m *httpMailer) Operational() bool {
+	return true
+}
+
+func (m *httpMailer) Mail(replyTo, recipient, subject, htmlMessage string, embedFiles ...string) error {
+	// Prepare a payload
+	p := &HTTPMailerPayload{From: m.emailFrom, To: recipient, ReplyTo: replyTo, Subject: subject, HTML: htmlMessage}
+	for _, file := range embedFiles {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(file)
+		ct := mime.TypeByExtension(filepath.Ext(name))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+		p.Attachments = append(p.Attachments, HTTPMailerAttachment{
+			Filename:    name,
+			ContentType: ct,
+			Content:     base64.StdEncoding.EncodeToString(b),
+		})
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	// Prepare a request
+	req, err := http.NewRequest(http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range m.headers {
+		req.Header.Set(k, v)
+	}
+
+	// Submit it
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer LogError(resp.Body.Close, "httpMailer.Mail, resp.Body.Close()")
+
+	// Any 2xx status is considered a success
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("mail API responded with HTTP status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// NewRegistryMailer instantiates a new Mailer that delegates to a mail transport registered with the given name. The
+// lookup is done on every call, so the transport can be registered later
+func NewRegistryMailer(name string) intf.Mailer {
+	return &registryMailer{name: name}
+}
+
+// registryMailer is a Mailer implementation that delegates to a registered mail transport
+type registryMailer struct {
+	name string
+}
+
+func (m *registryMailer) Operational() bool {
+	mt, ok := m.transport()
+	return ok && mt.Operational()
+}
+
+func (m *registryMailer) Mail(replyTo, recipient, subject, htmlMessage string, embedFiles ...string) error {
+	if mt, ok := m.transport(); ok {
+		return mt.Mail(replyTo, recipient, subject, htmlMessage, embedFiles...)
+	}
+	return fmt.Errorf("mail transport %q isn't registered", m.name)
+}
+
+// transport returns the registered mail transport, if any
+func (m *registryMailer) transport() (intf.Mailer, bool) {
+	if v, ok := mailTransports.Load(m.name); ok {
+		return v.(intf.Mailer), true
+	}
+	return nil, false
+}
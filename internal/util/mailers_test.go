@@ -0,0 +1,344 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readMailDir returns the contents of the files in the given directory, failing the test on an error
+func readMailDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir(%q) failed: %v", dir, err)
+	}
+	var res []string
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile(%q) failed: %v", e.Name(), err)
+		}
+		res = append(res, string(b))
+	}
+	return res
+}
+
+// checkMailHeaders verifies the given rendered message contains the expected headers and body
+func checkMailHeaders(t *testing.T, msg, replyTo string) {
+	t.Helper()
+	for _, s := range []string{
+		"From: noreply@example.com\r\n",
+		"To: jane@example.com\r\n",
+		"Subject: Greetings\r\n",
+		"Content-Type: text/html; charset=UTF-8\r\n",
+		"<p>Hello</p>",
+	} {
+		if !strings.Contains(msg, s) {
+			t.Errorf("message doesn't contain %q:\n%s", s, msg)
+		}
+	}
+	if got := strings.Contains(msg, "Reply-To: "+replyTo+"\r\n"); got != (replyTo != "") {
+		t.Errorf("message contains Reply-To = %v, want %v:\n%s", got, replyTo != "", msg)
+	}
+}
+
+func Test_dirMailer_Mail(t *testing.T) {
+	tests := []struct {
+		name    string
+		maildir bool
+		replyTo string
+	}{
+		{"plain directory         ", false, ""},
+		{"plain directory, replyTo", false, "support@example.com"},
+		{"maildir                 ", true, ""},
+		{"maildir, replyTo        ", true, "support@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Use a non-existent subdirectory to verify it gets created
+			dir := filepath.Join(t.TempDir(), "mail")
+			m := NewDirMailer(dir, tt.maildir, "noreply@example.com")
+			if !m.Operational() {
+				t.Errorf("Operational() = false, want true")
+			}
+
+			// Send two messages to verify they get unique names
+			for i := 0; i < 2; i++ {
+				if err := m.Mail(tt.replyTo, "jane@example.com", "Greetings", "<p>Hello</p>"); err != nil {
+					t.Fatalf("Mail() error = %v", err)
+				}
+			}
+
+			// Verify the output
+			var msgs []string
+			if tt.maildir {
+				msgs = readMailDir(t, filepath.Join(dir, "new"))
+				if l := readMailDir(t, filepath.Join(dir, "tmp")); len(l) != 0 {
+					t.Errorf("tmp/ contains %d files, want none", len(l))
+				}
+				if l := readMailDir(t, filepath.Join(dir, "cur")); len(l) != 0 {
+					t.Errorf("cur/ contains %d files, want none", len(l))
+				}
+			} else {
+				msgs = readMailDir(t, dir)
+				if matches, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(matches) != len(msgs) {
+					t.Errorf("got %d .eml files out of %d", len(matches), len(msgs))
+				}
+			}
+			if len(msgs) != 2 {
+				t.Fatalf("got %d messages, want 2", len(msgs))
+			}
+			for _, msg := range msgs {
+				checkMailHeaders(t, msg, tt.replyTo)
+			}
+		})
+	}
+}
+
+func Test_dirMailer_Mail_Error(t *testing.T) {
+	// Make the target directory path point to a file
+	dir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	for _, maildir := range []bool{false, true} {
+		if err := NewDirMailer(dir, maildir, "noreply@example.com").Mail("", "jane@example.com", "Greetings", "<p>Hello</p>"); err == nil {
+			t.Errorf("Mail(maildir=%v) succeeded, want error", maildir)
+		}
+	}
+}
+
+func Test_httpMailer_Mail(t *testing.T) {
+	// Prepare a file to embed
+	imgFile := filepath.Join(t.TempDir(), "logo.png")
+	imgData := []byte("\x89PNG fake image")
+	if err := os.WriteFile(imgFile, imgData, 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		replyTo    string
+		embedFiles []string
+		status     int
+		want       HTTPMailerPayload
+		wantErr    bool
+	}{
+		{
+			"plain message, 200   ",
+			"", nil, http.StatusOK,
+			HTTPMailerPayload{From: "noreply@example.com", To: "jane@example.com", Subject: "Greetings", HTML: "<p>Hello</p>"},
+			false,
+		},
+		{
+			"replyTo, 202         ",
+			"support@example.com", nil, http.StatusAccepted,
+			HTTPMailerPayload{From: "noreply@example.com", To: "jane@example.com", ReplyTo: "support@example.com", Subject: "Greetings", HTML: "<p>Hello</p>"},
+			false,
+		},
+		{
+			"embedded file, 204   ",
+			"", []string{imgFile}, http.StatusNoContent,
+			HTTPMailerPayload{
+				From:    "noreply@example.com",
+				To:      "jane@example.com",
+				Subject: "Greetings",
+				HTML:    "<p>Hello</p>",
+				Attachments: []HTTPMailerAttachment{
+					{Filename: "logo.png", ContentType: "image/png", Content: base64.StdEncoding.EncodeToString(imgData)},
+				},
+			},
+			false,
+		},
+		{
+			"client error         ",
+			"", nil, http.StatusBadRequest,
+			HTTPMailerPayload{From: "noreply@example.com", To: "jane@example.com", Subject: "Greetings", HTML: "<p>Hello</p>"},
+			true,
+		},
+		{
+			"server error         ",
+			"", nil, http.StatusInternalServerError,
+			HTTPMailerPayload{From: "noreply@example.com", To: "jane@example.com", Subject: "Greetings", HTML: "<p>Hello</p>"},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq *http.Request
+			var gotPayload HTTPMailerPayload
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotReq = r
+				if err := json.NewDecoder(r.Body).Decode(&gotPayload); err != nil {
+					t.Errorf("failed to decode request body: %v", err)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			m := NewHTTPMailer(srv.URL+"/send", map[string]string{"Authorization": "Bearer s3cr3t", "X-Tenant": "acme"}, "noreply@example.com", false)
+			err := m.Mail(tt.replyTo, "jane@example.com", "Greetings", "<p>Hello</p>", tt.embedFiles...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Mail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// Verify the request
+			if gotReq == nil {
+				t.Fatalf("Mail() didn't send a request")
+			}
+			if gotReq.Method != http.MethodPost || gotReq.URL.Path != "/send" {
+				t.Errorf("request = %s %s, want POST /send", gotReq.Method, gotReq.URL.Path)
+			}
+			for k, v := range map[string]string{"Content-Type": "application/json", "Authorization": "Bearer s3cr3t", "X-Tenant": "acme"} {
+				if got := gotReq.Header.Get(k); got != v {
+					t.Errorf("request header %s = %q, want %q", k, got, v)
+				}
+			}
+			if !reflect.DeepEqual(gotPayload, tt.want) {
+				t.Errorf("request payload = %#v, want %#v", gotPayload, tt.want)
+			}
+		})
+	}
+}
+
+func Test_httpMailer_Mail_Errors(t *testing.T) {
+	// A server that is closed right away, so that the connection is refused
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	if err := NewHTTPMailer(srv.URL, nil, "noreply@example.com", false).Mail("", "jane@example.com", "Greetings", "<p>Hello</p>"); err == nil {
+		t.Errorf("Mail() to an unreachable server succeeded, want error")
+	}
+
+	// A missing embedded file
+	called := false
+	srv = httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	defer srv.Close()
+	err := NewHTTPMailer(srv.URL, nil, "noreply@example.com", false).
+		Mail("", "jane@example.com", "Greetings", "<p>Hello</p>", filepath.Join(t.TempDir(), "missing.png"))
+	if err == nil {
+		t.Errorf("Mail() with a missing embedded file succeeded, want error")
+	}
+	if called {
+		t.Errorf("Mail() with a missing embedded file sent a request")
+	}
+
+	// An invalid URL
+	if err := NewHTTPMailer("://bad", nil, "noreply@example.com", false).Mail("", "jane@example.com", "Greetings", "<p>Hello</p>"); err == nil {
+		t.Errorf("Mail() to an invalid URL succeeded, want error")
+	}
+}
+
+func Test_httpMailer_Insecure(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	// The test server's certificate is self-signed, so it's only accepted in the insecure mode
+	tests := []struct {
+		name     string
+		insecure bool
+		wantErr  bool
+	}{
+		{"secure  ", false, true},
+		{"insecure", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewHTTPMailer(srv.URL, nil, "noreply@example.com", tt.insecure).Mail("", "jane@example.com", "Greetings", "<p>Hello</p>")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Mail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_sendmailMailer_Mail(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh isn't available")
+	}
+
+	// Use a shell script that saves the piped message into a file as a sendmail replacement
+	out := filepath.Join(t.TempDir(), "out.eml")
+	m := NewSendmailMailer(sh, []string{"-c", `cat > "$0"`, out}, "noreply@example.com")
+	if err := m.Mail("support@example.com", "jane@example.com", "Greetings", "<p>Hello</p>"); err != nil {
+		t.Fatalf("Mail() error = %v", err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	checkMailHeaders(t, string(b), "support@example.com")
+
+	// A failing binary results in an error including its output
+	m = NewSendmailMailer(sh, []string{"-c", "echo oops; exit 1"}, "noreply@example.com")
+	if err := m.Mail("", "jane@example.com", "Greetings", "<p>Hello</p>"); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("Mail() error = %v, want one containing the output", err)
+	}
+}
+
+// stubMailer is a Mailer recording the recipients of sent emails
+type stubMailer struct {
+	operational bool
+	recipients  []string
+}
+
+func (m *stubMailer) Operational() bool {
+	return m.operational
+}
+
+func (m *stubMailer) Mail(_, recipient, _, _ string, _ ...string) error {
+	if !m.operational {
+		return errors.New("not operational")
+	}
+	m.recipients = append(m.recipients, recipient)
+	return nil
+}
+
+func Test_registryMailer(t *testing.T) {
+	name := "test-" + t.Name()
+	t.Cleanup(func() { mailTransports.Delete(name) })
+
+	// Transport isn't registered yet
+	m := NewRegistryMailer(name)
+	if m.Operational() {
+		t.Errorf("Operational() = true before registration, want false")
+	}
+	if err := m.Mail("", "jane@example.com", "Greetings", "<p>Hello</p>"); err == nil {
+		t.Errorf("Mail() succeeded before registration, want error")
+	}
+
+	// Register the transport, then a duplicate one
+	sm := &stubMailer{operational: true}
+	if err := RegisterMailTransport(name, sm); err != nil {
+		t.Fatalf("RegisterMailTransport() error = %v", err)
+	}
+	if err := RegisterMailTransport(name, &stubMailer{}); err == nil {
+		t.Errorf("RegisterMailTransport() with a duplicate name succeeded, want error")
+	}
+
+	// The mailer now delegates to the registered transport
+	if !m.Operational() {
+		t.Errorf("Operational() = false after registration, want true")
+	}
+	if err := m.Mail("", "jane@example.com", "Greetings", "<p>Hello</p>"); err != nil {
+		t.Errorf("Mail() error = %v", err)
+	}
+	if !reflect.DeepEqual(sm.recipients, []string{"jane@example.com"}) {
+		t.Errorf("transport recipients = %v, want [jane@example.com]", sm.recipients)
+	}
+
+	// Operational state is taken over from the transport
+	sm.operational = false
+	if m.Operational() {
+		t.Errorf("Operational() = true for a non-operational transport, want false")
+	}
+}
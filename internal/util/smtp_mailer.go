@@ -36,19 +36,7 @@ func (m *smtpMailer) Operational() bool {
 
 func (m *smtpMailer) Mail(replyTo, recipient, subject, htmlMessage string, embedFiles ...string) error {
 	// Compose an email
-	msg := gomail.NewMessage()
-	msg.SetHeader("From", m.emailFrom)
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("Subject", subject)
-	msg.SetBody("text/html", htmlMessage)
-	if replyTo != "" {
-		msg.SetHeader("Reply-To", replyTo)
-	}
-
-	// Embed files
-	for _, file := range embedFiles {
-		msg.Embed(file)
-	}
+	msg := newMailMessage(m.emailFrom, replyTo, recipient, subject, htmlMessage, embedFiles...)
 
 	// Send it out
 	return m.dialer.DialAndSend(msg)
@@ -15,19 +15,28 @@ import (
 	"github.com/op/go-logging"
 	"github.com/phuslu/iploc"
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	gmutil "github.com/yuin/goldmark/util"
 	"gitlab.com/comentario/comentario/internal/intf"
 	"golang.org/x/net/html"
+	"hash/fnv"
 	"io"
 	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -47,6 +56,9 @@ var (
 		"ABCDEFGHIJKLMNOPQRSTUVWXYZ",
 	}
 
+	// trustedProxies is a list of networks whose requests' forwarding headers are trusted, see SetTrustedProxies()
+	trustedProxies []*net.IPNet
+
 	// TheMailer is a Mailer implementation available application-wide. Defaults to a mailer that doesn't do anything
 	TheMailer intf.Mailer = &noOpMailer{}
 )
@@ -89,6 +101,314 @@ func (p *pathRegistry) Has(path string) bool {
 
 // ----------------------------------------------------------------------------------------------------------------------
 
+// BlocklistAction is an action taken when a blocklist rule matches
+type BlocklistAction string
+
+const (
+	BlocklistActionNone     BlocklistAction = ""         // No rule matched
+	BlocklistActionMask     BlocklistAction = "mask"     // Mask the matched text
+	BlocklistActionModerate BlocklistAction = "moderate" // Send the text to moderation
+	BlocklistActionReject   BlocklistAction = "reject"   // Reject the text outright
+)
+
+// severity returns the relative severity of the action: the higher, the stronger
+func (a BlocklistAction) severity() int {
+	switch a {
+	case BlocklistActionMask:
+		return 1
+	case BlocklistActionModerate:
+		return 2
+	case BlocklistActionReject:
+		return 3
+	}
+	return 0
+}
+
+// blocklistRule is a single compiled blocklist rule
+type blocklistRule struct {
+	name      string          // Rule name
+	action    BlocklistAction // Action to take on match
+	re        *regexp.Regexp  // Regular expression to match the text against
+	wholeWord bool            // Whether matches must be delimited by non-word chars
+}
+
+// matches returns the [start, end) indices of all the rule's matches in s
+func (r *blocklistRule) matches(s string) [][]int {
+	all := r.re.FindAllStringIndex(s, -1)
+	if !r.wholeWord {
+		return all
+	}
+
+	// Only keep whole-word matches
+	var res [][]int
+	for _, m := range all {
+		if m[1] > m[0] && isWordBoundary(s, m[0], true) && isWordBoundary(s, m[1], false) {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// Blocklist is a parsed list of rules for checking texts against undesirable words and patterns
+type Blocklist struct {
+	rules []*blocklistRule
+}
+
+// Check the given text against the blocklist. Returns the strongest action among the matching rules, the name of the
+// (first) rule that triggered it, and the text with matches of all mask rules replaced with asterisks
+func (b *Blocklist) Check(s string) (action BlocklistAction, rule, masked string) {
+	var maskBytes []bool
+	for _, r := range b.rules {
+		ms := r.matches(s)
+		if len(ms) == 0 {
+			continue
+		}
+
+		// Pick the strongest action
+		if r.action.severity() > action.severity() {
+			action, rule = r.action, r.name
+		}
+
+		// Mark the bytes to mask, if needed
+		if r.action == BlocklistActionMask {
+			if maskBytes == nil {
+				maskBytes = make([]bool, len(s))
+			}
+			for _, m := range ms {
+				for i := m[0]; i < m[1]; i++ {
+					maskBytes[i] = true
+				}
+			}
+		}
+	}
+
+	// Nothing to mask
+	if maskBytes == nil {
+		return action, rule, s
+	}
+
+	// Replace every marked char with an asterisk
+	var sb strings.Builder
+	for i, c := range s {
+		if maskBytes[i] {
+			sb.WriteByte('*')
+		} else {
+			sb.WriteRune(c)
+		}
+	}
+	return action, rule, sb.String()
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// CronSchedule is a parsed cron-style schedule, whose fields are bit sets of matching values
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool // Whether the day-of-month/day-of-week field is unrestricted ("*")
+}
+
+// Next returns the earliest time matching the schedule that is strictly later than t, in UTC, or a zero time if
+// there's no such time within five years
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches returns whether the day of the given time matches the schedule. Like in cron, if both day-of-month and
+// day-of-week are restricted, the day matches when either of them does
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseCronField parses a single cron schedule field into a bit set of values, each in the range minVal..maxVal
+func parseCronField(s string, minVal, maxVal int) (uint64, error) {
+	var r uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, sStep, hasStep := strings.Cut(part, "/")
+
+		// Parse the step, if any
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(sStep); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		// Parse the range
+		lo, hi := minVal, maxVal
+		if rng != "*" {
+			sLo, sHi, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(sLo); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			switch {
+			case isRange:
+				if hi, err = strconv.Atoi(sHi); err != nil {
+					return 0, fmt.Errorf("invalid range end in %q", part)
+				}
+			case !hasStep:
+				// A single value
+				hi = lo
+			}
+		}
+		if lo < minVal || hi > maxVal || lo > hi {
+			return 0, fmt.Errorf("value out of range %d-%d in %q", minVal, maxVal, part)
+		}
+
+		// Set the matching bits
+		for i := lo; i <= hi; i += step {
+			r |= 1 << uint(i)
+		}
+	}
+	return r, nil
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// DiffOp is an operation on a chunk of text in a diff
+type DiffOp string
+
+const (
+	DiffOpEqual  DiffOp = "equal"  // Chunk is present in both texts
+	DiffOpInsert DiffOp = "insert" // Chunk is only present in the new text
+	DiffOpDelete DiffOp = "delete" // Chunk is only present in the old text
+)
+
+// DiffChunk is a single chunk of text in a diff
+type DiffChunk struct {
+	Op   DiffOp // Operation on the chunk
+	Text string // Chunk text
+}
+
+// maxTextDiffCells is the maximum size of the LCS table TextDiff is willing to build; larger diffs are reported as a
+// single deletion followed by a single insertion
+const maxTextDiffCells = 1_000_000
+
+// diffAppend appends a chunk to the given diff, merging it with the last chunk if the operation is the same
+func diffAppend(d []DiffChunk, op DiffOp, text string) []DiffChunk {
+	if text == "" {
+		return d
+	}
+	if l := len(d); l > 0 && d[l-1].Op == op {
+		d[l-1].Text += text
+		return d
+	}
+	return append(d, DiffChunk{Op: op, Text: text})
+}
+
+// diffTokens splits the given text into alternating runs of whitespace and non-whitespace characters
+func diffTokens(s string) []string {
+	var res []string
+	start, space := 0, false
+	for i, r := range s {
+		if sp := unicode.IsSpace(r); i == 0 {
+			space = sp
+		} else if sp != space {
+			res = append(res, s[start:i])
+			start, space = i, sp
+		}
+	}
+	if start < len(s) {
+		res = append(res, s[start:])
+	}
+	return res
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
+// kindMention is the Markdown AST node kind of user mentions
+var kindMention = ast.NewNodeKind("Mention")
+
+// mentionNode is a Markdown AST node for a user mention in the form "@[Name]"
+type mentionNode struct {
+	ast.BaseInline
+	name     string // Mentioned user's name
+	resolved bool   // Whether the mention has been resolved into a user
+}
+
+func (n *mentionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.name}, nil)
+}
+
+func (n *mentionNode) Kind() ast.NodeKind {
+	return kindMention
+}
+
+// mentionParser is a goldmark inline parser for user mentions
+type mentionParser struct{}
+
+func (p *mentionParser) Trigger() []byte {
+	return []byte{'@'}
+}
+
+func (p *mentionParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	// A mention looks like "@[Name]"
+	line, seg := block.PeekLine()
+	if len(line) < 4 || line[1] != '[' {
+		return nil
+	}
+	end := bytes.IndexByte(line[2:], ']')
+	if end < 1 || end > MaxMentionLength {
+		return nil
+	}
+	name := strings.TrimSpace(string(line[2 : 2+end]))
+	if name == "" {
+		return nil
+	}
+
+	// Consume the mention, keeping its source text as a child, to be rendered should the mention remain unresolved
+	n := &mentionNode{name: name}
+	n.AppendChild(n, ast.NewTextSegment(seg.WithStop(seg.Start+end+3)))
+	block.Advance(end + 3)
+	return n
+}
+
+// mentionRenderer is a goldmark renderer for user mentions
+type mentionRenderer struct{}
+
+func (r *mentionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMention, r.render)
+}
+
+func (r *mentionRenderer) render(w gmutil.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	// Unresolved mentions are rendered as is
+	n := node.(*mentionNode)
+	if !n.resolved {
+		return ast.WalkContinue, nil
+	}
+
+	// Render a resolved mention as a highlighted name
+	if entering {
+		_, _ = w.WriteString(`<span class="comentario-mention">@`)
+		_, _ = w.Write(gmutil.EscapeHTML([]byte(n.name)))
+		_, _ = w.WriteString("</span>")
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// ----------------------------------------------------------------------------------------------------------------------
+
 // CheckErrors picks and returns the first non-nil error, or nil if there's none
 func CheckErrors(errs ...error) error {
 	for _, err := range errs {
@@ -172,6 +492,12 @@ func DecompressZip(data []byte) ([]byte, error) {
 	}
 }
 
+// EscapeLike escapes the wildcard characters ('%' and '_') and the escape character ('\') in the given string, so that
+// it can be used as a literal in a LIKE pattern with ESCAPE '\'
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 // FormatVersion renders the given uasurfer.Version as a string
 func FormatVersion(v *uasurfer.Version) string {
 	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
@@ -233,6 +559,47 @@ func HTMLDocumentTitle(body io.Reader) (string, error) {
 	}
 }
 
+// HTMLLinkHosts parses the given HTML and returns the hosts referenced by its links (<a href>) and images (<img src>),
+// lowercased and stripped of the port. Relative URLs yield an empty host; absolute URLs without a host (such as
+// "mailto:") yield the entire lowercased URL
+func HTMLLinkHosts(s string) (links, images []string) {
+	// Converts a URL into a host
+	toHost := func(v string) string {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if u, err := url.Parse(v); err != nil {
+			return v
+		} else if u.Host != "" {
+			return u.Hostname()
+		} else if u.Scheme != "" {
+			return v
+		}
+		return ""
+	}
+
+	// Iterate the HTML's tokens
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		//goland:noinspection GoSwitchMissingCasesForIotaConsts
+		switch tokenizer.Next() {
+		// An error token, we either reached the end of the input, or the HTML was malformed
+		case html.ErrorToken:
+			return
+
+		// A start tag token
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			for _, a := range token.Attr {
+				switch {
+				case token.Data == "a" && a.Key == "href":
+					links = append(links, toHost(a.Val))
+				case token.Data == "img" && a.Key == "src":
+					images = append(images, toHost(a.Val))
+				}
+			}
+		}
+	}
+}
+
 // HTMLTitleFromURL tries to fetch the specified URL and subsequently extract the title from its HTML document
 func HTMLTitleFromURL(u *url.URL) (string, error) {
 	// Fetch the URL
@@ -251,6 +618,16 @@ func HTMLTitleFromURL(u *url.URL) (string, error) {
 	return HTMLDocumentTitle(resp.Body)
 }
 
+// HostMatches returns whether the given host equals any of the specified hosts or is a subdomain thereof
+func HostMatches(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
 // If returns one of the two given values depending on the boolean condition, filling in for the ternary operator
 // missing in Go
 func If[T any](cond bool, ifTrue, ifFalse T) T {
@@ -270,6 +647,36 @@ func IndexOfString(s string, slice []string) int {
 	return -1
 }
 
+// isWordBoundary returns whether the given byte position in s isn't adjacent to a word char on the given side (before
+// it if before == true, otherwise after it)
+func isWordBoundary(s string, pos int, before bool) bool {
+	var r rune
+	switch {
+	case before && pos > 0:
+		r, _ = utf8.DecodeLastRuneInString(s[:pos])
+	case !before && pos < len(s):
+		r, _ = utf8.DecodeRuneInString(s[pos:])
+	default:
+		return true
+	}
+	return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// IsPublicIP returns true if the passed string is a valid IP address routable on the public internet, i.e. not a
+// loopback, private, link-local, shared (carrier-grade NAT), multicast, or unspecified one
+func IsPublicIP(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// "This network" (0.0.0.0/8) and shared address space (100.64.0.0/10)
+		return ip4[0] != 0 && !(ip4[0] == 100 && ip4[1]&0xc0 == 64)
+	}
+	return true
+}
+
 // IsStrongPassword checks whether the provided password is a 'strong' one
 func IsStrongPassword(s string) bool {
 	// Check length
@@ -363,8 +770,10 @@ func LogError(f func() error, details string) {
 	}
 }
 
-// MarkdownToHTML renders the provided markdown string as HTML
-func MarkdownToHTML(markdown string, links, images, tables bool) string {
+// MarkdownToHTML renders the provided markdown string as HTML. If mentions is not nil, user mentions in the form
+// "@[Name]" are recognised in the text: mentions gets called with the (unique) names of all mentioned users, and must
+// return those of them that are to be rendered as mentions; any other mention is rendered as plain text
+func MarkdownToHTML(markdown string, links, images, tables bool, mentions func(names []string) []string) string {
 	// Create a new markdown parser/renderer
 	md := goldmark.New(
 		goldmark.WithExtensions(
@@ -376,6 +785,12 @@ func MarkdownToHTML(markdown string, links, images, tables bool) string {
 		),
 	)
 
+	// Mention processing
+	if mentions != nil {
+		md.Parser().AddOptions(parser.WithInlineParsers(gmutil.Prioritized(&mentionParser{}, 500)))
+		md.Renderer().AddOptions(renderer.WithNodeRenderers(gmutil.Prioritized(&mentionRenderer{}, 500)))
+	}
+
 	// Create a sanitizer policy
 	p := bluemonday.StrictPolicy()
 	p.AllowStandardAttributes()
@@ -390,6 +805,7 @@ func MarkdownToHTML(markdown string, links, images, tables bool) string {
 		"pre", "small", "strike", "tt", "u",
 	)
 	p.AllowLists()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^comentario-mention$`)).OnElements("span")
 	p.AddTargetBlankToFullyQualifiedLinks(true)
 	p.RequireNoFollowOnFullyQualifiedLinks(true)
 
@@ -411,8 +827,34 @@ func MarkdownToHTML(markdown string, links, images, tables bool) string {
 		extension.Table.Extend(md)
 	}
 
+	// Parse the Markdown
+	src := []byte(markdown)
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	// Resolve any mentions
+	if mentions != nil {
+		var nodes []*mentionNode
+		var names []string
+		_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if mn, ok := n.(*mentionNode); ok && entering {
+				nodes = append(nodes, mn)
+				if !slices.Contains(names, mn.name) {
+					names = append(names, mn.name)
+				}
+			}
+			return ast.WalkContinue, nil
+		})
+		if len(names) > 0 {
+			resolved := mentions(names)
+			for _, mn := range nodes {
+				mn.resolved = slices.Contains(resolved, mn.name)
+			}
+		}
+	}
+
+	// Render the HTML
 	var buf bytes.Buffer
-	if err := md.Convert([]byte(markdown), &buf); err != nil {
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
 		return fmt.Sprintf("[Error converting Markdown to HTML: %v]", err)
 	}
 
@@ -489,6 +931,114 @@ func ParseAbsoluteURL(s string, allowHTTP, trimTrailingSlash bool) (*url.URL, er
 	return u, nil
 }
 
+// ParseBlocklist parses the given rules, a map of rule specs indexed by rule name, into a Blocklist. Each spec has the
+// form "<action> <type> <pattern>", where action is "mask", "moderate", or "reject", and type is one of:
+//   - "word": case-insensitive literal whole word (or phrase);
+//   - "wildcard": case-insensitive whole word, where "*" matches any number of word chars and "?" a single word char;
+//   - "regex": RE2 regular expression, matched as is.
+//
+// Rules are applied in the order of their names
+func ParseBlocklist(rules map[string]string) (*Blocklist, error) {
+	b := &Blocklist{}
+	for name, spec := range rules {
+		// Split the spec into parts
+		fields := strings.SplitN(strings.TrimSpace(spec), " ", 3)
+		if len(fields) != 3 || strings.TrimSpace(fields[2]) == "" {
+			return nil, fmt.Errorf("rule %q: spec must have the form '<action> <type> <pattern>'", name)
+		}
+		r := &blocklistRule{name: name, action: BlocklistAction(fields[0])}
+		pattern := strings.TrimSpace(fields[2])
+
+		// Validate the action
+		if r.action.severity() == 0 {
+			return nil, fmt.Errorf("rule %q: invalid action %q", name, fields[0])
+		}
+
+		// Convert the pattern into a regular expression
+		var expr string
+		switch fields[1] {
+		case "word":
+			expr, r.wholeWord = "(?i)"+regexp.QuoteMeta(pattern), true
+		case "wildcard":
+			expr = regexp.QuoteMeta(pattern)
+			expr = strings.ReplaceAll(expr, `\*`, `[\pL\pN_]*`)
+			expr = strings.ReplaceAll(expr, `\?`, `[\pL\pN_]`)
+			expr, r.wholeWord = "(?i)"+expr, true
+		case "regex":
+			expr = pattern
+		default:
+			return nil, fmt.Errorf("rule %q: invalid type %q", name, fields[1])
+		}
+
+		// Compile the expression
+		var err error
+		if r.re, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", name, err)
+		}
+		b.rules = append(b.rules, r)
+	}
+
+	// Sort the rules by name for a stable ordering
+	sort.Slice(b.rules, func(i, j int) bool { return b.rules[i].name < b.rules[j].name })
+	return b, nil
+}
+
+// ParseHostList splits the given list of hosts, separated by whitespace and/or commas, into a slice of lowercase hosts
+func ParseHostList(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// ParseCronSchedule parses a cron-style schedule spec, consisting of five space-separated fields: minute (0-59), hour
+// (0-23), day of month (1-31), month (1-12), and day of week (0-7, both 0 and 7 standing for Sunday). Each field is
+// either "*" or a comma-separated list of values or ranges ("a-b"), optionally followed by a step ("/n"). Macros
+// @hourly, @daily, @weekly, @monthly, and @yearly are also supported
+func ParseCronSchedule(spec string) (*CronSchedule, error) {
+	// Expand macros
+	switch spec = strings.TrimSpace(spec); spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	case "@monthly":
+		spec = "0 0 1 * *"
+	case "@yearly":
+		spec = "0 0 1 1 *"
+	}
+
+	// Split the spec into fields
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron schedule %q: want 5 fields, got %d", spec, len(fields))
+	}
+
+	// Parse the fields
+	c := &CronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid cron schedule minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid cron schedule hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid cron schedule day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid cron schedule month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid cron schedule day of week: %w", err)
+	}
+
+	// Sunday can be specified as 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
 // RandomBytes makes a random byte slice of the desired size
 func RandomBytes(n int) ([]byte, error) {
 	b := make([]byte, n)
@@ -528,6 +1078,26 @@ func StripPort(hostOrIP string) string {
 	return s
 }
 
+// ShingleSimilarity returns the Jaccard similarity of the two given shingle sets, ranging from 0 (nothing in common)
+// to 1 (identical). Empty sets are considered dissimilar to anything
+func ShingleSimilarity(a, b map[uint64]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// Iterate the smaller set
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for h := range a {
+		if b[h] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
 // StrToFloatDef converts a string to a float64. If conversion fails, returns the given default
 func StrToFloatDef(s string, def float64) float64 {
 	if s == "" {
@@ -540,6 +1110,89 @@ func StrToFloatDef(s string, def float64) float64 {
 	return f
 }
 
+// TextDiff computes a word-level diff between texts a and b, returning a list of chunks that transform a into b
+func TextDiff(a, b string) []DiffChunk {
+	ta, tb := diffTokens(a), diffTokens(b)
+
+	// Skip the common prefix and suffix
+	var res []DiffChunk
+	pre := 0
+	for pre < len(ta) && pre < len(tb) && ta[pre] == tb[pre] {
+		pre++
+	}
+	res = diffAppend(res, DiffOpEqual, strings.Join(ta[:pre], ""))
+	ta, tb = ta[pre:], tb[pre:]
+	suf := 0
+	for suf < len(ta) && suf < len(tb) && ta[len(ta)-1-suf] == tb[len(tb)-1-suf] {
+		suf++
+	}
+	suffix := strings.Join(ta[len(ta)-suf:], "")
+	ta, tb = ta[:len(ta)-suf], tb[:len(tb)-suf]
+
+	// If the remainder is too large, report it as a whole replacement
+	if (len(ta)+1)*(len(tb)+1) > maxTextDiffCells {
+		res = diffAppend(res, DiffOpDelete, strings.Join(ta, ""))
+		res = diffAppend(res, DiffOpInsert, strings.Join(tb, ""))
+		return diffAppend(res, DiffOpEqual, suffix)
+	}
+
+	// Build a table of longest common subsequence lengths for the token suffixes
+	lcs := make([][]int, len(ta)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(tb)+1)
+	}
+	for i := len(ta) - 1; i >= 0; i-- {
+		for j := len(tb) - 1; j >= 0; j-- {
+			if ta[i] == tb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Walk the table to produce the chunks
+	i, j := 0, 0
+	for i < len(ta) || j < len(tb) {
+		switch {
+		case i < len(ta) && j < len(tb) && ta[i] == tb[j]:
+			res = diffAppend(res, DiffOpEqual, ta[i])
+			i++
+			j++
+		case j < len(tb) && (i == len(ta) || lcs[i][j+1] > lcs[i+1][j]):
+			res = diffAppend(res, DiffOpInsert, tb[j])
+			j++
+		default:
+			res = diffAppend(res, DiffOpDelete, ta[i])
+			i++
+		}
+	}
+	return diffAppend(res, DiffOpEqual, suffix)
+}
+
+// TextShingles splits the given text into words (see TextWords), and returns the number of words and a set of hashes of
+// all overlapping n-word sequences ("shingles"). A text shorter than n words yields a single shingle
+func TextShingles(s string, n int) (int, map[uint64]bool) {
+	words := TextWords(s)
+	if len(words) == 0 {
+		return 0, nil
+	}
+
+	// Hash every n-word sequence
+	res := make(map[uint64]bool)
+	for i := 0; i == 0 || i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:min(i+n, len(words))], " ")))
+		res[h.Sum64()] = true
+	}
+	return len(words), res
+}
+
+// TextWords splits the given text into lowercase words, ignoring anything but letters and digits
+func TextWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
 // ToStringSlice converts a slice of string-derived elements into a string slice
 func ToStringSlice[T ~string](in []T) []string {
 	// Don't convert nil
@@ -583,28 +1236,86 @@ func UserAgent(r *http.Request) string {
 	return r.Header.Get("User-Agent")
 }
 
-// UserIP tries to determine the user IP, returning either a valid IPv4/IPv6 address or an empty string
-func UserIP(r *http.Request) string {
-	// First, try the X-Forwarded-For
-	if s := r.Header.Get("X-Forwarded-For"); s != "" {
-		// This header may contain multiple, comma-separated values. We're interested in the first one (client IP)
-		if ip, _, _ := strings.Cut(s, ","); IsValidIP(ip) {
-			return ip
+// SetTrustedProxies parses the given comma-separated list of IP addresses and/or CIDR networks and makes them the
+// proxies whose X-Forwarded-For and X-Real-Ip headers are trusted by UserIP
+func SetTrustedProxies(s string) error {
+	var nets []*net.IPNet
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		// Treat a bare IP address as a single-address network
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy IP address: %q", item)
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
 		}
+
+		// Parse the network
+		_, n, err := net.ParseCIDR(item)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy network %q: %w", item, err)
+		}
+		nets = append(nets, n)
 	}
+	trustedProxies = nets
+	return nil
+}
 
-	// Next, the X-Real-Ip
-	if ip := r.Header.Get("X-Real-Ip"); IsValidIP(ip) {
+// IsTrustedProxy returns whether the given IP address belongs to a trusted proxy
+func IsTrustedProxy(s string) bool {
+	if ip := net.ParseIP(s); ip != nil {
+		for _, n := range trustedProxies {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UserIP tries to determine the user IP, returning either a valid IPv4/IPv6 address or an empty string. The
+// X-Forwarded-For and X-Real-Ip headers are only taken into account if the request comes from a trusted proxy, since
+// they are otherwise trivially spoofed by the client
+func UserIP(r *http.Request) string {
+	// Take the remote IP from the request: unless it's a trusted proxy, that's the client IP
+	ip := StripPort(r.RemoteAddr)
+	if !IsValidIP(ip) {
+		ip = ""
+	}
+	if !IsTrustedProxy(ip) {
 		return ip
 	}
 
-	// Fall back to the remote IP from the request
-	if ip := StripPort(r.RemoteAddr); IsValidIP(ip) {
+	// Next, try the X-Forwarded-For. This header may contain multiple, comma-separated values, each proxy appending the
+	// address it received the request from. The client is therefore the rightmost address not belonging to a trusted
+	// proxy; anything to the left of it could have been supplied by the client
+	if s := r.Header.Get("X-Forwarded-For"); s != "" {
+		fwd := strings.Split(s, ",")
+		for i := len(fwd) - 1; i >= 0; i-- {
+			fip := strings.TrimSpace(fwd[i])
+			if !IsValidIP(fip) {
+				break
+			}
+			ip = fip
+			if !IsTrustedProxy(fip) {
+				break
+			}
+		}
 		return ip
 	}
 
-	// Failed to determine the IP
-	return ""
+	// Next, the X-Real-Ip
+	if rip := r.Header.Get("X-Real-Ip"); IsValidIP(rip) {
+		return rip
+	}
+
+	// Fall back to the remote IP
+	return ip
 }
 
 // UserIPCountry tries to determine the IP address and country code of the user based on it, optionally masking the IP
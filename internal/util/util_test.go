@@ -5,12 +5,15 @@ import (
 	"encoding/hex"
 	"errors"
 	"github.com/go-openapi/strfmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 // mustDecode decodes the given hex string into a byte slice, panicking if it fails
@@ -251,6 +254,29 @@ func TestDecompressZip(t *testing.T) {
 	}
 }
 
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"empty          ", "", ""},
+		{"no special     ", "foo bar", "foo bar"},
+		{"percent        ", "100%", `100\%`},
+		{"underscore     ", "snake_case", `snake\_case`},
+		{"backslash      ", `C:\dir`, `C:\\dir`},
+		{"all            ", `%_\`, `\%\_\\`},
+		{"escaped already", `\%`, `\\\%`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeLike(tt.s); got != tt.want {
+				t.Errorf("EscapeLike() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestHMACSign(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -307,6 +333,60 @@ func TestHTMLDocumentTitle(t *testing.T) {
 	}
 }
 
+func TestHTMLLinkHosts(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		wantLinks  []string
+		wantImages []string
+	}{
+		{"empty          ", "", nil, nil},
+		{"no links       ", "<p>Hello <b>world</b></p>", nil, nil},
+		{"absolute link  ", `<p><a href="https://Example.com:8080/x">x</a></p>`, []string{"example.com"}, nil},
+		{"relative link  ", `<a href="/page">x</a>`, []string{""}, nil},
+		{"mailto link    ", `<a href="mailto:Me@example.com">x</a>`, []string{"mailto:me@example.com"}, nil},
+		{"anchor, no href", `<a name="top">x</a>`, nil, nil},
+		{"image          ", `<img src="https://img.example.org/a.png">`, nil, []string{"img.example.org"}},
+		{"self-closing   ", `<img src="//cdn.example.net/a.png"/>`, nil, []string{"cdn.example.net"}},
+		{"mixed          ", `<a href="http://a.com">a</a><img src="http://b.com/i.png"><a href="http://c.com">c</a>`, []string{"a.com", "c.com"}, []string{"b.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLinks, gotImages := HTMLLinkHosts(tt.html)
+			if !reflect.DeepEqual(gotLinks, tt.wantLinks) {
+				t.Errorf("HTMLLinkHosts() gotLinks = %v, want %v", gotLinks, tt.wantLinks)
+			}
+			if !reflect.DeepEqual(gotImages, tt.wantImages) {
+				t.Errorf("HTMLLinkHosts() gotImages = %v, want %v", gotImages, tt.wantImages)
+			}
+		})
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		host  string
+		hosts []string
+		want  bool
+	}{
+		{"no hosts     ", "example.com", nil, false},
+		{"exact        ", "example.com", []string{"foo.org", "example.com"}, true},
+		{"subdomain    ", "www.example.com", []string{"example.com"}, true},
+		{"deep sub     ", "a.b.example.com", []string{"example.com"}, true},
+		{"suffix only  ", "badexample.com", []string{"example.com"}, false},
+		{"parent       ", "example.com", []string{"www.example.com"}, false},
+		{"empty host   ", "", []string{"example.com"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HostMatches(tt.host, tt.hosts); got != tt.want {
+				t.Errorf("HostMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIf_bool(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -601,6 +681,41 @@ func TestIsValidHostPort(t *testing.T) {
 	}
 }
 
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"empty             ", "", false},
+		{"garbage           ", "foo2$%^@#$^%2bar", false},
+		{"public IPv4       ", "214.31.117.6", true},
+		{"public IPv6       ", "2a00:1450:4001:82b::200e", true},
+		{"localhost IPv4    ", "127.0.0.1", false},
+		{"localhost IPv6    ", "::1", false},
+		{"private 10/8      ", "10.1.2.3", false},
+		{"private 172.16/12 ", "172.20.0.1", false},
+		{"private 192.168/16", "192.168.1.1", false},
+		{"link-local IPv4   ", "169.254.169.254", false},
+		{"link-local IPv6   ", "fe80::1", false},
+		{"unique local IPv6 ", "fd00::1", false},
+		{"shared 100.64/10  ", "100.100.1.1", false},
+		{"not shared        ", "100.128.1.1", true},
+		{"this network      ", "0.1.2.3", false},
+		{"unspecified IPv6  ", "::", false},
+		{"multicast         ", "224.0.0.1", false},
+		{"mapped private    ", "::ffff:10.1.2.3", false},
+		{"mapped public     ", "::ffff:214.31.117.6", true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.name), func(t *testing.T) {
+			if got := IsPublicIP(tt.s); got != tt.want {
+				t.Errorf("IsPublicIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsValidIPv4(t *testing.T) {
 	tests := []struct {
 		name string
@@ -765,13 +880,54 @@ func TestMarkdownToHTML(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Trim leading/trailing whitespace explicitly before comparing (because it doesn't matter in the resulting
 			// HTML)
-			if got := strings.TrimSpace(MarkdownToHTML(tt.markdown, tt.links, tt.images, tt.tables)); got != tt.want {
+			if got := strings.TrimSpace(MarkdownToHTML(tt.markdown, tt.links, tt.images, tt.tables, nil)); got != tt.want {
 				t.Errorf("MarkdownToHTML() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestMarkdownToHTML_Mentions(t *testing.T) {
+	known := []string{"Alice", "Bob Smith"}
+	tests := []struct {
+		name      string
+		markdown  string
+		wantHTML  string
+		wantNames []string
+	}{
+		{"No mentions          ", "Hi @all", "<p>Hi @all</p>", nil},
+		{"Known mention        ", "Hi @[Alice]!", `<p>Hi <span class="comentario-mention">@Alice</span>!</p>`, []string{"Alice"}},
+		{"Name with space      ", "@[ Bob Smith ], see", `<p><span class="comentario-mention">@Bob Smith</span>, see</p>`, []string{"Bob Smith"}},
+		{"Unknown mention      ", "Hi @[Carol]", "<p>Hi @[Carol]</p>", []string{"Carol"}},
+		{"Repeated mentions    ", "@[Alice] @[Carol] @[Alice]", `<p><span class="comentario-mention">@Alice</span> @[Carol] <span class="comentario-mention">@Alice</span></p>`, []string{"Alice", "Carol"}},
+		{"Empty mention        ", "@[] @[ ]", "<p>@[] @[ ]</p>", nil},
+		{"Unclosed mention     ", "@[Alice", "<p>@[Alice</p>", nil},
+		{"Mention in code      ", "`@[Alice]`", "<p><code>@[Alice]</code></p>", nil},
+		{"Escaped name         ", "@[<b>]", "<p>@[&lt;b&gt;]</p>", []string{"<b>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotNames []string
+			got := strings.TrimSpace(MarkdownToHTML(tt.markdown, false, false, false, func(names []string) []string {
+				gotNames = names
+				var res []string
+				for _, n := range names {
+					if slices.Contains(known, n) {
+						res = append(res, n)
+					}
+				}
+				return res
+			}))
+			if got != tt.wantHTML {
+				t.Errorf("MarkdownToHTML() = %v, want %v", got, tt.wantHTML)
+			}
+			if !reflect.DeepEqual(gotNames, tt.wantNames) {
+				t.Errorf("MarkdownToHTML() mentioned names = %v, want %v", gotNames, tt.wantNames)
+			}
+		})
+	}
+}
+
 func TestMaskIP(t *testing.T) {
 	tests := []struct {
 		name string
@@ -806,6 +962,123 @@ func TestMaskIP(t *testing.T) {
 	}
 }
 
+func TestParseBlocklist(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      map[string]string
+		text       string
+		wantErr    bool
+		wantAction BlocklistAction
+		wantRule   string
+		wantMasked string
+	}{
+		{"no rules            ", nil, "Hello world", false, BlocklistActionNone, "", "Hello world"},
+		{"bad spec            ", map[string]string{"a": "moderate word"}, "", true, "", "", ""},
+		{"bad action          ", map[string]string{"a": "delete word foo"}, "", true, "", "", ""},
+		{"bad type            ", map[string]string{"a": "mask glob foo"}, "", true, "", "", ""},
+		{"bad regex           ", map[string]string{"a": "reject regex fo(o"}, "", true, "", "", ""},
+		{"no match            ", map[string]string{"a": "moderate word foo"}, "Hello world", false, BlocklistActionNone, "", "Hello world"},
+		{"word, partial       ", map[string]string{"a": "moderate word foo"}, "Food for thought", false, BlocklistActionNone, "", "Food for thought"},
+		{"word, case          ", map[string]string{"a": "moderate word foo"}, "Say FOO!", false, BlocklistActionModerate, "a", "Say FOO!"},
+		{"word, unicode       ", map[string]string{"a": "mask word über"}, "Das ist Über, nicht überall", false, BlocklistActionMask, "a", "Das ist ****, nicht überall"},
+		{"wildcard            ", map[string]string{"a": "mask wildcard sp*m"}, "Spam and spaaam, not spa", false, BlocklistActionMask, "a", "**** and ******, not spa"},
+		{"wildcard, single    ", map[string]string{"a": "mask wildcard b?d"}, "bad bud bead", false, BlocklistActionMask, "a", "*** *** bead"},
+		{"regex               ", map[string]string{"a": "reject regex casino\\d+"}, "Visit casino777 now", false, BlocklistActionReject, "a", "Visit casino777 now"},
+		{"strongest action    ", map[string]string{"m": "mask word darn", "r": "reject word spam", "x": "moderate word spam"}, "darn spam", false, BlocklistActionReject, "r", "**** spam"},
+		{"first rule by name  ", map[string]string{"b": "moderate word foo", "a": "moderate word bar"}, "foo bar", false, BlocklistActionModerate, "a", "foo bar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ParseBlocklist(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBlocklist() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			action, rule, masked := b.Check(tt.text)
+			if action != tt.wantAction {
+				t.Errorf("Check() action = %q, want %q", action, tt.wantAction)
+			}
+			if rule != tt.wantRule {
+				t.Errorf("Check() rule = %q, want %q", rule, tt.wantRule)
+			}
+			if masked != tt.wantMasked {
+				t.Errorf("Check() masked = %q, want %q", masked, tt.wantMasked)
+			}
+		})
+	}
+}
+
+func TestParseHostList(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{"empty         ", "", []string{}},
+		{"blank         ", " \n\t, ", []string{}},
+		{"single        ", "Example.com", []string{"example.com"}},
+		{"newlines      ", "a.com\nb.org\r\nc.net", []string{"a.com", "b.org", "c.net"}},
+		{"commas, spaces", "a.com, b.org,,c.net ", []string{"a.com", "b.org", "c.net"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseHostList(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseHostList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronSchedule(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 30, 15, 0, time.UTC) // Wednesday
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+		want    time.Time
+	}{
+		{"empty              ", "", true, time.Time{}},
+		{"too few fields     ", "* * * *", true, time.Time{}},
+		{"too many fields    ", "* * * * * *", true, time.Time{}},
+		{"bad value          ", "x * * * *", true, time.Time{}},
+		{"minute out of range", "60 * * * *", true, time.Time{}},
+		{"day out of range   ", "* * 0 * *", true, time.Time{}},
+		{"reversed range     ", "* 5-3 * * *", true, time.Time{}},
+		{"zero step          ", "*/0 * * * *", true, time.Time{}},
+		{"every minute       ", "* * * * *", false, time.Date(2024, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{"every 15 minutes   ", "*/15 * * * *", false, time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"list               ", "5,20 * * * *", false, time.Date(2024, 1, 31, 11, 5, 0, 0, time.UTC)},
+		{"hourly             ", "@hourly", false, time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"daily              ", "@daily", false, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"range with step    ", "0 9-17/4 * * *", false, time.Date(2024, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"weekly             ", "@weekly", false, time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"Sunday as 7        ", "0 0 * * 7", false, time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"Friday             ", "30 8 * * 5", false, time.Date(2024, 2, 2, 8, 30, 0, 0, time.UTC)},
+		{"leap day           ", "0 0 29 2 *", false, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"day of month or dow", "0 0 15 * 1", false, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{"yearly             ", "@yearly", false, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"never              ", "0 0 31 2 *", false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCronSchedule(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCronSchedule() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("CronSchedule.Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestRandomBytesLength(t *testing.T) {
 	tests := []struct {
 		name string
@@ -896,6 +1169,32 @@ func TestStripPort(t *testing.T) {
 	}
 }
 
+func TestShingleSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want float64
+	}{
+		{"empty        ", "", "", 0},
+		{"one empty    ", "foo bar baz", "", 0},
+		{"identical    ", "Buy cheap pills at our shop", "Buy cheap pills at our shop", 1},
+		{"normalised   ", "Buy cheap pills at our shop!", "buy  CHEAP pills, at our... shop", 1},
+		{"different    ", "Buy cheap pills at our shop", "I really enjoyed reading this", 0},
+		{"near         ", "Buy cheap pills at our shop now", "Buy cheap pills at our shop today", 2.0 / 3},
+		{"short texts  ", "Thanks", "Thanks", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, a := TextShingles(tt.a, 3)
+			_, b := TextShingles(tt.b, 3)
+			if got := ShingleSimilarity(a, b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ShingleSimilarity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStrToFloatDef(t *testing.T) {
 	tests := []struct {
 		name string
@@ -919,6 +1218,83 @@ func TestStrToFloatDef(t *testing.T) {
 	}
 }
 
+func TestTextDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []DiffChunk
+	}{
+		{"both empty     ", "", "", nil},
+		{"identical      ", "foo bar", "foo bar", []DiffChunk{{DiffOpEqual, "foo bar"}}},
+		{"from empty     ", "", "foo", []DiffChunk{{DiffOpInsert, "foo"}}},
+		{"to empty       ", "foo", "", []DiffChunk{{DiffOpDelete, "foo"}}},
+		{"word replaced  ", "the quick fox", "the slow fox", []DiffChunk{{DiffOpEqual, "the "}, {DiffOpDelete, "quick"}, {DiffOpInsert, "slow"}, {DiffOpEqual, " fox"}}},
+		{"word inserted  ", "the fox", "the quick fox", []DiffChunk{{DiffOpEqual, "the "}, {DiffOpInsert, "quick "}, {DiffOpEqual, "fox"}}},
+		{"word deleted   ", "a b c d", "a c d", []DiffChunk{{DiffOpEqual, "a "}, {DiffOpDelete, "b "}, {DiffOpEqual, "c d"}}},
+		{"multiple edits ", "one two three four", "zero two four five", []DiffChunk{{DiffOpDelete, "one"}, {DiffOpInsert, "zero"}, {DiffOpEqual, " two "}, {DiffOpDelete, "three "}, {DiffOpEqual, "four"}, {DiffOpInsert, " five"}}},
+		{"unicode        ", "Über straße", "Über gasse", []DiffChunk{{DiffOpEqual, "Über "}, {DiffOpDelete, "straße"}, {DiffOpInsert, "gasse"}}},
+		{"whitespace     ", "foo bar", "foo\n\nbar", []DiffChunk{{DiffOpEqual, "foo"}, {DiffOpDelete, " "}, {DiffOpInsert, "\n\n"}, {DiffOpEqual, "bar"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TextDiff(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TextDiff() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextShingles(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		n         int
+		wantWords int
+		wantLen   int
+	}{
+		{"empty        ", "", 3, 0, 0},
+		{"punctuation  ", "!?, ...", 3, 0, 0},
+		{"short        ", "Hello world", 3, 2, 1},
+		{"exact        ", "one two three", 3, 3, 1},
+		{"longer       ", "one two three four five", 3, 5, 3},
+		{"repeated     ", "spam spam spam spam spam", 3, 5, 1},
+		{"unicode      ", "Über straße, naïve café", 2, 4, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, got := TextShingles(tt.s, tt.n)
+			if words != tt.wantWords {
+				t.Errorf("TextShingles() words = %v, want %v", words, tt.wantWords)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("TextShingles() len = %v, want %v", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestTextWords(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{"empty        ", "", []string{}},
+		{"punctuation  ", "!?, ...", []string{}},
+		{"words        ", "Hello, World!", []string{"hello", "world"}},
+		{"digits       ", "Call 555-1234 now", []string{"call", "555", "1234", "now"}},
+		{"unicode      ", "Über straße", []string{"über", "straße"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TextWords(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TextWords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestToStringSlice(t *testing.T) {
 	in := []strfmt.UUID{"foo", "", "bar"}
 	want := []string{"foo", "", "bar"}
@@ -978,35 +1354,45 @@ func TestTruncateStr(t *testing.T) {
 }
 
 func TestUserIP(t *testing.T) {
+	const trusted = "89.0.142.86,10.0.0.0/8"
 	tests := []struct {
 		name       string
+		trusted    string
 		remoteAddr string
 		headers    http.Header
 		want       string
 	}{
-		{"no data                                                 ", "", nil, ""},
-		{"remote ipv4                                             ", "89.0.142.86", nil, "89.0.142.86"},
-		{"remote ipv4, invalid                                    ", "892.0.142.86", nil, ""},
-		{"remote ipv4 + port                                      ", "89.0.142.86:12345", nil, "89.0.142.86"},
-		{"remote ipv4 + port, invalid                             ", "89.0.342.86:12345", nil, ""},
-		{"remote ipv6                                             ", "[f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8]", nil, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
-		{"remote ipv6 + port                                      ", "[f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8]:12345", nil, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
-		{"remote ipv4 + empty X-Forwarded-For                     ", "89.0.142.86", http.Header{"X-Forwarded-For": []string{""}}, "89.0.142.86"},
-		{"remote ipv4 + lacking X-Forwarded-For                   ", "89.0.142.86", http.Header{"X-Forwarded-For": []string{",15.47.231.14"}}, "89.0.142.86"},
-		{"remote ipv4 + single X-Forwarded-For                    ", "89.0.142.86", http.Header{"X-Forwarded-For": []string{"15.47.231.14"}}, "15.47.231.14"},
-		{"remote ipv4 + multiple X-Forwarded-For                  ", "89.0.142.86", http.Header{"X-Forwarded-For": []string{"242.213.47.98,15.47.231.14,16.47.231.14"}}, "242.213.47.98"},
-		{"remote ipv4 + empty X-Real-Ip                           ", "89.0.142.86", http.Header{"X-Real-Ip": []string{""}}, "89.0.142.86"},
-		{"remote ipv4 + valid X-Real-Ip                           ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}}, "11.22.33.44"},
-		{"remote ipv4 + IPv6 X-Real-Ip                            ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"}}, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
-		{"remote ipv4 + valid X-Real-Ip + empty X-Forwarded-For   ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{""}}, "11.22.33.44"},
-		{"remote ipv4 + valid X-Real-Ip + lacking X-Forwarded-For ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{",15.47.231.14"}}, "11.22.33.44"},
-		{"remote ipv4 + valid X-Real-Ip + single X-Forwarded-For  ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{"15.47.231.14"}}, "15.47.231.14"},
-		{"remote ipv4 + valid X-Real-Ip + multiple X-Forwarded-For", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{"242.213.47.98,15.47.231.14,16.47.231.14"}}, "242.213.47.98"},
-		{"remote ipv4 + valid X-Real-Ip + IPv6 X-Forwarded-For    ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{"be4f:c05d:32c3:b007:0afb:6691:12fa:55b5,242.213.47.98,15.47.231.14,16.47.231.14"}}, "be4f:c05d:32c3:b007:0afb:6691:12fa:55b5"},
-		{"remote ipv4 + IPv6 X-Real-Ip + IPv6 X-Forwarded-For     ", "89.0.142.86", http.Header{"X-Real-Ip": []string{"1637:4bf3:42cd:7980:220b:feb2:98e8:ff82"}, "X-Forwarded-For": []string{"f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8,242.213.47.98,15.47.231.14,16.47.231.14"}}, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
+		{"no data                                                   ", "", "", nil, ""},
+		{"remote ipv4                                               ", "", "89.0.142.86", nil, "89.0.142.86"},
+		{"remote ipv4, invalid                                      ", "", "892.0.142.86", nil, ""},
+		{"remote ipv4 + port                                        ", "", "89.0.142.86:12345", nil, "89.0.142.86"},
+		{"remote ipv4 + port, invalid                               ", "", "89.0.342.86:12345", nil, ""},
+		{"remote ipv6                                               ", "", "[f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8]", nil, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
+		{"remote ipv6 + port                                        ", "", "[f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8]:12345", nil, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
+		{"untrusted remote + X-Forwarded-For                        ", "", "89.0.142.86", http.Header{"X-Forwarded-For": []string{"15.47.231.14"}}, "89.0.142.86"},
+		{"untrusted remote + X-Real-Ip                              ", "", "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}}, "89.0.142.86"},
+		{"other trusted proxy + X-Forwarded-For                     ", trusted, "89.0.142.87", http.Header{"X-Forwarded-For": []string{"15.47.231.14"}}, "89.0.142.87"},
+		{"trusted remote + empty X-Forwarded-For                    ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{""}}, "89.0.142.86"},
+		{"trusted remote + invalid X-Forwarded-For                  ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{"15.47.231.14,"}}, "89.0.142.86"},
+		{"trusted remote + lacking X-Forwarded-For                  ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{",15.47.231.14"}}, "15.47.231.14"},
+		{"trusted remote + single X-Forwarded-For                   ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{"15.47.231.14"}}, "15.47.231.14"},
+		{"trusted remote + multiple X-Forwarded-For                 ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{"242.213.47.98,15.47.231.14,16.47.231.14"}}, "16.47.231.14"},
+		{"trusted remote + X-Forwarded-For via trusted proxies      ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{"242.213.47.98, 15.47.231.14, 10.1.2.3, 89.0.142.86"}}, "15.47.231.14"},
+		{"trusted remote + X-Forwarded-For of trusted proxies only  ", trusted, "89.0.142.86", http.Header{"X-Forwarded-For": []string{"10.1.2.3,10.3.2.1"}}, "10.1.2.3"},
+		{"trusted ipv4 + port + X-Forwarded-For                     ", trusted, "10.20.30.40:12345", http.Header{"X-Forwarded-For": []string{"15.47.231.14"}}, "15.47.231.14"},
+		{"trusted remote + empty X-Real-Ip                          ", trusted, "89.0.142.86", http.Header{"X-Real-Ip": []string{""}}, "89.0.142.86"},
+		{"trusted remote + valid X-Real-Ip                          ", trusted, "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}}, "11.22.33.44"},
+		{"trusted remote + IPv6 X-Real-Ip                           ", trusted, "89.0.142.86", http.Header{"X-Real-Ip": []string{"f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"}}, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
+		{"trusted remote + valid X-Real-Ip + empty X-Forwarded-For  ", trusted, "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{""}}, "11.22.33.44"},
+		{"trusted remote + valid X-Real-Ip + single X-Forwarded-For ", trusted, "89.0.142.86", http.Header{"X-Real-Ip": []string{"11.22.33.44"}, "X-Forwarded-For": []string{"15.47.231.14"}}, "15.47.231.14"},
+		{"trusted remote + IPv6 X-Real-Ip + IPv6 X-Forwarded-For    ", trusted, "89.0.142.86", http.Header{"X-Real-Ip": []string{"1637:4bf3:42cd:7980:220b:feb2:98e8:ff82"}, "X-Forwarded-For": []string{"242.213.47.98,f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"}}, "f16c:f7ec:cfa2:e1c5:9a3c:cb08:801f:36b8"},
 	}
+	defer func() { trustedProxies = nil }()
 	for _, tt := range tests {
 		t.Run(strings.TrimSpace(tt.name), func(t *testing.T) {
+			if err := SetTrustedProxies(tt.trusted); err != nil {
+				t.Fatalf("SetTrustedProxies() error = %v", err)
+			}
 			r := httptest.NewRequest("GET", "/", nil)
 			r.RemoteAddr = tt.remoteAddr
 			r.Header = tt.headers
@@ -1016,3 +1402,38 @@ func TestUserIP(t *testing.T) {
 		})
 	}
 }
+
+func TestSetTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		ip      string
+		want    bool
+		wantErr bool
+	}{
+		{"empty             ", "", "127.0.0.1", false, false},
+		{"single ipv4       ", "127.0.0.1", "127.0.0.1", true, false},
+		{"single ipv4, other", "127.0.0.1", "127.0.0.2", false, false},
+		{"ipv4 network      ", " 10.0.0.0/8 , 192.168.0.0/16", "192.168.12.1", true, false},
+		{"ipv4 network, out ", "10.0.0.0/8,192.168.0.0/16", "172.16.0.1", false, false},
+		{"single ipv6       ", "::1", "::1", true, false},
+		{"ipv6 network      ", "fc00::/7", "fd12:3456::1", true, false},
+		{"ipv6 vs ipv4      ", "fc00::/7", "10.0.0.1", false, false},
+		{"invalid ip        ", "127.0.0.256", "", false, true},
+		{"invalid network   ", "10.0.0.0/33", "", false, true},
+	}
+	defer func() { trustedProxies = nil }()
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.name), func(t *testing.T) {
+			trustedProxies = nil
+			if err := SetTrustedProxies(tt.s); (err != nil) != tt.wantErr {
+				t.Fatalf("SetTrustedProxies() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != nil {
+				return
+			}
+			if got := IsTrustedProxy(tt.ip); got != tt.want {
+				t.Errorf("IsTrustedProxy(%q) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}